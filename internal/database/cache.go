@@ -0,0 +1,423 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+var _ DBClient = &Cache{}
+
+// Cache is an in-memory DBClient for running the frontend without a Cosmos DB,
+// such as in hermetic end-to-end tests. Documents are copied in and out of the
+// cache so callers never share state with it, mimicking a real database. Call
+// NewCache to initialize a Cache correctly.
+type Cache struct {
+	mutex        sync.Mutex
+	resource     map[string]*ResourceDocument
+	operation    map[string]*OperationDocument
+	subscription map[string]*arm.Subscription
+}
+
+// NewCache instantiates an empty in-memory DBClient.
+func NewCache() *Cache {
+	return &Cache{
+		resource:     make(map[string]*ResourceDocument),
+		operation:    make(map[string]*OperationDocument),
+		subscription: make(map[string]*arm.Subscription),
+	}
+}
+
+// cacheCopy copies a document through its JSON representation, the same
+// way documents round-trip through Cosmos DB.
+func cacheCopy[T any](doc *T) (*T, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var copied T
+	if err = json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+func (c *Cache) DBConnectionTest(ctx context.Context) error {
+	return nil
+}
+
+// GetLockClient returns nil since subscriptions are never locked in memory.
+func (c *Cache) GetLockClient() *LockClient {
+	return nil
+}
+
+// getResourceDoc returns a copy of a resource document. The caller must hold the mutex.
+func (c *Cache) getResourceDoc(resourceID *azcorearm.ResourceID) (*ResourceDocument, error) {
+	doc, ok := c.resource[strings.ToLower(resourceID.String())]
+	if !ok {
+		return nil, fmt.Errorf("failed to read Resources container item for '%s': %w", resourceID, ErrNotFound)
+	}
+
+	copied, err := cacheCopy(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy Resources container item for '%s': %w", resourceID, err)
+	}
+
+	return copied, nil
+}
+
+func (c *Cache) GetResourceDoc(ctx context.Context, resourceID *azcorearm.ResourceID) (*ResourceDocument, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	doc, err := c.getResourceDoc(resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Preserve the casing of the given resourceID, like the Cosmos DB client.
+	doc.ResourceID = resourceID
+
+	return doc, nil
+}
+
+func (c *Cache) CreateResourceDoc(ctx context.Context, doc *ResourceDocument) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := strings.ToLower(doc.ResourceID.String())
+	if _, ok := c.resource[key]; ok {
+		return fmt.Errorf("failed to create Resources container item for '%s': already exists", doc.ResourceID)
+	}
+
+	copied, err := cacheCopy(doc)
+	if err != nil {
+		return fmt.Errorf("failed to copy Resources container item for '%s': %w", doc.ResourceID, err)
+	}
+	c.resource[key] = copied
+
+	return nil
+}
+
+// UpdateResourceDoc holds the mutex across the read, the callback and the write
+// so that concurrent updates are applied one after another, as the etag check
+// of the Cosmos DB client ensures. The callback must not call into the cache.
+func (c *Cache) UpdateResourceDoc(ctx context.Context, resourceID *azcorearm.ResourceID, callback func(*ResourceDocument) bool) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	doc, err := c.getResourceDoc(resourceID)
+	if err != nil {
+		return false, err
+	}
+
+	if !callback(doc) {
+		return false, nil
+	}
+
+	copied, err := cacheCopy(doc)
+	if err != nil {
+		return false, fmt.Errorf("failed to copy Resources container item for '%s': %w", resourceID, err)
+	}
+	c.resource[strings.ToLower(resourceID.String())] = copied
+
+	return true, nil
+}
+
+func (c *Cache) DeleteResourceDoc(ctx context.Context, resourceID *azcorearm.ResourceID) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.resource, strings.ToLower(resourceID.String()))
+
+	return nil
+}
+
+func (c *Cache) ListResourceDocs(prefix *azcorearm.ResourceID, maxItems int32, continuationToken *string) DBClientIterator[ResourceDocument] {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	prefixString := strings.ToLower(prefix.String() + "/")
+
+	var keys []string
+	for key := range c.resource {
+		if strings.HasPrefix(key, prefixString) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	// The continuation token is simply the offset of the next page.
+	var offset int
+	if continuationToken != nil {
+		var err error
+		offset, err = strconv.Atoi(*continuationToken)
+		if err != nil || offset < 0 || offset > len(keys) {
+			return &cacheIterator[ResourceDocument]{err: fmt.Errorf("invalid continuation token '%s'", *continuationToken)}
+		}
+	}
+
+	iterator := &cacheIterator[ResourceDocument]{}
+	limit := len(keys)
+	if maxItems > 0 && offset+int(maxItems) < len(keys) {
+		limit = offset + int(maxItems)
+		iterator.continuationToken = strconv.Itoa(limit)
+	}
+	keys = keys[offset:limit]
+
+	for _, key := range keys {
+		copied, err := cacheCopy(c.resource[key])
+		if err != nil {
+			return &cacheIterator[ResourceDocument]{err: fmt.Errorf("failed to copy Resources container item for '%s': %w", key, err)}
+		}
+		iterator.ids = append(iterator.ids, key)
+		iterator.items = append(iterator.items, copied)
+	}
+
+	return iterator
+}
+
+func (c *Cache) GetOperationDoc(ctx context.Context, pk azcosmos.PartitionKey, operationID string) (*OperationDocument, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.getOperationDoc(pk, operationID)
+}
+
+// getOperationDoc returns a copy of an operation document. The caller must hold the mutex.
+func (c *Cache) getOperationDoc(pk azcosmos.PartitionKey, operationID string) (*OperationDocument, error) {
+	// Make sure lookup keys are lowercase.
+	operationID = strings.ToLower(operationID)
+
+	doc, ok := c.operation[operationID]
+	if !ok || !reflect.DeepEqual(pk, NewPartitionKey(doc.ExternalID.SubscriptionID)) {
+		return nil, fmt.Errorf("failed to read Operations container item for '%s': %w", operationID, ErrNotFound)
+	}
+
+	copied, err := cacheCopy(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy Operations container item for '%s': %w", operationID, err)
+	}
+
+	return copied, nil
+}
+
+func (c *Cache) CreateOperationDoc(ctx context.Context, doc *OperationDocument) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	operationID := uuid.New().String()
+
+	copied, err := cacheCopy(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to copy Operations container item for '%s': %w", operationID, err)
+	}
+	c.operation[operationID] = copied
+
+	return operationID, nil
+}
+
+// UpdateOperationDoc holds the mutex across the read, the callback and the write,
+// like UpdateResourceDoc.
+func (c *Cache) UpdateOperationDoc(ctx context.Context, pk azcosmos.PartitionKey, operationID string, callback func(*OperationDocument) bool) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	doc, err := c.getOperationDoc(pk, operationID)
+	if err != nil {
+		return false, err
+	}
+
+	if !callback(doc) {
+		return false, nil
+	}
+
+	copied, err := cacheCopy(doc)
+	if err != nil {
+		return false, fmt.Errorf("failed to copy Operations container item for '%s': %w", operationID, err)
+	}
+	c.operation[strings.ToLower(operationID)] = copied
+
+	return true, nil
+}
+
+func (c *Cache) ListActiveOperationDocs(pk azcosmos.PartitionKey, options *DBClientListActiveOperationDocsOptions) DBClientIterator[OperationDocument] {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var keys []string
+	for key, doc := range c.operation {
+		if doc.Status.IsTerminal() || !reflect.DeepEqual(pk, NewPartitionKey(doc.ExternalID.SubscriptionID)) {
+			continue
+		}
+		if options != nil {
+			if options.Request != nil && doc.Request != *options.Request {
+				continue
+			}
+			if options.ExternalID != nil && !strings.EqualFold(doc.ExternalID.String(), options.ExternalID.String()) {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	iterator := &cacheIterator[OperationDocument]{}
+	for _, key := range keys {
+		copied, err := cacheCopy(c.operation[key])
+		if err != nil {
+			return &cacheIterator[OperationDocument]{err: fmt.Errorf("failed to copy Operations container item for '%s': %w", key, err)}
+		}
+		iterator.ids = append(iterator.ids, key)
+		iterator.items = append(iterator.items, copied)
+	}
+
+	return iterator
+}
+
+func (c *Cache) GetSubscriptionDoc(ctx context.Context, subscriptionID string) (*arm.Subscription, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.getSubscriptionDoc(subscriptionID)
+}
+
+// getSubscriptionDoc returns a copy of a subscription document. The caller must hold the mutex.
+func (c *Cache) getSubscriptionDoc(subscriptionID string) (*arm.Subscription, error) {
+	// Make sure lookup keys are lowercase.
+	subscriptionID = strings.ToLower(subscriptionID)
+
+	doc, ok := c.subscription[subscriptionID]
+	if !ok {
+		return nil, fmt.Errorf("failed to read Subscriptions container item for '%s': %w", subscriptionID, ErrNotFound)
+	}
+
+	copied, err := cacheCopy(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy Subscriptions container item for '%s': %w", subscriptionID, err)
+	}
+
+	return copied, nil
+}
+
+func (c *Cache) CreateSubscriptionDoc(ctx context.Context, subscriptionID string, subscription *arm.Subscription) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Make sure lookup keys are lowercase.
+	subscriptionID = strings.ToLower(subscriptionID)
+
+	if _, ok := c.subscription[subscriptionID]; ok {
+		return fmt.Errorf("failed to create Subscriptions container item for '%s': already exists", subscriptionID)
+	}
+
+	copied, err := cacheCopy(subscription)
+	if err != nil {
+		return fmt.Errorf("failed to copy Subscriptions container item for '%s': %w", subscriptionID, err)
+	}
+	c.subscription[subscriptionID] = copied
+
+	return nil
+}
+
+// UpdateSubscriptionDoc holds the mutex across the read, the callback and the
+// write, like UpdateResourceDoc.
+func (c *Cache) UpdateSubscriptionDoc(ctx context.Context, subscriptionID string, callback func(*arm.Subscription) bool) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	doc, err := c.getSubscriptionDoc(subscriptionID)
+	if err != nil {
+		return false, err
+	}
+
+	if !callback(doc) {
+		return false, nil
+	}
+
+	copied, err := cacheCopy(doc)
+	if err != nil {
+		return false, fmt.Errorf("failed to copy Subscriptions container item for '%s': %w", subscriptionID, err)
+	}
+	c.subscription[strings.ToLower(subscriptionID)] = copied
+
+	return true, nil
+}
+
+func (c *Cache) ListAllSubscriptionDocs() DBClientIterator[arm.Subscription] {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var keys []string
+	for key := range c.subscription {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	iterator := &cacheIterator[arm.Subscription]{}
+	for _, key := range keys {
+		copied, err := cacheCopy(c.subscription[key])
+		if err != nil {
+			return &cacheIterator[arm.Subscription]{err: fmt.Errorf("failed to copy Subscriptions container item for '%s': %w", key, err)}
+		}
+		iterator.ids = append(iterator.ids, key)
+		iterator.items = append(iterator.items, copied)
+	}
+
+	return iterator
+}
+
+// cacheIterator is a DBClientIterator over a snapshot of cached documents.
+type cacheIterator[T DocumentProperties] struct {
+	ids               []string
+	items             []*T
+	continuationToken string
+	err               error
+}
+
+// Items returns a push iterator that can be used directly in for/range loops.
+func (iter *cacheIterator[T]) Items(ctx context.Context) DBClientIteratorItem[T] {
+	return func(yield func(string, *T) bool) {
+		for i, item := range iter.items {
+			if !yield(iter.ids[i], item) {
+				return
+			}
+		}
+	}
+}
+
+// GetContinuationToken returns a continuation token that can be used to obtain
+// the next page of results, if a limited number of items was requested.
+func (iter *cacheIterator[T]) GetContinuationToken() string {
+	return iter.continuationToken
+}
+
+// GetError returns any error that occurred while preparing the iterator.
+func (iter *cacheIterator[T]) GetError() error {
+	return iter.err
+}
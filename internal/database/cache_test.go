@@ -0,0 +1,159 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+const testSubscriptionID = "11111111-1111-1111-1111-111111111111"
+
+func newTestResourceID(t *testing.T, path string) *azcorearm.ResourceID {
+	resourceID, err := azcorearm.ParseResourceID("/subscriptions/" + testSubscriptionID + path)
+	require.NoError(t, err)
+	return resourceID
+}
+
+func TestCacheResourceDocs(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache()
+
+	group := newTestResourceID(t, "/resourceGroups/myGroup")
+	for _, name := range []string{"alpha", "beta", "gamma"} {
+		resourceID := newTestResourceID(t, "/resourceGroups/myGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/"+name)
+		require.NoError(t, cache.CreateResourceDoc(ctx, NewResourceDocument(resourceID)))
+	}
+
+	// Lookups are case-insensitive but preserve the requested casing.
+	resourceID := newTestResourceID(t, "/resourceGroups/MYGROUP/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/Alpha")
+	doc, err := cache.GetResourceDoc(ctx, resourceID)
+	require.NoError(t, err)
+	assert.Equal(t, resourceID.String(), doc.ResourceID.String())
+
+	// Modifying a returned document does not modify the cache.
+	doc.ProvisioningState = arm.ProvisioningStateFailed
+	doc, err = cache.GetResourceDoc(ctx, resourceID)
+	require.NoError(t, err)
+	assert.Empty(t, doc.ProvisioningState)
+
+	updated, err := cache.UpdateResourceDoc(ctx, resourceID, func(doc *ResourceDocument) bool {
+		doc.ProvisioningState = arm.ProvisioningStateSucceeded
+		return true
+	})
+	require.NoError(t, err)
+	assert.True(t, updated)
+	doc, err = cache.GetResourceDoc(ctx, resourceID)
+	require.NoError(t, err)
+	assert.Equal(t, arm.ProvisioningStateSucceeded, doc.ProvisioningState)
+
+	var names []string
+	iterator := cache.ListResourceDocs(group, 2, nil)
+	for _, doc := range iterator.Items(ctx) {
+		names = append(names, doc.ResourceID.Name)
+	}
+	require.NoError(t, iterator.GetError())
+	assert.Equal(t, []string{"alpha", "beta"}, names)
+
+	continuationToken := iterator.GetContinuationToken()
+	assert.NotEmpty(t, continuationToken)
+	iterator = cache.ListResourceDocs(group, 2, &continuationToken)
+	names = nil
+	for _, doc := range iterator.Items(ctx) {
+		names = append(names, doc.ResourceID.Name)
+	}
+	require.NoError(t, iterator.GetError())
+	assert.Equal(t, []string{"gamma"}, names)
+	assert.Empty(t, iterator.GetContinuationToken())
+
+	require.NoError(t, cache.DeleteResourceDoc(ctx, resourceID))
+	_, err = cache.GetResourceDoc(ctx, resourceID)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestCacheConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache()
+
+	resourceID := newTestResourceID(t, "/resourceGroups/myGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/alpha")
+	require.NoError(t, cache.CreateResourceDoc(ctx, NewResourceDocument(resourceID)))
+
+	// Every update sees the result of the previous one, none of them is lost.
+	const updates = 50
+	var wg sync.WaitGroup
+	for i := range updates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.UpdateResourceDoc(ctx, resourceID, func(doc *ResourceDocument) bool {
+				if doc.Tags == nil {
+					doc.Tags = make(map[string]string)
+				}
+				doc.Tags[strconv.Itoa(i)] = "updated"
+				return true
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	doc, err := cache.GetResourceDoc(ctx, resourceID)
+	require.NoError(t, err)
+	assert.Len(t, doc.Tags, updates)
+}
+
+func TestCacheOperationDocs(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache()
+	pk := NewPartitionKey(testSubscriptionID)
+
+	resourceID := newTestResourceID(t, "/resourceGroups/myGroup/providers/Microsoft.RedHatOpenShift/hcpOpenShiftClusters/alpha")
+	createID, err := cache.CreateOperationDoc(ctx, &OperationDocument{Request: OperationRequestCreate, ExternalID: resourceID, Status: arm.ProvisioningStateAccepted})
+	require.NoError(t, err)
+	deleteID, err := cache.CreateOperationDoc(ctx, &OperationDocument{Request: OperationRequestDelete, ExternalID: resourceID, Status: arm.ProvisioningStateDeleting})
+	require.NoError(t, err)
+
+	_, err = cache.GetOperationDoc(ctx, NewPartitionKey("22222222-2222-2222-2222-222222222222"), createID)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	updated, err := cache.UpdateOperationDoc(ctx, pk, createID, func(doc *OperationDocument) bool {
+		return doc.UpdateStatus(arm.ProvisioningStateSucceeded, nil)
+	})
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	var active []string
+	iterator := cache.ListActiveOperationDocs(pk, nil)
+	for id := range iterator.Items(ctx) {
+		active = append(active, id)
+	}
+	require.NoError(t, iterator.GetError())
+	assert.Equal(t, []string{deleteID}, active)
+
+	request := OperationRequestCreate
+	iterator = cache.ListActiveOperationDocs(pk, &DBClientListActiveOperationDocsOptions{Request: &request})
+	for id := range iterator.Items(ctx) {
+		t.Errorf("unexpected active operation %s", id)
+	}
+}
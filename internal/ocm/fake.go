@@ -0,0 +1,414 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+)
+
+const fakeBreakGlassCredentialLifetime = 24 * time.Hour
+
+var _ ClusterServiceClientSpec = &FakeClusterServiceClient{}
+
+// FakeClusterServiceClient is an in-memory ClusterServiceClientSpec for running
+// the frontend without a Cluster Service, such as in hermetic end-to-end tests.
+// Clusters and node pools are ready as soon as they are created, and deletions
+// take effect immediately. Search expressions are ignored, list methods return
+// every object. Call NewFakeClusterServiceClient to initialize it correctly.
+type FakeClusterServiceClient struct {
+	mutex                 sync.Mutex
+	clusters              map[string]*arohcpv1alpha1.Cluster
+	nodePools             map[string]map[string]*arohcpv1alpha1.NodePool
+	breakGlassCredentials map[string]map[string]*cmv1.BreakGlassCredential
+}
+
+// NewFakeClusterServiceClient instantiates an empty FakeClusterServiceClient.
+func NewFakeClusterServiceClient() *FakeClusterServiceClient {
+	return &FakeClusterServiceClient{
+		clusters:              make(map[string]*arohcpv1alpha1.Cluster),
+		nodePools:             make(map[string]map[string]*arohcpv1alpha1.NodePool),
+		breakGlassCredentials: make(map[string]map[string]*cmv1.BreakGlassCredential),
+	}
+}
+
+// fakeID generates an identifier in the style of Cluster Service.
+func fakeID() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// fakeClusterID returns the identifier of the cluster an InternalID belongs to.
+func fakeClusterID(internalID InternalID) string {
+	segments := strings.Split(internalID.String(), "/")
+	for i, segment := range segments[:len(segments)-1] {
+		if segment == "clusters" {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
+
+func fakeNotFound(kind string, internalID InternalID) error {
+	err, buildErr := ocmerrors.NewError().
+		Status(http.StatusNotFound).
+		Reason(fmt.Sprintf("%s '%s' not found", kind, internalID.String())).
+		Build()
+	if buildErr != nil {
+		return buildErr
+	}
+	return err
+}
+
+// fakeMerge applies update onto current the way Cluster Service applies a
+// PATCH request: objects are merged recursively, any other value replaces
+// the current one.
+func fakeMerge[T any](current, update *T, marshal func(*T, io.Writer) error, unmarshal func(interface{}) (*T, error)) (*T, error) {
+	var currentData, updateData bytes.Buffer
+	if err := marshal(current, &currentData); err != nil {
+		return nil, err
+	}
+	if err := marshal(update, &updateData); err != nil {
+		return nil, err
+	}
+
+	var currentMap, updateMap map[string]any
+	if err := json.Unmarshal(currentData.Bytes(), &currentMap); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(updateData.Bytes(), &updateMap); err != nil {
+		return nil, err
+	}
+
+	merged, err := json.Marshal(fakeMergeMaps(currentMap, updateMap))
+	if err != nil {
+		return nil, err
+	}
+	return unmarshal(merged)
+}
+
+func fakeMergeMaps(current, update map[string]any) map[string]any {
+	for key, value := range update {
+		currentValue, currentIsMap := current[key].(map[string]any)
+		updateValue, updateIsMap := value.(map[string]any)
+		if currentIsMap && updateIsMap {
+			current[key] = fakeMergeMaps(currentValue, updateValue)
+		} else {
+			current[key] = value
+		}
+	}
+	return current
+}
+
+func (csc *FakeClusterServiceClient) AddProperties(builder *arohcpv1alpha1.ClusterBuilder) *arohcpv1alpha1.ClusterBuilder {
+	return builder
+}
+
+func (csc *FakeClusterServiceClient) GetCluster(ctx context.Context, internalID InternalID) (*arohcpv1alpha1.Cluster, error) {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	cluster, ok := csc.clusters[internalID.ID()]
+	if !ok {
+		return nil, fakeNotFound("Cluster", internalID)
+	}
+	return cluster, nil
+}
+
+func (csc *FakeClusterServiceClient) GetClusterStatus(ctx context.Context, internalID InternalID) (*arohcpv1alpha1.ClusterStatus, error) {
+	if _, err := csc.GetCluster(ctx, internalID); err != nil {
+		return nil, err
+	}
+	return arohcpv1alpha1.NewClusterStatus().
+		ID(internalID.ID()).
+		State(arohcpv1alpha1.ClusterStateReady).
+		Build()
+}
+
+func (csc *FakeClusterServiceClient) GetClusterInflightChecks(ctx context.Context, internalID InternalID) (*arohcpv1alpha1.InflightCheckList, error) {
+	if _, err := csc.GetCluster(ctx, internalID); err != nil {
+		return nil, err
+	}
+	return arohcpv1alpha1.NewInflightCheckList().Build()
+}
+
+func (csc *FakeClusterServiceClient) PostCluster(ctx context.Context, cluster *arohcpv1alpha1.Cluster) (*arohcpv1alpha1.Cluster, error) {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	id := fakeID()
+	cluster, err := arohcpv1alpha1.NewCluster().
+		Copy(cluster).
+		ID(id).
+		HREF(path.Join(aroHcpV1Alpha1Pattern, "clusters", id)).
+		State(arohcpv1alpha1.ClusterStateReady).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	csc.clusters[id] = cluster
+	csc.nodePools[id] = make(map[string]*arohcpv1alpha1.NodePool)
+	csc.breakGlassCredentials[id] = make(map[string]*cmv1.BreakGlassCredential)
+
+	return cluster, nil
+}
+
+func (csc *FakeClusterServiceClient) UpdateCluster(ctx context.Context, internalID InternalID, cluster *arohcpv1alpha1.Cluster) (*arohcpv1alpha1.Cluster, error) {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	current, ok := csc.clusters[internalID.ID()]
+	if !ok {
+		return nil, fakeNotFound("Cluster", internalID)
+	}
+
+	cluster, err := fakeMerge(current, cluster, arohcpv1alpha1.MarshalCluster, arohcpv1alpha1.UnmarshalCluster)
+	if err != nil {
+		return nil, err
+	}
+
+	csc.clusters[internalID.ID()] = cluster
+
+	return cluster, nil
+}
+
+func (csc *FakeClusterServiceClient) DeleteCluster(ctx context.Context, internalID InternalID) error {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	if _, ok := csc.clusters[internalID.ID()]; !ok {
+		return fakeNotFound("Cluster", internalID)
+	}
+
+	// Node pools and credentials go away with their cluster.
+	delete(csc.clusters, internalID.ID())
+	delete(csc.nodePools, internalID.ID())
+	delete(csc.breakGlassCredentials, internalID.ID())
+
+	return nil
+}
+
+func (csc *FakeClusterServiceClient) ListClusters(searchExpression string) ClusterListIterator {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	var iterator ClusterListIterator
+	for _, id := range sortedKeys(csc.clusters) {
+		iterator.items = append(iterator.items, csc.clusters[id])
+	}
+	return iterator
+}
+
+func (csc *FakeClusterServiceClient) GetNodePool(ctx context.Context, internalID InternalID) (*arohcpv1alpha1.NodePool, error) {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	nodePool, ok := csc.nodePools[fakeClusterID(internalID)][internalID.ID()]
+	if !ok {
+		return nil, fakeNotFound("Node pool", internalID)
+	}
+	return nodePool, nil
+}
+
+func (csc *FakeClusterServiceClient) GetNodePoolStatus(ctx context.Context, internalID InternalID) (*arohcpv1alpha1.NodePoolStatus, error) {
+	if _, err := csc.GetNodePool(ctx, internalID); err != nil {
+		return nil, err
+	}
+	return arohcpv1alpha1.NewNodePoolStatus().
+		ID(internalID.ID()).
+		State(arohcpv1alpha1.NewNodePoolState().NodePoolStateValue("ready")).
+		Build()
+}
+
+func (csc *FakeClusterServiceClient) PostNodePool(ctx context.Context, clusterInternalID InternalID, nodePool *arohcpv1alpha1.NodePool) (*arohcpv1alpha1.NodePool, error) {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	nodePools, ok := csc.nodePools[clusterInternalID.ID()]
+	if !ok {
+		return nil, fakeNotFound("Cluster", clusterInternalID)
+	}
+
+	// Node pools are identified by the ID given in the request.
+	id := nodePool.ID()
+	if _, ok := nodePools[id]; ok {
+		err, _ := ocmerrors.NewError().
+			Status(http.StatusConflict).
+			Reason(fmt.Sprintf("Node pool '%s' already exists", id)).
+			Build()
+		return nil, err
+	}
+
+	nodePool, err := arohcpv1alpha1.NewNodePool().
+		Copy(nodePool).
+		HREF(GenerateNodePoolHREF(csc.clusters[clusterInternalID.ID()].HREF(), id)).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	nodePools[id] = nodePool
+
+	return nodePool, nil
+}
+
+func (csc *FakeClusterServiceClient) UpdateNodePool(ctx context.Context, internalID InternalID, nodePool *arohcpv1alpha1.NodePool) (*arohcpv1alpha1.NodePool, error) {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	current, ok := csc.nodePools[fakeClusterID(internalID)][internalID.ID()]
+	if !ok {
+		return nil, fakeNotFound("Node pool", internalID)
+	}
+
+	nodePool, err := fakeMerge(current, nodePool, arohcpv1alpha1.MarshalNodePool, arohcpv1alpha1.UnmarshalNodePool)
+	if err != nil {
+		return nil, err
+	}
+
+	csc.nodePools[fakeClusterID(internalID)][internalID.ID()] = nodePool
+
+	return nodePool, nil
+}
+
+func (csc *FakeClusterServiceClient) DeleteNodePool(ctx context.Context, internalID InternalID) error {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	if _, ok := csc.nodePools[fakeClusterID(internalID)][internalID.ID()]; !ok {
+		return fakeNotFound("Node pool", internalID)
+	}
+
+	delete(csc.nodePools[fakeClusterID(internalID)], internalID.ID())
+
+	return nil
+}
+
+func (csc *FakeClusterServiceClient) ListNodePools(clusterInternalID InternalID, searchExpression string) NodePoolListIterator {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	nodePools, ok := csc.nodePools[clusterInternalID.ID()]
+	if !ok {
+		return NodePoolListIterator{err: fakeNotFound("Cluster", clusterInternalID)}
+	}
+
+	var iterator NodePoolListIterator
+	for _, id := range sortedKeys(nodePools) {
+		iterator.items = append(iterator.items, nodePools[id])
+	}
+	return iterator
+}
+
+func (csc *FakeClusterServiceClient) GetBreakGlassCredential(ctx context.Context, internalID InternalID) (*cmv1.BreakGlassCredential, error) {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	breakGlassCredential, ok := csc.breakGlassCredentials[fakeClusterID(internalID)][internalID.ID()]
+	if !ok {
+		return nil, fakeNotFound("Break glass credential", internalID)
+	}
+	return breakGlassCredential, nil
+}
+
+func (csc *FakeClusterServiceClient) PostBreakGlassCredential(ctx context.Context, clusterInternalID InternalID) (*cmv1.BreakGlassCredential, error) {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	breakGlassCredentials, ok := csc.breakGlassCredentials[clusterInternalID.ID()]
+	if !ok {
+		return nil, fakeNotFound("Cluster", clusterInternalID)
+	}
+
+	// Break glass credentials are only offered by the v1 API.
+	id := fakeID()
+	breakGlassCredential, err := cmv1.NewBreakGlassCredential().
+		ID(id).
+		HREF(GenerateBreakGlassCredentialHREF(GenerateClusterHREF(clusterInternalID.ID()), id)).
+		Username("system:admin").
+		Status(cmv1.BreakGlassCredentialStatusIssued).
+		ExpirationTimestamp(time.Now().Add(fakeBreakGlassCredentialLifetime)).
+		Kubeconfig(fmt.Sprintf("apiVersion: v1\nkind: Config\ncurrent-context: %s\n", clusterInternalID.ID())).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	breakGlassCredentials[id] = breakGlassCredential
+
+	return breakGlassCredential, nil
+}
+
+func (csc *FakeClusterServiceClient) DeleteBreakGlassCredentials(ctx context.Context, clusterInternalID InternalID) error {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	breakGlassCredentials, ok := csc.breakGlassCredentials[clusterInternalID.ID()]
+	if !ok {
+		return fakeNotFound("Cluster", clusterInternalID)
+	}
+
+	for id, breakGlassCredential := range breakGlassCredentials {
+		revoked, err := cmv1.NewBreakGlassCredential().
+			Copy(breakGlassCredential).
+			Status(cmv1.BreakGlassCredentialStatusRevoked).
+			Build()
+		if err != nil {
+			return err
+		}
+		breakGlassCredentials[id] = revoked
+	}
+
+	return nil
+}
+
+func (csc *FakeClusterServiceClient) ListBreakGlassCredentials(clusterInternalID InternalID, searchExpression string) BreakGlassCredentialListIterator {
+	csc.mutex.Lock()
+	defer csc.mutex.Unlock()
+
+	breakGlassCredentials, ok := csc.breakGlassCredentials[clusterInternalID.ID()]
+	if !ok {
+		return BreakGlassCredentialListIterator{err: fakeNotFound("Cluster", clusterInternalID)}
+	}
+
+	var iterator BreakGlassCredentialListIterator
+	for _, id := range sortedKeys(breakGlassCredentials) {
+		iterator.items = append(iterator.items, breakGlassCredentials[id])
+	}
+	return iterator
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
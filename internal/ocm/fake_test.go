@@ -0,0 +1,88 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	arohcpv1alpha1 "github.com/openshift-online/ocm-sdk-go/arohcp/v1alpha1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClusterServiceClient(t *testing.T) {
+	ctx := context.Background()
+	csc := NewFakeClusterServiceClient()
+
+	cluster, err := arohcpv1alpha1.NewCluster().
+		Name("myCluster").
+		Region(arohcpv1alpha1.NewCloudRegion().ID("westus3")).
+		Build()
+	require.NoError(t, err)
+
+	cluster, err = csc.PostCluster(ctx, cluster)
+	require.NoError(t, err)
+	clusterID, err := NewInternalID(cluster.HREF())
+	require.NoError(t, err)
+	assert.Equal(t, arohcpv1alpha1.ClusterKind, clusterID.Kind())
+
+	// Updates are merged like a PATCH request.
+	update, err := arohcpv1alpha1.NewCluster().
+		Region(arohcpv1alpha1.NewCloudRegion().DisplayName("West US 3")).
+		Build()
+	require.NoError(t, err)
+	cluster, err = csc.UpdateCluster(ctx, clusterID, update)
+	require.NoError(t, err)
+	assert.Equal(t, "myCluster", cluster.Name())
+	assert.Equal(t, "westus3", cluster.Region().ID())
+	assert.Equal(t, "West US 3", cluster.Region().DisplayName())
+
+	nodePool, err := arohcpv1alpha1.NewNodePool().ID("mynodepool").Replicas(2).Build()
+	require.NoError(t, err)
+	nodePool, err = csc.PostNodePool(ctx, clusterID, nodePool)
+	require.NoError(t, err)
+	nodePoolID, err := NewInternalID(nodePool.HREF())
+	require.NoError(t, err)
+	assert.Equal(t, arohcpv1alpha1.NodePoolKind, nodePoolID.Kind())
+
+	var nodePools []string
+	for item := range csc.ListNodePools(clusterID, "").Items(ctx) {
+		nodePools = append(nodePools, item.ID())
+	}
+	assert.Equal(t, []string{"mynodepool"}, nodePools)
+
+	breakGlassCredential, err := csc.PostBreakGlassCredential(ctx, clusterID)
+	require.NoError(t, err)
+	breakGlassCredentialID, err := NewInternalID(breakGlassCredential.HREF())
+	require.NoError(t, err)
+	assert.Equal(t, cmv1.BreakGlassCredentialStatusIssued, breakGlassCredential.Status())
+
+	require.NoError(t, csc.DeleteBreakGlassCredentials(ctx, clusterID))
+	breakGlassCredential, err = csc.GetBreakGlassCredential(ctx, breakGlassCredentialID)
+	require.NoError(t, err)
+	assert.Equal(t, cmv1.BreakGlassCredentialStatusRevoked, breakGlassCredential.Status())
+
+	// Deleting the cluster deletes its node pools.
+	require.NoError(t, csc.DeleteCluster(ctx, clusterID))
+	_, err = csc.GetNodePool(ctx, nodePoolID)
+	var ocmError *ocmerrors.Error
+	require.True(t, errors.As(err, &ocmError))
+	assert.Equal(t, http.StatusNotFound, ocmError.Status())
+}
@@ -25,6 +25,7 @@ import (
 
 type ClusterListIterator struct {
 	request *arohcpv1alpha1.ClustersListRequest
+	items   []*arohcpv1alpha1.Cluster
 	err     error
 }
 
@@ -32,7 +33,13 @@ type ClusterListIterator struct {
 // If an error occurs during paging, iteration stops and the error is recorded.
 func (iter ClusterListIterator) Items(ctx context.Context) iter.Seq[*arohcpv1alpha1.Cluster] {
 	return func(yield func(*arohcpv1alpha1.Cluster) bool) {
-		// Request can be nil to allow for mocking.
+		// Request can be nil to allow for mocking and faking,
+		// in which case only the preset items are yielded.
+		for _, item := range iter.items {
+			if !yield(item) {
+				return
+			}
+		}
 		if iter.request != nil {
 			var page = 0
 			var count = 0
@@ -79,6 +86,7 @@ func (iter ClusterListIterator) GetError() error {
 
 type NodePoolListIterator struct {
 	request *arohcpv1alpha1.NodePoolsListRequest
+	items   []*arohcpv1alpha1.NodePool
 	err     error
 }
 
@@ -86,7 +94,13 @@ type NodePoolListIterator struct {
 // If an error occurs during paging, iteration stops and the error is recorded.
 func (iter NodePoolListIterator) Items(ctx context.Context) iter.Seq[*arohcpv1alpha1.NodePool] {
 	return func(yield func(*arohcpv1alpha1.NodePool) bool) {
-		// Request can be nil to allow for mocking.
+		// Request can be nil to allow for mocking and faking,
+		// in which case only the preset items are yielded.
+		for _, item := range iter.items {
+			if !yield(item) {
+				return
+			}
+		}
 		if iter.request != nil {
 			var page = 0
 			var count = 0
@@ -133,6 +147,7 @@ func (iter NodePoolListIterator) GetError() error {
 
 type BreakGlassCredentialListIterator struct {
 	request *cmv1.BreakGlassCredentialsListRequest
+	items   []*cmv1.BreakGlassCredential
 	err     error
 }
 
@@ -140,7 +155,13 @@ type BreakGlassCredentialListIterator struct {
 // If an error occurs during paging, iteration stops and the error is recorded.
 func (iter BreakGlassCredentialListIterator) Items(ctx context.Context) iter.Seq[*cmv1.BreakGlassCredential] {
 	return func(yield func(*cmv1.BreakGlassCredential) bool) {
-		// Request can be nil to allow for mocking.
+		// Request can be nil to allow for mocking and faking,
+		// in which case only the preset items are yielded.
+		for _, item := range iter.items {
+			if !yield(item) {
+				return
+			}
+		}
 		if iter.request != nil {
 			var page = 0
 			var count = 0
@@ -0,0 +1,166 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+const (
+	apiVersion = "2024-06-10-preview"
+
+	// operationTimeout bounds how long a test case waits for an asynchronous
+	// operation to reach a terminal state.
+	operationTimeout = 45 * time.Minute
+)
+
+// asyncOperation holds the callback URLs returned by a request that
+// initiated an asynchronous operation.
+type asyncOperation struct {
+	// StatusURL is the "Azure-AsyncOperation" header, pointing to the
+	// hcpOperationStatuses endpoint.
+	StatusURL string
+	// ResultURL is the "Location" header, pointing to the
+	// hcpOperationResults endpoint.
+	ResultURL string
+}
+
+// captureResponse returns a context that records the raw HTTP response of
+// the first request sent with it.
+func captureResponse(ctx context.Context) (context.Context, **http.Response) {
+	var resp *http.Response
+	return policy.WithCaptureResponse(ctx, &resp), &resp
+}
+
+// expectAsyncOperation asserts the response carries the ARM headers of an
+// asynchronous operation. PUT requests only return an "Azure-AsyncOperation"
+// header while PATCH, DELETE and POST requests also return a "Location" header.
+func expectAsyncOperation(resp *http.Response, expectedStatusCode int) asyncOperation {
+	Expect(resp).ToNot(BeNil())
+	Expect(resp.StatusCode).To(Equal(expectedStatusCode))
+
+	operation := asyncOperation{
+		StatusURL: resp.Header.Get(arm.HeaderNameAsyncOperation),
+		ResultURL: resp.Header.Get("Location"),
+	}
+
+	Expect(operation.StatusURL).ToNot(BeEmpty(), "missing %s header", arm.HeaderNameAsyncOperation)
+	operationName := expectOperationURL(operation.StatusURL, api.OperationStatusResourceTypeName)
+
+	if resp.Request.Method == http.MethodPut {
+		Expect(operation.ResultURL).To(BeEmpty(), "unexpected Location header")
+	} else {
+		Expect(operation.ResultURL).ToNot(BeEmpty(), "missing Location header")
+		Expect(expectOperationURL(operation.ResultURL, api.OperationResultResourceTypeName)).To(Equal(operationName))
+	}
+
+	return operation
+}
+
+// expectOperationURL asserts the URL points to the given operation endpoint
+// and returns the operation name.
+func expectOperationURL(rawURL, resourceTypeName string) string {
+	u, err := url.Parse(rawURL)
+	Expect(err).To(BeNil())
+	Expect(u.Query().Get("api-version")).To(Equal(apiVersion))
+	Expect(strings.ToLower(path.Dir(u.Path))).To(HaveSuffix(strings.ToLower(
+		fmt.Sprintf("/subscriptions/%s/providers/%s/locations/%s/%s", subscriptionID, api.ProviderNamespace, location, resourceTypeName))))
+	return path.Base(u.Path)
+}
+
+// sendRequest sends a raw request to the given URL, which is either absolute
+// or a resource path relative to the ARM endpoint.
+func sendRequest(ctx context.Context, method, rawURL string, body any) *http.Response {
+	if strings.HasPrefix(rawURL, "/") {
+		rawURL = runtime.JoinPaths(armClient.Endpoint(), rawURL) + "?api-version=" + apiVersion
+	}
+
+	req, err := runtime.NewRequest(ctx, method, rawURL)
+	Expect(err).To(BeNil())
+	req.Raw().Header.Set("Accept", "application/json")
+	if body != nil {
+		Expect(runtime.MarshalAsJSON(req, body)).To(Succeed())
+	}
+
+	resp, err := armClient.Pipeline().Do(req)
+	Expect(err).To(BeNil())
+	return resp
+}
+
+// waitForOperation polls the hcpOperationStatuses endpoint until the
+// operation reaches a terminal state and returns the final status.
+func waitForOperation(ctx context.Context, operation asyncOperation) *arm.Operation {
+	status := &arm.Operation{}
+
+	Eventually(func(g Gomega) {
+		resp := sendRequest(ctx, http.MethodGet, operation.StatusURL, nil)
+		g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		g.Expect(runtime.UnmarshalAsJSON(resp, status)).To(Succeed())
+		g.Expect(status.Status.IsTerminal()).To(BeTrue(), "operation %s is %s", status.Name, status.Status)
+	}).WithContext(ctx).WithTimeout(operationTimeout).WithPolling(time.Second).Should(Succeed())
+
+	By(fmt.Sprintf("Operation %s finished with status %s", status.Name, status.Status))
+	return status
+}
+
+// expectOperationSucceeded waits for the operation to complete and asserts
+// that it succeeded.
+func expectOperationSucceeded(ctx context.Context, operation asyncOperation) {
+	status := waitForOperation(ctx, operation)
+	Expect(status.Error).To(BeNil())
+	Expect(status.Status).To(Equal(arm.ProvisioningStateSucceeded))
+	Expect(status.StartTime).ToNot(BeNil())
+	Expect(status.EndTime).ToNot(BeNil())
+}
+
+// getOperationResult sends a request to the hcpOperationResults endpoint
+// of a completed operation.
+func getOperationResult(ctx context.Context, operation asyncOperation) *http.Response {
+	Expect(operation.ResultURL).ToNot(BeEmpty())
+	return sendRequest(ctx, http.MethodGet, operation.ResultURL, nil)
+}
+
+// expectCloudError asserts the response is an ARM error response with the
+// given status code and returns its body.
+func expectCloudError(resp *http.Response, expectedStatusCode int) *arm.CloudError {
+	Expect(resp.StatusCode).To(Equal(expectedStatusCode))
+	cloudError := &arm.CloudError{}
+	Expect(runtime.UnmarshalAsJSON(resp, cloudError)).To(Succeed())
+	Expect(cloudError.CloudErrorBody).ToNot(BeNil())
+	return cloudError
+}
+
+// cloudErrorMessages flattens the messages of an ARM error and its details.
+func cloudErrorMessages(cloudError *arm.CloudError) []string {
+	messages := []string{cloudError.Message}
+	for _, detail := range cloudError.Details {
+		messages = append(messages, detail.Message)
+	}
+	return messages
+}
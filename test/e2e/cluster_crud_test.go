@@ -0,0 +1,254 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	hcpapi "github.com/Azure/ARO-HCP/internal/api/v20240610preview/generated"
+	"github.com/Azure/ARO-HCP/test/util/labels"
+)
+
+// randomName returns a resource name unique enough to not collide with
+// resources of concurrent test runs.
+func randomName(prefix string) string {
+	return fmt.Sprintf("%s-%05d", prefix, rand.IntN(100000))
+}
+
+func newTestCluster() hcpapi.HcpOpenShiftCluster {
+	return hcpapi.HcpOpenShiftCluster{
+		Location: to.Ptr(location),
+		Properties: &hcpapi.HcpOpenShiftClusterProperties{
+			Platform: &hcpapi.PlatformProfile{
+				SubnetID:               to.Ptr(customerSubnetID),
+				NetworkSecurityGroupID: to.Ptr(customerNSGID),
+			},
+		},
+	}
+}
+
+func skipUnlessClusterCreationConfigured() {
+	if customerSubnetID == "" || customerNSGID == "" {
+		Skip("CUSTOMER_SUBNET_ID and CUSTOMER_NSG_ID must be set to create clusters")
+	}
+}
+
+// expectNotFound asserts the error is an Azure error with a Not Found status.
+func expectNotFound(err error) {
+	var respErr *azcore.ResponseError
+	Expect(errors.As(err, &respErr)).To(BeTrue())
+	Expect(respErr.StatusCode).To(Equal(http.StatusNotFound))
+}
+
+func clusterResourcePath(clusterName string) string {
+	return path.Join("/subscriptions", subscriptionID,
+		"resourceGroups", customerRGName,
+		"providers", api.ProviderNamespace,
+		api.ClusterResourceTypeName, clusterName)
+}
+
+// createClusterAndWait creates a cluster, asserting the asynchronous operation
+// headers, and waits for the creation to succeed.
+func createClusterAndWait(ctx context.Context, clustersClient *hcpapi.HcpOpenShiftClustersClient, clusterName string) {
+	captureCtx, resp := captureResponse(ctx)
+	_, err := clustersClient.BeginCreateOrUpdate(captureCtx, customerRGName, clusterName, newTestCluster(), nil)
+	Expect(err).To(BeNil())
+	expectOperationSucceeded(ctx, expectAsyncOperation(*resp, http.StatusCreated))
+}
+
+// deleteClusterAndWait deletes a cluster, asserting the asynchronous operation
+// headers, and waits for the deletion to succeed.
+func deleteClusterAndWait(ctx context.Context, clustersClient *hcpapi.HcpOpenShiftClustersClient, clusterName string) asyncOperation {
+	captureCtx, resp := captureResponse(ctx)
+	_, err := clustersClient.BeginDelete(captureCtx, customerRGName, clusterName, nil)
+	Expect(err).To(BeNil())
+	operation := expectAsyncOperation(*resp, http.StatusAccepted)
+	expectOperationSucceeded(ctx, operation)
+	return operation
+}
+
+var _ = Describe("Create, update and delete HCPOpenShiftCluster", labels.ClusterLifecycle, Ordered, func() {
+	defer GinkgoRecover()
+
+	var (
+		clustersClient *hcpapi.HcpOpenShiftClustersClient
+		clusterName    string
+		deleted        bool
+	)
+
+	BeforeAll(func(ctx context.Context) {
+		skipUnlessClusterCreationConfigured()
+
+		By("Preparing HCP clusters client")
+		clustersClient = clients.NewHcpOpenShiftClustersClient()
+		clusterName = randomName("e2e-cluster")
+
+		By("Creating the cluster and polling the operation until it succeeds")
+		createClusterAndWait(ctx, clustersClient, clusterName)
+
+		DeferCleanup(func(ctx context.Context) {
+			if !deleted {
+				By("Deleting the cluster")
+				deleteClusterAndWait(ctx, clustersClient, clusterName)
+			}
+		})
+	})
+
+	It("Gets and lists the created cluster", labels.Critical, func(ctx context.Context) {
+		By("Getting the cluster with a Succeeded provisioning state")
+		getResp, err := clustersClient.Get(ctx, customerRGName, clusterName, nil)
+		Expect(err).To(BeNil())
+		Expect(*getResp.Properties.ProvisioningState).To(Equal(hcpapi.ProvisioningStateSucceeded))
+
+		By("Finding the cluster in the resource group listing")
+		var names []string
+		pager := clustersClient.NewListByResourceGroupPager(customerRGName, nil)
+		for pager.More() {
+			clusterList, err := pager.NextPage(ctx)
+			Expect(err).To(BeNil())
+			for _, val := range clusterList.Value {
+				names = append(names, *val.Name)
+			}
+		}
+		Expect(names).To(ContainElement(clusterName))
+	})
+
+	It("Rejects a PATCH request changing a create-only field", labels.High, labels.Negative, func(ctx context.Context) {
+		By("Sending a PATCH request for a new subnet")
+		body := map[string]any{
+			"properties": map[string]any{
+				"platform": map[string]any{
+					"subnetId": customerSubnetID + "-changed",
+				},
+			},
+		}
+		resp := sendRequest(ctx, http.MethodPatch, clusterResourcePath(clusterName), body)
+
+		By("Checking the request was rejected without starting an operation")
+		cloudError := expectCloudError(resp, http.StatusBadRequest)
+		Expect(cloudErrorMessages(cloudError)).To(ContainElement("Field 'subnetId' cannot be updated"))
+		Expect(resp.Header.Get(arm.HeaderNameAsyncOperation)).To(BeEmpty())
+		Expect(resp.Header.Get("Location")).To(BeEmpty())
+	})
+
+	It("Rejects a PATCH request changing a read-only field", labels.High, labels.Negative, func(ctx context.Context) {
+		By("Sending a PATCH request for a new console URL")
+		body := map[string]any{
+			"properties": map[string]any{
+				"console": map[string]any{
+					"url": "https://console.example.com",
+				},
+			},
+		}
+		resp := sendRequest(ctx, http.MethodPatch, clusterResourcePath(clusterName), body)
+
+		By("Checking the request was rejected without starting an operation")
+		cloudError := expectCloudError(resp, http.StatusBadRequest)
+		Expect(cloudErrorMessages(cloudError)).To(ContainElement("Field 'url' is read-only"))
+		Expect(resp.Header.Get(arm.HeaderNameAsyncOperation)).To(BeEmpty())
+		Expect(resp.Header.Get("Location")).To(BeEmpty())
+	})
+
+	It("Updates the cluster tags with a PATCH request", labels.High, func(ctx context.Context) {
+		By("Sending a PATCH request for new tags")
+		update := hcpapi.HcpOpenShiftClusterUpdate{
+			Tags: map[string]*string{"e2e": to.Ptr("updated")},
+		}
+		captureCtx, resp := captureResponse(ctx)
+		_, err := clustersClient.BeginUpdate(captureCtx, customerRGName, clusterName, update, nil)
+		Expect(err).To(BeNil())
+
+		By("Checking the response has Azure-AsyncOperation and Location headers")
+		operation := expectAsyncOperation(*resp, http.StatusAccepted)
+		expectOperationSucceeded(ctx, operation)
+
+		By("Getting the operation result with the updated cluster")
+		resultResp := getOperationResult(ctx, operation)
+		Expect(resultResp.StatusCode).To(Equal(http.StatusOK))
+		var cluster hcpapi.HcpOpenShiftCluster
+		Expect(runtime.UnmarshalAsJSON(resultResp, &cluster)).To(Succeed())
+		Expect(cluster.Tags).To(HaveKeyWithValue("e2e", to.Ptr("updated")))
+	})
+
+	Context("Admin credentials", labels.CredentialLifecycle, func() {
+		It("Requests an admin credential", labels.Critical, func(ctx context.Context) {
+			By("Sending a POST request for an admin credential")
+			captureCtx, resp := captureResponse(ctx)
+			_, err := clustersClient.BeginRequestAdminCredential(captureCtx, customerRGName, clusterName, nil)
+			Expect(err).To(BeNil())
+
+			By("Checking the response has Azure-AsyncOperation and Location headers")
+			operation := expectAsyncOperation(*resp, http.StatusAccepted)
+			expectOperationSucceeded(ctx, operation)
+
+			By("Getting the operation result with the kubeconfig")
+			resultResp := getOperationResult(ctx, operation)
+			Expect(resultResp.StatusCode).To(Equal(http.StatusOK))
+			var credential hcpapi.HcpOpenShiftClusterAdminCredential
+			Expect(runtime.UnmarshalAsJSON(resultResp, &credential)).To(Succeed())
+			Expect(credential.Kubeconfig).ToNot(BeNil())
+			Expect(*credential.Kubeconfig).ToNot(BeEmpty())
+			Expect(credential.ExpirationTimestamp).ToNot(BeNil())
+			Expect(*credential.ExpirationTimestamp).To(BeTemporally(">", time.Now()))
+		})
+
+		It("Revokes all admin credentials", labels.Critical, func(ctx context.Context) {
+			By("Sending a POST request to revoke credentials")
+			captureCtx, resp := captureResponse(ctx)
+			_, err := clustersClient.BeginRevokeCredentials(captureCtx, customerRGName, clusterName, nil)
+			Expect(err).To(BeNil())
+
+			By("Checking the response has Azure-AsyncOperation and Location headers")
+			operation := expectAsyncOperation(*resp, http.StatusAccepted)
+			expectOperationSucceeded(ctx, operation)
+
+			By("Getting the operation result without content")
+			resultResp := getOperationResult(ctx, operation)
+			Expect(resultResp.StatusCode).To(Equal(http.StatusNoContent))
+		})
+	})
+
+	It("Deletes the cluster", labels.Critical, func(ctx context.Context) {
+		By("Sending a DELETE request and polling the operation until it succeeds")
+		operation := deleteClusterAndWait(ctx, clustersClient, clusterName)
+		deleted = true
+
+		By("Getting the operation result without content")
+		resultResp := getOperationResult(ctx, operation)
+		Expect(resultResp.StatusCode).To(Equal(http.StatusNoContent))
+
+		By("Verifying the cluster is gone")
+		_, err := clustersClient.Get(ctx, customerRGName, clusterName, nil)
+		expectNotFound(err)
+
+		By("Verifying a repeated DELETE request returns No Content")
+		resp := sendRequest(ctx, http.MethodDelete, clusterResourcePath(clusterName), nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+	})
+})
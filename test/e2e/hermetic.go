@@ -0,0 +1,167 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Azure/ARO-HCP/frontend/pkg/frontend"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/internal/database"
+	"github.com/Azure/ARO-HCP/internal/ocm"
+
+	// This will invoke the init() function in each
+	// API version package so it can register itself.
+	_ "github.com/Azure/ARO-HCP/internal/api/v20240610preview"
+)
+
+const (
+	hermeticTenantID = "00000000-0000-0000-0000-000000000000"
+
+	// hermeticOperationInterval is how often the fake backend completes
+	// pending asynchronous operations.
+	hermeticOperationInterval = 100 * time.Millisecond
+)
+
+// hermetic reports whether the suite runs against a frontend started in-process
+// with an in-memory database and a fake Cluster Service, instead of a deployed RP.
+func hermetic() bool {
+	return os.Getenv("E2E_HERMETIC") == "true"
+}
+
+// staticTokenCredential hands out a fixed token. The frontend relies on ARM for
+// authentication, so any token is accepted in hermetic mode.
+type staticTokenCredential struct{}
+
+func (staticTokenCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "hermetic", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// startHermeticFrontend starts the frontend against an in-memory database and a fake
+// Cluster Service, registers the test subscription and returns the frontend endpoint
+// along with a function to stop it.
+func startHermeticFrontend(ctx context.Context) (string, func(), error) {
+	logger := slog.New(slog.NewTextHandler(ginkgo.GinkgoWriter, nil))
+
+	dbClient := database.NewCache()
+	registrationDate := time.Now().UTC().Format(time.RFC1123)
+	tenantID := hermeticTenantID
+	err := dbClient.CreateSubscriptionDoc(ctx, subscriptionID, &arm.Subscription{
+		State:            arm.SubscriptionStateRegistered,
+		RegistrationDate: &registrationDate,
+		Properties:       &arm.SubscriptionProperties{TenantId: &tenantID},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+	metricsListener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		listener.Close()
+		return "", nil, err
+	}
+
+	f := frontend.NewFrontend(logger, listener, metricsListener, prometheus.NewRegistry(), dbClient, location, ocm.NewFakeClusterServiceClient())
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := make(chan struct{})
+	go f.Run(ctx, stop)
+	go completeOperations(ctx, logger, dbClient)
+
+	return fmt.Sprintf("http://%s", listener.Addr()), func() {
+		close(stop)
+		cancel()
+	}, nil
+}
+
+// completeOperations stands in for the backend. The fake Cluster Service applies
+// every change immediately, so all pending asynchronous operations succeed and
+// deleted resources are removed from the database, as the backend would record.
+func completeOperations(ctx context.Context, logger *slog.Logger, dbClient database.DBClient) {
+	ticker := time.NewTicker(hermeticOperationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		subscriptions := dbClient.ListAllSubscriptionDocs()
+		for subscriptionID := range subscriptions.Items(ctx) {
+			operations := dbClient.ListActiveOperationDocs(database.NewPartitionKey(subscriptionID), nil)
+			for operationID, operation := range operations.Items(ctx) {
+				if err := completeOperation(ctx, dbClient, subscriptionID, operationID, operation); err != nil {
+					logger.Error(fmt.Sprintf("Failed to complete operation %s: %v", operationID, err))
+				}
+			}
+			if err := operations.GetError(); err != nil {
+				logger.Error(fmt.Sprintf("Failed to list operations of subscription %s: %v", subscriptionID, err))
+			}
+		}
+		if err := subscriptions.GetError(); err != nil {
+			logger.Error(fmt.Sprintf("Failed to list subscriptions: %v", err))
+		}
+	}
+}
+
+// completeOperation marks a single operation as succeeded and updates the resource
+// it applies to, mirroring how the backend finalizes operations.
+func completeOperation(ctx context.Context, dbClient database.DBClient, subscriptionID, operationID string, operation *database.OperationDocument) error {
+	const opStatus = arm.ProvisioningStateSucceeded
+
+	pk := database.NewPartitionKey(subscriptionID)
+
+	if operation.Request == database.OperationRequestDelete {
+		if err := dbClient.DeleteResourceDoc(ctx, operation.ExternalID); err != nil {
+			return err
+		}
+	}
+
+	_, err := dbClient.UpdateOperationDoc(ctx, pk, operationID, func(updateDoc *database.OperationDocument) bool {
+		return updateDoc.UpdateStatus(opStatus, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	switch operation.Request {
+	case database.OperationRequestCreate, database.OperationRequestUpdate:
+		_, err = dbClient.UpdateResourceDoc(ctx, operation.ExternalID, func(updateDoc *database.ResourceDocument) bool {
+			if updateDoc.ActiveOperationID != operationID {
+				return false
+			}
+			updateDoc.ProvisioningState = opStatus
+			updateDoc.ActiveOperationID = ""
+			return true
+		})
+	}
+	return err
+}
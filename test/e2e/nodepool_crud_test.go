@@ -0,0 +1,163 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"path"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/ARO-HCP/internal/api"
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	hcpapi "github.com/Azure/ARO-HCP/internal/api/v20240610preview/generated"
+	"github.com/Azure/ARO-HCP/test/util/labels"
+)
+
+func newTestNodePool(replicas int32) hcpapi.NodePool {
+	return hcpapi.NodePool{
+		Location: to.Ptr(location),
+		Properties: &hcpapi.NodePoolProperties{
+			Platform: &hcpapi.NodePoolPlatformProfile{
+				VMSize: to.Ptr("Standard_D8s_v3"),
+			},
+			Replicas: to.Ptr(replicas),
+		},
+	}
+}
+
+func nodePoolResourcePath(clusterName, nodePoolName string) string {
+	return path.Join(clusterResourcePath(clusterName), api.NodePoolResourceTypeName, nodePoolName)
+}
+
+var _ = Describe("Create, update and delete NodePool", labels.NodePoolLifecycle, Ordered, func() {
+	defer GinkgoRecover()
+
+	var (
+		clustersClient  *hcpapi.HcpOpenShiftClustersClient
+		nodePoolsClient *hcpapi.NodePoolsClient
+		clusterName     string
+		nodePoolName    string
+	)
+
+	BeforeAll(func(ctx context.Context) {
+		skipUnlessClusterCreationConfigured()
+
+		By("Preparing HCP clusters and node pools clients")
+		clustersClient = clients.NewHcpOpenShiftClustersClient()
+		nodePoolsClient = clients.NewNodePoolsClient()
+		clusterName = randomName("e2e-np-cluster")
+		nodePoolName = randomName("e2e-np")
+
+		By("Creating the parent cluster")
+		createClusterAndWait(ctx, clustersClient, clusterName)
+
+		DeferCleanup(func(ctx context.Context) {
+			By("Deleting the parent cluster")
+			deleteClusterAndWait(ctx, clustersClient, clusterName)
+		})
+	})
+
+	It("Creates a node pool and tracks the operation through hcpOperationStatuses", labels.Critical, func(ctx context.Context) {
+		By("Sending a PUT request for the node pool")
+		captureCtx, resp := captureResponse(ctx)
+		_, err := nodePoolsClient.BeginCreateOrUpdate(captureCtx, customerRGName, clusterName, nodePoolName, newTestNodePool(2), nil)
+		Expect(err).To(BeNil())
+
+		By("Checking the response only has an Azure-AsyncOperation header")
+		operation := expectAsyncOperation(*resp, http.StatusCreated)
+		expectOperationSucceeded(ctx, operation)
+
+		By("Getting the node pool with a Succeeded provisioning state")
+		getResp, err := nodePoolsClient.Get(ctx, customerRGName, clusterName, nodePoolName, nil)
+		Expect(err).To(BeNil())
+		Expect(*getResp.Properties.ProvisioningState).To(Equal(hcpapi.ProvisioningStateSucceeded))
+		Expect(*getResp.Properties.Replicas).To(BeEquivalentTo(2))
+
+		By("Finding the node pool in the cluster listing")
+		var names []string
+		pager := nodePoolsClient.NewListByParentPager(customerRGName, clusterName, nil)
+		for pager.More() {
+			nodePoolList, err := pager.NextPage(ctx)
+			Expect(err).To(BeNil())
+			for _, val := range nodePoolList.Value {
+				names = append(names, *val.Name)
+			}
+		}
+		Expect(names).To(ContainElement(nodePoolName))
+	})
+
+	It("Rejects a PATCH request changing a create-only field", labels.High, labels.Negative, func(ctx context.Context) {
+		By("Sending a PATCH request for a new VM size")
+		body := map[string]any{
+			"properties": map[string]any{
+				"platform": map[string]any{
+					"vmSize": "Standard_D4s_v3",
+				},
+			},
+		}
+		resp := sendRequest(ctx, http.MethodPatch, nodePoolResourcePath(clusterName, nodePoolName), body)
+
+		By("Checking the request was rejected without starting an operation")
+		cloudError := expectCloudError(resp, http.StatusBadRequest)
+		Expect(cloudErrorMessages(cloudError)).To(ContainElement("Field 'vmSize' cannot be updated"))
+		Expect(resp.Header.Get(arm.HeaderNameAsyncOperation)).To(BeEmpty())
+		Expect(resp.Header.Get("Location")).To(BeEmpty())
+	})
+
+	It("Scales the node pool with a PATCH request", labels.High, func(ctx context.Context) {
+		By("Sending a PATCH request for more replicas")
+		update := hcpapi.NodePoolUpdate{
+			Properties: &hcpapi.NodePoolPropertiesUpdate{Replicas: to.Ptr[int32](3)},
+		}
+		captureCtx, resp := captureResponse(ctx)
+		_, err := nodePoolsClient.BeginUpdate(captureCtx, customerRGName, clusterName, nodePoolName, update, nil)
+		Expect(err).To(BeNil())
+
+		By("Checking the response has Azure-AsyncOperation and Location headers")
+		operation := expectAsyncOperation(*resp, http.StatusAccepted)
+		expectOperationSucceeded(ctx, operation)
+
+		By("Getting the operation result with the scaled node pool")
+		resultResp := getOperationResult(ctx, operation)
+		Expect(resultResp.StatusCode).To(Equal(http.StatusOK))
+		var nodePool hcpapi.NodePool
+		Expect(runtime.UnmarshalAsJSON(resultResp, &nodePool)).To(Succeed())
+		Expect(*nodePool.Properties.Replicas).To(BeEquivalentTo(3))
+	})
+
+	It("Deletes the node pool", labels.Critical, func(ctx context.Context) {
+		By("Sending a DELETE request for the node pool")
+		captureCtx, resp := captureResponse(ctx)
+		_, err := nodePoolsClient.BeginDelete(captureCtx, customerRGName, clusterName, nodePoolName, nil)
+		Expect(err).To(BeNil())
+
+		By("Checking the response has Azure-AsyncOperation and Location headers")
+		operation := expectAsyncOperation(*resp, http.StatusAccepted)
+		expectOperationSucceeded(ctx, operation)
+
+		By("Getting the operation result without content")
+		resultResp := getOperationResult(ctx, operation)
+		Expect(resultResp.StatusCode).To(Equal(http.StatusNoContent))
+
+		By("Verifying the node pool is gone")
+		_, err = nodePoolsClient.Get(ctx, customerRGName, clusterName, nodePoolName, nil)
+		expectNotFound(err)
+	})
+})
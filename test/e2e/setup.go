@@ -16,6 +16,7 @@ package e2e
 
 import (
 	"context"
+	"fmt"
 	"os"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
@@ -28,17 +29,27 @@ import (
 
 var (
 	clients        *api.ClientFactory
+	armClient      *azcorearm.Client
 	subscriptionID string
 	customerRGName string
+	location       string
+
+	// customerSubnetID and customerNSGID are the customer network resources
+	// required to create clusters.
+	customerSubnetID string
+	customerNSGID    string
+
+	// stopHermeticFrontend stops the in-process frontend in hermetic mode.
+	stopHermeticFrontend func()
 )
 
-func prepareDevelopmentConf() azcore.ClientOptions {
+func prepareDevelopmentConf(endpoint string) azcore.ClientOptions {
 	c := cloud.Configuration{
 		ActiveDirectoryAuthorityHost: "https://login.microsoftonline.com/",
 		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
 			cloud.ResourceManager: {
 				Audience: "https://management.core.windows.net/",
-				Endpoint: "http://localhost:8443",
+				Endpoint: endpoint,
 			},
 		},
 	}
@@ -62,8 +73,18 @@ func setup(ctx context.Context) error {
 	}
 
 	customerRGName = os.Getenv("CUSTOMER_RG_NAME")
+	customerSubnetID = os.Getenv("CUSTOMER_SUBNET_ID")
+	customerNSGID = os.Getenv("CUSTOMER_NSG_ID")
 
-	opts := prepareDevelopmentConf()
+	if location, found = os.LookupEnv("LOCATION"); !found {
+		location = "westus3"
+	}
+
+	if hermetic() {
+		return setupHermetic(ctx)
+	}
+
+	opts := prepareDevelopmentConf("http://localhost:8443")
 
 	envOptions := &azidentity.EnvironmentCredentialOptions{
 		ClientOptions: opts,
@@ -77,6 +98,39 @@ func setup(ctx context.Context) error {
 		return err
 	}
 
+	return newClientFactory(creds, opts)
+}
+
+// setupHermetic starts the frontend in-process and points the clients at it.
+// The customer network resources are never looked up, so placeholders are used
+// unless explicitly set.
+func setupHermetic(ctx context.Context) error {
+	var (
+		endpoint string
+		err      error
+	)
+
+	if customerRGName == "" {
+		customerRGName = "hermetic-e2e"
+	}
+	if customerSubnetID == "" {
+		customerSubnetID = fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet", subscriptionID, customerRGName)
+	}
+	if customerNSGID == "" {
+		customerNSGID = fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/networkSecurityGroups/nsg", subscriptionID, customerRGName)
+	}
+
+	endpoint, stopHermeticFrontend, err = startHermeticFrontend(ctx)
+	if err != nil {
+		return err
+	}
+
+	return newClientFactory(staticTokenCredential{}, prepareDevelopmentConf(endpoint))
+}
+
+func newClientFactory(creds azcore.TokenCredential, opts azcore.ClientOptions) error {
+	var err error
+
 	armOptions := &azcorearm.ClientOptions{
 		ClientOptions: opts,
 	}
@@ -85,5 +139,14 @@ func setup(ctx context.Context) error {
 		return err
 	}
 
-	return nil
+	// armClient sends raw requests where the test cases need to inspect
+	// response headers or send bodies the generated clients cannot express.
+	armClient, err = azcorearm.NewClient("github.com/Azure/ARO-HCP/test/e2e", "v0.0.1", creds, armOptions)
+	return err
+}
+
+func teardown() {
+	if stopHermeticFrontend != nil {
+		stopHermeticFrontend()
+	}
 }
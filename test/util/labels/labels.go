@@ -29,3 +29,10 @@ var (
 	High     = ginkgo.Label("High")
 	Critical = ginkgo.Label("Critical")
 )
+
+// Test suites
+var (
+	ClusterLifecycle    = ginkgo.Label("ClusterLifecycle")
+	NodePoolLifecycle   = ginkgo.Label("NodePoolLifecycle")
+	CredentialLifecycle = ginkgo.Label("CredentialLifecycle")
+)
@@ -0,0 +1,101 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/cmd/options"
+)
+
+func NewCommand() (*cobra.Command, error) {
+	opts := options.DefaultOptions()
+	cmd := &cobra.Command{
+		Use:     "cluster",
+		Aliases: []string{"clusters"},
+		Short:   "manage HCP OpenShift clusters",
+		Long:    "manage HCP OpenShift clusters",
+	}
+	if err := options.BindOptions(opts, cmd); err != nil {
+		return nil, err
+	}
+
+	createOpts := DefaultCreateOptions()
+	createCmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "create a cluster",
+		Long:  "create a cluster and wait for it to be provisioned",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := createOpts.Cluster()
+			if err != nil {
+				return err
+			}
+			completed, err := options.Complete(opts)
+			if err != nil {
+				return err
+			}
+			return create(cmd.Context(), completed, args[0], cluster)
+		},
+	}
+	if err := BindCreateOptions(createOpts, createCmd); err != nil {
+		return nil, err
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get NAME",
+		Short: "get a cluster",
+		Long:  "get a cluster",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			completed, err := options.Complete(opts)
+			if err != nil {
+				return err
+			}
+			return get(cmd.Context(), completed, args[0])
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "list clusters",
+		Long:  "list the clusters of the resource group, or of the subscription if no resource group is given",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			completed, err := options.Complete(opts)
+			if err != nil {
+				return err
+			}
+			return list(cmd.Context(), completed)
+		},
+	}
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete NAME",
+		Short: "delete a cluster",
+		Long:  "delete a cluster along with its node pools and wait for it to be deleted",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			completed, err := options.Complete(opts)
+			if err != nil {
+				return err
+			}
+			return deleteCluster(cmd.Context(), completed, args[0])
+		},
+	}
+
+	cmd.AddCommand(createCmd, getCmd, listCmd, deleteCmd)
+	return cmd, nil
+}
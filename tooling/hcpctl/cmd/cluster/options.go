@@ -0,0 +1,183 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/spf13/cobra"
+
+	api "github.com/Azure/ARO-HCP/internal/api/v20240610preview/generated"
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/cmd/options"
+)
+
+func DefaultCreateOptions() *RawCreateOptions {
+	return &RawCreateOptions{
+		ChannelGroup: "stable",
+	}
+}
+
+func BindCreateOptions(opts *RawCreateOptions, cmd *cobra.Command) error {
+	cmd.Flags().StringVarP(&opts.Location, "location", "l", opts.Location, "location of the cluster")
+	cmd.Flags().StringVar(&opts.SubnetID, "subnet-id", opts.SubnetID, "resource ID of the subnet for the cluster")
+	cmd.Flags().StringVar(&opts.NetworkSecurityGroupID, "nsg-id", opts.NetworkSecurityGroupID, "resource ID of the network security group for the cluster")
+	cmd.Flags().StringVar(&opts.Version, "version", opts.Version, "OpenShift version of the cluster, defaults to the latest version of the channel group")
+	cmd.Flags().StringVar(&opts.ChannelGroup, "channel-group", opts.ChannelGroup, "channel group of the OpenShift version")
+	cmd.Flags().StringVarP(&opts.File, "file", "f", opts.File, "JSON file with the cluster resource, flags override its values")
+
+	if err := cmd.MarkFlagFilename("file", "json"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as a file: %w", "file", err)
+	}
+	return nil
+}
+
+// RawCreateOptions holds input values for creating a cluster.
+type RawCreateOptions struct {
+	Location               string
+	SubnetID               string
+	NetworkSecurityGroupID string
+	Version                string
+	ChannelGroup           string
+	File                   string
+}
+
+// Cluster builds the cluster resource to create from the file and flags.
+func (o *RawCreateOptions) Cluster() (*api.HcpOpenShiftCluster, error) {
+	cluster := &api.HcpOpenShiftCluster{}
+	if o.File != "" {
+		raw, err := os.ReadFile(o.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cluster file %s: %w", o.File, err)
+		}
+		if err := json.Unmarshal(raw, cluster); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cluster file %s: %w", o.File, err)
+		}
+	}
+
+	if cluster.Properties == nil {
+		cluster.Properties = &api.HcpOpenShiftClusterProperties{}
+	}
+	if cluster.Properties.Platform == nil {
+		cluster.Properties.Platform = &api.PlatformProfile{}
+	}
+	if cluster.Properties.Version == nil {
+		cluster.Properties.Version = &api.VersionProfile{}
+	}
+
+	setIfNotEmpty(&cluster.Location, o.Location)
+	setIfNotEmpty(&cluster.Properties.Platform.SubnetID, o.SubnetID)
+	setIfNotEmpty(&cluster.Properties.Platform.NetworkSecurityGroupID, o.NetworkSecurityGroupID)
+	setIfNotEmpty(&cluster.Properties.Version.ID, o.Version)
+	setIfNotEmpty(&cluster.Properties.Version.ChannelGroup, o.ChannelGroup)
+
+	if cluster.Location == nil {
+		return nil, fmt.Errorf("location is required, set --location")
+	}
+	if cluster.Properties.Platform.SubnetID == nil {
+		return nil, fmt.Errorf("subnet is required, set --subnet-id")
+	}
+	if cluster.Properties.Platform.NetworkSecurityGroupID == nil {
+		return nil, fmt.Errorf("network security group is required, set --nsg-id")
+	}
+
+	return cluster, nil
+}
+
+func setIfNotEmpty(field **string, value string) {
+	if value != "" {
+		*field = to.Ptr(value)
+	}
+}
+
+func create(ctx context.Context, opts *options.Options, name string, cluster *api.HcpOpenShiftCluster) error {
+	if err := opts.RequireResourceGroup(); err != nil {
+		return err
+	}
+
+	client := opts.Clients.NewHcpOpenShiftClustersClient()
+	captureCtx, resp := options.CaptureResponse(ctx)
+	if _, err := client.BeginCreateOrUpdate(captureCtx, opts.ResourceGroup, name, *cluster, nil); err != nil {
+		return fmt.Errorf("failed to create cluster %s: %w", name, err)
+	}
+	if _, err := opts.WaitForOperation(ctx, *resp); err != nil {
+		return fmt.Errorf("failed to create cluster %s: %w", name, err)
+	}
+	return get(ctx, opts, name)
+}
+
+func get(ctx context.Context, opts *options.Options, name string) error {
+	if err := opts.RequireResourceGroup(); err != nil {
+		return err
+	}
+
+	client := opts.Clients.NewHcpOpenShiftClustersClient()
+	resp, err := client.Get(ctx, opts.ResourceGroup, name, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s: %w", name, err)
+	}
+	return opts.Print(&resp.HcpOpenShiftCluster)
+}
+
+// list lists the clusters of the resource group, or of the subscription if
+// no resource group was given.
+func list(ctx context.Context, opts *options.Options) error {
+	client := opts.Clients.NewHcpOpenShiftClustersClient()
+
+	clusters := []*api.HcpOpenShiftCluster{}
+	if opts.ResourceGroup != "" {
+		pager := client.NewListByResourceGroupPager(opts.ResourceGroup, nil)
+		for pager.More() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list clusters: %w", err)
+			}
+			clusters = append(clusters, page.Value...)
+		}
+	} else {
+		pager := client.NewListBySubscriptionPager(nil)
+		for pager.More() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list clusters: %w", err)
+			}
+			clusters = append(clusters, page.Value...)
+		}
+	}
+	return opts.Print(clusters)
+}
+
+func deleteCluster(ctx context.Context, opts *options.Options, name string) error {
+	if err := opts.RequireResourceGroup(); err != nil {
+		return err
+	}
+
+	client := opts.Clients.NewHcpOpenShiftClustersClient()
+	captureCtx, resp := options.CaptureResponse(ctx)
+	if _, err := client.BeginDelete(captureCtx, opts.ResourceGroup, name, nil); err != nil {
+		return fmt.Errorf("failed to delete cluster %s: %w", name, err)
+	}
+	waited, err := opts.WaitForOperation(ctx, *resp)
+	if err != nil {
+		return fmt.Errorf("failed to delete cluster %s: %w", name, err)
+	}
+	if waited {
+		fmt.Fprintf(opts.Progress, "Deleted cluster %s\n", name)
+	}
+	return nil
+}
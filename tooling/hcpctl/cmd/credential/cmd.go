@@ -0,0 +1,139 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credential
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/cmd/options"
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/pkg/output"
+)
+
+func NewCommand() (*cobra.Command, error) {
+	opts := options.DefaultOptions()
+	cmd := &cobra.Command{
+		Use:     "credential",
+		Aliases: []string{"credentials"},
+		Short:   "manage admin credentials of HCP OpenShift clusters",
+		Long:    "manage admin credentials of HCP OpenShift clusters",
+	}
+	if err := options.BindOptions(opts, cmd); err != nil {
+		return nil, err
+	}
+
+	var kubeconfig string
+	requestCmd := &cobra.Command{
+		Use:   "request CLUSTER",
+		Short: "request an admin credential",
+		Long:  "request an admin credential for a cluster and print its kubeconfig, or write it to a file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			completed, err := options.Complete(opts)
+			if err != nil {
+				return err
+			}
+			return request(cmd.Context(), completed, args[0], kubeconfig)
+		},
+	}
+	requestCmd.Flags().StringVar(&kubeconfig, "kubeconfig", kubeconfig, "file to write the kubeconfig of the credential to")
+	if err := requestCmd.MarkFlagFilename("kubeconfig"); err != nil {
+		return nil, fmt.Errorf("failed to mark flag %q as a file: %w", "kubeconfig", err)
+	}
+
+	revokeCmd := &cobra.Command{
+		Use:   "revoke CLUSTER",
+		Short: "revoke all admin credentials",
+		Long:  "revoke all admin credentials of a cluster and wait for the revocation to complete",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			completed, err := options.Complete(opts)
+			if err != nil {
+				return err
+			}
+			return revoke(cmd.Context(), completed, args[0])
+		},
+	}
+
+	cmd.AddCommand(requestCmd, revokeCmd)
+	return cmd, nil
+}
+
+func request(ctx context.Context, opts *options.Options, cluster, kubeconfig string) error {
+	if err := opts.RequireResourceGroup(); err != nil {
+		return err
+	}
+
+	client := opts.Clients.NewHcpOpenShiftClustersClient()
+	captureCtx, resp := options.CaptureResponse(ctx)
+	poller, err := client.BeginRequestAdminCredential(captureCtx, opts.ResourceGroup, cluster, nil)
+	if err != nil {
+		return fmt.Errorf("failed to request admin credential for cluster %s: %w", cluster, err)
+	}
+	waited, err := opts.WaitForOperation(ctx, *resp)
+	if err != nil {
+		return fmt.Errorf("failed to request admin credential for cluster %s: %w", cluster, err)
+	}
+	if !waited {
+		return nil
+	}
+
+	// The operation already completed so this only fetches the result.
+	result, err := poller.PollUntilDone(ctx, &runtime.PollUntilDoneOptions{Frequency: opts.Watcher.Interval})
+	if err != nil {
+		return fmt.Errorf("failed to get admin credential for cluster %s: %w", cluster, err)
+	}
+	if result.Kubeconfig == nil {
+		return fmt.Errorf("admin credential for cluster %s has no kubeconfig", cluster)
+	}
+
+	if kubeconfig != "" {
+		if err := os.WriteFile(kubeconfig, []byte(*result.Kubeconfig), 0600); err != nil {
+			return fmt.Errorf("failed to write kubeconfig %s: %w", kubeconfig, err)
+		}
+		return opts.Print(&result.HcpOpenShiftClusterAdminCredential)
+	}
+
+	// Tables cannot hold a kubeconfig, so print it as is.
+	if opts.Output == output.FormatTable {
+		_, err = fmt.Fprintln(opts.Out, *result.Kubeconfig)
+		return err
+	}
+	return opts.Print(&result.HcpOpenShiftClusterAdminCredential)
+}
+
+func revoke(ctx context.Context, opts *options.Options, cluster string) error {
+	if err := opts.RequireResourceGroup(); err != nil {
+		return err
+	}
+
+	client := opts.Clients.NewHcpOpenShiftClustersClient()
+	captureCtx, resp := options.CaptureResponse(ctx)
+	if _, err := client.BeginRevokeCredentials(captureCtx, opts.ResourceGroup, cluster, nil); err != nil {
+		return fmt.Errorf("failed to revoke credentials of cluster %s: %w", cluster, err)
+	}
+	waited, err := opts.WaitForOperation(ctx, *resp)
+	if err != nil {
+		return fmt.Errorf("failed to revoke credentials of cluster %s: %w", cluster, err)
+	}
+	if waited {
+		fmt.Fprintf(opts.Progress, "Revoked admin credentials of cluster %s\n", cluster)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodepool
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/cmd/options"
+)
+
+func NewCommand() (*cobra.Command, error) {
+	opts := options.DefaultOptions()
+	nodePoolOpts := &RawNodePoolOptions{}
+	cmd := &cobra.Command{
+		Use:     "nodepool",
+		Aliases: []string{"nodepools", "np"},
+		Short:   "manage node pools of HCP OpenShift clusters",
+		Long:    "manage node pools of HCP OpenShift clusters",
+	}
+	if err := options.BindOptions(opts, cmd); err != nil {
+		return nil, err
+	}
+	if err := BindNodePoolOptions(nodePoolOpts, cmd); err != nil {
+		return nil, err
+	}
+
+	complete := func() (*options.Options, error) {
+		if err := nodePoolOpts.Validate(); err != nil {
+			return nil, err
+		}
+		return options.Complete(opts)
+	}
+
+	createOpts := DefaultCreateOptions()
+	createCmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "create a node pool",
+		Long:  "create a node pool and wait for it to be provisioned",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			nodePool, err := createOpts.NodePool()
+			if err != nil {
+				return err
+			}
+			completed, err := complete()
+			if err != nil {
+				return err
+			}
+			return create(cmd.Context(), completed, nodePoolOpts.Cluster, args[0], nodePool)
+		},
+	}
+	if err := BindCreateOptions(createOpts, createCmd); err != nil {
+		return nil, err
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get NAME",
+		Short: "get a node pool",
+		Long:  "get a node pool",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			completed, err := complete()
+			if err != nil {
+				return err
+			}
+			return get(cmd.Context(), completed, nodePoolOpts.Cluster, args[0])
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "list node pools",
+		Long:  "list the node pools of a cluster",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			completed, err := complete()
+			if err != nil {
+				return err
+			}
+			return list(cmd.Context(), completed, nodePoolOpts.Cluster)
+		},
+	}
+
+	var replicas int32 = -1
+	scaleCmd := &cobra.Command{
+		Use:   "scale NAME",
+		Short: "scale a node pool",
+		Long:  "set the number of nodes of a node pool and wait for the update to complete",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if replicas < 0 {
+				return fmt.Errorf("replicas is required, set --replicas")
+			}
+			completed, err := complete()
+			if err != nil {
+				return err
+			}
+			return scale(cmd.Context(), completed, nodePoolOpts.Cluster, args[0], replicas)
+		},
+	}
+	scaleCmd.Flags().Int32Var(&replicas, "replicas", replicas, "number of nodes")
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete NAME",
+		Short: "delete a node pool",
+		Long:  "delete a node pool and wait for it to be deleted",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			completed, err := complete()
+			if err != nil {
+				return err
+			}
+			return deleteNodePool(cmd.Context(), completed, nodePoolOpts.Cluster, args[0])
+		},
+	}
+
+	cmd.AddCommand(createCmd, getCmd, listCmd, scaleCmd, deleteCmd)
+	return cmd, nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodepool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/spf13/cobra"
+
+	api "github.com/Azure/ARO-HCP/internal/api/v20240610preview/generated"
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/cmd/options"
+)
+
+func DefaultCreateOptions() *RawCreateOptions {
+	return &RawCreateOptions{
+		Replicas: -1,
+	}
+}
+
+func BindCreateOptions(opts *RawCreateOptions, cmd *cobra.Command) error {
+	cmd.Flags().StringVarP(&opts.Location, "location", "l", opts.Location, "location of the node pool, defaults to the location of the cluster")
+	cmd.Flags().StringVar(&opts.VMSize, "vm-size", opts.VMSize, "VM size of the nodes")
+	cmd.Flags().Int32Var(&opts.Replicas, "replicas", opts.Replicas, "number of nodes")
+	cmd.Flags().StringVar(&opts.Version, "version", opts.Version, "OpenShift version of the nodes, defaults to the latest version of the channel group")
+	cmd.Flags().StringVarP(&opts.File, "file", "f", opts.File, "JSON file with the node pool resource, flags override its values")
+
+	if err := cmd.MarkFlagFilename("file", "json"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as a file: %w", "file", err)
+	}
+	return nil
+}
+
+// RawCreateOptions holds input values for creating a node pool.
+type RawCreateOptions struct {
+	Location string
+	VMSize   string
+	// Replicas is negative if not given.
+	Replicas int32
+	Version  string
+	File     string
+}
+
+// NodePool builds the node pool resource to create from the file and flags.
+func (o *RawCreateOptions) NodePool() (*api.NodePool, error) {
+	nodePool := &api.NodePool{}
+	if o.File != "" {
+		raw, err := os.ReadFile(o.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read node pool file %s: %w", o.File, err)
+		}
+		if err := json.Unmarshal(raw, nodePool); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal node pool file %s: %w", o.File, err)
+		}
+	}
+
+	if nodePool.Properties == nil {
+		nodePool.Properties = &api.NodePoolProperties{}
+	}
+	if nodePool.Properties.Platform == nil {
+		nodePool.Properties.Platform = &api.NodePoolPlatformProfile{}
+	}
+
+	if o.Location != "" {
+		nodePool.Location = to.Ptr(o.Location)
+	}
+	if o.VMSize != "" {
+		nodePool.Properties.Platform.VMSize = to.Ptr(o.VMSize)
+	}
+	if o.Replicas >= 0 {
+		nodePool.Properties.Replicas = to.Ptr(o.Replicas)
+	}
+	if o.Version != "" {
+		if nodePool.Properties.Version == nil {
+			nodePool.Properties.Version = &api.NodePoolVersionProfile{}
+		}
+		nodePool.Properties.Version.ID = to.Ptr(o.Version)
+	}
+
+	if nodePool.Properties.Platform.VMSize == nil {
+		return nil, fmt.Errorf("VM size is required, set --vm-size")
+	}
+
+	return nodePool, nil
+}
+
+// RawNodePoolOptions holds the input values shared by the node pool commands.
+type RawNodePoolOptions struct {
+	Cluster string
+}
+
+func BindNodePoolOptions(opts *RawNodePoolOptions, cmd *cobra.Command) error {
+	cmd.PersistentFlags().StringVarP(&opts.Cluster, "cluster", "c", opts.Cluster, "name of the cluster of the node pools")
+	return nil
+}
+
+func (o *RawNodePoolOptions) Validate() error {
+	if o.Cluster == "" {
+		return fmt.Errorf("cluster is required, set --cluster")
+	}
+	return nil
+}
+
+func create(ctx context.Context, opts *options.Options, cluster, name string, nodePool *api.NodePool) error {
+	if err := opts.RequireResourceGroup(); err != nil {
+		return err
+	}
+
+	if nodePool.Location == nil {
+		resp, err := opts.Clients.NewHcpOpenShiftClustersClient().Get(ctx, opts.ResourceGroup, cluster, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get cluster %s: %w", cluster, err)
+		}
+		nodePool.Location = resp.Location
+	}
+
+	client := opts.Clients.NewNodePoolsClient()
+	captureCtx, resp := options.CaptureResponse(ctx)
+	if _, err := client.BeginCreateOrUpdate(captureCtx, opts.ResourceGroup, cluster, name, *nodePool, nil); err != nil {
+		return fmt.Errorf("failed to create node pool %s: %w", name, err)
+	}
+	if _, err := opts.WaitForOperation(ctx, *resp); err != nil {
+		return fmt.Errorf("failed to create node pool %s: %w", name, err)
+	}
+	return get(ctx, opts, cluster, name)
+}
+
+func get(ctx context.Context, opts *options.Options, cluster, name string) error {
+	if err := opts.RequireResourceGroup(); err != nil {
+		return err
+	}
+
+	client := opts.Clients.NewNodePoolsClient()
+	resp, err := client.Get(ctx, opts.ResourceGroup, cluster, name, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get node pool %s: %w", name, err)
+	}
+	return opts.Print(&resp.NodePool)
+}
+
+func list(ctx context.Context, opts *options.Options, cluster string) error {
+	if err := opts.RequireResourceGroup(); err != nil {
+		return err
+	}
+
+	client := opts.Clients.NewNodePoolsClient()
+	nodePools := []*api.NodePool{}
+	pager := client.NewListByParentPager(opts.ResourceGroup, cluster, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list node pools: %w", err)
+		}
+		nodePools = append(nodePools, page.Value...)
+	}
+	return opts.Print(nodePools)
+}
+
+func scale(ctx context.Context, opts *options.Options, cluster, name string, replicas int32) error {
+	if err := opts.RequireResourceGroup(); err != nil {
+		return err
+	}
+
+	client := opts.Clients.NewNodePoolsClient()
+	update := api.NodePoolUpdate{
+		Properties: &api.NodePoolPropertiesUpdate{
+			Replicas: to.Ptr(replicas),
+		},
+	}
+	captureCtx, resp := options.CaptureResponse(ctx)
+	if _, err := client.BeginUpdate(captureCtx, opts.ResourceGroup, cluster, name, update, nil); err != nil {
+		return fmt.Errorf("failed to scale node pool %s: %w", name, err)
+	}
+	if _, err := opts.WaitForOperation(ctx, *resp); err != nil {
+		return fmt.Errorf("failed to scale node pool %s: %w", name, err)
+	}
+	return get(ctx, opts, cluster, name)
+}
+
+func deleteNodePool(ctx context.Context, opts *options.Options, cluster, name string) error {
+	if err := opts.RequireResourceGroup(); err != nil {
+		return err
+	}
+
+	client := opts.Clients.NewNodePoolsClient()
+	captureCtx, resp := options.CaptureResponse(ctx)
+	if _, err := client.BeginDelete(captureCtx, opts.ResourceGroup, cluster, name, nil); err != nil {
+		return fmt.Errorf("failed to delete node pool %s: %w", name, err)
+	}
+	waited, err := opts.WaitForOperation(ctx, *resp)
+	if err != nil {
+		return fmt.Errorf("failed to delete node pool %s: %w", name, err)
+	}
+	if waited {
+		fmt.Fprintf(opts.Progress, "Deleted node pool %s\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/cmd/options"
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/pkg/operation"
+)
+
+func NewCommand() (*cobra.Command, error) {
+	opts := options.DefaultOptions()
+	cmd := &cobra.Command{
+		Use:     "operation",
+		Aliases: []string{"operations", "op"},
+		Short:   "track asynchronous operations",
+		Long:    "track asynchronous operations",
+	}
+	if err := options.BindOptions(opts, cmd); err != nil {
+		return nil, err
+	}
+
+	var location string
+	watchCmd := &cobra.Command{
+		Use:   "watch ID|URL",
+		Short: "watch an operation until it completes",
+		Long:  "poll the status of an operation, given by its ID or Azure-AsyncOperation URL, until it completes",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			completed, err := options.Complete(opts)
+			if err != nil {
+				return err
+			}
+			return watch(cmd.Context(), completed, args[0], location)
+		},
+	}
+	watchCmd.Flags().StringVarP(&location, "location", "l", location, "location of the operation, required when given an operation ID")
+
+	cmd.AddCommand(watchCmd)
+	return cmd, nil
+}
+
+func watch(ctx context.Context, opts *options.Options, operationID, location string) error {
+	statusURL := operationID
+	if !strings.Contains(operationID, "://") {
+		if location == "" {
+			return fmt.Errorf("location is required to watch an operation by its ID, set --location")
+		}
+		statusURL = operation.StatusURL(opts.Endpoint, opts.SubscriptionID, location, operationID)
+	}
+
+	start := time.Now()
+	status, err := opts.Watcher.Watch(ctx, statusURL, func(op *arm.Operation) {
+		fmt.Fprintf(opts.Progress, "[%s] operation %s is %s\n", time.Since(start).Round(time.Second), op.Name, op.Status)
+	})
+	if status != nil {
+		if printErr := opts.Print(status); printErr != nil {
+			return printErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,263 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	azcorearm "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	api "github.com/Azure/ARO-HCP/internal/api/v20240610preview/generated"
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/pkg/operation"
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/pkg/output"
+)
+
+const (
+	EndpointARM = "arm"
+	EndpointDev = "dev"
+
+	// DevEndpoint is the frontend of the development environment, port-forwarded
+	// to localhost like for the E2E tests.
+	DevEndpoint = "http://localhost:8443"
+)
+
+func DefaultOptions() *RawOptions {
+	return &RawOptions{
+		SubscriptionID: os.Getenv("AZURE_SUBSCRIPTION_ID"),
+		Endpoint:       EndpointARM,
+		Output:         output.FormatTable,
+		PollInterval:   10 * time.Second,
+	}
+}
+
+func BindOptions(opts *RawOptions, cmd *cobra.Command) error {
+	cmd.PersistentFlags().StringVar(&opts.SubscriptionID, "subscription", opts.SubscriptionID, "subscription ID, defaults to $AZURE_SUBSCRIPTION_ID")
+	cmd.PersistentFlags().StringVarP(&opts.ResourceGroup, "resource-group", "g", opts.ResourceGroup, "resource group of the cluster")
+	cmd.PersistentFlags().StringVar(&opts.Endpoint, "endpoint", opts.Endpoint, fmt.Sprintf("%q for Azure Resource Manager, %q for the development frontend at %s, or the URL of a frontend", EndpointARM, EndpointDev, DevEndpoint))
+	cmd.PersistentFlags().StringVarP(&opts.Output, "output", "o", opts.Output, fmt.Sprintf("output format, one of %s", strings.Join(output.Formats, ", ")))
+	cmd.PersistentFlags().BoolVar(&opts.NoWait, "no-wait", opts.NoWait, "do not wait for asynchronous operations to complete")
+	cmd.PersistentFlags().DurationVar(&opts.PollInterval, "poll-interval", opts.PollInterval, "interval between polls of asynchronous operations")
+	return nil
+}
+
+// RawOptions holds input values.
+type RawOptions struct {
+	SubscriptionID string
+	ResourceGroup  string
+	Endpoint       string
+	Output         string
+	NoWait         bool
+	PollInterval   time.Duration
+}
+
+// validatedOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
+type validatedOptions struct {
+	*RawOptions
+}
+
+type ValidatedOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*validatedOptions
+}
+
+// completedOptions is a private wrapper that enforces a call of Complete() before commands can be run.
+type completedOptions struct {
+	Clients        *api.ClientFactory
+	SubscriptionID string
+	ResourceGroup  string
+	Endpoint       string
+	Output         string
+	Wait           bool
+	Watcher        *operation.Watcher
+	Out            io.Writer
+	Progress       io.Writer
+}
+
+type Options struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*completedOptions
+}
+
+func (o *RawOptions) Validate() (*ValidatedOptions, error) {
+	if o.SubscriptionID == "" {
+		return nil, fmt.Errorf("subscription is required, set --subscription or $AZURE_SUBSCRIPTION_ID")
+	}
+
+	if !slices.Contains(output.Formats, o.Output) {
+		return nil, fmt.Errorf("output format %q is not one of %s", o.Output, strings.Join(output.Formats, ", "))
+	}
+
+	if o.Endpoint != EndpointARM && o.Endpoint != EndpointDev {
+		u, err := url.Parse(o.Endpoint)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("endpoint %q is neither %q, %q nor a URL", o.Endpoint, EndpointARM, EndpointDev)
+		}
+	}
+
+	if o.PollInterval < time.Second {
+		return nil, fmt.Errorf("poll interval must be at least one second")
+	}
+
+	return &ValidatedOptions{
+		validatedOptions: &validatedOptions{
+			RawOptions: o,
+		},
+	}, nil
+}
+
+func (o *ValidatedOptions) Complete() (*Options, error) {
+	var (
+		clientOptions azcore.ClientOptions
+		creds         azcore.TokenCredential
+		err           error
+	)
+
+	endpoint := o.Endpoint
+	switch endpoint {
+	case EndpointARM:
+		endpoint = cloud.AzurePublic.Services[cloud.ResourceManager].Endpoint
+		creds, err = azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure credential: %w", err)
+		}
+	case EndpointDev:
+		endpoint = DevEndpoint
+		fallthrough
+	default:
+		// A frontend reached directly relies on ARM for authentication,
+		// so any token is accepted.
+		clientOptions = azcore.ClientOptions{
+			Cloud: cloud.Configuration{
+				ActiveDirectoryAuthorityHost: cloud.AzurePublic.ActiveDirectoryAuthorityHost,
+				Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+					cloud.ResourceManager: {
+						Audience: cloud.AzurePublic.Services[cloud.ResourceManager].Audience,
+						Endpoint: endpoint,
+					},
+				},
+			},
+			InsecureAllowCredentialWithHTTP: true,
+		}
+		creds = frontendCredential{}
+	}
+
+	armOptions := &azcorearm.ClientOptions{
+		ClientOptions: clientOptions,
+	}
+
+	clients, err := api.NewClientFactory(o.SubscriptionID, creds, armOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clients: %w", err)
+	}
+
+	armClient, err := azcorearm.NewClient("github.com/Azure/ARO-HCP/tooling/hcpctl", "v0.0.1", creds, armOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ARM client: %w", err)
+	}
+
+	return &Options{
+		completedOptions: &completedOptions{
+			Clients:        clients,
+			SubscriptionID: o.SubscriptionID,
+			ResourceGroup:  o.ResourceGroup,
+			Endpoint:       endpoint,
+			Output:         o.Output,
+			Wait:           !o.NoWait,
+			Watcher: &operation.Watcher{
+				Pipeline: armClient.Pipeline(),
+				Interval: o.PollInterval,
+			},
+			Out:      os.Stdout,
+			Progress: os.Stderr,
+		},
+	}, nil
+}
+
+// frontendCredential is used when talking to a frontend directly.
+type frontendCredential struct{}
+
+func (frontendCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "hcpctl", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// RequireResourceGroup returns an error if no resource group was given.
+func (o *Options) RequireResourceGroup() error {
+	if o.ResourceGroup == "" {
+		return fmt.Errorf("resource group is required, set --resource-group")
+	}
+	return nil
+}
+
+// Print writes the value to the output in the requested format.
+func (o *Options) Print(value any) error {
+	return output.Print(o.Out, o.Output, value)
+}
+
+// CaptureResponse returns a context that records the raw HTTP response of
+// the first request sent with it.
+func CaptureResponse(ctx context.Context) (context.Context, **http.Response) {
+	var resp *http.Response
+	return policy.WithCaptureResponse(ctx, &resp), &resp
+}
+
+// WaitForOperation reports the operation started by the captured response
+// and, unless told not to wait, watches it until it completes. It returns
+// false if the operation was not waited for.
+func (o *Options) WaitForOperation(ctx context.Context, resp *http.Response) (bool, error) {
+	if resp == nil {
+		return false, fmt.Errorf("no response was captured")
+	}
+
+	statusURL := resp.Header.Get(arm.HeaderNameAsyncOperation)
+	if statusURL == "" {
+		// The request completed synchronously.
+		return true, nil
+	}
+	fmt.Fprintf(o.Progress, "Started operation %s\n", statusURL)
+
+	if !o.Wait {
+		return false, nil
+	}
+
+	start := time.Now()
+	_, err := o.Watcher.Watch(ctx, statusURL, func(op *arm.Operation) {
+		fmt.Fprintf(o.Progress, "[%s] operation %s is %s\n", time.Since(start).Round(time.Second), op.Name, op.Status)
+	})
+	return true, err
+}
+
+// Complete validates and completes the options in one go, for commands
+// without options of their own.
+func Complete(o *RawOptions) (*Options, error) {
+	validated, err := o.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return validated.Complete()
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/cmd/cluster"
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/cmd/credential"
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/cmd/nodepool"
+	"github.com/Azure/ARO-HCP/tooling/hcpctl/cmd/operation"
+)
+
+func main() {
+	// Create a root context with signal handling
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cmd := &cobra.Command{
+		Use:              "hcpctl",
+		Short:            "hcpctl",
+		Long:             "hcpctl manages HCP OpenShift clusters through Azure Resource Manager or a frontend directly",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		CompletionOptions: cobra.CompletionOptions{
+			HiddenDefaultCmd: true,
+		},
+	}
+
+	commands := []func() (*cobra.Command, error){
+		cluster.NewCommand,
+		nodepool.NewCommand,
+		credential.NewCommand,
+		operation.NewCommand,
+	}
+	for _, newCmd := range commands {
+		c, err := newCmd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create command: %v\n", err)
+			os.Exit(1)
+		}
+		cmd.AddCommand(c)
+	}
+
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+const (
+	APIVersion = "2024-06-10-preview"
+
+	providerNamespace               = "Microsoft.RedHatOpenShift"
+	operationStatusResourceTypeName = "hcpOperationStatuses"
+)
+
+// StatusURL returns the URL of the hcpOperationStatuses endpoint for an
+// operation, as returned in the "Azure-AsyncOperation" response header.
+func StatusURL(endpoint, subscriptionID, location, operationID string) string {
+	u := runtime.JoinPaths(endpoint, path.Join("/",
+		"subscriptions", subscriptionID,
+		"providers", providerNamespace,
+		"locations", location,
+		operationStatusResourceTypeName, operationID))
+	return u + "?" + url.Values{"api-version": []string{APIVersion}}.Encode()
+}
+
+// Watcher polls the hcpOperationStatuses endpoint of asynchronous operations.
+type Watcher struct {
+	Pipeline runtime.Pipeline
+	Interval time.Duration
+}
+
+// Watch polls the operation status until it reaches a terminal state. The
+// progress function, if given, is called with the initial status and every
+// time the status changes. An error is returned if the operation did not
+// succeed.
+func (w *Watcher) Watch(ctx context.Context, statusURL string, progress func(*arm.Operation)) (*arm.Operation, error) {
+	var lastStatus arm.ProvisioningState
+
+	for {
+		operation, err := w.get(ctx, statusURL)
+		if err != nil {
+			return nil, err
+		}
+
+		if operation.Status != lastStatus {
+			lastStatus = operation.Status
+			if progress != nil {
+				progress(operation)
+			}
+		}
+
+		if operation.Status.IsTerminal() {
+			if operation.Status != arm.ProvisioningStateSucceeded {
+				if operation.Error != nil {
+					return operation, fmt.Errorf("operation %s %s: %s: %s", operation.Name, operation.Status, operation.Error.Code, operation.Error.Message)
+				}
+				return operation, fmt.Errorf("operation %s %s", operation.Name, operation.Status)
+			}
+			return operation, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return operation, ctx.Err()
+		case <-time.After(w.Interval):
+		}
+	}
+}
+
+func (w *Watcher) get(ctx context.Context, statusURL string) (*arm.Operation, error) {
+	req, err := runtime.NewRequest(ctx, http.MethodGet, statusURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create operation status request: %w", err)
+	}
+	req.Raw().Header.Set("Accept", "application/json")
+
+	resp, err := w.Pipeline.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operation status: %w", err)
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return nil, runtime.NewResponseError(resp)
+	}
+
+	operation := &arm.Operation{}
+	if err := runtime.UnmarshalAsJSON(resp, operation); err != nil {
+		return nil, fmt.Errorf("failed to decode operation status: %w", err)
+	}
+	return operation, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+)
+
+const testSubscriptionID = "11111111-1111-1111-1111-111111111111"
+
+func TestStatusURL(t *testing.T) {
+	assert.Equal(t,
+		"https://management.azure.com/subscriptions/"+testSubscriptionID+"/providers/Microsoft.RedHatOpenShift/locations/westus3/hcpOperationStatuses/abc?api-version=2024-06-10-preview",
+		StatusURL("https://management.azure.com/", testSubscriptionID, "westus3", "abc"))
+}
+
+func TestWatch(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		statuses []string
+		err      string
+	}{
+		{
+			name:     "succeeded",
+			statuses: []string{"Accepted", "Provisioning", "Provisioning", "Succeeded"},
+		},
+		{
+			name:     "failed",
+			statuses: []string{"Deleting", "Failed"},
+			err:      "operation abc Failed: InternalServerError: boom",
+		},
+		{
+			name:     "canceled",
+			statuses: []string{"Canceled"},
+			err:      "operation abc Canceled",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			polls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tc.statuses[min(polls, len(tc.statuses)-1)]
+				polls++
+				body := fmt.Sprintf(`{"name":"abc","status":%q}`, status)
+				if status == "Failed" {
+					body = fmt.Sprintf(`{"name":"abc","status":%q,"error":{"code":"InternalServerError","message":"boom"}}`, status)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(body))
+			}))
+			defer server.Close()
+
+			watcher := &Watcher{
+				Pipeline: runtime.NewPipeline("test", "v0.0.1", runtime.PipelineOptions{}, &policy.ClientOptions{}),
+				Interval: time.Millisecond,
+			}
+
+			var progress []arm.ProvisioningState
+			operation, err := watcher.Watch(context.Background(), StatusURL(server.URL, testSubscriptionID, "westus3", "abc"), func(op *arm.Operation) {
+				progress = append(progress, op.Status)
+			})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// Repeated statuses are only reported once.
+			var expected []arm.ProvisioningState
+			for _, status := range tc.statuses {
+				if len(expected) == 0 || expected[len(expected)-1] != arm.ProvisioningState(status) {
+					expected = append(expected, arm.ProvisioningState(status))
+				}
+			}
+			assert.Equal(t, expected, progress)
+			assert.Equal(t, len(tc.statuses), polls)
+			assert.Equal(t, expected[len(expected)-1], operation.Status)
+		})
+	}
+}
+
+func TestWatchNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	watcher := &Watcher{
+		Pipeline: runtime.NewPipeline("test", "v0.0.1", runtime.PipelineOptions{}, &policy.ClientOptions{}),
+		Interval: time.Millisecond,
+	}
+	_, err := watcher.Watch(context.Background(), StatusURL(server.URL, testSubscriptionID, "westus3", "abc"), nil)
+	assert.ErrorContains(t, err, "404")
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/Azure/ARO-HCP/internal/api/arm"
+	api "github.com/Azure/ARO-HCP/internal/api/v20240610preview/generated"
+)
+
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+)
+
+var Formats = []string{FormatTable, FormatJSON, FormatYAML}
+
+// Print writes the value to the writer in the given format. The table format
+// is only supported for the resource types of the API.
+func Print(w io.Writer, format string, value any) error {
+	switch format {
+	case FormatJSON:
+		raw, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal output as JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(raw))
+		return err
+	case FormatYAML:
+		raw, err := yaml.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal output as YAML: %w", err)
+		}
+		_, err = w.Write(raw)
+		return err
+	case FormatTable:
+		headers, rows, err := table(value)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, must be one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+func table(value any) ([]string, [][]string, error) {
+	switch v := value.(type) {
+	case *api.HcpOpenShiftCluster:
+		return table([]*api.HcpOpenShiftCluster{v})
+	case []*api.HcpOpenShiftCluster:
+		headers := []string{"NAME", "LOCATION", "VERSION", "STATE", "API URL"}
+		rows := make([][]string, 0, len(v))
+		for _, cluster := range v {
+			row := []string{str(cluster.Name), str(cluster.Location), "", "", ""}
+			if p := cluster.Properties; p != nil {
+				if p.Version != nil {
+					row[2] = str(p.Version.ID)
+				}
+				row[3] = str(p.ProvisioningState)
+				if p.API != nil {
+					row[4] = str(p.API.URL)
+				}
+			}
+			rows = append(rows, row)
+		}
+		return headers, rows, nil
+	case *api.NodePool:
+		return table([]*api.NodePool{v})
+	case []*api.NodePool:
+		headers := []string{"NAME", "VM SIZE", "REPLICAS", "AUTOSCALING", "STATE"}
+		rows := make([][]string, 0, len(v))
+		for _, nodePool := range v {
+			row := []string{str(nodePool.Name), "", "", "", ""}
+			if p := nodePool.Properties; p != nil {
+				if p.Platform != nil {
+					row[1] = str(p.Platform.VMSize)
+				}
+				if p.Replicas != nil {
+					row[2] = strconv.Itoa(int(*p.Replicas))
+				}
+				if p.AutoScaling != nil {
+					row[3] = fmt.Sprintf("%s-%s", str(p.AutoScaling.Min), str(p.AutoScaling.Max))
+				}
+				row[4] = str(p.ProvisioningState)
+			}
+			rows = append(rows, row)
+		}
+		return headers, rows, nil
+	case *api.HcpOpenShiftClusterAdminCredential:
+		return []string{"EXPIRES"}, [][]string{{str(v.ExpirationTimestamp)}}, nil
+	case *arm.Operation:
+		row := []string{v.Name, string(v.Status), str(v.StartTime), str(v.EndTime), ""}
+		if v.Error != nil {
+			row[4] = fmt.Sprintf("%s: %s", v.Error.Code, v.Error.Message)
+		}
+		return []string{"NAME", "STATUS", "STARTED", "ENDED", "ERROR"}, [][]string{row}, nil
+	default:
+		return nil, nil, fmt.Errorf("table output is not supported for %T", value)
+	}
+}
+
+// str renders an optional value of the generated API models.
+func str[T any](value *T) string {
+	if value == nil {
+		return ""
+	}
+	switch v := any(value).(type) {
+	case *time.Time:
+		return v.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprint(*value)
+}
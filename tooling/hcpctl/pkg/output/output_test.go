@@ -0,0 +1,98 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/Azure/ARO-HCP/internal/api/v20240610preview/generated"
+)
+
+func TestPrint(t *testing.T) {
+	nodePools := []*api.NodePool{
+		{
+			Name: to.Ptr("default"),
+			Properties: &api.NodePoolProperties{
+				Platform:          &api.NodePoolPlatformProfile{VMSize: to.Ptr("Standard_D8s_v3")},
+				Replicas:          to.Ptr[int32](2),
+				ProvisioningState: to.Ptr(api.ProvisioningStateSucceeded),
+			},
+		},
+		{
+			Name: to.Ptr("autoscaled"),
+			Properties: &api.NodePoolProperties{
+				AutoScaling: &api.NodePoolAutoScaling{Min: to.Ptr[int32](1), Max: to.Ptr[int32](5)},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		format   string
+		value    any
+		expected string
+		err      string
+	}{
+		{
+			name:   "table",
+			format: FormatTable,
+			value:  nodePools,
+			expected: "" +
+				"NAME         VM SIZE           REPLICAS   AUTOSCALING   STATE\n" +
+				"default      Standard_D8s_v3   2                        Succeeded\n" +
+				"autoscaled                                1-5           \n",
+		},
+		{
+			name:     "json",
+			format:   FormatJSON,
+			value:    nodePools[0],
+			expected: "{\n  \"name\": \"default\",\n  \"properties\": {\n    \"platform\": {\n      \"vmSize\": \"Standard_D8s_v3\"\n    },\n    \"provisioningState\": \"Succeeded\",\n    \"replicas\": 2\n  }\n}\n",
+		},
+		{
+			name:     "yaml",
+			format:   FormatYAML,
+			value:    nodePools[0],
+			expected: "name: default\nproperties:\n  platform:\n    vmSize: Standard_D8s_v3\n  provisioningState: Succeeded\n  replicas: 2\n",
+		},
+		{
+			name:   "unsupported table",
+			format: FormatTable,
+			value:  "text",
+			err:    "table output is not supported for string",
+		},
+		{
+			name:   "unknown format",
+			format: "xml",
+			value:  nodePools,
+			err:    `unknown output format "xml", must be one of table, json, yaml`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Print(&out, tc.format, tc.value)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/containers/image/v5/types"
+)
+
+const (
+	AuthTypeNone                  = "none"
+	AuthTypeBearer                = "bearer"
+	AuthTypeBasic                 = "basic"
+	AuthTypeDockerConfig          = "dockerConfig"
+	AuthTypeAzureWorkloadIdentity = "azureWorkloadIdentity"
+)
+
+const (
+	// acrTokenUsername is the user name ACR expects together with a refresh token
+	acrTokenUsername = "00000000-0000-0000-0000-000000000000"
+	// quayTokenUsername is the user name Quay expects together with an OAuth token
+	quayTokenUsername = "$oauthtoken"
+	// ociTokenUsername is the user name GCR based registries expect together with an access token
+	ociTokenUsername = "oauth2accesstoken"
+)
+
+// AuthConfig declares how to authenticate against a source registry
+type AuthConfig struct {
+	// Type is one of none, bearer, basic, dockerConfig or azureWorkloadIdentity
+	Type string
+	// SecretFile contains {"BearerToken": "..."} for bearer or {"Username": "...", "Password": "..."} for basic auth
+	SecretFile string
+	// DockerConfigFile is the docker config.json to read the credentials from, defaults to ~/.docker/config.json
+	DockerConfigFile string
+	// ClientID of the workload identity, defaults to AZURE_CLIENT_ID
+	ClientID string
+	// TenantID of the workload identity, defaults to AZURE_TENANT_ID
+	TenantID string
+	// Pull also pulls images, digests and referrers with the credentials, instead of those of
+	// the containers auth files
+	Pull bool
+}
+
+// Credentials are the result of an authentication, either a bearer token or basic credentials
+type Credentials struct {
+	BearerToken string
+	Username    string
+	Password    string
+}
+
+func (c *Credentials) isBasic() bool {
+	return c != nil && c.Username != ""
+}
+
+func (c *Credentials) isBearer() bool {
+	return c != nil && c.BearerToken != ""
+}
+
+// dockerAuth returns the credentials to pull images with, nil for anonymous access. Bearer
+// tokens are passed as password of tokenUsername, they are not used if it is empty.
+func (c *Credentials) dockerAuth(tokenUsername string) *types.DockerAuthConfig {
+	switch {
+	case c.isBasic():
+		return &types.DockerAuthConfig{Username: c.Username, Password: c.Password}
+	case c.isBearer() && tokenUsername != "":
+		return &types.DockerAuthConfig{Username: tokenUsername, Password: c.BearerToken}
+	default:
+		return nil
+	}
+}
+
+// AuthProvider obtains the credentials for a registry
+type AuthProvider func(ctx context.Context, registry RegistryConfig, httpclient *http.Client) (*Credentials, error)
+
+var authProviders = map[string]AuthProvider{
+	AuthTypeNone: func(context.Context, RegistryConfig, *http.Client) (*Credentials, error) {
+		return nil, nil
+	},
+	AuthTypeBearer:                bearerAuth,
+	AuthTypeBasic:                 basicAuth,
+	AuthTypeDockerConfig:          dockerConfigAuth,
+	AuthTypeAzureWorkloadIdentity: azureWorkloadIdentityAuth,
+}
+
+// RegisterAuthProvider makes an additional authentication method available
+func RegisterAuthProvider(authType string, provider AuthProvider) {
+	authProviders[authType] = provider
+}
+
+// Authenticate returns the credentials for a registry, nil for anonymous access
+func Authenticate(ctx context.Context, registry RegistryConfig, httpclient *http.Client) (*Credentials, error) {
+	authType := registry.Auth.Type
+	if authType == "" {
+		authType = AuthTypeNone
+	}
+	provider, ok := authProviders[authType]
+	if !ok {
+		return nil, fmt.Errorf("unknown auth type %q for registry %s", authType, registry.Registry)
+	}
+	return provider(ctx, registry, httpclient)
+}
+
+func bearerAuth(_ context.Context, registry RegistryConfig, _ *http.Client) (*Credentials, error) {
+	secret, err := readBearerSecret(registry.Auth.SecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading secret file: %w %s", err, registry.Auth.SecretFile)
+	}
+	return &Credentials{BearerToken: secret.BearerToken}, nil
+}
+
+func basicAuth(_ context.Context, registry RegistryConfig, _ *http.Client) (*Credentials, error) {
+	secret, err := readAzureSecret(registry.Auth.SecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading secret file: %w %s", err, registry.Auth.SecretFile)
+	}
+	return &Credentials{Username: secret.Username, Password: secret.Password}, nil
+}
+
+type dockerConfigFile struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		RegistryToken string `json:"registrytoken"`
+	} `json:"auths"`
+}
+
+func dockerConfigAuth(_ context.Context, registry RegistryConfig, _ *http.Client) (*Credentials, error) {
+	path := registry.Auth.DockerConfigFile
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find home directory: %w", err)
+		}
+		path = filepath.Join(home, ".docker", "config.json")
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docker config %s: %w", path, err)
+	}
+	var config dockerConfigFile
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal docker config %s: %w", path, err)
+	}
+
+	for _, key := range []string{registry.Registry, "https://" + registry.Registry} {
+		entry, ok := config.Auths[key]
+		if !ok {
+			continue
+		}
+		if entry.RegistryToken != "" {
+			return &Credentials{BearerToken: entry.RegistryToken}, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode auth for %s in %s: %w", registry.Registry, path, err)
+		}
+		username, password, found := strings.Cut(string(decoded), ":")
+		if !found {
+			return nil, fmt.Errorf("invalid auth for %s in %s", registry.Registry, path)
+		}
+		return &Credentials{Username: username, Password: password}, nil
+	}
+	return nil, fmt.Errorf("no credentials for %s in %s", registry.Registry, path)
+}
+
+// azureWorkloadIdentityAuth exchanges the federated token of the workload identity for an
+// ACR refresh token
+func azureWorkloadIdentityAuth(ctx context.Context, registry RegistryConfig, httpclient *http.Client) (*Credentials, error) {
+	cred, err := azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+		ClientID: registry.Auth.ClientID,
+		TenantID: registry.Auth.TenantID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workload identity credential: %w", err)
+	}
+	acr := &AzureContainerRegistry{
+		acrName:            registry.Registry,
+		credential:         cred,
+		httpClient:         httpclient,
+		tenantId:           registry.Auth.TenantID,
+		getAccessTokenImpl: getManagementAccessToken,
+		getACRUrlImpl: func(string) string {
+			return registry.baseURL()
+		},
+	}
+	secret, err := acr.GetPullSecret(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange workload identity token for %s: %w", registry.Registry, err)
+	}
+	return &Credentials{Username: acrTokenUsername, Password: secret.RefreshToken}, nil
+}
+
+// doRegistryRequest sends a GET request to a registry. A configured bearer token is sent
+// right away, otherwise the bearer token or basic auth challenge of the registry is followed.
+func doRegistryRequest(ctx context.Context, httpclient *http.Client, path, accept string, creds *Credentials) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if creds.isBearer() {
+		req.Header.Set("Authorization", "Bearer "+creds.BearerToken)
+	}
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized || creds.isBearer() {
+		return resp, nil
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	if scheme, _, _ := strings.Cut(challenge, " "); strings.EqualFold(scheme, "Basic") {
+		if !creds.isBasic() {
+			return nil, fmt.Errorf("registry requires basic auth, but no credentials are configured")
+		}
+		req.SetBasicAuth(creds.Username, creds.Password)
+		return httpclient.Do(req)
+	}
+
+	token, err := getRegistryToken(ctx, httpclient, challenge, creds)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return httpclient.Do(req)
+}
+
+// parseChallenge parses the parameters of a WWW-Authenticate bearer challenge, i.e.
+// Bearer realm="https://quay.io/v2/auth",service="quay.io",scope="repository:foo:pull"
+func parseChallenge(challenge string) (map[string]string, error) {
+	scheme, params, found := strings.Cut(challenge, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+	parsed := make(map[string]string)
+	for _, param := range strings.Split(params, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found {
+			continue
+		}
+		parsed[key] = strings.Trim(value, `"`)
+	}
+	if parsed["realm"] == "" {
+		return nil, fmt.Errorf("authentication challenge %q has no realm", challenge)
+	}
+	return parsed, nil
+}
+
+func getRegistryToken(ctx context.Context, httpclient *http.Client, challenge string, creds *Credentials) (string, error) {
+	params, err := parseChallenge(challenge)
+	if err != nil {
+		return "", err
+	}
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", fmt.Errorf("invalid realm %q: %v", params["realm"], err)
+	}
+	query := tokenURL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", tokenURL.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	if creds.isBasic() {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send token request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d requesting token", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %v", err)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("failed to unmarshal token: %v", err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
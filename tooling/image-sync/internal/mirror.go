@@ -0,0 +1,262 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/pkg/docker/config"
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/types"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// registriesConfig enables reading and writing sigstore signatures stored as
+// sha256-<digest>.sig tags for all registries
+const registriesConfig = `default-docker:
+  use-sigstore-attachments: true
+`
+
+// cosignAttachmentSuffixes are the tag suffixes cosign uses for attestations and SBOMs
+// that are attached to an image without the OCI referrers API
+var cosignAttachmentSuffixes = []string{".att", ".sbom"}
+
+// MirrorOptions control how images, their platforms and their signatures are copied
+type MirrorOptions struct {
+	// AllPlatforms copies the full manifest list instead of the image for the current platform
+	AllPlatforms bool
+	// Platforms copies only the given platforms of a manifest list
+	Platforms []imgspecv1.Platform
+	// PreserveSignatures copies sigstore signatures along with the image
+	PreserveSignatures bool
+	// MirrorReferrers copies the OCI referrers and cosign attachments of the image
+	MirrorReferrers bool
+	// SignaturePublicKeys are the public keys the source image must be signed with, no verification if empty
+	SignaturePublicKeys []string
+
+	registriesDir string
+	httpclient    *http.Client
+}
+
+// parsePlatform parses a platform in the os/arch[/variant] format
+func parsePlatform(platform string) (imgspecv1.Platform, error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return imgspecv1.Platform{}, fmt.Errorf("invalid platform %q, expected os/arch[/variant]", platform)
+	}
+	p := imgspecv1.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// NewMirrorOptions creates the mirror options from the sync configuration. The returned
+// cleanup function removes temporary files and must be called once the sync is done.
+func NewMirrorOptions(cfg *SyncConfig) (*MirrorOptions, func(), error) {
+	opts := &MirrorOptions{
+		AllPlatforms:        cfg.AllPlatforms,
+		PreserveSignatures:  cfg.PreserveSignatures || len(cfg.SignaturePublicKeys) > 0,
+		MirrorReferrers:     cfg.MirrorReferrers,
+		SignaturePublicKeys: cfg.SignaturePublicKeys,
+		httpclient:          &http.Client{Timeout: time.Duration(cfg.RequestTimeout) * time.Second},
+	}
+	if cfg.AllPlatforms && len(cfg.Platforms) > 0 {
+		return nil, nil, fmt.Errorf("allPlatforms and platforms are mutually exclusive")
+	}
+	for _, platform := range cfg.Platforms {
+		p, err := parsePlatform(platform)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts.Platforms = append(opts.Platforms, p)
+	}
+	for _, key := range cfg.SignaturePublicKeys {
+		if _, err := os.Stat(key); err != nil {
+			return nil, nil, fmt.Errorf("signature public key %s: %w", key, err)
+		}
+	}
+
+	cleanup := func() {}
+	if opts.PreserveSignatures {
+		dir, err := os.MkdirTemp("", "image-sync-registries.d")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create registries.d: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "default.yaml"), []byte(registriesConfig), 0644); err != nil {
+			os.RemoveAll(dir)
+			return nil, nil, fmt.Errorf("failed to write registries.d config: %w", err)
+		}
+		opts.registriesDir = dir
+		cleanup = func() {
+			os.RemoveAll(dir)
+		}
+	}
+	return opts, cleanup, nil
+}
+
+// policy returns the signature policy the source images have to satisfy
+func (o *MirrorOptions) policy() (*signature.Policy, error) {
+	if o == nil || len(o.SignaturePublicKeys) == 0 {
+		return &signature.Policy{
+			Default: signature.PolicyRequirements{
+				signature.NewPRInsecureAcceptAnything(),
+			},
+		}, nil
+	}
+	requirement, err := signature.NewPRSigstoreSigned(
+		signature.PRSigstoreSignedWithKeyPaths(o.SignaturePublicKeys),
+		signature.PRSigstoreSignedWithSignedIdentity(signature.NewPRMMatchRepoDigestOrExact()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signature requirement: %w", err)
+	}
+	return &signature.Policy{
+		Default: signature.PolicyRequirements{requirement},
+	}, nil
+}
+
+func (o *MirrorOptions) systemContext(auth *types.DockerAuthConfig) *types.SystemContext {
+	sys := &types.SystemContext{
+		DockerAuthConfig: auth,
+	}
+	if o != nil {
+		sys.RegistriesDirPath = o.registriesDir
+	}
+	return sys
+}
+
+// copyOptions configures the manifest list handling of a copy
+func (o *MirrorOptions) copyOptions(options *copy.Options) {
+	if o == nil {
+		return
+	}
+	switch {
+	case o.AllPlatforms:
+		options.ImageListSelection = copy.CopyAllImages
+	case len(o.Platforms) > 0:
+		options.ImageListSelection = copy.CopySpecificImages
+		options.InstancePlatforms = o.Platforms
+	}
+}
+
+// cosignAttachmentTag returns the tag cosign uses to attach an artifact to the given digest
+func cosignAttachmentTag(digest, suffix string) string {
+	return strings.Replace(digest, ":", "-", 1) + suffix
+}
+
+// MirrorReferrers copies the artifacts referring to the image with the given digest, i.e.
+// signatures, attestations and SBOMs. Both OCI referrers and cosign attachments stored as
+// tags are copied. Without source credentials, those of the local auth files are used.
+func MirrorReferrers(ctx context.Context, dstrepository, srcrepository, digest string, dstauth, srcauth *types.DockerAuthConfig, opts *MirrorOptions) error {
+	registry, repository, _ := strings.Cut(srcrepository, "/")
+	if srcauth == nil {
+		auth, err := config.GetCredentials(opts.systemContext(nil), registry)
+		if err != nil {
+			return fmt.Errorf("failed to get credentials for %s: %w", registry, err)
+		}
+		srcauth = &auth
+	}
+
+	creds := &Credentials{Username: srcauth.Username, Password: srcauth.Password}
+	referrers, err := listReferrers(ctx, opts.httpclient, registry, repository, digest, creds)
+	if err != nil {
+		return err
+	}
+	for _, referrer := range referrers {
+		source := fmt.Sprintf("%s@%s", srcrepository, referrer.Digest)
+		target := fmt.Sprintf("%s@%s", dstrepository, referrer.Digest)
+		Log().Infow("Copying referrer", "from", source, "to", target, "artifactType", referrer.ArtifactType)
+		if err := copyArtifact(ctx, target, source, dstauth, srcauth, opts); err != nil {
+			return fmt.Errorf("error copying referrer %s: %w", referrer.Digest, err)
+		}
+		// referrers of referrers, like signatures of an SBOM
+		if err := MirrorReferrers(ctx, dstrepository, srcrepository, referrer.Digest.String(), dstauth, srcauth, opts); err != nil {
+			return err
+		}
+	}
+
+	for _, suffix := range cosignAttachmentSuffixes {
+		tag := cosignAttachmentTag(digest, suffix)
+		source := fmt.Sprintf("%s:%s", srcrepository, tag)
+		if _, err := GetDigest(ctx, source, srcauth); err != nil {
+			Log().Debugw("No cosign attachment found", "image", source, "error", err)
+			continue
+		}
+		target := fmt.Sprintf("%s:%s", dstrepository, tag)
+		Log().Infow("Copying cosign attachment", "from", source, "to", target)
+		if err := copyArtifact(ctx, target, source, dstauth, srcauth, opts); err != nil {
+			return fmt.Errorf("error copying cosign attachment %s: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// copyArtifact copies an artifact as is, without platform selection or signature verification
+func copyArtifact(ctx context.Context, dstreference, srcreference string, dstauth, srcauth *types.DockerAuthConfig, opts *MirrorOptions) error {
+	artifactOpts := &MirrorOptions{AllPlatforms: true}
+	if opts != nil {
+		artifactOpts.PreserveSignatures = opts.PreserveSignatures
+		artifactOpts.registriesDir = opts.registriesDir
+	}
+	_, err := Copy(ctx, dstreference, srcreference, dstauth, srcauth, artifactOpts)
+	return err
+}
+
+// listReferrers calls the OCI referrers API. Registries without support for the API
+// return no referrers.
+func listReferrers(ctx context.Context, httpclient *http.Client, registry, repository, digest string, creds *Credentials) ([]imgspecv1.Descriptor, error) {
+	path := fmt.Sprintf("%s/v2/%s/referrers/%s", registryURL(registry), repository, digest)
+
+	resp, err := doRegistryRequest(ctx, httpclient, path, imgspecv1.MediaTypeImageIndex, creds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referrers: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		Log().Debugw("Registry does not support referrers API", "registry", registry)
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list referrers: unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	var index imgspecv1.Index
+	if err := json.Unmarshal(body, &index); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal referrers: %v", err)
+	}
+	return index.Manifests, nil
+}
+
+func registryURL(registry string) string {
+	if strings.HasPrefix(registry, "http://") || strings.HasPrefix(registry, "https://") {
+		return registry
+	}
+	return "https://" + registry
+}
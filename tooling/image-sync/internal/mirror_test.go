@@ -0,0 +1,95 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestParsePlatform(t *testing.T) {
+	p, err := parsePlatform("linux/arm64/v8")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, imgspecv1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, p)
+
+	_, err = parsePlatform("amd64")
+	assert.Error(t, err, `invalid platform "amd64", expected os/arch[/variant]`)
+}
+
+func TestNewMirrorOptions(t *testing.T) {
+	_, _, err := NewMirrorOptions(&SyncConfig{AllPlatforms: true, Platforms: []string{"linux/amd64"}})
+	assert.Error(t, err, "allPlatforms and platforms are mutually exclusive")
+
+	opts, cleanup, err := NewMirrorOptions(&SyncConfig{PreserveSignatures: true, Platforms: []string{"linux/amd64"}})
+	assert.NilError(t, err)
+	assert.Assert(t, opts.registriesDir != "")
+	assert.Equal(t, opts.registriesDir, opts.systemContext(nil).RegistriesDirPath)
+	cleanup()
+}
+
+func TestCosignAttachmentTag(t *testing.T) {
+	assert.Equal(t, "sha256-abc.att", cosignAttachmentTag("sha256:abc", ".att"))
+}
+
+func TestRepositoryOf(t *testing.T) {
+	assert.Equal(t, "quay.io/app", repositoryOf("quay.io/app:1.0"))
+	assert.Equal(t, "localhost:5000/app", repositoryOf("localhost:5000/app"))
+	assert.Equal(t, "localhost:5000/app", repositoryOf("localhost:5000/app:1.0"))
+}
+
+func TestListReferrers(t *testing.T) {
+	var mock *httptest.Server
+	mock = httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/token":
+				user, password, ok := r.BasicAuth()
+				assert.Assert(t, ok)
+				assert.Equal(t, "user", user)
+				assert.Equal(t, "password", password)
+				assert.Equal(t, "repository:app:pull", r.URL.Query().Get("scope"))
+				_, err := w.Write([]byte(`{"token":"fooBar"}`))
+				assert.NilError(t, err)
+			case "/v2/app/referrers/sha256:abc":
+				if r.Header.Get("Authorization") != "Bearer fooBar" {
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:app:pull"`, mock.URL))
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_, err := w.Write([]byte(`{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:def","size":1,"artifactType":"application/spdx+json"}]}`))
+				assert.NilError(t, err)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	defer mock.Close()
+
+	creds := &Credentials{Username: "user", Password: "password"}
+	referrers, err := listReferrers(context.TODO(), mock.Client(), mock.URL, "app", "sha256:abc", creds)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(referrers))
+	assert.Equal(t, "sha256:def", referrers[0].Digest.String())
+	assert.Equal(t, "application/spdx+json", referrers[0].ArtifactType)
+
+	referrers, err = listReferrers(context.TODO(), mock.Client(), mock.URL, "other", "sha256:abc", creds)
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(referrers))
+}
@@ -0,0 +1,248 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/containers/image/v5/types"
+)
+
+const (
+	RegistryTypeQuay = "quay"
+	RegistryTypeACR  = "acr"
+	RegistryTypeOCI  = "oci"
+	RegistryTypeECR  = "ecr"
+	RegistryTypeGHCR = "ghcr"
+)
+
+// RegistryConfig declares how a source registry is accessed
+type RegistryConfig struct {
+	// Registry is the host name used in the repositories, i.e. quay.io
+	Registry string
+	// Type is the provider used to list tags, one of quay, acr, oci, ecr or ghcr
+	Type string
+	// URL overrides the base URL of the registry API, defaults to https://<registry>
+	URL string
+	// Auth declares how to authenticate, anonymous if empty
+	Auth AuthConfig
+}
+
+func (r RegistryConfig) baseURL() string {
+	if r.URL != "" {
+		return strings.TrimSuffix(r.URL, "/")
+	}
+	return registryURL(r.Registry)
+}
+
+// pullAuth returns the credentials of the client to pull images with if the registry is
+// configured to pull with them, nil to use the containers auth files
+func (r RegistryConfig) pullAuth(client Registry) *types.DockerAuthConfig {
+	if !r.Auth.Pull {
+		return nil
+	}
+	return client.DockerAuth()
+}
+
+// RegistryProviderOptions are passed to a RegistryProvider to create a Registry client
+type RegistryProviderOptions struct {
+	Registry RegistryConfig
+	// Credentials are the result of the configured authentication, nil for anonymous access
+	Credentials  *Credentials
+	HTTPClient   *http.Client
+	NumberOfTags int
+}
+
+// RegistryProvider creates a Registry client for a registry type
+type RegistryProvider func(ctx context.Context, opts RegistryProviderOptions) (Registry, error)
+
+var registryProviders = map[string]RegistryProvider{
+	RegistryTypeQuay: newQuayProvider,
+	RegistryTypeACR:  newACRProvider,
+	RegistryTypeOCI:  newOCIProvider,
+	RegistryTypeECR:  newECRProvider,
+	RegistryTypeGHCR: newGHCRProvider,
+}
+
+// RegisterRegistryProvider makes an additional registry type available
+func RegisterRegistryProvider(registryType string, provider RegistryProvider) {
+	registryProviders[registryType] = provider
+}
+
+// NewRegistry authenticates against a registry and creates a client for its type
+func NewRegistry(ctx context.Context, cfg *SyncConfig, registry RegistryConfig) (Registry, error) {
+	httpclient := &http.Client{Timeout: time.Duration(cfg.RequestTimeout) * time.Second}
+	return newRegistry(ctx, registry, httpclient, cfg.NumberOfTags)
+}
+
+func newRegistry(ctx context.Context, registry RegistryConfig, httpclient *http.Client, numberOfTags int) (Registry, error) {
+	provider, ok := registryProviders[registry.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown registry type %q for registry %s", registry.Type, registry.Registry)
+	}
+	creds, err := Authenticate(ctx, registry, httpclient)
+	if err != nil {
+		return nil, err
+	}
+	return provider(ctx, RegistryProviderOptions{
+		Registry:     registry,
+		Credentials:  creds,
+		HTTPClient:   httpclient,
+		NumberOfTags: numberOfTags,
+	})
+}
+
+// legacyRegistryConfig converts an entry of the secrets list, detecting the registry type from
+// the registry name
+func legacyRegistryConfig(secret Secrets) RegistryConfig {
+	switch {
+	case secret.Registry == "quay.io":
+		return RegistryConfig{
+			Registry: secret.Registry,
+			Type:     RegistryTypeQuay,
+			Auth:     AuthConfig{Type: AuthTypeBearer, SecretFile: secret.SecretFile},
+		}
+	case strings.HasSuffix(secret.Registry, "azurecr.io") ||
+		strings.HasSuffix(secret.Registry, "azurecr.cn") ||
+		strings.HasSuffix(secret.Registry, "azurecr.us"):
+		return RegistryConfig{
+			Registry: secret.Registry,
+			Type:     RegistryTypeACR,
+			Auth:     AuthConfig{Type: AuthTypeBasic, SecretFile: secret.SecretFile},
+		}
+	default:
+		return RegistryConfig{
+			Registry: secret.Registry,
+			Type:     RegistryTypeOCI,
+			Auth:     AuthConfig{Type: AuthTypeBearer, SecretFile: secret.SecretFile},
+		}
+	}
+}
+
+// registryConfigs returns the configured registries followed by those declared as secrets
+func registryConfigs(cfg *SyncConfig) ([]RegistryConfig, error) {
+	registries := append([]RegistryConfig{}, cfg.Registries...)
+	for _, secret := range cfg.Secrets {
+		registries = append(registries, legacyRegistryConfig(secret))
+	}
+	seen := make(map[string]bool)
+	for _, registry := range registries {
+		if registry.Registry == "" {
+			return nil, fmt.Errorf("registry without name")
+		}
+		if seen[registry.Registry] {
+			return nil, fmt.Errorf("registry %s is configured more than once", registry.Registry)
+		}
+		seen[registry.Registry] = true
+	}
+	return registries, nil
+}
+
+func withTransport(httpclient *http.Client, wrap func(http.RoundTripper) http.RoundTripper) *http.Client {
+	wrapped := *httpclient
+	transport := httpclient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	wrapped.Transport = wrap(transport)
+	return &wrapped
+}
+
+func newQuayProvider(_ context.Context, opts RegistryProviderOptions) (Registry, error) {
+	httpclient := opts.HTTPClient
+	switch {
+	case opts.Credentials.isBearer():
+		httpclient = withTransport(httpclient, func(rt http.RoundTripper) http.RoundTripper {
+			return &AuthedTransport{Key: "Bearer " + opts.Credentials.BearerToken, Wrapped: rt}
+		})
+	case opts.Credentials != nil:
+		return nil, fmt.Errorf("registry %s: quay supports bearer tokens only", opts.Registry.Registry)
+	}
+	return &QuayRegistry{
+		httpclient:   httpclient,
+		baseUrl:      opts.Registry.baseURL(),
+		numberOftags: opts.NumberOfTags,
+		dockerAuth:   opts.Credentials.dockerAuth(quayTokenUsername),
+	}, nil
+}
+
+func newACRProvider(ctx context.Context, opts RegistryProviderOptions) (Registry, error) {
+	acr := &ACRWithTokenAuth{
+		httpclient:   opts.HTTPClient,
+		acrName:      opts.Registry.Registry,
+		baseURL:      opts.Registry.baseURL(),
+		numberOftags: opts.NumberOfTags,
+		dockerAuth:   opts.Credentials.dockerAuth(acrTokenUsername),
+	}
+	switch {
+	case opts.Credentials.isBearer():
+		acr.bearerToken = opts.Credentials.BearerToken
+	case opts.Credentials.isBasic():
+		secret := AzureSecretFile{Username: opts.Credentials.Username, Password: opts.Credentials.Password}
+		token, err := getACRBearerToken(ctx, opts.HTTPClient, acr.baseURL, secret, acr.acrName)
+		if err != nil {
+			return nil, fmt.Errorf("error getting ACR bearer token: %w", err)
+		}
+		acr.bearerToken = token
+	default:
+		return nil, fmt.Errorf("registry %s: acr requires authentication", opts.Registry.Registry)
+	}
+	return acr, nil
+}
+
+func newOCIProvider(_ context.Context, opts RegistryProviderOptions) (Registry, error) {
+	o := &OCIRegistry{
+		httpclient:   opts.HTTPClient,
+		baseURL:      opts.Registry.baseURL(),
+		numberOftags: opts.NumberOfTags,
+		dockerAuth:   opts.Credentials.dockerAuth(ociTokenUsername),
+	}
+	switch {
+	case opts.Credentials.isBearer():
+		o.bearerToken = opts.Credentials.BearerToken
+	case opts.Credentials != nil:
+		return nil, fmt.Errorf("registry %s: oci supports bearer tokens only", opts.Registry.Registry)
+	}
+	return o, nil
+}
+
+func newDistributionRegistry(opts RegistryProviderOptions) *DistributionRegistry {
+	return &DistributionRegistry{
+		httpclient:   opts.HTTPClient,
+		baseURL:      opts.Registry.baseURL(),
+		numberOftags: opts.NumberOfTags,
+		pageSize:     100,
+		credentials:  opts.Credentials,
+	}
+}
+
+// newECRProvider creates an ECR client. ECR requires basic auth with the user AWS and the
+// password from `aws ecr get-login-password`.
+func newECRProvider(_ context.Context, opts RegistryProviderOptions) (Registry, error) {
+	if !opts.Credentials.isBasic() {
+		return nil, fmt.Errorf("registry %s: ecr requires basic auth", opts.Registry.Registry)
+	}
+	return newDistributionRegistry(opts), nil
+}
+
+// newGHCRProvider creates a GHCR client. Public packages can be read anonymously, private
+// packages need basic auth with a GitHub user and a personal access token.
+func newGHCRProvider(_ context.Context, opts RegistryProviderOptions) (Registry, error) {
+	return newDistributionRegistry(opts), nil
+}
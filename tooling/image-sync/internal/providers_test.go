@@ -0,0 +1,312 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containers/image/v5/types"
+	"gotest.tools/v3/assert"
+)
+
+// registryStandIn is a minimal local registry serving the tag listing APIs of all providers.
+// Tags are ordered from newest to oldest and every API returns pageSize tags per page.
+type registryStandIn struct {
+	t        *testing.T
+	server   *httptest.Server
+	tags     []string
+	pageSize int
+}
+
+func newRegistryStandIn(t *testing.T, tags []string, pageSize int) *registryStandIn {
+	r := &registryStandIn{t: t, tags: tags, pageSize: pageSize}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repository/", r.quayTags)
+	mux.HandleFunc("/oauth2/token", r.acrToken)
+	mux.HandleFunc("/acr/v1/", r.acrTags)
+	mux.HandleFunc("/token", r.distributionToken)
+	mux.HandleFunc("/v2/", r.distributionTags)
+	r.server = httptest.NewTLSServer(mux)
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *registryStandIn) createdAt(i int) time.Time {
+	return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(len(r.tags)-i) * time.Hour)
+}
+
+// page returns the tags following last
+func (r *registryStandIn) page(last string) ([]int, bool) {
+	start := 0
+	if last != "" {
+		for i, tag := range r.tags {
+			if tag == last {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+r.pageSize, len(r.tags))
+	var indexes []int
+	for i := start; i < end; i++ {
+		indexes = append(indexes, i)
+	}
+	return indexes, end < len(r.tags)
+}
+
+func (r *registryStandIn) write(w http.ResponseWriter, body any) {
+	raw, err := json.Marshal(body)
+	assert.NilError(r.t, err)
+	_, err = w.Write(raw)
+	assert.NilError(r.t, err)
+}
+
+func (r *registryStandIn) setNextLink(w http.ResponseWriter, req *http.Request, indexes []int) {
+	query := req.URL.Query()
+	query.Set("last", r.tags[indexes[len(indexes)-1]])
+	w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, req.URL.Path, query.Encode()))
+}
+
+func (r *registryStandIn) quayTags(w http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("Authorization") != "Bearer quay-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	page, err := strconv.Atoi(req.URL.Query().Get("page"))
+	assert.NilError(r.t, err)
+	last := ""
+	if page > 1 {
+		last = r.tags[(page-1)*r.pageSize-1]
+	}
+	indexes, more := r.page(last)
+	resp := map[string]any{"page": page, "has_additional": more}
+	var tags []map[string]any
+	for _, i := range indexes {
+		tags = append(tags, map[string]any{"name": r.tags[i], "manifest_digest": "sha256:" + r.tags[i], "start_ts": r.createdAt(i).Unix()})
+	}
+	resp["tags"] = tags
+	r.write(w, resp)
+}
+
+func (r *registryStandIn) acrToken(w http.ResponseWriter, req *http.Request) {
+	user, password, ok := req.BasicAuth()
+	if !ok || user != "user" || password != "password" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	r.write(w, map[string]string{"access_token": "acr-token"})
+}
+
+func (r *registryStandIn) acrTags(w http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("Authorization") != "Bearer acr-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	indexes, more := r.page(req.URL.Query().Get("last"))
+	if more {
+		r.setNextLink(w, req, indexes)
+	}
+	var tags []map[string]any
+	for _, i := range indexes {
+		tags = append(tags, map[string]any{"name": r.tags[i], "digest": "sha256:" + r.tags[i], "createdTime": r.createdAt(i)})
+	}
+	r.write(w, map[string]any{"tags": tags})
+}
+
+func (r *registryStandIn) distributionToken(w http.ResponseWriter, req *http.Request) {
+	user, password, ok := req.BasicAuth()
+	if !ok || user != "user" || password != "password" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	r.write(w, map[string]string{"token": "registry-token"})
+}
+
+// distributionTags serves the tags list API. Requests with the bearer token oci-token get the
+// GCR style response with manifest details, others have to follow the auth challenge.
+func (r *registryStandIn) distributionTags(w http.ResponseWriter, req *http.Request) {
+	authorization := req.Header.Get("Authorization")
+	user, password, basic := req.BasicAuth()
+	switch {
+	case authorization == "Bearer oci-token" || authorization == "Bearer registry-token":
+	case basic && user == "user" && password == "password":
+	case strings.HasPrefix(req.URL.Path, "/v2/basic/"):
+		w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	default:
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:app:pull"`, r.server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	indexes, more := r.page(req.URL.Query().Get("last"))
+	if more {
+		r.setNextLink(w, req, indexes)
+	}
+	resp := map[string]any{"name": "app"}
+	var tags []string
+	manifests := make(map[string]any)
+	for _, i := range indexes {
+		tags = append(tags, r.tags[i])
+		manifests["sha256:"+r.tags[i]] = map[string]any{
+			"timeUploadedMs": strconv.FormatInt(r.createdAt(i).UnixMilli(), 10),
+			"tag":            []string{r.tags[i]},
+		}
+	}
+	resp["tags"] = tags
+	if authorization == "Bearer oci-token" {
+		resp["manifest"] = manifests
+	}
+	r.write(w, resp)
+}
+
+func writeJSONFile(t *testing.T, content any) string {
+	raw, err := json.Marshal(content)
+	assert.NilError(t, err)
+	path := filepath.Join(t.TempDir(), "secret.json")
+	assert.NilError(t, os.WriteFile(path, raw, 0600))
+	return path
+}
+
+func TestRegistryProviders(t *testing.T) {
+	standIn := newRegistryStandIn(t, []string{"v1.4.0", "v1.3.0", "v1.2.0", "v1.1.0", "v1.0.0"}, 2)
+
+	bearerSecret := func(token string) string {
+		return writeJSONFile(t, BearerSecret{BearerToken: token})
+	}
+	basicSecret := writeJSONFile(t, AzureSecretFile{Username: "user", Password: "password"})
+	host := strings.TrimPrefix(standIn.server.URL, "https://")
+	dockerConfig := writeJSONFile(t, map[string]any{
+		"auths": map[string]any{
+			host: map[string]string{"auth": base64.StdEncoding.EncodeToString([]byte("user:password"))},
+		},
+	})
+
+	testCases := []struct {
+		name       string
+		repository string
+		registry   RegistryConfig
+		dockerAuth *types.DockerAuthConfig
+	}{
+		{
+			name:       "quay",
+			repository: "app",
+			registry:   RegistryConfig{Type: RegistryTypeQuay, Auth: AuthConfig{Type: AuthTypeBearer, SecretFile: bearerSecret("quay-token")}},
+			dockerAuth: &types.DockerAuthConfig{Username: "$oauthtoken", Password: "quay-token"},
+		},
+		{
+			name:       "acr",
+			repository: "app",
+			registry:   RegistryConfig{Type: RegistryTypeACR, Auth: AuthConfig{Type: AuthTypeBasic, SecretFile: basicSecret}},
+			dockerAuth: &types.DockerAuthConfig{Username: "user", Password: "password"},
+		},
+		{
+			name:       "oci",
+			repository: "app",
+			registry:   RegistryConfig{Type: RegistryTypeOCI, Auth: AuthConfig{Type: AuthTypeBearer, SecretFile: bearerSecret("oci-token")}},
+			dockerAuth: &types.DockerAuthConfig{Username: "oauth2accesstoken", Password: "oci-token"},
+		},
+		{
+			name:       "ecr",
+			repository: "basic/app",
+			registry:   RegistryConfig{Type: RegistryTypeECR, Auth: AuthConfig{Type: AuthTypeBasic, SecretFile: basicSecret}},
+			dockerAuth: &types.DockerAuthConfig{Username: "user", Password: "password"},
+		},
+		{
+			name:       "ghcr with token challenge",
+			repository: "app",
+			registry:   RegistryConfig{Type: RegistryTypeGHCR, Auth: AuthConfig{Type: AuthTypeDockerConfig, DockerConfigFile: dockerConfig}},
+			dockerAuth: &types.DockerAuthConfig{Username: "user", Password: "password"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.registry.Registry = host
+			tc.registry.URL = standIn.server.URL
+			registry, err := newRegistry(context.TODO(), tc.registry, standIn.server.Client(), 3)
+			assert.NilError(t, err)
+
+			tags, err := registry.GetTags(context.TODO(), tc.repository)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, []string{"v1.4.0", "v1.3.0", "v1.2.0"}, tags)
+
+			allTags, err := registry.ListTags(context.TODO(), tc.repository)
+			assert.NilError(t, err)
+			assert.Equal(t, len(standIn.tags), len(allTags))
+
+			// images are pulled with the credentials the tags were listed with only on request,
+			// the containers auth files are used otherwise
+			assert.DeepEqual(t, tc.dockerAuth, registry.DockerAuth())
+			assert.Assert(t, tc.registry.pullAuth(registry) == nil)
+			tc.registry.Auth.Pull = true
+			assert.DeepEqual(t, tc.dockerAuth, tc.registry.pullAuth(registry))
+		})
+	}
+}
+
+func TestCredentialsDockerAuth(t *testing.T) {
+	var anonymous *Credentials
+	assert.Assert(t, anonymous.dockerAuth(ociTokenUsername) == nil)
+	assert.Assert(t, (&Credentials{BearerToken: "token"}).dockerAuth("") == nil)
+	assert.DeepEqual(t, &types.DockerAuthConfig{Username: acrTokenUsername, Password: "token"}, (&Credentials{BearerToken: "token"}).dockerAuth(acrTokenUsername))
+	assert.DeepEqual(t, &types.DockerAuthConfig{Username: "user", Password: "password"}, (&Credentials{Username: "user", Password: "password"}).dockerAuth(acrTokenUsername))
+}
+
+func TestRegistryProviderErrors(t *testing.T) {
+	_, err := newRegistry(context.TODO(), RegistryConfig{Registry: "example.com", Type: "unknown"}, http.DefaultClient, 1)
+	assert.Error(t, err, `unknown registry type "unknown" for registry example.com`)
+
+	_, err = newRegistry(context.TODO(), RegistryConfig{Registry: "example.com", Type: RegistryTypeOCI, Auth: AuthConfig{Type: "unknown"}}, http.DefaultClient, 1)
+	assert.Error(t, err, `unknown auth type "unknown" for registry example.com`)
+
+	_, err = newRegistry(context.TODO(), RegistryConfig{Registry: "example.com", Type: RegistryTypeECR}, http.DefaultClient, 1)
+	assert.Error(t, err, "registry example.com: ecr requires basic auth")
+}
+
+func TestRegistryConfigs(t *testing.T) {
+	registries, err := registryConfigs(&SyncConfig{
+		Registries: []RegistryConfig{{Registry: "ghcr.io", Type: RegistryTypeGHCR}},
+		Secrets: []Secrets{
+			{Registry: "quay.io", SecretFile: "quay.json"},
+			{Registry: "arohcp.azurecr.io", SecretFile: "acr.json"},
+			{Registry: "registry.k8s.io", SecretFile: "k8s.json"},
+		},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []RegistryConfig{
+		{Registry: "ghcr.io", Type: RegistryTypeGHCR},
+		{Registry: "quay.io", Type: RegistryTypeQuay, Auth: AuthConfig{Type: AuthTypeBearer, SecretFile: "quay.json"}},
+		{Registry: "arohcp.azurecr.io", Type: RegistryTypeACR, Auth: AuthConfig{Type: AuthTypeBasic, SecretFile: "acr.json"}},
+		{Registry: "registry.k8s.io", Type: RegistryTypeOCI, Auth: AuthConfig{Type: AuthTypeBearer, SecretFile: "k8s.json"}},
+	}, registries)
+
+	_, err = registryConfigs(&SyncConfig{
+		Registries: []RegistryConfig{{Registry: "quay.io", Type: RegistryTypeQuay}},
+		Secrets:    []Secrets{{Registry: "quay.io", SecretFile: "quay.json"}},
+	})
+	assert.Error(t, err, "registry quay.io is configured more than once")
+}
@@ -0,0 +1,161 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	digestRegexp = regexp.MustCompile(`sha256:[a-f0-9]{64}`)
+	// attachmentTagRegexp matches the tags cosign and sigstore use to attach signatures,
+	// attestations and SBOMs to a digest
+	attachmentTagRegexp = regexp.MustCompile(`^(sha256)-([a-f0-9]{64})\.(sig|att|sbom)$`)
+)
+
+// PrunePlan lists what is removed from a repository in the target registry
+type PrunePlan struct {
+	Repository string
+	// Tags are the tags to delete
+	Tags []string
+	// Manifests are the digests that have no tags left once the tags are deleted
+	Manifests []string
+}
+
+// LoadProtectedDigests collects all sha256 digests mentioned in the given files, i.e. the
+// digests referenced by the deployed configuration
+func LoadProtectedDigests(files []string) (map[string]bool, error) {
+	digests := make(map[string]bool)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read protected digests file %s: %w", file, err)
+		}
+		for _, d := range digestRegexp.FindAllString(string(content), -1) {
+			digests[d] = true
+		}
+	}
+	return digests, nil
+}
+
+// planPrune decides which tags of a target repository are deleted. A tag is kept if it is
+// listed in keep, points to a protected digest or is an attachment of a kept digest.
+func planPrune(repository string, tags []TagInfo, keep map[string]bool, protected map[string]bool) PrunePlan {
+	plan := PrunePlan{Repository: repository}
+
+	isKept := func(tag TagInfo) bool {
+		return tag.Name == "latest" || keep[tag.Name] || (tag.Digest != "" && protected[tag.Digest])
+	}
+
+	// a manifest stays if any of its tags stays, so its other tags are kept as well
+	keptDigests := make(map[string]bool)
+	remainingTags := make(map[string]int)
+	for _, tag := range tags {
+		if tag.Digest == "" {
+			continue
+		}
+		remainingTags[tag.Digest]++
+		if isKept(tag) {
+			keptDigests[tag.Digest] = true
+		}
+	}
+
+	for _, tag := range tags {
+		if isKept(tag) || keptDigests[tag.Digest] {
+			continue
+		}
+		if match := attachmentTagRegexp.FindStringSubmatch(tag.Name); match != nil {
+			if keptDigests[match[1]+":"+match[2]] {
+				continue
+			}
+		}
+		plan.Tags = append(plan.Tags, tag.Name)
+		if tag.Digest == "" {
+			continue
+		}
+		remainingTags[tag.Digest]--
+		if remainingTags[tag.Digest] == 0 {
+			plan.Manifests = append(plan.Manifests, tag.Digest)
+		}
+	}
+	sort.Strings(plan.Tags)
+	sort.Strings(plan.Manifests)
+	return plan
+}
+
+// retainedTags returns the tags of a target repository the tag selection still wants to keep,
+// the tags selected from the source during this sync and the tags the policy selects among
+// the existing target tags
+func retainedTags(targetTags []TagInfo, selected []string, policy *TagPolicy, numberOfTags int) map[string]bool {
+	if policy == nil {
+		policy = &TagPolicy{}
+	}
+	keep := make(map[string]bool)
+	for _, tag := range selected {
+		keep[tag] = true
+	}
+	for _, tag := range policy.SelectTags(targetTags, numberOfTags, time.Now()) {
+		keep[tag] = true
+	}
+	return keep
+}
+
+// pruneRepository deletes the tags of a target repository that are no longer retained. In dry-run
+// mode the plan is only logged.
+func pruneRepository(ctx context.Context, targetACR *AzureContainerRegistry, repository string, selected []string, policy *TagPolicy, cfg *SyncConfig, protected map[string]bool) (*PrunePlan, error) {
+	targetTags, err := targetACR.ListTags(ctx, repository)
+	if err != nil {
+		return nil, fmt.Errorf("error listing ACR tags: %w", err)
+	}
+
+	plan := planPrune(repository, targetTags, retainedTags(targetTags, selected, policy, cfg.NumberOfTags), protected)
+	if len(plan.Tags) == 0 {
+		return &plan, nil
+	}
+
+	if cfg.PruneDryRun {
+		Log().Infow("Would prune tags (dry-run)", "repository", repository, "tags", plan.Tags, "manifests", plan.Manifests)
+		return &plan, nil
+	}
+
+	Log().Infow("Pruning tags", "repository", repository, "tags", plan.Tags)
+	var failed []string
+	for _, tag := range plan.Tags {
+		if err := targetACR.DeleteTag(ctx, repository, tag); err != nil {
+			Log().Errorw("Failed to delete tag", "repository", repository, "tag", tag, "error", err)
+			failed = append(failed, tag)
+		}
+	}
+	if len(failed) > 0 {
+		// keep the manifests, some of them still have tags
+		return &plan, fmt.Errorf("failed to delete tags %s", strings.Join(failed, ", "))
+	}
+	for _, digest := range plan.Manifests {
+		if err := targetACR.DeleteManifest(ctx, repository, digest); err != nil {
+			Log().Errorw("Failed to delete manifest", "repository", repository, "digest", digest, "error", err)
+			failed = append(failed, digest)
+		}
+	}
+	if len(failed) > 0 {
+		return &plan, fmt.Errorf("failed to delete manifests %s", strings.Join(failed, ", "))
+	}
+	return &plan, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func testDigest(c string) string {
+	return "sha256:" + strings.Repeat(c, 64)
+}
+
+func TestPlanPrune(t *testing.T) {
+	tags := []TagInfo{
+		{Name: "latest", Digest: testDigest("a")},
+		{Name: "v3", Digest: testDigest("a")},
+		{Name: "v2", Digest: testDigest("b")},
+		{Name: "v2.0", Digest: testDigest("b")},
+		{Name: "v1", Digest: testDigest("c")},
+		{Name: "v0", Digest: testDigest("d")},
+		{Name: "sha256-" + strings.Repeat("a", 64) + ".sig", Digest: testDigest("e")},
+		{Name: "sha256-" + strings.Repeat("c", 64) + ".sig", Digest: testDigest("f")},
+	}
+
+	plan := planPrune("app", tags, map[string]bool{"v2": true}, map[string]bool{testDigest("d"): true})
+	assert.DeepEqual(t, PrunePlan{
+		Repository: "app",
+		Tags:       []string{"sha256-" + strings.Repeat("c", 64) + ".sig", "v1"},
+		Manifests:  []string{testDigest("c"), testDigest("f")},
+	}, plan)
+}
+
+func TestRetainedTags(t *testing.T) {
+	now := time.Now()
+	targetTags := []TagInfo{
+		{Name: "v1", CreatedAt: now.Add(-3 * time.Hour)},
+		{Name: "v2", CreatedAt: now.Add(-2 * time.Hour)},
+		{Name: "v3", CreatedAt: now.Add(-1 * time.Hour)},
+	}
+	assert.DeepEqual(t, map[string]bool{"v3": true, "v4": true}, retainedTags(targetTags, []string{"v4"}, nil, 1))
+
+	policy := &TagPolicy{Repository: "quay.io/app", Semver: "< 2"}
+	assert.NilError(t, policy.Compile())
+	assert.DeepEqual(t, map[string]bool{"v1": true}, retainedTags(targetTags, nil, policy, 10))
+}
+
+func TestLoadProtectedDigests(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NilError(t, os.WriteFile(file, []byte("image:\n  digest: "+testDigest("a")+"\nother: "+testDigest("b")+"\n"), 0644))
+
+	digests, err := LoadProtectedDigests([]string{file})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]bool{testDigest("a"): true, testDigest("b"): true}, digests)
+
+	_, err = LoadProtectedDigests([]string{filepath.Join(t.TempDir(), "missing.yaml")})
+	assert.ErrorContains(t, err, "failed to read protected digests file")
+}
@@ -30,11 +30,17 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/containers/azcontainerregistry"
+	"github.com/containers/image/v5/types"
 )
 
 // Registry is the interface for accessing image repositories
 type Registry interface {
+	// GetTags returns the newest tags of a repository
 	GetTags(context.Context, string) ([]string, error)
+	// ListTags returns all tags of a repository, to be filtered by a TagPolicy
+	ListTags(context.Context, string) ([]TagInfo, error)
+	// DockerAuth returns the credentials to pull images with, nil for anonymous access
+	DockerAuth() *types.DockerAuthConfig
 }
 
 // AuthedTransport is a http.RoundTripper that adds an Authorization header
@@ -54,6 +60,7 @@ type QuayRegistry struct {
 	httpclient   *http.Client
 	baseUrl      string
 	numberOftags int
+	dockerAuth   *types.DockerAuthConfig
 }
 
 // NewQuayRegistry creates a new QuayRegistry access client
@@ -67,10 +74,16 @@ func NewQuayRegistry(cfg *SyncConfig, bearerToken string) *QuayRegistry {
 		},
 		baseUrl:      "https://quay.io",
 		numberOftags: cfg.NumberOfTags,
+		dockerAuth:   (&Credentials{BearerToken: bearerToken}).dockerAuth(quayTokenUsername),
 	}
 	return q
 }
 
+// DockerAuth returns the credentials to pull images with
+func (q *QuayRegistry) DockerAuth() *types.DockerAuthConfig {
+	return q.dockerAuth
+}
+
 type TagsResponse struct {
 	Tags          []Tags
 	Page          int
@@ -78,7 +91,9 @@ type TagsResponse struct {
 }
 
 type Tags struct {
-	Name string
+	Name           string
+	ManifestDigest string `json:"manifest_digest"`
+	StartTs        int64  `json:"start_ts"`
 }
 
 func (q *QuayRegistry) getTagPage(ctx context.Context, image string, page int) (*TagsResponse, error) {
@@ -93,6 +108,7 @@ func (q *QuayRegistry) getTagPage(ctx context.Context, image string, page int) (
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %v", err)
 	}
+	defer resp.Body.Close()
 	Log().Debugw("Got response", "statuscode", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
@@ -143,6 +159,32 @@ func (q *QuayRegistry) GetTags(ctx context.Context, image string) ([]string, err
 	return tags, nil
 }
 
+// ListTags returns all tags of the given image
+func (q *QuayRegistry) ListTags(ctx context.Context, image string) ([]TagInfo, error) {
+	Log().Debugw("Listing tags for image", "image", image)
+
+	var tags []TagInfo
+
+	// hard coded limit of 100, to make sure process does not get stuck
+	for page := 1; page < 100; page++ {
+		tagsResponse, err := q.getTagPage(ctx, image, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tags: %v", err)
+		}
+		for _, tag := range tagsResponse.Tags {
+			info := TagInfo{Name: tag.Name, Digest: tag.ManifestDigest}
+			if tag.StartTs != 0 {
+				info.CreatedAt = time.Unix(tag.StartTs, 0)
+			}
+			tags = append(tags, info)
+		}
+		if !tagsResponse.HasAdditional {
+			break
+		}
+	}
+	return tags, nil
+}
+
 type getAccessToken func(context.Context, azcore.TokenCredential) (string, error)
 type getACRUrl func(string) string
 
@@ -190,13 +232,7 @@ func NewAzureContainerRegistry(cfg *SyncConfig) *AzureContainerRegistry {
 		numberOfTags: cfg.NumberOfTags,
 		tenantId:     cfg.TenantId,
 
-		getAccessTokenImpl: func(ctx context.Context, dac azcore.TokenCredential) (string, error) {
-			accessToken, err := dac.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{"https://management.core.windows.net//.default"}})
-			if err != nil {
-				return "", err
-			}
-			return accessToken.Token, nil
-		},
+		getAccessTokenImpl: getManagementAccessToken,
 
 		getACRUrlImpl: func(acrName string) string {
 			return fmt.Sprintf("https://%s", acrName)
@@ -204,6 +240,15 @@ func NewAzureContainerRegistry(cfg *SyncConfig) *AzureContainerRegistry {
 	}
 }
 
+// getManagementAccessToken gets an ARM access token, which ACR accepts in exchange for a refresh token
+func getManagementAccessToken(ctx context.Context, dac azcore.TokenCredential) (string, error) {
+	accessToken, err := dac.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{"https://management.core.windows.net//.default"}})
+	if err != nil {
+		return "", err
+	}
+	return accessToken.Token, nil
+}
+
 type AuthSecret struct {
 	RefreshToken string `json:"refresh_token"`
 }
@@ -241,6 +286,7 @@ func (a *AzureContainerRegistry) GetPullSecret(ctx context.Context) (*AuthSecret
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %v", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
@@ -309,11 +355,80 @@ func (a *AzureContainerRegistry) GetTags(ctx context.Context, repository string)
 	return tags, nil
 }
 
+func (a *AzureContainerRegistry) listManifests(ctx context.Context, repository string) ([]*azcontainerregistry.ManifestAttributes, error) {
+	var manifests []*azcontainerregistry.ManifestAttributes
+
+	pager := a.acrClient.NewListManifestsPager(repository, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance page: %v", err)
+		}
+		for _, m := range page.Attributes {
+			if m.Digest != nil {
+				manifests = append(manifests, m)
+			}
+		}
+	}
+
+	return manifests, nil
+}
+
+// GetDigests returns the digests of all manifests in the given repository, tagged or not
+func (a *AzureContainerRegistry) GetDigests(ctx context.Context, repository string) ([]string, error) {
+	manifests, err := a.listManifests(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	digests := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		digests = append(digests, *m.Digest)
+	}
+	return digests, nil
+}
+
+// ListTags returns all tags in the given repository, including their digests and creation times
+func (a *AzureContainerRegistry) ListTags(ctx context.Context, repository string) ([]TagInfo, error) {
+	manifests, err := a.listManifests(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	var tags []TagInfo
+	for _, m := range manifests {
+		for _, tag := range m.Tags {
+			info := TagInfo{Name: *tag, Digest: *m.Digest}
+			if m.CreatedOn != nil {
+				info.CreatedAt = *m.CreatedOn
+			}
+			tags = append(tags, info)
+		}
+	}
+	return tags, nil
+}
+
+// DeleteTag removes a tag from the given repository, the manifest stays
+func (a *AzureContainerRegistry) DeleteTag(ctx context.Context, repository, tag string) error {
+	if _, err := a.acrClient.DeleteTag(ctx, repository, tag, nil); err != nil {
+		return fmt.Errorf("failed to delete tag %s: %v", tag, err)
+	}
+	return nil
+}
+
+// DeleteManifest removes a manifest and all its tags from the given repository
+func (a *AzureContainerRegistry) DeleteManifest(ctx context.Context, repository, digest string) error {
+	if _, err := a.acrClient.DeleteManifest(ctx, repository, digest, nil); err != nil {
+		return fmt.Errorf("failed to delete manifest %s: %v", digest, err)
+	}
+	return nil
+}
+
 type ACRWithTokenAuth struct {
 	httpclient   *http.Client
 	acrName      string
+	baseURL      string
 	numberOftags int
 	bearerToken  string
+	dockerAuth   *types.DockerAuthConfig
 }
 
 type AccessSecret struct {
@@ -325,12 +440,14 @@ type rawACRTagResponse struct {
 }
 
 type rawACRTags struct {
-	Name string
+	Name        string
+	Digest      string
+	CreatedTime time.Time
 }
 
-func getACRBearerToken(ctx context.Context, secret AzureSecretFile, acrName string) (string, error) {
+func getACRBearerToken(ctx context.Context, httpclient *http.Client, baseURL string, secret AzureSecretFile, acrName string) (string, error) {
 	scope := "repository:*:*"
-	path := fmt.Sprintf("https://%s/oauth2/token?service=%s&scope=%s", acrName, acrName, scope)
+	path := fmt.Sprintf("%s/oauth2/token?service=%s&scope=%s", baseURL, acrName, scope)
 
 	Log().Debugw("Creating request", "path", path)
 	req, err := http.NewRequestWithContext(ctx, "GET", path, nil)
@@ -339,11 +456,11 @@ func getACRBearerToken(ctx context.Context, secret AzureSecretFile, acrName stri
 		return "", fmt.Errorf("failed to create request: %v", err)
 	}
 
-	// todo replace with timeout enabled client
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %v", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
@@ -367,50 +484,114 @@ func NewACRWithTokenAuth(cfg *SyncConfig, acrName string, bearerToken string) *A
 	return &ACRWithTokenAuth{
 		httpclient:   &http.Client{Timeout: time.Duration(cfg.RequestTimeout) * time.Second},
 		acrName:      acrName,
+		baseURL:      fmt.Sprintf("https://%s", acrName),
 		bearerToken:  bearerToken,
 		numberOftags: cfg.NumberOfTags,
 	}
 }
 
+// DockerAuth returns the credentials to pull images with
+func (n *ACRWithTokenAuth) DockerAuth() *types.DockerAuthConfig {
+	return n.dockerAuth
+}
+
+// GetTags returns the most recently updated tags of the given image
 func (n *ACRWithTokenAuth) GetTags(ctx context.Context, image string) ([]string, error) {
 	Log().Debugw("Getting tags for image", "image", image)
 
-	path := fmt.Sprintf("https://%s/acr/v1/%s/_tags?orderby=%s&n=%d", n.acrName, image, azcontainerregistry.ArtifactTagOrderByLastUpdatedOnDescending, n.numberOftags)
-	req, err := http.NewRequestWithContext(ctx, "GET", path, nil)
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", n.bearerToken))
+	tags, err := n.listTags(ctx, image, n.numberOftags)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+		return nil, err
 	}
-
-	Log().Debugw("Sending request", "path", path)
-	resp, err := n.httpclient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+	tagList := make([]string, 0)
+	for _, tag := range tags {
+		if tag.Name == "latest" {
+			continue
+		}
+		tagList = append(tagList, tag.Name)
+		if len(tagList) >= n.numberOftags {
+			break
+		}
 	}
-	Log().Debugw("Got response", "statuscode", resp.StatusCode)
+	return tagList, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
-	}
+// ListTags returns all tags of the given image, following the pagination links of the ACR API
+func (n *ACRWithTokenAuth) ListTags(ctx context.Context, image string) ([]TagInfo, error) {
+	Log().Debugw("Listing tags for image", "image", image)
+	return n.listTags(ctx, image, 0)
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
-	}
+// listTags pages through the tags ordered by their last update, until limit tags
+// besides latest are found. A limit of 0 lists all tags.
+func (n *ACRWithTokenAuth) listTags(ctx context.Context, image string, limit int) ([]TagInfo, error) {
+	var tags []TagInfo
+	found := 0
+	path := fmt.Sprintf("%s/acr/v1/%s/_tags?orderby=%s&n=100", n.baseURL, image, azcontainerregistry.ArtifactTagOrderByLastUpdatedOnDescending)
 
-	var acrResponse rawACRTagResponse
-	err = json.Unmarshal(body, &acrResponse)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
-	}
+	// hard coded limit of 100, to make sure process does not get stuck
+	for page := 0; path != "" && page < 100; page++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", n.bearerToken))
 
-	tagList := make([]string, 0)
+		Log().Debugw("Sending request", "path", path)
+		resp, err := n.httpclient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send request: %v", err)
+		}
+		Log().Debugw("Got response", "statuscode", resp.StatusCode)
 
-	for _, tag := range acrResponse.Tags {
-		tagList = append(tagList, tag.Name)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+
+		var acrResponse rawACRTagResponse
+		err = json.Unmarshal(body, &acrResponse)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+		}
+		for _, tag := range acrResponse.Tags {
+			tags = append(tags, TagInfo{Name: tag.Name, Digest: tag.Digest, CreatedAt: tag.CreatedTime})
+			if tag.Name != "latest" {
+				found++
+			}
+		}
+		if limit > 0 && found >= limit {
+			break
+		}
+
+		path, err = nextPageURL(req.URL, resp.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
 	}
+	return tags, nil
+}
 
-	return tagList, nil
+// nextPageURL resolves the next page of a paginated registry API from the Link header, i.e.
+// </v2/foo/tags/list?last=bar&n=100>; rel="next". It returns an empty string on the last page.
+func nextPageURL(current *url.URL, link string) (string, error) {
+	if link == "" {
+		return "", nil
+	}
+	start := strings.Index(link, "<")
+	end := strings.Index(link, ">")
+	if start < 0 || end < start || !strings.Contains(link[end:], `rel="next"`) {
+		return "", nil
+	}
+	next, err := url.Parse(link[start+1 : end])
+	if err != nil {
+		return "", fmt.Errorf("failed to parse link header %q: %v", link, err)
+	}
+	return current.ResolveReference(next).String(), nil
 }
 
 // OCIRegistry implements OCI Repository access
@@ -419,6 +600,7 @@ type OCIRegistry struct {
 	baseURL      string
 	numberOftags int
 	bearerToken  string
+	dockerAuth   *types.DockerAuthConfig
 }
 
 // NewOCIRegistry creates a new OCIRegistry access client
@@ -427,6 +609,7 @@ func NewOCIRegistry(cfg *SyncConfig, baseURL, bearerToken string) *OCIRegistry {
 		httpclient:   &http.Client{Timeout: time.Duration(cfg.RequestTimeout) * time.Second},
 		numberOftags: cfg.NumberOfTags,
 		bearerToken:  bearerToken,
+		dockerAuth:   (&Credentials{BearerToken: bearerToken}).dockerAuth(ociTokenUsername),
 	}
 	if !strings.HasPrefix(o.baseURL, "https://") {
 		o.baseURL = fmt.Sprintf("https://%s", baseURL)
@@ -436,6 +619,11 @@ func NewOCIRegistry(cfg *SyncConfig, baseURL, bearerToken string) *OCIRegistry {
 	return o
 }
 
+// DockerAuth returns the credentials to pull images with
+func (o *OCIRegistry) DockerAuth() *types.DockerAuthConfig {
+	return o.dockerAuth
+}
+
 type rawManifest struct {
 	TimeUploadedMs string
 	Tag            []string
@@ -475,40 +663,192 @@ func getNewestTags(response *rawOCIResponse, numberOfTags int) ([]string, error)
 	return returnTags, nil
 }
 
+// getTagList lists the tags of a repository, following the pagination links of the
+// distribution API and merging all pages
+func (o *OCIRegistry) getTagList(ctx context.Context, image string) (*rawOCIResponse, error) {
+	merged := &rawOCIResponse{Manifest: make(map[string]rawManifest)}
+	path := fmt.Sprintf("%s/v2/%s/tags/list", o.baseURL, image)
+
+	// hard coded limit of 100, to make sure process does not get stuck
+	for page := 0; path != "" && page < 100; page++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+		if o.bearerToken != "" {
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", o.bearerToken))
+		}
+
+		Log().Debugw("Sending request", "path", path)
+		resp, err := o.httpclient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send request: %v", err)
+		}
+		Log().Debugw("Got response", "statuscode", resp.StatusCode)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+
+		var rawOCIResponse rawOCIResponse
+		err = json.Unmarshal(body, &rawOCIResponse)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+		}
+		merged.Tags = append(merged.Tags, rawOCIResponse.Tags...)
+		for digest, manifest := range rawOCIResponse.Manifest {
+			merged.Manifest[digest] = manifest
+		}
+
+		path, err = nextPageURL(req.URL, resp.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
 // GetTags returns the tags in the given repository
 func (o *OCIRegistry) GetTags(ctx context.Context, image string) ([]string, error) {
 	Log().Debugw("Getting tags for image", "image", image)
 
-	path := fmt.Sprintf("%s/v2/%s/tags/list", o.baseURL, image)
-	req, err := http.NewRequestWithContext(ctx, "GET", path, nil)
-	if o.bearerToken != "" {
-		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", o.bearerToken))
-	}
+	rawOCIResponse, err := o.getTagList(ctx, image)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+		return nil, err
 	}
+	return getNewestTags(rawOCIResponse, o.numberOftags)
+}
 
-	Log().Debugw("Sending request", "path", path)
-	resp, err := o.httpclient.Do(req)
+// ListTags returns all tags in the given repository. Digests and upload times are only
+// known if the registry returns the manifest details like GCR based registries do.
+func (o *OCIRegistry) ListTags(ctx context.Context, image string) ([]TagInfo, error) {
+	Log().Debugw("Listing tags for image", "image", image)
+
+	rawOCIResponse, err := o.getTagList(ctx, image)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+		return nil, err
 	}
-	Log().Debugw("Got response", "statuscode", resp.StatusCode)
+	return listOCITags(rawOCIResponse)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+func listOCITags(response *rawOCIResponse) ([]TagInfo, error) {
+	var tags []TagInfo
+	known := make(map[string]bool)
+	for digest, manifest := range response.Manifest {
+		uploadedAt, err := strconv.ParseInt(manifest.TimeUploadedMs, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest %s time: %v", manifest, err)
+		}
+		for _, tag := range manifest.Tag {
+			known[tag] = true
+			tags = append(tags, TagInfo{Name: tag, Digest: digest, CreatedAt: time.UnixMilli(uploadedAt)})
+		}
+	}
+	for _, tag := range response.Tags {
+		if !known[tag] {
+			tags = append(tags, TagInfo{Name: tag})
+		}
 	}
+	// newest first, tags without manifest details last
+	sort.SliceStable(tags, func(i, j int) bool {
+		if !tags[i].CreatedAt.Equal(tags[j].CreatedAt) {
+			return tags[i].CreatedAt.After(tags[j].CreatedAt)
+		}
+		return newerTagName(tags[i].Name, tags[j].Name)
+	})
+	return tags, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
+// DistributionRegistry implements repository access with the plain OCI distribution API, as
+// offered by ECR, GHCR and most other registries
+type DistributionRegistry struct {
+	httpclient   *http.Client
+	baseURL      string
+	numberOftags int
+	pageSize     int
+	credentials  *Credentials
+}
+
+// DockerAuth returns the basic credentials to pull images with, bearer tokens are only used
+// to list tags
+func (d *DistributionRegistry) DockerAuth() *types.DockerAuthConfig {
+	return d.credentials.dockerAuth("")
+}
+
+type rawDistributionTagList struct {
+	Name string
+	Tags []string
+}
+
+// ListTags returns all tags of the given image. The distribution API does not report digests
+// or creation times.
+func (d *DistributionRegistry) ListTags(ctx context.Context, image string) ([]TagInfo, error) {
+	Log().Debugw("Listing tags for image", "image", image)
+
+	var tags []TagInfo
+	path := fmt.Sprintf("%s/v2/%s/tags/list?n=%d", d.baseURL, image, d.pageSize)
+
+	// hard coded limit of 100, to make sure process does not get stuck
+	for page := 0; path != "" && page < 100; page++ {
+		Log().Debugw("Sending request", "path", path)
+		resp, err := doRegistryRequest(ctx, d.httpclient, path, "", d.credentials)
+		if err != nil {
+			return nil, err
+		}
+		Log().Debugw("Got response", "statuscode", resp.StatusCode)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+
+		var tagList rawDistributionTagList
+		if err := json.Unmarshal(body, &tagList); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+		}
+		for _, tag := range tagList.Tags {
+			tags = append(tags, TagInfo{Name: tag})
+		}
+
+		path, err = nextPageURL(resp.Request.URL, resp.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
 	}
+	return tags, nil
+}
 
-	var rawOCIResponse rawOCIResponse
-	err = json.Unmarshal(body, &rawOCIResponse)
+// GetTags returns the newest tags of the given image. Without creation times, semantic versions
+// are ordered by version and other tags in reverse lexical order.
+func (d *DistributionRegistry) GetTags(ctx context.Context, image string) ([]string, error) {
+	tags, err := d.ListTags(ctx, image)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+		return nil, fmt.Errorf("failed to get tags: %v", err)
 	}
+	return newestTagsByName(tags, d.numberOftags), nil
+}
 
-	return getNewestTags(&rawOCIResponse, o.numberOftags)
+func newestTagsByName(tags []TagInfo, numberOfTags int) []string {
+	var names []string
+	for _, tag := range tags {
+		if tag.Name != "latest" {
+			names = append(names, tag.Name)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return newerTagName(names[i], names[j])
+	})
+	if len(names) > numberOfTags {
+		names = names[:numberOfTags]
+	}
+	return names
 }
@@ -21,7 +21,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
@@ -403,3 +405,65 @@ func TestOciGetTags(t *testing.T) {
 		})
 	}
 }
+
+func TestACRWithTokenAuthListTags(t *testing.T) {
+	pages := map[string]string{
+		"":  `{"tags":[{"name":"b","digest":"sha256:b","createdTime":"2025-01-02T00:00:00Z"}]}`,
+		"b": `{"tags":[{"name":"a","digest":"sha256:a","createdTime":"2025-01-01T00:00:00Z"}]}`,
+		"a": `{"tags":[]}`,
+	}
+	mock := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer fooBar", r.Header.Get("Authorization"))
+			last := r.URL.Query().Get("last")
+			if last != "a" {
+				next := "b"
+				if last == "b" {
+					next = "a"
+				}
+				w.Header().Set("Link", fmt.Sprintf(`</acr/v1/test/_tags?last=%s&n=100&orderby=timedesc>; rel="next"`, next))
+			}
+			_, err := w.Write([]byte(pages[last]))
+			assert.NilError(t, err)
+		}))
+	defer mock.Close()
+
+	n := &ACRWithTokenAuth{
+		httpclient:  mock.Client(),
+		acrName:     strings.TrimPrefix(mock.URL, "https://"),
+		baseURL:     mock.URL,
+		bearerToken: "fooBar",
+	}
+	tags, err := n.ListTags(context.TODO(), "test")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []TagInfo{
+		{Name: "b", Digest: "sha256:b", CreatedAt: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{Name: "a", Digest: "sha256:a", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}, tags)
+}
+
+func TestListOCITags(t *testing.T) {
+	tags, err := listOCITags(&rawOCIResponse{
+		Manifest: map[string]rawManifest{
+			"sha256:a": {
+				TimeUploadedMs: "1000",
+				Tag:            []string{"v1", "v1.0"},
+			},
+			"sha256:b": {
+				TimeUploadedMs: "2000",
+				Tag:            []string{"a-build"},
+			},
+		},
+		Tags: []string{"a-build", "nightly", "v1", "v1.0", "v2", "v10"},
+	})
+	assert.NilError(t, err)
+	// newest first, tags without manifest details ordered by version and name
+	assert.DeepEqual(t, []TagInfo{
+		{Name: "a-build", Digest: "sha256:b", CreatedAt: time.UnixMilli(2000)},
+		{Name: "v1", Digest: "sha256:a", CreatedAt: time.UnixMilli(1000)},
+		{Name: "v1.0", Digest: "sha256:a", CreatedAt: time.UnixMilli(1000)},
+		{Name: "v10"},
+		{Name: "v2"},
+		{Name: "nightly"},
+	}, tags)
+}
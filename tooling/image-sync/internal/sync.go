@@ -20,10 +20,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/containers/image/v5/copy"
 	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/manifest"
 	"github.com/containers/image/v5/signature"
 	"github.com/containers/image/v5/types"
 	"go.uber.org/zap"
@@ -43,7 +47,32 @@ type SyncConfig struct {
 	RequestTimeout          int
 	AddLatest               bool
 	ManagedIdentityClientID string
+	// Workers is the number of images copied in parallel
+	Workers int
+	// AllPlatforms copies complete manifest lists instead of the image for the current platform
+	AllPlatforms bool
+	// Platforms copies only the given os/arch[/variant] platforms of manifest lists
+	Platforms []string
+	// PreserveSignatures copies sigstore signatures along with the images
+	PreserveSignatures bool
+	// MirrorReferrers copies OCI referrers and cosign attachments like signatures, attestations and SBOMs
+	MirrorReferrers bool
+	// SignaturePublicKeys are paths to cosign public keys, source images must be signed by one of them
+	SignaturePublicKeys []string
+	// Prune deletes tags from the target registry that are no longer selected by the tag selection
+	Prune bool
+	// PruneDryRun only logs the tags that would be pruned
+	PruneDryRun bool
+	// ProtectedDigestFiles are searched for sha256 digests, tags pointing to them are never pruned
+	ProtectedDigestFiles []string
+	// Registries declare the type and authentication of source registries
+	Registries []RegistryConfig
+	// TagPolicies replace the newest NumberOfTags selection for individual repositories
+	TagPolicies []TagPolicy
 }
+
+// Secrets declare the authentication of a source registry, the registry type is detected from
+// its name. Use Registries to configure the type and authentication explicitly.
 type Secrets struct {
 	Registry   string
 	SecretFile string
@@ -64,37 +93,68 @@ func (a AzureSecretFile) BasicAuthEncoded() string {
 	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", a.Username, a.Password)))
 }
 
-// Copy copies an image from one registry to another
-func Copy(ctx context.Context, dstreference, srcreference string, dstauth, srcauth *types.DockerAuthConfig) error {
-	policyctx, err := signature.NewPolicyContext(&signature.Policy{
-		Default: signature.PolicyRequirements{
-			signature.NewPRInsecureAcceptAnything(),
-		},
-	})
+// Copy copies an image from one registry to another and returns the digest of the manifest
+// written to the destination. It differs from the source digest if platforms were selected
+// from a manifest list.
+func Copy(ctx context.Context, dstreference, srcreference string, dstauth, srcauth *types.DockerAuthConfig, opts *MirrorOptions) (string, error) {
+	policy, err := opts.policy()
+	if err != nil {
+		return "", err
+	}
+	policyctx, err := signature.NewPolicyContext(policy)
 	if err != nil {
-		return err
+		return "", err
 	}
+	defer func() {
+		if err := policyctx.Destroy(); err != nil {
+			Log().Warnw("Failed to destroy policy context", "error", err)
+		}
+	}()
 
 	src, err := docker.ParseReference("//" + srcreference)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	dst, err := docker.ParseReference("//" + dstreference)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	_, err = copy.Image(ctx, policyctx, dst, src, &copy.Options{
-		SourceCtx: &types.SystemContext{
-			DockerAuthConfig: srcauth,
-		},
-		DestinationCtx: &types.SystemContext{
-			DockerAuthConfig: dstauth,
-		},
-	})
+	options := &copy.Options{
+		SourceCtx:      opts.systemContext(srcauth),
+		DestinationCtx: opts.systemContext(dstauth),
+	}
+	opts.copyOptions(options)
 
-	return err
+	copied, err := copy.Image(ctx, policyctx, dst, src, options)
+	if err != nil {
+		return "", err
+	}
+	d, err := manifest.Digest(copied)
+	if err != nil {
+		return "", fmt.Errorf("error computing digest of copied manifest: %w", err)
+	}
+	return d.String(), nil
+}
+
+// Tag points the tag of the target reference at a manifest that already exists in the same
+// repository. Only the manifest is uploaded, as all blobs it references are present.
+func Tag(ctx context.Context, reference, digest string, auth *types.DockerAuthConfig, opts *MirrorOptions) error {
+	return copyArtifact(ctx, reference, fmt.Sprintf("%s@%s", repositoryOf(reference), digest), auth, auth, opts)
+}
+
+// GetDigest returns the digest of the manifest the reference points to
+func GetDigest(ctx context.Context, reference string, auth *types.DockerAuthConfig) (string, error) {
+	ref, err := docker.ParseReference("//" + reference)
+	if err != nil {
+		return "", err
+	}
+	d, err := docker.GetDigest(ctx, &types.SystemContext{DockerAuthConfig: auth}, ref)
+	if err != nil {
+		return "", err
+	}
+	return d.String(), nil
 }
 
 func readBearerSecret(filename string) (*BearerSecret, error) {
@@ -141,43 +201,293 @@ func filterTagsToSync(src, target []string) []string {
 	return tagsToSync
 }
 
+// copyJob is a single image to copy to the target registry
+type copyJob struct {
+	repository string
+	tag        string
+	source     string
+	target     string
+}
+
+// SyncSummary is the outcome of a sync run
+type SyncSummary struct {
+	mu sync.Mutex
+
+	Copied  []string
+	Tagged  []string
+	Skipped []string
+	Pruned  []string
+	Failed  map[string]error
+}
+
+func newSyncSummary() *SyncSummary {
+	return &SyncSummary{Failed: make(map[string]error)}
+}
+
+func (s *SyncSummary) copied(image string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Copied = append(s.Copied, image)
+}
+
+func (s *SyncSummary) tagged(image string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Tagged = append(s.Tagged, image)
+}
+
+func (s *SyncSummary) skipped(image string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Skipped = append(s.Skipped, image)
+}
+
+func (s *SyncSummary) pruned(images ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Pruned = append(s.Pruned, images...)
+}
+
+func (s *SyncSummary) failed(image string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Failed[image] = err
+}
+
+// Err returns an error listing all failed images, or nil if everything was synced
+func (s *SyncSummary) Err() error {
+	if len(s.Failed) == 0 {
+		return nil
+	}
+	images := make([]string, 0, len(s.Failed))
+	for image := range s.Failed {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return fmt.Errorf("failed to sync %d images: %s", len(images), strings.Join(images, ", "))
+}
+
+func (s *SyncSummary) log() {
+	sort.Strings(s.Copied)
+	sort.Strings(s.Tagged)
+	sort.Strings(s.Skipped)
+	sort.Strings(s.Pruned)
+	for image, err := range s.Failed {
+		Log().Errorw("Failed to sync image", "image", image, "error", err)
+	}
+	Log().Infow("Sync finished", "copied", len(s.Copied), "tagged", len(s.Tagged), "skipped", len(s.Skipped), "pruned", len(s.Pruned), "failed", len(s.Failed))
+	Log().Debugw("Sync details", "copied", s.Copied, "tagged", s.Tagged, "skipped", s.Skipped, "pruned", s.Pruned)
+}
+
+// targetDigests tracks the manifest digests of each repository in the target registry,
+// including those copied during the current run. Copies are keyed by their source digest.
+type targetDigests struct {
+	mu      sync.Mutex
+	digests map[string]map[string]*targetDigest
+}
+
+// targetDigest is a manifest of the target repository. A digest claimed by a copy is
+// pending until the copy finished, done is closed once it succeeded or failed. target is
+// the digest of the manifest in the target repository, which differs from the source
+// digest if only some platforms of a manifest list were copied.
+type targetDigest struct {
+	done    chan struct{}
+	present bool
+	target  string
+}
+
+func newTargetDigests() *targetDigests {
+	return &targetDigests{digests: make(map[string]map[string]*targetDigest)}
+}
+
+func (t *targetDigests) add(repository string, digests ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.digests[repository] == nil {
+		t.digests[repository] = make(map[string]*targetDigest)
+	}
+	for _, d := range digests {
+		done := make(chan struct{})
+		close(done)
+		t.digests[repository][d] = &targetDigest{done: done, present: true, target: d}
+	}
+}
+
+// claim registers a digest that is about to be copied. It returns false if the digest
+// already exists in the repository or is copied by another job.
+func (t *targetDigests) claim(repository, digest string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.digests[repository][digest] != nil {
+		return false
+	}
+	if t.digests[repository] == nil {
+		t.digests[repository] = make(map[string]*targetDigest)
+	}
+	t.digests[repository][digest] = &targetDigest{done: make(chan struct{})}
+	return true
+}
+
+// complete marks a claimed digest as present after it was copied, target is the digest
+// of the manifest that was pushed to the repository
+func (t *targetDigests) complete(repository, digest, target string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if d := t.digests[repository][digest]; d != nil && !d.present {
+		d.present = true
+		d.target = target
+		close(d.done)
+	}
+}
+
+// release removes a claimed digest whose copy failed
+func (t *targetDigests) release(repository, digest string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if d := t.digests[repository][digest]; d != nil && !d.present {
+		close(d.done)
+	}
+	delete(t.digests[repository], digest)
+}
+
+// wait blocks until a pending copy of the digest finished and returns the digest of the
+// manifest in the target repository, and whether it is present
+func (t *targetDigests) wait(ctx context.Context, repository, digest string) (string, bool) {
+	t.mu.Lock()
+	d := t.digests[repository][digest]
+	t.mu.Unlock()
+	if d == nil {
+		return "", false
+	}
+	select {
+	case <-d.done:
+	case <-ctx.Done():
+		return "", false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return d.target, d.present
+}
+
+// runConcurrently calls fn for every item, using at most workers goroutines
+func runConcurrently[T any](workers int, items []T, fn func(T)) {
+	if workers < 1 {
+		workers = 1
+	}
+	work := make(chan T)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				fn(item)
+			}
+		}()
+	}
+	for _, item := range items {
+		work <- item
+	}
+	close(work)
+	wg.Wait()
+}
+
+type getDigestFunc func(ctx context.Context, reference string) (string, error)
+
+// copyFunc copies the source image and returns the digest of the manifest pushed to the target
+type copyFunc func(ctx context.Context, target, source, digest string) (string, error)
+type tagFunc func(ctx context.Context, target, digest string) error
+
+// runCopyJobs copies the images of all jobs. If the source digest was already copied to the
+// target repository, only the target tag is pointed at the manifest pushed for it. Failures
+// are recorded in the summary and don't stop other jobs.
+func runCopyJobs(ctx context.Context, workers int, jobs []copyJob, digests *targetDigests, getDigest getDigestFunc, copyImage copyFunc, tagImage tagFunc, summary *SyncSummary) {
+	runConcurrently(workers, jobs, func(job copyJob) {
+		digest, err := getDigest(ctx, job.source)
+		claimed := false
+		switch {
+		case err != nil:
+			// deduplication is an optimization only, try copying the image anyway
+			Log().Warnw("Failed to get digest of source image", "image", job.source, "error", err)
+		case digests.claim(job.repository, digest):
+			claimed = true
+		default:
+			targetDigest, present := digests.wait(ctx, job.repository, digest)
+			if !present || targetDigest == "" {
+				Log().Warnw("Copy of the same digest by another job failed, copying image", "image", job.source, "digest", digest)
+				break
+			}
+			if current, err := getDigest(ctx, job.target); err == nil && current == targetDigest {
+				Log().Infow("Skipping image, target tag exists", "image", job.source, "digest", targetDigest)
+				summary.skipped(job.source)
+				return
+			}
+			Log().Infow("Tagging existing manifest in target repository", "image", job.target, "digest", targetDigest)
+			if err := tagImage(ctx, job.target, targetDigest); err != nil {
+				summary.failed(job.source, fmt.Errorf("error tagging image: %w", err))
+				return
+			}
+			summary.tagged(job.source)
+			return
+		}
+
+		Log().Infow("Copying image", "from", job.source, "to", job.target)
+		targetDigest, err := copyImage(ctx, job.target, job.source, digest)
+		if err != nil {
+			if claimed {
+				digests.release(job.repository, digest)
+			}
+			summary.failed(job.source, fmt.Errorf("error copying image: %w", err))
+			return
+		}
+		if claimed {
+			digests.complete(job.repository, digest, targetDigest)
+		}
+		summary.copied(job.source)
+	})
+}
+
 // DoSync syncs the images from the source registry to the target registry
 func DoSync(cfg *SyncConfig) error {
-	Log().Infow("Syncing images", "images", cfg.Repositories, "numberoftags", cfg.NumberOfTags)
+	Log().Infow("Syncing images", "images", cfg.Repositories, "numberoftags", cfg.NumberOfTags, "workers", cfg.Workers)
 	ctx := context.Background()
 
+	policies, err := tagPolicies(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid tag policies: %w", err)
+	}
+
+	var protectedDigests map[string]bool
+	if cfg.Prune {
+		protectedDigests, err = LoadProtectedDigests(cfg.ProtectedDigestFiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	mirrorOptions, cleanup, err := NewMirrorOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid mirror options: %w", err)
+	}
+	defer cleanup()
+
 	srcRegistries := make(map[string]Registry)
-	var err error
+	// srcAuths are the credentials to pull from source registries, missing registries are
+	// pulled with the containers auth files
+	srcAuths := make(map[string]*types.DockerAuthConfig)
 
-	for _, secret := range cfg.Secrets {
-		if secret.Registry == "quay.io" {
-			quaySecret, err := readBearerSecret(secret.SecretFile)
-			if err != nil {
-				return fmt.Errorf("error reading secret file: %w %s", err, secret.SecretFile)
-			}
-			qr := NewQuayRegistry(cfg, quaySecret.BearerToken)
-			srcRegistries[secret.Registry] = qr
-		} else {
-			if strings.HasSuffix(secret.Registry, "azurecr.io") ||
-				strings.HasSuffix(secret.Registry, "azurecr.cn") ||
-				strings.HasSuffix(secret.Registry, "azurecr.us") {
-				azureSecret, err := readAzureSecret(secret.SecretFile)
-				if err != nil {
-					return fmt.Errorf("error reading azure secret file: %w %s", err, secret.SecretFile)
-				}
-				bearerSecret, err := getACRBearerToken(ctx, *azureSecret, secret.Registry)
-				if err != nil {
-					return fmt.Errorf("error getting ACR bearer token: %w", err)
-				}
-				srcRegistries[secret.Registry] = NewACRWithTokenAuth(cfg, secret.Registry, bearerSecret)
-			} else {
-				s, err := readBearerSecret(secret.SecretFile)
-				bearerSecret := s.BearerToken
-				if err != nil {
-					return fmt.Errorf("error reading secret file: %w %s", err, secret.SecretFile)
-				}
-				srcRegistries[secret.Registry] = NewOCIRegistry(cfg, secret.Registry, bearerSecret)
-			}
+	registries, err := registryConfigs(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid registries: %w", err)
+	}
+	for _, registry := range registries {
+		client, err := NewRegistry(ctx, cfg, registry)
+		if err != nil {
+			return fmt.Errorf("error creating client for registry %s: %w", registry.Registry, err)
+		}
+		srcRegistries[registry.Registry] = client
+		if auth := registry.pullAuth(client); auth != nil {
+			srcAuths[registry.Registry] = auth
 		}
 	}
 
@@ -189,60 +499,154 @@ func DoSync(cfg *SyncConfig) error {
 
 	targetACRAuth := types.DockerAuthConfig{Username: "00000000-0000-0000-0000-000000000000", Password: acrPullSecret.RefreshToken}
 
-	for _, repoName := range cfg.Repositories {
-		var srcTags, acrTags []string
+	summary := newSyncSummary()
+	digests := newTargetDigests()
 
-		baseURL := strings.Split(repoName, "/")[0]
-		repoName = strings.Join(strings.Split(repoName, "/")[1:], "/")
+	var jobsMu sync.Mutex
+	var jobs []copyJob
+	// selectedTags are the source tags selected per target repository, used for pruning
+	selectedTags := make(map[string][]string)
 
-		Log().Infow("Syncing repository", "repository", repoName, "baseurl", baseURL)
+	runConcurrently(cfg.Workers, cfg.Repositories, func(repository string) {
+		repoJobs, selected, err := listCopyJobs(ctx, cfg, srcRegistries, policies[repository], targetACR, digests, repository)
+		if err != nil {
+			summary.failed(repository, err)
+			return
+		}
+		jobsMu.Lock()
+		defer jobsMu.Unlock()
+		jobs = append(jobs, repoJobs...)
+		selectedTags[repository] = selected
+	})
 
-		if client, ok := srcRegistries[baseURL]; ok {
-			srcTags, err = client.GetTags(ctx, repoName)
-			if err != nil {
-				return fmt.Errorf("error getting tags from %s: %w", baseURL, err)
+	// sourceAuth returns the credentials of the registry an image is pulled from
+	sourceAuth := func(reference string) *types.DockerAuthConfig {
+		return srcAuths[strings.Split(reference, "/")[0]]
+	}
+
+	runCopyJobs(ctx, cfg.Workers, jobs, digests,
+		func(ctx context.Context, reference string) (string, error) {
+			if strings.HasPrefix(reference, cfg.AcrTargetRegistry+"/") {
+				return GetDigest(ctx, reference, &targetACRAuth)
 			}
-			Log().Debugw("Got tags from quay", "tags", srcTags)
-		} else {
-			// No secret defined, create a default client without auth
-			oci := NewOCIRegistry(cfg, baseURL, "")
-			srcTags, err = oci.GetTags(ctx, repoName)
+			return GetDigest(ctx, reference, sourceAuth(reference))
+		},
+		func(ctx context.Context, target, source, digest string) (string, error) {
+			srcAuth := sourceAuth(source)
+			targetDigest, err := Copy(ctx, target, source, &targetACRAuth, srcAuth, mirrorOptions)
 			if err != nil {
-				return fmt.Errorf("error getting oci tags: %w", err)
+				return "", err
+			}
+			if !mirrorOptions.MirrorReferrers || digest == "" {
+				return targetDigest, nil
 			}
-			Log().Debugw(fmt.Sprintf("Got tags from %s", baseURL), "repo", repoName, "tags", srcTags)
+			return targetDigest, MirrorReferrers(ctx, repositoryOf(target), repositoryOf(source), digest, &targetACRAuth, srcAuth, mirrorOptions)
+		},
+		func(ctx context.Context, target, digest string) error {
+			return Tag(ctx, target, digest, &targetACRAuth, mirrorOptions)
+		},
+		summary,
+	)
+
+	if cfg.Prune {
+		// only repositories with a known tag selection are pruned
+		var repositories []string
+		for repository := range selectedTags {
+			repositories = append(repositories, repository)
 		}
+		runConcurrently(cfg.Workers, repositories, func(repository string) {
+			repoName := strings.Join(strings.Split(repository, "/")[1:], "/")
+			plan, err := pruneRepository(ctx, targetACR, repoName, selectedTags[repository], policies[repository], cfg, protectedDigests)
+			if plan != nil {
+				for _, tag := range plan.Tags {
+					summary.pruned(fmt.Sprintf("%s/%s:%s", cfg.AcrTargetRegistry, repoName, tag))
+				}
+			}
+			if err != nil {
+				summary.failed(repository, fmt.Errorf("error pruning repository: %w", err))
+			}
+		})
+	}
+
+	summary.log()
+	return summary.Err()
+}
+
+// repositoryOf strips the tag from an image reference
+func repositoryOf(reference string) string {
+	if i := strings.LastIndex(reference, ":"); i > strings.LastIndex(reference, "/") {
+		return reference[:i]
+	}
+	return reference
+}
+
+// listCopyJobs compares the tags of a source repository with the target registry and returns a
+// job for each tag that is missing in the target, along with all selected source tags. The tags are selected by the policy of the
+// repository if there is one. The digests existing in the target repository are recorded for
+// deduplication.
+func listCopyJobs(ctx context.Context, cfg *SyncConfig, srcRegistries map[string]Registry, policy *TagPolicy, targetACR *AzureContainerRegistry, digests *targetDigests, repository string) ([]copyJob, []string, error) {
+	var srcTags, acrTags []string
+	var err error
+
+	baseURL := strings.Split(repository, "/")[0]
+	repoName := strings.Join(strings.Split(repository, "/")[1:], "/")
+
+	Log().Infow("Syncing repository", "repository", repoName, "baseurl", baseURL)
 
-		exists, err := targetACR.RepositoryExists(ctx, repoName)
+	client, ok := srcRegistries[baseURL]
+	if !ok {
+		// No secret defined, create a default client without auth
+		client = NewOCIRegistry(cfg, baseURL, "")
+	}
+
+	if policy != nil {
+		tags, err := client.ListTags(ctx, repoName)
 		if err != nil {
-			return fmt.Errorf("error getting ACR repository information: %w", err)
+			return nil, nil, fmt.Errorf("error listing tags from %s: %w", baseURL, err)
 		}
-
-		if exists {
-			acrTags, err = targetACR.GetTags(ctx, repoName)
-			if err != nil {
-				return fmt.Errorf("error getting ACR tags: %w", err)
-			}
-			Log().Infow("Got tags from acr", "tags", acrTags)
-		} else {
-			Log().Infow("Repository does not exist", "repository", repoName)
+		srcTags = policy.SelectTags(tags, cfg.NumberOfTags, time.Now())
+		Log().Debugw(fmt.Sprintf("Selected tags from %s by policy", baseURL), "repo", repoName, "tags", srcTags)
+	} else {
+		srcTags, err = client.GetTags(ctx, repoName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting tags from %s: %w", baseURL, err)
 		}
+		Log().Debugw(fmt.Sprintf("Got tags from %s", baseURL), "repo", repoName, "tags", srcTags)
+	}
 
-		tagsToSync := filterTagsToSync(srcTags, acrTags)
-
-		Log().Infow("Images to sync", "images", tagsToSync)
+	exists, err := targetACR.RepositoryExists(ctx, repoName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting ACR repository information: %w", err)
+	}
 
-		for _, tagToSync := range tagsToSync {
-			source := fmt.Sprintf("%s/%s:%s", baseURL, repoName, tagToSync)
-			target := fmt.Sprintf("%s/%s:%s", cfg.AcrTargetRegistry, repoName, tagToSync)
-			Log().Infow("Copying images", "images", tagToSync, "from", source, "to", target)
+	if exists {
+		acrTags, err = targetACR.GetTags(ctx, repoName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting ACR tags: %w", err)
+		}
+		Log().Infow("Got tags from acr", "tags", acrTags)
 
-			err = Copy(ctx, target, source, &targetACRAuth, nil)
-			if err != nil {
-				return fmt.Errorf("error copying image: %w", err)
-			}
+		acrDigests, err := targetACR.GetDigests(ctx, repoName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting ACR digests: %w", err)
 		}
+		digests.add(repoName, acrDigests...)
+	} else {
+		Log().Infow("Repository does not exist", "repository", repoName)
+	}
+
+	tagsToSync := filterTagsToSync(srcTags, acrTags)
+
+	Log().Infow("Images to sync", "images", tagsToSync)
 
+	var jobs []copyJob
+	for _, tagToSync := range tagsToSync {
+		jobs = append(jobs, copyJob{
+			repository: repoName,
+			tag:        tagToSync,
+			source:     fmt.Sprintf("%s/%s:%s", baseURL, repoName, tagToSync),
+			target:     fmt.Sprintf("%s/%s:%s", cfg.AcrTargetRegistry, repoName, tagToSync),
+		})
 	}
-	return nil
+	return jobs, srcTags, nil
 }
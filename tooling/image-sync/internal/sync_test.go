@@ -15,6 +15,12 @@
 package internal
 
 import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -71,3 +77,123 @@ func TestFilterTagsToSync(t *testing.T) {
 	}
 
 }
+
+func TestRunCopyJobs(t *testing.T) {
+	imageDigests := map[string]string{
+		"quay.io/app:1":        "sha256:1",
+		"quay.io/app:1.0":      "sha256:1",
+		"quay.io/app:2":        "sha256:2",
+		"quay.io/app:2.0":      "sha256:2",
+		"quay.io/app:3":        "sha256:3",
+		"quay.io/app:4":        "sha256:4",
+		"acr.azurecr.io/app:2": "sha256:2",
+	}
+	var jobs []copyJob
+	for _, tag := range []string{"1", "1.0", "2", "2.0", "3", "4", "5"} {
+		jobs = append(jobs, copyJob{
+			repository: "app",
+			tag:        tag,
+			source:     "quay.io/app:" + tag,
+			target:     "acr.azurecr.io/app:" + tag,
+		})
+	}
+
+	digests := newTargetDigests()
+	digests.add("app", "sha256:2")
+
+	var copies, tags atomic.Int32
+	var taggedMu sync.Mutex
+	taggedDigests := make(map[string]string)
+	summary := newSyncSummary()
+	runCopyJobs(context.Background(), 3, jobs, digests,
+		func(_ context.Context, reference string) (string, error) {
+			if d, ok := imageDigests[reference]; ok {
+				return d, nil
+			}
+			return "", errors.New("manifest unknown")
+		},
+		func(_ context.Context, target, source, digest string) (string, error) {
+			copies.Add(1)
+			if strings.HasSuffix(source, ":3") {
+				return "", errors.New("flaky registry")
+			}
+			// the platform selected from the manifest list is pushed, with a digest of its own
+			return digest + "-amd64", nil
+		},
+		func(_ context.Context, target, digest string) error {
+			tags.Add(1)
+			taggedMu.Lock()
+			defer taggedMu.Unlock()
+			taggedDigests[target] = digest
+			return nil
+		},
+		summary,
+	)
+
+	// 1 and 1.0 share a digest, only one of them is copied and the other one is tagged
+	assert.Equal(t, int32(4), copies.Load())
+	assert.Equal(t, 3, len(summary.Copied))
+	// 2.0 points at the digest of 2 which exists in the target already
+	assert.Equal(t, int32(2), tags.Load())
+	assert.Equal(t, 2, len(summary.Tagged))
+	assert.Assert(t, slices.Contains(summary.Tagged, "quay.io/app:2.0"))
+	assert.Equal(t, "sha256:2", taggedDigests["acr.azurecr.io/app:2.0"])
+	// the tag sharing a digest with a copied image points at the manifest pushed by the copy
+	if d, ok := taggedDigests["acr.azurecr.io/app:1"]; ok {
+		assert.Equal(t, "sha256:1-amd64", d)
+	} else {
+		assert.Equal(t, "sha256:1-amd64", taggedDigests["acr.azurecr.io/app:1.0"])
+	}
+	// 2 already resolves to the source digest in the target
+	assert.DeepEqual(t, []string{"quay.io/app:2"}, summary.Skipped)
+	assert.Equal(t, 1, len(summary.Failed))
+	assert.ErrorContains(t, summary.Failed["quay.io/app:3"], "flaky registry")
+	assert.ErrorContains(t, summary.Err(), "failed to sync 1 images: quay.io/app:3")
+
+	// the digest of the failed copy is not considered present in the target
+	assert.Assert(t, digests.claim("app", "sha256:3"))
+}
+
+func TestTargetDigestsWait(t *testing.T) {
+	ctx := context.Background()
+	digests := newTargetDigests()
+	digests.add("app", "sha256:1")
+	target, present := digests.wait(ctx, "app", "sha256:1")
+	assert.Assert(t, present)
+	assert.Equal(t, "sha256:1", target)
+	_, present = digests.wait(ctx, "app", "sha256:unknown")
+	assert.Assert(t, !present)
+
+	assert.Assert(t, digests.claim("app", "sha256:2"))
+	go digests.complete("app", "sha256:2", "sha256:2-amd64")
+	target, present = digests.wait(ctx, "app", "sha256:2")
+	assert.Assert(t, present)
+	assert.Equal(t, "sha256:2-amd64", target)
+
+	assert.Assert(t, digests.claim("app", "sha256:3"))
+	go digests.release("app", "sha256:3")
+	_, present = digests.wait(ctx, "app", "sha256:3")
+	assert.Assert(t, !present)
+
+	assert.Assert(t, digests.claim("app", "sha256:4"))
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, present = digests.wait(cancelled, "app", "sha256:4")
+	assert.Assert(t, !present)
+}
+
+func TestRunConcurrently(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	items := make([]int, 20)
+	runConcurrently(4, items, func(int) {
+		current := running.Add(1)
+		for {
+			previous := maxRunning.Load()
+			if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		running.Add(-1)
+	})
+	assert.Assert(t, maxRunning.Load() <= 4)
+}
@@ -0,0 +1,231 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// TagInfo describes a tag in a source repository. Digest and CreatedAt are empty
+// if the registry does not provide them.
+type TagInfo struct {
+	Name      string
+	Digest    string
+	CreatedAt time.Time
+}
+
+// TagPolicy selects the tags to sync for a single repository
+type TagPolicy struct {
+	// Repository is the source repository including the registry, i.e. quay.io/acm-d/rhtap-hypershift-operator
+	Repository string
+	// NumberOfTags is the number of matching tags to sync, defaults to the global NumberOfTags
+	NumberOfTags int
+	// Semver is a semantic version constraint like ">= 1.2.3, < 2", tags that are no semantic version are ignored
+	Semver string
+	// Include is a list of regular expressions, a tag must match at least one of them
+	Include []string
+	// Exclude is a list of regular expressions, a tag must not match any of them
+	Exclude []string
+	// PinnedDigests are always synced, regardless of the other rules
+	PinnedDigests []string
+	// MaxAge ignores tags created longer ago, if the registry reports creation times
+	MaxAge time.Duration
+	// AddLatest syncs the latest tag in addition to the selected tags, defaults to the global AddLatest if unset
+	AddLatest *bool
+
+	constraint *semver.Constraints
+	include    []*regexp.Regexp
+	exclude    []*regexp.Regexp
+}
+
+func compileRegexps(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Compile validates the policy and prepares it for use
+func (p *TagPolicy) Compile() error {
+	if p.Repository == "" {
+		return fmt.Errorf("tag policy without repository")
+	}
+	if p.Semver != "" {
+		constraint, err := semver.NewConstraint(p.Semver)
+		if err != nil {
+			return fmt.Errorf("invalid semver constraint %q for %s: %w", p.Semver, p.Repository, err)
+		}
+		p.constraint = constraint
+	}
+	var err error
+	if p.include, err = compileRegexps(p.Include); err != nil {
+		return fmt.Errorf("invalid include for %s: %w", p.Repository, err)
+	}
+	if p.exclude, err = compileRegexps(p.Exclude); err != nil {
+		return fmt.Errorf("invalid exclude for %s: %w", p.Repository, err)
+	}
+	return nil
+}
+
+func matchesAny(res []*regexp.Regexp, tag string) bool {
+	for _, re := range res {
+		if re.MatchString(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+type candidate struct {
+	TagInfo
+	version *semver.Version
+}
+
+// matches returns the tag as candidate if it matches the rules of the policy. latest is no
+// candidate, it is only synced with AddLatest.
+func (p *TagPolicy) matches(tag TagInfo, now time.Time) (*candidate, bool) {
+	if tag.Name == "latest" {
+		return nil, false
+	}
+	c := &candidate{TagInfo: tag}
+	if p.constraint != nil {
+		version, err := semver.NewVersion(tag.Name)
+		if err != nil || !p.constraint.Check(version) {
+			return nil, false
+		}
+		c.version = version
+	}
+	if len(p.include) > 0 && !matchesAny(p.include, tag.Name) {
+		return nil, false
+	}
+	if matchesAny(p.exclude, tag.Name) {
+		return nil, false
+	}
+	if p.MaxAge > 0 && !tag.CreatedAt.IsZero() && now.Sub(tag.CreatedAt) > p.MaxAge {
+		return nil, false
+	}
+	return c, true
+}
+
+// SelectTags returns the newest numberOfTags tags matching the policy, plus all tags of
+// pinned digests and latest if requested. With a semver constraint the tags are ordered by
+// version, otherwise by creation time. Tags without creation time are ordered by name, see
+// newerTagName.
+func (p *TagPolicy) SelectTags(tags []TagInfo, numberOfTags int, now time.Time) []string {
+	if p.NumberOfTags > 0 {
+		numberOfTags = p.NumberOfTags
+	}
+
+	var candidates []*candidate
+	for _, tag := range tags {
+		if c, ok := p.matches(tag, now); ok {
+			candidates = append(candidates, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		switch {
+		case ci.version != nil && cj.version != nil:
+			return ci.version.GreaterThan(cj.version)
+		case !ci.CreatedAt.Equal(cj.CreatedAt):
+			return ci.CreatedAt.After(cj.CreatedAt)
+		default:
+			return newerTagName(ci.Name, cj.Name)
+		}
+	})
+
+	selected := make(map[string]bool)
+	var tagsToSync []string
+	for _, c := range candidates {
+		if len(tagsToSync) >= numberOfTags {
+			break
+		}
+		selected[c.Name] = true
+		tagsToSync = append(tagsToSync, c.Name)
+	}
+
+	pinned := make(map[string]bool)
+	for _, d := range p.PinnedDigests {
+		pinned[d] = true
+	}
+	digestsKnown := false
+	for _, tag := range tags {
+		if tag.Digest == "" {
+			continue
+		}
+		digestsKnown = true
+		if pinned[tag.Digest] && !selected[tag.Name] {
+			selected[tag.Name] = true
+			tagsToSync = append(tagsToSync, tag.Name)
+		}
+	}
+	if len(p.PinnedDigests) > 0 && len(tags) > 0 && !digestsKnown {
+		Log().Warnw("Registry does not report tag digests, pinned digests of the tag policy are not synced", "repository", p.Repository, "pinnedDigests", p.PinnedDigests)
+	}
+
+	if p.AddLatest != nil && *p.AddLatest && !selected["latest"] {
+		for _, tag := range tags {
+			if tag.Name == "latest" {
+				tagsToSync = append(tagsToSync, tag.Name)
+				break
+			}
+		}
+	}
+	return tagsToSync
+}
+
+// newerTagName orders tag names without creation time newest first. Semantic versions come
+// first, ordered by version, followed by all other tags in reverse lexical order.
+func newerTagName(a, b string) bool {
+	va, erra := semver.NewVersion(a)
+	vb, errb := semver.NewVersion(b)
+	switch {
+	case erra == nil && errb == nil:
+		return va.GreaterThan(vb)
+	case erra == nil || errb == nil:
+		return erra == nil
+	default:
+		return a > b
+	}
+}
+
+// tagPolicies compiles the configured policies and indexes them by repository
+func tagPolicies(cfg *SyncConfig) (map[string]*TagPolicy, error) {
+	policies := make(map[string]*TagPolicy)
+	for i := range cfg.TagPolicies {
+		policy := &cfg.TagPolicies[i]
+		if err := policy.Compile(); err != nil {
+			return nil, err
+		}
+		if policy.AddLatest == nil {
+			policy.AddLatest = ptr(cfg.AddLatest)
+		}
+		if _, exists := policies[policy.Repository]; exists {
+			return nil, fmt.Errorf("duplicate tag policy for %s", policy.Repository)
+		}
+		policies[policy.Repository] = policy
+	}
+	return policies, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTagPolicySelectTags(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tags := []TagInfo{
+		{Name: "latest", Digest: "sha256:e", CreatedAt: now.Add(-1 * day)},
+		{Name: "v1.2.0", Digest: "sha256:a", CreatedAt: now.Add(-90 * day)},
+		{Name: "v1.2.1", Digest: "sha256:b", CreatedAt: now.Add(-60 * day)},
+		{Name: "v1.3.0-rc.1", Digest: "sha256:c", CreatedAt: now.Add(-20 * day)},
+		{Name: "v1.3.0", Digest: "sha256:d", CreatedAt: now.Add(-10 * day)},
+		{Name: "v2.0.0", Digest: "sha256:e", CreatedAt: now.Add(-1 * day)},
+		{Name: "nightly", Digest: "sha256:f", CreatedAt: now.Add(-2 * day)},
+	}
+
+	testCases := []struct {
+		name         string
+		policy       TagPolicy
+		numberOfTags int
+		expected     []string
+	}{
+		{
+			name:         "newest tags",
+			policy:       TagPolicy{},
+			numberOfTags: 2,
+			expected:     []string{"v2.0.0", "nightly"},
+		},
+		{
+			name:         "semver range ordered by version",
+			policy:       TagPolicy{Semver: ">= 1.2.0, < 2.0.0"},
+			numberOfTags: 10,
+			expected:     []string{"v1.3.0", "v1.2.1", "v1.2.0"},
+		},
+		{
+			name:         "policy overrides number of tags",
+			policy:       TagPolicy{Semver: "~1.2", NumberOfTags: 1},
+			numberOfTags: 10,
+			expected:     []string{"v1.2.1"},
+		},
+		{
+			name:         "include and exclude",
+			policy:       TagPolicy{Include: []string{`^v1\.`}, Exclude: []string{`-rc`}},
+			numberOfTags: 10,
+			expected:     []string{"v1.3.0", "v1.2.1", "v1.2.0"},
+		},
+		{
+			name:         "max age",
+			policy:       TagPolicy{MaxAge: 30 * day},
+			numberOfTags: 10,
+			expected:     []string{"v2.0.0", "nightly", "v1.3.0", "v1.3.0-rc.1"},
+		},
+		{
+			name:         "latest is added on request",
+			policy:       TagPolicy{Semver: "^1.3", AddLatest: ptr(true)},
+			numberOfTags: 10,
+			expected:     []string{"v1.3.0", "latest"},
+		},
+		{
+			name:         "pinned digests bypass the rules",
+			policy:       TagPolicy{Semver: "^2", PinnedDigests: []string{"sha256:a"}},
+			numberOfTags: 10,
+			expected:     []string{"v2.0.0", "v1.2.0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.policy.Repository = "quay.io/test"
+			assert.NilError(t, tc.policy.Compile())
+			assert.DeepEqual(t, tc.expected, tc.policy.SelectTags(tags, tc.numberOfTags, now))
+		})
+	}
+}
+
+func TestTagPolicySelectTagsWithoutCreationTime(t *testing.T) {
+	tags := []TagInfo{{Name: "a"}, {Name: "v1.9.0"}, {Name: "b"}, {Name: "v1.10.0"}}
+	policy := TagPolicy{Repository: "quay.io/test", PinnedDigests: []string{"sha256:a"}}
+	assert.NilError(t, policy.Compile())
+	// semantic versions first, the pinned digest can't match as the registry reports no digests
+	assert.DeepEqual(t, []string{"v1.10.0", "v1.9.0", "b"}, policy.SelectTags(tags, 3, time.Now()))
+}
+
+func TestTagPolicies(t *testing.T) {
+	_, err := tagPolicies(&SyncConfig{TagPolicies: []TagPolicy{{Repository: "quay.io/test", Semver: "not a version"}}})
+	assert.ErrorContains(t, err, "invalid semver constraint")
+
+	_, err = tagPolicies(&SyncConfig{TagPolicies: []TagPolicy{{Repository: "quay.io/test", Exclude: []string{"("}}}})
+	assert.ErrorContains(t, err, "invalid exclude for quay.io/test")
+
+	_, err = tagPolicies(&SyncConfig{TagPolicies: []TagPolicy{{Repository: "quay.io/test"}, {Repository: "quay.io/test"}}})
+	assert.ErrorContains(t, err, "duplicate tag policy for quay.io/test")
+
+	policies, err := tagPolicies(&SyncConfig{TagPolicies: []TagPolicy{{Repository: "quay.io/test", Semver: "1.x"}}})
+	assert.NilError(t, err)
+	assert.Equal(t, "1.x", policies["quay.io/test"].Semver)
+	assert.Equal(t, false, *policies["quay.io/test"].AddLatest)
+
+	policies, err = tagPolicies(&SyncConfig{AddLatest: true, TagPolicies: []TagPolicy{{Repository: "quay.io/test"}}})
+	assert.NilError(t, err)
+	assert.Equal(t, true, *policies["quay.io/test"].AddLatest)
+
+	// the policy overrides the global setting in both directions
+	policies, err = tagPolicies(&SyncConfig{AddLatest: true, TagPolicies: []TagPolicy{
+		{Repository: "quay.io/disabled", AddLatest: ptr(false)},
+	}})
+	assert.NilError(t, err)
+	assert.Equal(t, false, *policies["quay.io/disabled"].AddLatest)
+
+	policies, err = tagPolicies(&SyncConfig{TagPolicies: []TagPolicy{{Repository: "quay.io/enabled", AddLatest: ptr(true)}}})
+	assert.NilError(t, err)
+	assert.Equal(t, true, *policies["quay.io/enabled"].AddLatest)
+}
@@ -70,6 +70,9 @@ func newSyncConfig() *internal.SyncConfig {
 	v.SetDefault("numberoftags", 10)
 	v.SetDefault("requesttimeout", 10)
 	v.SetDefault("addlatest", false)
+	v.SetDefault("workers", 4)
+	v.SetDefault("prune", false)
+	v.SetDefault("prunedryrun", false)
 
 	// bind environment variables
 	// we can't use vipers native viper.AutomaticEnv() because it only works
@@ -78,6 +81,9 @@ func newSyncConfig() *internal.SyncConfig {
 		"NumberOfTags":            "NUMBER_OF_TAGS",
 		"RequestTimeout":          "REQUEST_TIMEOUT",
 		"AddLatest":               "ADD_LATEST",
+		"Workers":                 "WORKERS",
+		"Prune":                   "PRUNE",
+		"PruneDryRun":             "PRUNE_DRY_RUN",
 		"Repositories":            "REPOSITORIES",
 		"AcrTargetRegistry":       "ACR_TARGET_REGISTRY",
 		"TenantId":                "TENANT_ID",
@@ -0,0 +1,293 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartdiff
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+)
+
+const valuesFile = "values.yaml"
+
+// operandImageEnvVarPrefix marks env vars of the operator deployment that hold operand images
+const operandImageEnvVarPrefix = "OPERAND_IMAGE_"
+
+// Report lists the changes between two versions of a chart that need a review before upgrading
+type Report struct {
+	AddedCRDs       []string
+	RemovedCRDs     []string
+	CRDChanges      []CRDChange
+	RBACEscalations []RBACEscalation
+	AddedImages     []string
+	RemovedImages   []string
+	AddedValues     []string
+	RemovedValues   []string
+}
+
+// Breaking returns true if upgrading would break existing custom resources
+func (r *Report) Breaking() bool {
+	if len(r.RemovedCRDs) > 0 {
+		return true
+	}
+	for _, c := range r.CRDChanges {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty returns true if the report contains no changes
+func (r *Report) Empty() bool {
+	return len(r.AddedCRDs) == 0 && len(r.RemovedCRDs) == 0 && len(r.CRDChanges) == 0 &&
+		len(r.RBACEscalations) == 0 && len(r.AddedImages) == 0 && len(r.RemovedImages) == 0 &&
+		len(r.AddedValues) == 0 && len(r.RemovedValues) == 0
+}
+
+// chartContent are the parts of a chart relevant for an upgrade
+type chartContent struct {
+	crds         map[string]*apiextensionsv1.CustomResourceDefinition
+	clusterRoles map[string]*rbacv1.ClusterRole
+	images       sets.Set[string]
+	values       sets.Set[string]
+}
+
+// LoadDir reads the values, CRDs and templates of a chart directory
+func LoadDir(dir string) ([]*chart.File, error) {
+	var files []*chart.File
+	data, err := os.ReadFile(filepath.Join(dir, valuesFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %v", err)
+	}
+	files = append(files, &chart.File{Name: valuesFile, Data: data})
+
+	for _, subDir := range []string{"crds", "templates"} {
+		entries, err := os.ReadDir(filepath.Join(dir, subDir))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s directory: %v", subDir, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !isYAML(entry.Name()) {
+				continue
+			}
+			name := filepath.Join(subDir, entry.Name())
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", name, err)
+			}
+			files = append(files, &chart.File{Name: name, Data: data})
+		}
+	}
+	return files, nil
+}
+
+func isYAML(name string) bool {
+	return filepath.Ext(name) == ".yaml" || filepath.Ext(name) == ".yml"
+}
+
+// Compare compares the files of the current chart with the files of the new chart
+func Compare(current, updated []*chart.File) (*Report, error) {
+	currentContent, err := parseChart(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse current chart: %v", err)
+	}
+	newContent, err := parseChart(updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse new chart: %v", err)
+	}
+
+	report := &Report{
+		AddedImages:     sets.List(newContent.images.Difference(currentContent.images)),
+		RemovedImages:   sets.List(currentContent.images.Difference(newContent.images)),
+		AddedValues:     sets.List(newContent.values.Difference(currentContent.values)),
+		RemovedValues:   sets.List(currentContent.values.Difference(newContent.values)),
+		RBACEscalations: compareClusterRoles(currentContent.clusterRoles, newContent.clusterRoles),
+	}
+	for _, name := range sortedKeys(newContent.crds) {
+		currentCRD, ok := currentContent.crds[name]
+		if !ok {
+			report.AddedCRDs = append(report.AddedCRDs, name)
+			continue
+		}
+		report.CRDChanges = append(report.CRDChanges, compareCRDs(currentCRD, newContent.crds[name])...)
+	}
+	for _, name := range sortedKeys(currentContent.crds) {
+		if _, ok := newContent.crds[name]; !ok {
+			report.RemovedCRDs = append(report.RemovedCRDs, name)
+		}
+	}
+	return report, nil
+}
+
+func parseChart(files []*chart.File) (*chartContent, error) {
+	content := &chartContent{
+		crds:         make(map[string]*apiextensionsv1.CustomResourceDefinition),
+		clusterRoles: make(map[string]*rbacv1.ClusterRole),
+		images:       sets.New[string](),
+		values:       sets.New[string](),
+	}
+	for _, file := range files {
+		// named templates, i.e. _helpers.tpl, render no manifests
+		if !isYAML(file.Name) {
+			continue
+		}
+		data := quoteTemplates(file.Data)
+		obj := make(map[string]interface{})
+		if err := yaml.Unmarshal(data, &obj); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %v", file.Name, err)
+		}
+		if file.Name == valuesFile {
+			flattenKeys("", obj, content.values)
+			continue
+		}
+
+		u := unstructured.Unstructured{Object: obj}
+		switch u.GetKind() {
+		case "CustomResourceDefinition":
+			crd := &apiextensionsv1.CustomResourceDefinition{}
+			if err := yaml.Unmarshal(data, crd); err != nil {
+				return nil, fmt.Errorf("failed to convert %s to CustomResourceDefinition: %v", file.Name, err)
+			}
+			content.crds[crd.Name] = crd
+		case "ClusterRole":
+			clusterRole := &rbacv1.ClusterRole{}
+			if err := yaml.Unmarshal(data, clusterRole); err != nil {
+				return nil, fmt.Errorf("failed to convert %s to ClusterRole: %v", file.Name, err)
+			}
+			content.clusterRoles[clusterRole.Name] = clusterRole
+		default:
+			collectImages(u, content.images)
+		}
+	}
+	return content, nil
+}
+
+// unquotedTemplatePattern matches values that are templates rendering a YAML block, i.e.
+// nodeSelector: {{- toYaml .Values.nodeSelector | nindent 8 }}
+var unquotedTemplatePattern = regexp.MustCompile(`(?m)^( *(?:- )?[^\s:#'"][^:\n]*: )(\{\{.*\}\})$`)
+
+// quoteTemplates turns block templates into strings, so the manifest can be parsed
+func quoteTemplates(data []byte) []byte {
+	return unquotedTemplatePattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := unquotedTemplatePattern.FindSubmatch(match)
+		return []byte(string(groups[1]) + "'" + strings.ReplaceAll(string(groups[2]), "'", "''") + "'")
+	})
+}
+
+// flattenKeys adds the keys of all leaf values, i.e. global.registryOverride
+func flattenKeys(prefix string, values map[string]interface{}, keys sets.Set[string]) {
+	for k, v := range values {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenKeys(key, nested, keys)
+			continue
+		}
+		keys.Insert(key)
+	}
+}
+
+// collectImages adds the container images and operand images of workloads with a pod template
+func collectImages(obj unstructured.Unstructured, images sets.Set[string]) {
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", field)
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if image, ok := container["image"].(string); ok && image != "" {
+				images.Insert(image)
+			}
+			env, _, _ := unstructured.NestedSlice(container, "env")
+			for _, e := range env {
+				envVar, ok := e.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := envVar["name"].(string)
+				value, _ := envVar["value"].(string)
+				if strings.HasPrefix(name, operandImageEnvVarPrefix) && value != "" {
+					images.Insert(value)
+				}
+			}
+		}
+	}
+}
+
+// Write writes the report in a human readable form
+func (r *Report) Write(w io.Writer) error {
+	var b strings.Builder
+	if r.Empty() {
+		b.WriteString("No changes that need a review\n")
+	}
+	writeSection(&b, "Added CRDs", r.AddedCRDs)
+	writeSection(&b, "Removed CRDs (breaking)", r.RemovedCRDs)
+
+	crdChanges := make([]string, 0, len(r.CRDChanges))
+	for _, c := range r.CRDChanges {
+		crdChanges = append(crdChanges, c.String())
+	}
+	writeSection(&b, "CRD changes", crdChanges)
+
+	escalations := make([]string, 0, len(r.RBACEscalations))
+	for _, e := range r.RBACEscalations {
+		escalations = append(escalations, e.String())
+	}
+	writeSection(&b, "ClusterRole escalations", escalations)
+
+	writeSection(&b, "Added images", r.AddedImages)
+	writeSection(&b, "Removed images", r.RemovedImages)
+	writeSection(&b, "Added values", r.AddedValues)
+	writeSection(&b, "Removed values", r.RemovedValues)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeSection(b *strings.Builder, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%s:\n", title)
+	for _, line := range lines {
+		fmt.Fprintf(b, "  - %s\n", line)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,312 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartdiff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+const currentCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: engines.example.com
+spec:
+  group: example.com
+  scope: Cluster
+  names:
+    kind: Engine
+    plural: engines
+  versions:
+  - name: v1beta1
+    served: true
+    storage: false
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              availability:
+                type: string
+                enum: ["High", "Basic"]
+              replicas:
+                type: integer
+              components:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    name:
+                      type: string
+                    enabled:
+                      type: boolean
+`
+
+const updatedCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: engines.example.com
+spec:
+  group: example.com
+  scope: Cluster
+  names:
+    kind: Engine
+    plural: engines
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: ["owner"]
+            properties:
+              availability:
+                type: string
+                enum: ["High"]
+              replicas:
+                type: string
+              owner:
+                type: string
+              components:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    name:
+                      type: string
+`
+
+const currentClusterRole = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: engine-operator-abc
+rules:
+- apiGroups: [""]
+  resources: ["configmaps", "*/status"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: ["apps"]
+  resources: ["deployments"]
+  verbs: ["*"]
+`
+
+const updatedClusterRole = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: engine-operator-def
+rules:
+- apiGroups: [""]
+  resources: ["configmaps", "pods/status"]
+  verbs: ["get", "list"]
+- apiGroups: ["apps"]
+  resources: ["deployments"]
+  verbs: ["create", "delete"]
+- apiGroups: [""]
+  resources: ["secrets"]
+  resourceNames: ["pull-secret"]
+  verbs: ["get"]
+`
+
+func deployment(image string, operandImages ...string) string {
+	d := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: engine-operator
+spec:
+  template:
+    spec:
+      containers:
+      - name: operator
+        image: ` + image + `
+        env:
+        - name: POD_NAMESPACE
+          value: default
+`
+	for _, operandImage := range operandImages {
+		d += `        - name: OPERAND_IMAGE_COMPONENT
+          value: ` + operandImage + "\n"
+	}
+	return d
+}
+
+func files(contents ...string) []*chart.File {
+	var files []*chart.File
+	for i, content := range contents {
+		name := "templates/" + string(rune('a'+i)) + ".yaml"
+		if i == 0 {
+			name = valuesFile
+		}
+		files = append(files, &chart.File{Name: name, Data: []byte(content)})
+	}
+	return files
+}
+
+func TestCompareNoChanges(t *testing.T) {
+	chartFiles := files("imageRegistry: \"\"\n", currentCRD, currentClusterRole, deployment("registry/operator:v1", "registry/component:v1"))
+
+	report, err := Compare(chartFiles, chartFiles)
+	assert.Nil(t, err)
+	assert.True(t, report.Empty())
+	assert.False(t, report.Breaking())
+
+	var out bytes.Buffer
+	assert.Nil(t, report.Write(&out))
+	assert.Equal(t, "No changes that need a review\n", out.String())
+}
+
+func TestCompareCRDs(t *testing.T) {
+	removedCRD := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: removed.example.com
+spec:
+  scope: Namespaced
+`
+	addedCRD := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: added.example.com
+spec:
+  scope: Namespaced
+`
+	report, err := Compare(files("{}", currentCRD, removedCRD), files("{}", updatedCRD, addedCRD))
+	assert.Nil(t, err)
+	assert.True(t, report.Breaking())
+	assert.Equal(t, []string{"added.example.com"}, report.AddedCRDs)
+	assert.Equal(t, []string{"removed.example.com"}, report.RemovedCRDs)
+
+	var changes []string
+	for _, c := range report.CRDChanges {
+		changes = append(changes, c.String())
+	}
+	assert.Equal(t, []string{
+		"engines.example.com v1beta1: served version removed (breaking)",
+		"engines.example.com v1 .spec: property owner is now required (breaking)",
+		"engines.example.com v1 .spec.availability: enum value \"Basic\" removed (breaking)",
+		"engines.example.com v1 .spec.components[*]: property enabled removed (breaking)",
+		"engines.example.com v1 .spec.replicas: type changed from integer to string (breaking)",
+	}, changes)
+}
+
+func TestCompareCRDStorageVersion(t *testing.T) {
+	current := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: engines.example.com
+spec:
+  scope: Cluster
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+  - name: v1
+    served: true
+    storage: false
+`
+	updated := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: engines.example.com
+spec:
+  scope: Cluster
+  versions:
+  - name: v1beta1
+    served: true
+    storage: false
+  - name: v1
+    served: true
+    storage: true
+`
+	report, err := Compare(files("{}", current), files("{}", updated))
+	assert.Nil(t, err)
+	assert.False(t, report.Breaking())
+	assert.Len(t, report.CRDChanges, 1)
+	assert.Equal(t, "engines.example.com v1beta1: version is no longer the storage version, stored custom resources need a migration", report.CRDChanges[0].String())
+}
+
+func TestCompareClusterRoles(t *testing.T) {
+	report, err := Compare(files("{}", currentClusterRole), files("{}", updatedClusterRole))
+	assert.Nil(t, err)
+	assert.False(t, report.Breaking())
+	assert.Equal(t, []RBACEscalation{
+		{ClusterRole: "engine-operator-def", Permission: "get secrets/pull-secret"},
+	}, report.RBACEscalations)
+}
+
+func TestCompareImagesAndValues(t *testing.T) {
+	report, err := Compare(
+		files("imageRegistry: \"\"\nglobal:\n  pullSecret: \"\"\n", deployment("registry/operator:v1", "registry/component:v1")),
+		files("imageRegistry: \"\"\nglobal:\n  registryOverride: \"\"\n", deployment("registry/operator:v2", "registry/component:v1", "registry/addon:v2")),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"registry/addon:v2", "registry/operator:v2"}, report.AddedImages)
+	assert.Equal(t, []string{"registry/operator:v1"}, report.RemovedImages)
+	assert.Equal(t, []string{"global.registryOverride"}, report.AddedValues)
+	assert.Equal(t, []string{"global.pullSecret"}, report.RemovedValues)
+
+	var out bytes.Buffer
+	assert.Nil(t, report.Write(&out))
+	assert.Equal(t, `Added images:
+  - registry/addon:v2
+  - registry/operator:v2
+Removed images:
+  - registry/operator:v1
+Added values:
+  - global.registryOverride
+Removed values:
+  - global.pullSecret
+`, out.String())
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "crds"), 0755))
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "templates"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte("name: engine\n"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("imageRegistry: \"\"\n"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "crds", "engines.example.com.yaml"), []byte(currentCRD), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "templates", "engine-operator.deployment.yaml"), []byte(deployment("registry/operator:v1")), 0644))
+
+	chartFiles, err := LoadDir(dir)
+	assert.Nil(t, err)
+	var names []string
+	for _, f := range chartFiles {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"values.yaml", "crds/engines.example.com.yaml", "templates/engine-operator.deployment.yaml"}, names)
+}
+
+func TestCompareTemplatizedBlocks(t *testing.T) {
+	current := deployment("registry/operator:v1")
+	updated := current + "      nodeSelector: {{- toYaml .Values.nodeSelector | nindent 8 }}\n"
+
+	report, err := Compare(files("{}", current), files("nodeSelector: {}\n", updated))
+	assert.Nil(t, err)
+	assert.Empty(t, report.AddedImages)
+	assert.Equal(t, []string{"nodeSelector"}, report.AddedValues)
+}
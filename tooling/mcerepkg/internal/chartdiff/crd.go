@@ -0,0 +1,169 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartdiff
+
+import (
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// CRDChange is a change of a CRD that affects existing custom resources
+type CRDChange struct {
+	CRD     string
+	Version string
+	// Path is the JSON path of the changed field in the schema, empty for changes of the version itself
+	Path    string
+	Message string
+	// Breaking is set if custom resources stored with the current chart can no longer be read or updated
+	Breaking bool
+}
+
+func (c CRDChange) String() string {
+	s := c.CRD
+	if c.Version != "" {
+		s += " " + c.Version
+	}
+	if c.Path != "" {
+		s += " " + c.Path
+	}
+	s += ": " + c.Message
+	if c.Breaking {
+		s += " (breaking)"
+	}
+	return s
+}
+
+// compareCRDs reports removed versions, storage version changes and schema changes of versions
+// that are served by both CRDs. Added versions and optional fields are compatible and not reported.
+func compareCRDs(current, updated *apiextensionsv1.CustomResourceDefinition) []CRDChange {
+	var changes []CRDChange
+	if current.Spec.Scope != updated.Spec.Scope {
+		changes = append(changes, CRDChange{
+			CRD:      current.Name,
+			Message:  fmt.Sprintf("scope changed from %s to %s", current.Spec.Scope, updated.Spec.Scope),
+			Breaking: true,
+		})
+	}
+
+	updatedVersions := make(map[string]*apiextensionsv1.CustomResourceDefinitionVersion)
+	for i := range updated.Spec.Versions {
+		updatedVersions[updated.Spec.Versions[i].Name] = &updated.Spec.Versions[i]
+	}
+	for _, currentVersion := range current.Spec.Versions {
+		change := CRDChange{CRD: current.Name, Version: currentVersion.Name, Breaking: true}
+		updatedVersion, ok := updatedVersions[currentVersion.Name]
+		switch {
+		case !ok && currentVersion.Storage:
+			change.Message = "storage version removed, stored custom resources can no longer be read"
+		case !ok && currentVersion.Served:
+			change.Message = "served version removed"
+		case !ok:
+			continue
+		case currentVersion.Served && !updatedVersion.Served:
+			change.Message = "version is no longer served"
+		default:
+			if currentVersion.Storage && !updatedVersion.Storage {
+				changes = append(changes, CRDChange{
+					CRD:     current.Name,
+					Version: currentVersion.Name,
+					Message: "version is no longer the storage version, stored custom resources need a migration",
+				})
+			}
+			changes = append(changes, compareSchemas(current.Name, currentVersion.Name, "", schemaOf(currentVersion), schemaOf(*updatedVersion))...)
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func schemaOf(version apiextensionsv1.CustomResourceDefinitionVersion) *apiextensionsv1.JSONSchemaProps {
+	if version.Schema == nil {
+		return nil
+	}
+	return version.Schema.OpenAPIV3Schema
+}
+
+// compareSchemas walks both schemas and reports changes that make existing objects invalid or drop
+// their fields: removed properties, changed types, new required properties and narrowed enums
+func compareSchemas(crd, version, path string, current, updated *apiextensionsv1.JSONSchemaProps) []CRDChange {
+	if current == nil || updated == nil {
+		return nil
+	}
+	breaking := func(format string, args ...any) CRDChange {
+		p := path
+		if p == "" {
+			p = "."
+		}
+		return CRDChange{CRD: crd, Version: version, Path: p, Message: fmt.Sprintf(format, args...), Breaking: true}
+	}
+
+	var changes []CRDChange
+	if current.Type != "" && updated.Type != "" && current.Type != updated.Type {
+		changes = append(changes, breaking("type changed from %s to %s", current.Type, updated.Type))
+		return changes
+	}
+	if preservesUnknownFields(current) && !preservesUnknownFields(updated) {
+		changes = append(changes, breaking("unknown fields are no longer preserved"))
+	}
+
+	currentRequired := sets.New(current.Required...)
+	for _, required := range updated.Required {
+		if !currentRequired.Has(required) {
+			changes = append(changes, breaking("property %s is now required", required))
+		}
+	}
+
+	switch {
+	case len(current.Enum) == 0 && len(updated.Enum) > 0:
+		changes = append(changes, breaking("values are now restricted to an enum"))
+	case len(current.Enum) > 0 && len(updated.Enum) > 0:
+		updatedEnum := sets.New[string]()
+		for _, e := range updated.Enum {
+			updatedEnum.Insert(string(e.Raw))
+		}
+		for _, e := range current.Enum {
+			if !updatedEnum.Has(string(e.Raw)) {
+				changes = append(changes, breaking("enum value %s removed", string(e.Raw)))
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(current.Properties) {
+		currentProperty := current.Properties[name]
+		updatedProperty, ok := updated.Properties[name]
+		propertyPath := path + "." + name
+		if !ok {
+			if !preservesUnknownFields(updated) {
+				changes = append(changes, breaking("property %s removed", name))
+			}
+			continue
+		}
+		changes = append(changes, compareSchemas(crd, version, propertyPath, &currentProperty, &updatedProperty)...)
+	}
+	if current.Items != nil && updated.Items != nil {
+		changes = append(changes, compareSchemas(crd, version, path+"[*]", current.Items.Schema, updated.Items.Schema)...)
+	}
+	if current.AdditionalProperties != nil && updated.AdditionalProperties != nil {
+		changes = append(changes, compareSchemas(crd, version, path+".*", current.AdditionalProperties.Schema, updated.AdditionalProperties.Schema)...)
+	}
+	return changes
+}
+
+func preservesUnknownFields(schema *apiextensionsv1.JSONSchemaProps) bool {
+	return schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields
+}
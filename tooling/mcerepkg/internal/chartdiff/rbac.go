@@ -0,0 +1,134 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartdiff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// RBACEscalation is a permission of a ClusterRole in the new chart that no ClusterRole of the current
+// chart grants
+type RBACEscalation struct {
+	ClusterRole string
+	Permission  string
+}
+
+func (e RBACEscalation) String() string {
+	return fmt.Sprintf("%s: %s", e.ClusterRole, e.Permission)
+}
+
+// permission is a single verb on a single resource, resource name or non-resource URL
+type permission struct {
+	verb           string
+	apiGroup       string
+	resource       string
+	resourceName   string
+	nonResourceURL string
+}
+
+func (p permission) String() string {
+	if p.nonResourceURL != "" {
+		return fmt.Sprintf("%s %s", p.verb, p.nonResourceURL)
+	}
+	resource := p.resource
+	if p.apiGroup != "" {
+		resource += "." + p.apiGroup
+	}
+	if p.resourceName != "" {
+		resource += "/" + p.resourceName
+	}
+	return fmt.Sprintf("%s %s", p.verb, resource)
+}
+
+// compareClusterRoles reports the permissions of the updated ClusterRoles that are not granted by
+// any current ClusterRole. ClusterRoles are not compared by name, because the names generated from
+// the bundle contain a hash of their rules.
+func compareClusterRoles(current, updated map[string]*rbacv1.ClusterRole) []RBACEscalation {
+	var currentRules []rbacv1.PolicyRule
+	for _, clusterRole := range current {
+		currentRules = append(currentRules, clusterRole.Rules...)
+	}
+
+	var escalations []RBACEscalation
+	for _, name := range sortedKeys(updated) {
+		for _, rule := range updated[name].Rules {
+			for _, p := range expandRule(rule) {
+				if !slices.ContainsFunc(currentRules, func(r rbacv1.PolicyRule) bool { return grants(r, p) }) {
+					escalations = append(escalations, RBACEscalation{ClusterRole: name, Permission: p.String()})
+				}
+			}
+		}
+	}
+	return escalations
+}
+
+func expandRule(rule rbacv1.PolicyRule) []permission {
+	var permissions []permission
+	for _, verb := range rule.Verbs {
+		for _, url := range rule.NonResourceURLs {
+			permissions = append(permissions, permission{verb: verb, nonResourceURL: url})
+		}
+		for _, apiGroup := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				if len(rule.ResourceNames) == 0 {
+					permissions = append(permissions, permission{verb: verb, apiGroup: apiGroup, resource: resource})
+				}
+				for _, resourceName := range rule.ResourceNames {
+					permissions = append(permissions, permission{verb: verb, apiGroup: apiGroup, resource: resource, resourceName: resourceName})
+				}
+			}
+		}
+	}
+	return permissions
+}
+
+// grants follows the matching of the RBAC authorizer, including wildcards
+func grants(rule rbacv1.PolicyRule, p permission) bool {
+	if !matches(rule.Verbs, p.verb) {
+		return false
+	}
+	if p.nonResourceURL != "" {
+		for _, url := range rule.NonResourceURLs {
+			if url == rbacv1.NonResourceAll || url == p.nonResourceURL ||
+				(strings.HasSuffix(url, "*") && strings.HasPrefix(p.nonResourceURL, strings.TrimSuffix(url, "*"))) {
+				return true
+			}
+		}
+		return false
+	}
+	if !matches(rule.APIGroups, p.apiGroup) || !matchesResource(rule.Resources, p.resource) {
+		return false
+	}
+	return len(rule.ResourceNames) == 0 || slices.Contains(rule.ResourceNames, p.resourceName)
+}
+
+func matches(values []string, value string) bool {
+	return slices.Contains(values, rbacv1.VerbAll) || slices.Contains(values, value)
+}
+
+// matchesResource also matches subresources, i.e. */status grants pods/status
+func matchesResource(resources []string, resource string) bool {
+	if matches(resources, resource) {
+		return true
+	}
+	if _, subresource, ok := strings.Cut(resource, "/"); ok {
+		return slices.Contains(resources, "*/"+subresource)
+	}
+	return false
+}
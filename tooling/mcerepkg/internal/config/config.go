@@ -0,0 +1,75 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/Azure/ARO-HCP/tooling/mcerepkg/internal/customize"
+)
+
+// Config describes how an OLM bundle is repackaged as a Helm chart
+type Config struct {
+	Chart    ChartConfig              `json:"chart"`
+	Operator customize.OperatorConfig `json:"operator"`
+	// Customizers are applied to every manifest in the listed order, customize.DefaultCustomizers if empty
+	Customizers []customize.CustomizerConfig `json:"customizers,omitempty"`
+	// Values override the defaults of the values introduced by the customizers
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// ChartConfig is the metadata of the generated chart. The version is taken from the bundle.
+type ChartConfig struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Keywords default to the keywords of the ClusterServiceVersion
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+// Default is the config for the multicluster-engine bundle
+func Default() *Config {
+	return &Config{
+		Chart: ChartConfig{
+			Name:        "multicluster-engine",
+			Description: "A Helm chart for multicluster-engine",
+		},
+		Operator: customize.MulticlusterEngineOperator,
+	}
+}
+
+// Load reads a config file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config file: %v", err)
+	}
+	if cfg.Chart.Name == "" {
+		return nil, fmt.Errorf("chart.name is required")
+	}
+	if cfg.Operator.Deployment == "" {
+		return nil, fmt.Errorf("operator.deployment is required")
+	}
+	if cfg.Chart.Description == "" {
+		cfg.Chart.Description = fmt.Sprintf("A Helm chart for %s", cfg.Chart.Name)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ARO-HCP/tooling/mcerepkg/internal/customize"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `
+chart:
+  name: cert-manager
+operator:
+  deployment: cert-manager-operator-controller-manager
+  operandImageEnvVarPrefix: RELATED_IMAGE_
+customizers:
+- name: namespace
+- name: deploymentImageRegistries
+  options:
+    value: global.imageRegistry
+- name: nodeSelector
+  options:
+    deployments:
+    - cert-manager-operator-controller-manager
+values:
+  nodeSelector:
+    kubernetes.io/os: linux
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, &Config{
+		Chart: ChartConfig{
+			Name:        "cert-manager",
+			Description: "A Helm chart for cert-manager",
+		},
+		Operator: customize.OperatorConfig{
+			Deployment:               "cert-manager-operator-controller-manager",
+			OperandImageEnvVarPrefix: "RELATED_IMAGE_",
+		},
+		Customizers: []customize.CustomizerConfig{
+			{Name: "namespace"},
+			{Name: "deploymentImageRegistries", Options: map[string]interface{}{"value": "global.imageRegistry"}},
+			{Name: "nodeSelector", Options: map[string]interface{}{"deployments": []interface{}{"cert-manager-operator-controller-manager"}}},
+		},
+		Values: map[string]interface{}{
+			"nodeSelector": map[string]interface{}{"kubernetes.io/os": "linux"},
+		},
+	}, cfg)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{
+			name:     "missing chart name",
+			config:   "operator:\n  deployment: operator\n",
+			expected: "chart.name is required",
+		},
+		{
+			name:     "missing operator deployment",
+			config:   "chart:\n  name: operator\n",
+			expected: "operator.deployment is required",
+		},
+		{
+			name:     "unknown field",
+			config:   "chart:\n  name: operator\n  version: 1.0.0\n",
+			expected: "unknown field",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, testCase.config))
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), testCase.expected)
+		})
+	}
+}
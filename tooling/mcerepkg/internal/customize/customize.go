@@ -18,34 +18,48 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 var (
-	operandImageEnvVarPrefix = "OPERAND_IMAGE_"
-	imageRegistryParamName   = "imageRegistry"
+	operandImageEnvVarPrefix   = "OPERAND_IMAGE_"
+	imageRegistryParamName     = "imageRegistry"
+	targetNamespacesAnnotation = "olm.targetNamespaces"
 )
 
-type Customizer func(unstructured.Unstructured) (unstructured.Unstructured, map[string]string, error)
+// OperatorConfig identifies the operator deployment of a bundle
+type OperatorConfig struct {
+	// Deployment is the name of the operator deployment
+	Deployment string `json:"deployment"`
+	// OperandImageEnvVarPrefix is the prefix of the env vars of the operator deployment that contain operand images
+	OperandImageEnvVarPrefix string `json:"operandImageEnvVarPrefix,omitempty"`
+	// WatchNamespaces are the target namespaces of the operator, they need to be supported by the
+	// installModes of the bundle. If empty, the operator watches all namespaces or, if the bundle
+	// does not support that, the release namespace.
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+	// RelatedImages are the images of the bundle, they are set from the bundle and not configurable
+	RelatedImages []v1alpha1.RelatedImage `json:"-"`
+}
 
-var customizerFuncs = []Customizer{
-	parameterizeNamespace,
-	parameterizeRoleBindingSubjectsNamespace,
-	parameterizeClusterRoleBindingSubjectsNamespace,
-	parameterizeOperandsImageRegistries,
-	parameterizeDeployment,
-	annotationCleaner,
+// MulticlusterEngineOperator is the operator of the multicluster-engine bundle
+var MulticlusterEngineOperator = OperatorConfig{
+	Deployment:               mceOperatorDeploymentName,
+	OperandImageEnvVarPrefix: operandImageEnvVarPrefix,
 }
 
-func CustomizeManifests(objects []unstructured.Unstructured) ([]unstructured.Unstructured, map[string]interface{}, error) {
-	parameters := make(map[string]string)
+// Customizer modifies a manifest and returns the values it introduces, keyed by their dotted path in values.yaml
+type Customizer func(unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error)
+
+func CustomizeManifests(objects []unstructured.Unstructured, customizers []Customizer) ([]unstructured.Unstructured, map[string]interface{}, error) {
+	parameters := make(map[string]interface{})
 	customizedManifests := make([]unstructured.Unstructured, len(objects))
 	for i, obj := range objects {
 		var err error
-		var newParams map[string]string
-		for _, customerFunc := range customizerFuncs {
+		var newParams map[string]interface{}
+		for _, customerFunc := range customizers {
 			obj, newParams, err = customerFunc(obj)
 			if err != nil {
 				return nil, nil, fmt.Errorf("failed to apply customer function: %v", err)
@@ -59,20 +73,42 @@ func CustomizeManifests(objects []unstructured.Unstructured) ([]unstructured.Uns
 	return customizedManifests, makeNestedMap(parameters), nil
 }
 
-func parameterizeNamespace(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]string, error) {
-	// check if the resource is a namespaced resource
-	if obj.GetNamespace() != "" {
-		obj.SetNamespace("{{ .Release.Namespace }}")
+func parameterizeNamespace(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
+	// check if the resource is a namespaced resource, namespaces that are already templated
+	// are configured watch namespaces
+	namespace := obj.GetNamespace()
+	if namespace == "" || strings.HasPrefix(namespace, "{{") {
+		return obj, nil, nil
+	}
+	obj.SetNamespace("{{ .Release.Namespace }}")
+	if isDeployment(obj) {
+		// an operator that watches its own namespace, watches the release namespace
+		targetNamespaces, found, err := unstructured.NestedString(obj.Object, "spec", "template", "metadata", "annotations", targetNamespacesAnnotation)
+		if err != nil {
+			return unstructured.Unstructured{}, nil, fmt.Errorf("failed to read target namespaces of deployment %s: %v", obj.GetName(), err)
+		}
+		if found {
+			parts := strings.Split(targetNamespaces, ",")
+			for i, part := range parts {
+				if part == namespace {
+					parts[i] = "{{ .Release.Namespace }}"
+				}
+			}
+			err = unstructured.SetNestedField(obj.Object, strings.Join(parts, ","), "spec", "template", "metadata", "annotations", targetNamespacesAnnotation)
+			if err != nil {
+				return unstructured.Unstructured{}, nil, fmt.Errorf("failed to set target namespaces of deployment %s: %v", obj.GetName(), err)
+			}
+		}
 	}
 	return obj, nil, nil
 }
 
-func parameterizeRoleBindingSubjectsNamespace(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]string, error) {
+func parameterizeRoleBindingSubjectsNamespace(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
 	if isRoleBinding(obj) {
 		roleBinding := &rbacv1.RoleBinding{}
 		err := convertFromUnstructured(obj, roleBinding)
 		if err != nil {
-			return unstructured.Unstructured{}, map[string]string{}, fmt.Errorf("failed to convert unstructured object to RoleBinding: %v", err)
+			return unstructured.Unstructured{}, nil, fmt.Errorf("failed to convert unstructured object to RoleBinding: %v", err)
 		}
 		for s, subject := range roleBinding.Subjects {
 			if subject.Kind == "ServiceAccount" {
@@ -85,7 +121,7 @@ func parameterizeRoleBindingSubjectsNamespace(obj unstructured.Unstructured) (un
 	return obj, nil, nil
 }
 
-func parameterizeClusterRoleBindingSubjectsNamespace(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]string, error) {
+func parameterizeClusterRoleBindingSubjectsNamespace(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
 	if isClusterRoleBinding(obj) {
 		clusterRoleBinding := &rbacv1.ClusterRoleBinding{}
 		err := convertFromUnstructured(obj, clusterRoleBinding)
@@ -103,8 +139,11 @@ func parameterizeClusterRoleBindingSubjectsNamespace(obj unstructured.Unstructur
 	return obj, nil, nil
 }
 
-func parameterizeOperandsImageRegistries(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]string, error) {
-	if isOperatorDeployment(obj) {
+func parameterizeOperandsImageRegistries(operator OperatorConfig, registryParamName string) Customizer {
+	return func(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
+		if !isOperatorDeployment(obj, operator) {
+			return obj, nil, nil
+		}
 		deployment := &appsv1.Deployment{}
 		err := convertFromUnstructured(obj, deployment)
 		if err != nil {
@@ -112,23 +151,25 @@ func parameterizeOperandsImageRegistries(obj unstructured.Unstructured) (unstruc
 		}
 		for c, container := range deployment.Spec.Template.Spec.Containers {
 			for e, env := range container.Env {
-				if isOperandImageEnvVar(env.Name) {
-					deployment.Spec.Template.Spec.Containers[c].Env[e].Value = parameterizeImageRegistry(env.Value, imageRegistryParamName)
+				if isOperandImageEnvVar(env.Name, operator) {
+					deployment.Spec.Template.Spec.Containers[c].Env[e].Value = parameterizeImageRegistry(env.Value, registryParamName)
 				}
 			}
 		}
 		modifiedObj, err := convertToUnstructured(deployment)
-		return modifiedObj, map[string]string{imageRegistryParamName: ""}, err
+		return modifiedObj, map[string]interface{}{registryParamName: ""}, err
 	}
-	return obj, nil, nil
 }
 
-func isOperandImageEnvVar(envVarName string) bool {
-	return strings.HasPrefix(envVarName, operandImageEnvVarPrefix)
+func isOperandImageEnvVar(envVarName string, operator OperatorConfig) bool {
+	return operator.OperandImageEnvVarPrefix != "" && strings.HasPrefix(envVarName, operator.OperandImageEnvVarPrefix)
 }
 
-func parameterizeDeployment(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]string, error) {
-	if isDeployment(obj) {
+func parameterizeDeployment(registryParamName string) Customizer {
+	return func(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
+		if !isDeployment(obj) {
+			return obj, nil, nil
+		}
 		deployment := &appsv1.Deployment{}
 		err := convertFromUnstructured(obj, deployment)
 		if err != nil {
@@ -136,15 +177,14 @@ func parameterizeDeployment(obj unstructured.Unstructured) (unstructured.Unstruc
 		}
 		// image registry
 		for c, container := range deployment.Spec.Template.Spec.Containers {
-			deployment.Spec.Template.Spec.Containers[c].Image = parameterizeImageRegistry(container.Image, imageRegistryParamName)
+			deployment.Spec.Template.Spec.Containers[c].Image = parameterizeImageRegistry(container.Image, registryParamName)
 		}
 		modifiedObj, err := convertToUnstructured(deployment)
-		return modifiedObj, map[string]string{imageRegistryParamName: ""}, err
+		return modifiedObj, map[string]interface{}{registryParamName: ""}, err
 	}
-	return obj, nil, nil
 }
 
-func annotationCleaner(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]string, error) {
+func annotationCleaner(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
 	annotationToScrape := []string{"openshift.io", "operatorframework.io", "olm", "alm-examples", "createdAt"}
 	annotations := obj.GetAnnotations()
 	for k := range annotations {
@@ -43,6 +43,28 @@ func TestParameterizeNamespaceNoNamespace(t *testing.T) {
 	assert.Nil(t, params)
 }
 
+func TestParameterizeNamespaceTargetNamespaces(t *testing.T) {
+	deployment := buildDeployment("test-deployment", "registry.io/test-image:abcdef", nil)
+	deployment.Namespace = "test-namespace"
+	deployment.Spec.Template.Annotations = map[string]string{targetNamespacesAnnotation: "test-namespace,other-namespace"}
+	obj, err := convertToUnstructured(deployment)
+	assert.Nil(t, err)
+
+	modifiedObj, _, err := parameterizeNamespace(obj)
+	assert.Nil(t, err)
+	targetNamespaces, _, err := unstructured.NestedString(modifiedObj.Object, "spec", "template", "metadata", "annotations", targetNamespacesAnnotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "{{ .Release.Namespace }},other-namespace", targetNamespaces)
+}
+
+func TestParameterizeNamespaceTemplated(t *testing.T) {
+	obj := unstructured.Unstructured{}
+	obj.SetNamespace("{{ .Values.watchNamespace }}")
+	modifiedObj, _, err := parameterizeNamespace(obj)
+	assert.Nil(t, err)
+	assert.Equal(t, "{{ .Values.watchNamespace }}", modifiedObj.GetNamespace())
+}
+
 func TestParameterizeRoleBindingSubjectsNamespace(t *testing.T) {
 	rb := &rbacv1.RoleBinding{
 		TypeMeta: metav1.TypeMeta{
@@ -162,7 +184,7 @@ func TestParameterizeOperandsImageRegistry(t *testing.T) {
 	obj, err := convertToUnstructured(deployment)
 	assert.Nil(t, err)
 
-	modifiedObj, params, err := parameterizeOperandsImageRegistries(obj)
+	modifiedObj, params, err := parameterizeOperandsImageRegistries(MulticlusterEngineOperator, imageRegistryParamName)(obj)
 	assert.Nil(t, err)
 	assert.NotNil(t, params)
 	_, imageRegistryParamExists := params[imageRegistryParamName]
@@ -208,7 +230,7 @@ func TestParameterizeDeploymentImage(t *testing.T) {
 	obj, err := convertToUnstructured(deployment)
 	assert.Nil(t, err)
 
-	modifiedObj, params, err := parameterizeDeployment(obj)
+	modifiedObj, params, err := parameterizeDeployment(imageRegistryParamName)(obj)
 	assert.Nil(t, err)
 	assert.NotNil(t, params)
 	_, imageRegistryParamExists := params[imageRegistryParamName]
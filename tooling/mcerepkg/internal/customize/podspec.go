@@ -0,0 +1,193 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customize
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// The customizers in this file replace fields of the pod template of deployments with values.
+// Structured fields like nodeSelector are replaced with a toYaml placeholder, that TemplatizeBlocks
+// turns into a template after the manifest is marshalled. They need to run after customizers that
+// convert the deployment into its typed form.
+
+// podTemplateOptions are the options of all pod template customizers
+type podTemplateOptions struct {
+	// Value is the key in values.yaml
+	Value string `json:"value"`
+	// Deployments limits the customizer to deployments with these names, all deployments if empty
+	Deployments []string `json:"deployments,omitempty"`
+}
+
+func (o podTemplateOptions) selects(obj unstructured.Unstructured) bool {
+	return isDeployment(obj) && (len(o.Deployments) == 0 || slices.Contains(o.Deployments, obj.GetName()))
+}
+
+func decodePodTemplateOptions(options map[string]interface{}, defaultValue string) (podTemplateOptions, error) {
+	opts := podTemplateOptions{Value: defaultValue}
+	if err := DecodeOptions(options, &opts); err != nil {
+		return opts, err
+	}
+	if opts.Value == "" {
+		return opts, fmt.Errorf("value must not be empty")
+	}
+	return opts, nil
+}
+
+// podSpec returns the pod spec of a deployment, changes to it modify the object
+func podSpec(obj unstructured.Unstructured) (map[string]interface{}, error) {
+	spec, found, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "template", "spec")
+	if err != nil || !found {
+		return nil, fmt.Errorf("deployment %s has no pod template", obj.GetName())
+	}
+	podSpec, ok := spec.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("deployment %s has an invalid pod template", obj.GetName())
+	}
+	return podSpec, nil
+}
+
+// podSpecFieldCustomizer replaces a field of the pod spec with a structured value
+func podSpecFieldCustomizer(field string, defaultValue interface{}, opts podTemplateOptions) Customizer {
+	return func(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
+		if !opts.selects(obj) {
+			return obj, nil, nil
+		}
+		spec, err := podSpec(obj)
+		if err != nil {
+			return unstructured.Unstructured{}, nil, err
+		}
+		spec[field] = toYamlPlaceholder(opts.Value)
+		return obj, map[string]interface{}{opts.Value: defaultValue}, nil
+	}
+}
+
+func newNodeSelectorCustomizer(_ OperatorConfig, options map[string]interface{}) (Customizer, error) {
+	opts, err := decodePodTemplateOptions(options, "nodeSelector")
+	if err != nil {
+		return nil, err
+	}
+	return podSpecFieldCustomizer("nodeSelector", map[string]interface{}{}, opts), nil
+}
+
+func newTolerationsCustomizer(_ OperatorConfig, options map[string]interface{}) (Customizer, error) {
+	opts, err := decodePodTemplateOptions(options, "tolerations")
+	if err != nil {
+		return nil, err
+	}
+	return podSpecFieldCustomizer("tolerations", []interface{}{}, opts), nil
+}
+
+// newResourcesCustomizer replaces the resources of every container with the value
+// <value>.<deployment>.<container>. The resources of the bundle are the default.
+func newResourcesCustomizer(_ OperatorConfig, options map[string]interface{}) (Customizer, error) {
+	opts, err := decodePodTemplateOptions(options, "resources")
+	if err != nil {
+		return nil, err
+	}
+	return func(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
+		if !opts.selects(obj) {
+			return obj, nil, nil
+		}
+		spec, err := podSpec(obj)
+		if err != nil {
+			return unstructured.Unstructured{}, nil, err
+		}
+		containers, _ := spec["containers"].([]interface{})
+		params := make(map[string]interface{})
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := container["name"].(string)
+			key := strings.Join([]string{opts.Value, obj.GetName(), name}, ".")
+			resources, ok := container["resources"].(map[string]interface{})
+			if !ok {
+				resources = map[string]interface{}{}
+			}
+			params[key] = resources
+			container["resources"] = toYamlPlaceholder(key)
+		}
+		return obj, params, nil
+	}, nil
+}
+
+type priorityClassOptions struct {
+	podTemplateOptions
+	// Default is the priority class used if the value is not set
+	Default string `json:"default,omitempty"`
+}
+
+func newPriorityClassCustomizer(_ OperatorConfig, options map[string]interface{}) (Customizer, error) {
+	opts := priorityClassOptions{podTemplateOptions: podTemplateOptions{Value: "priorityClassName"}}
+	if err := DecodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	if opts.Value == "" {
+		return nil, fmt.Errorf("value must not be empty")
+	}
+	return func(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
+		if !opts.selects(obj) {
+			return obj, nil, nil
+		}
+		spec, err := podSpec(obj)
+		if err != nil {
+			return unstructured.Unstructured{}, nil, err
+		}
+		spec["priorityClassName"] = fmt.Sprintf("{{ %s }}", valuesRef(opts.Value))
+		return obj, map[string]interface{}{opts.Value: opts.Default}, nil
+	}, nil
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// valuesRef references a dotted values key in a template, keys that are no identifiers,
+// i.e. deployment names, are accessed with index
+func valuesRef(key string) string {
+	parts := strings.Split(key, ".")
+	if !slices.ContainsFunc(parts, func(p string) bool { return !identifierPattern.MatchString(p) }) {
+		return ".Values." + key
+	}
+	quoted := make([]string, 0, len(parts))
+	for _, p := range parts {
+		quoted = append(quoted, strconv.Quote(p))
+	}
+	return fmt.Sprintf("(index .Values %s)", strings.Join(quoted, " "))
+}
+
+func toYamlPlaceholder(key string) string {
+	return fmt.Sprintf("{{ toYaml %s }}", valuesRef(key))
+}
+
+// placeholderPattern matches a marshalled toYaml placeholder, optionally as the first key of a list item
+var placeholderPattern = regexp.MustCompile(`(?m)^( *)(- )?([^\s:#'"][^:\n]*): '\{\{ toYaml (.+?) \}\}'$`)
+
+// TemplatizeBlocks replaces toYaml placeholders in a marshalled manifest with a template that
+// renders the value as a YAML block, indented below its key
+func TemplatizeBlocks(data []byte) []byte {
+	return placeholderPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := placeholderPattern.FindSubmatch(match)
+		indent, item, key, ref := string(groups[1]), string(groups[2]), string(groups[3]), string(groups[4])
+		keyColumn := len(indent) + len(item)
+		return []byte(fmt.Sprintf("%s%s%s: {{- toYaml %s | nindent %d }}", indent, item, key, ref, keyColumn+2))
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v3"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNodeSelectorCustomizer(t *testing.T) {
+	customizer, err := newNodeSelectorCustomizer(MulticlusterEngineOperator, map[string]interface{}{
+		"value":       "operator.nodeSelector",
+		"deployments": []interface{}{"selected"},
+	})
+	assert.Nil(t, err)
+
+	selected, err := convertToUnstructured(buildDeployment("selected", "registry.io/image:abcdef", nil))
+	assert.Nil(t, err)
+	modifiedObj, params, err := customizer(selected)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"operator.nodeSelector": map[string]interface{}{}}, params)
+	nodeSelector, _, _ := unstructured.NestedString(modifiedObj.Object, "spec", "template", "spec", "nodeSelector")
+	assert.Equal(t, "{{ toYaml .Values.operator.nodeSelector }}", nodeSelector)
+
+	other, err := convertToUnstructured(buildDeployment("other", "registry.io/image:abcdef", nil))
+	assert.Nil(t, err)
+	_, params, err = customizer(other)
+	assert.Nil(t, err)
+	assert.Nil(t, params)
+}
+
+func TestResourcesCustomizer(t *testing.T) {
+	customizer, err := newResourcesCustomizer(MulticlusterEngineOperator, nil)
+	assert.Nil(t, err)
+
+	deployment := buildDeployment("engine-operator", "registry.io/image:abcdef", nil)
+	deployment.Spec.Template.Spec.Containers[0].Resources = v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")},
+	}
+	obj, err := convertToUnstructured(deployment)
+	assert.Nil(t, err)
+
+	modifiedObj, params, err := customizer(obj)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"resources.engine-operator.main": map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": "100m"},
+		},
+	}, params)
+	containers, _, _ := unstructured.NestedSlice(modifiedObj.Object, "spec", "template", "spec", "containers")
+	assert.Equal(t, `{{ toYaml (index .Values "resources" "engine-operator" "main") }}`, containers[0].(map[string]interface{})["resources"])
+}
+
+func TestPriorityClassCustomizer(t *testing.T) {
+	customizer, err := newPriorityClassCustomizer(MulticlusterEngineOperator, map[string]interface{}{"default": "system-cluster-critical"})
+	assert.Nil(t, err)
+
+	obj, err := convertToUnstructured(buildDeployment("engine-operator", "registry.io/image:abcdef", nil))
+	assert.Nil(t, err)
+	modifiedObj, params, err := customizer(obj)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"priorityClassName": "system-cluster-critical"}, params)
+	priorityClassName, _, _ := unstructured.NestedString(modifiedObj.Object, "spec", "template", "spec", "priorityClassName")
+	assert.Equal(t, "{{ .Values.priorityClassName }}", priorityClassName)
+}
+
+func TestPodTemplateCustomizerInvalidOptions(t *testing.T) {
+	_, err := newTolerationsCustomizer(MulticlusterEngineOperator, map[string]interface{}{"key": "tolerations"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown field \"key\"")
+
+	_, err = newTolerationsCustomizer(MulticlusterEngineOperator, map[string]interface{}{"value": ""})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "value must not be empty")
+}
+
+func TestTemplatizeBlocks(t *testing.T) {
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"resources": toYamlPlaceholder("resources.engine-operator.main"),
+							"name":      "main",
+						},
+					},
+					"nodeSelector": toYamlPlaceholder("nodeSelector"),
+				},
+			},
+		},
+	}
+	data, err := yaml.Marshal(obj)
+	assert.Nil(t, err)
+	assert.Equal(t, `spec:
+    template:
+        spec:
+            containers:
+                - name: main
+                  resources: {{- toYaml (index .Values "resources" "engine-operator" "main") | nindent 20 }}
+            nodeSelector: {{- toYaml .Values.nodeSelector | nindent 14 }}
+`, string(TemplatizeBlocks(data)))
+}
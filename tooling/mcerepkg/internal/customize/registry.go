@@ -0,0 +1,145 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customize
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// CustomizerConfig selects a customizer by name and configures it
+type CustomizerConfig struct {
+	Name    string                 `json:"name"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// CustomizerFactory creates a customizer for the operator of a bundle from the options in the config file
+type CustomizerFactory func(operator OperatorConfig, options map[string]interface{}) (Customizer, error)
+
+var customizerFactories = map[string]CustomizerFactory{
+	"namespace":                           staticCustomizer(parameterizeNamespace),
+	"roleBindingSubjectsNamespace":        staticCustomizer(parameterizeRoleBindingSubjectsNamespace),
+	"clusterRoleBindingSubjectsNamespace": staticCustomizer(parameterizeClusterRoleBindingSubjectsNamespace),
+	"operandImageRegistries":              newOperandImageRegistriesCustomizer,
+	"deploymentImageRegistries":           newDeploymentImageRegistriesCustomizer,
+	"annotationCleaner":                   staticCustomizer(annotationCleaner),
+	"nodeSelector":                        newNodeSelectorCustomizer,
+	"tolerations":                         newTolerationsCustomizer,
+	"resources":                           newResourcesCustomizer,
+	"priorityClass":                       newPriorityClassCustomizer,
+	"webhookCertificates":                 staticCustomizer(webhookCertificates),
+	"relatedImages":                       newRelatedImagesCustomizer,
+}
+
+// DefaultCustomizers are applied if the config file selects no customizers
+var DefaultCustomizers = []CustomizerConfig{
+	{Name: "namespace"},
+	{Name: "roleBindingSubjectsNamespace"},
+	{Name: "clusterRoleBindingSubjectsNamespace"},
+	{Name: "operandImageRegistries"},
+	{Name: "deploymentImageRegistries"},
+	{Name: "annotationCleaner"},
+	{Name: "webhookCertificates"},
+}
+
+// RegisterCustomizer makes a customizer available to config files. An existing customizer with
+// the same name is replaced.
+func RegisterCustomizer(name string, factory CustomizerFactory) {
+	customizerFactories[name] = factory
+}
+
+// NewCustomizers creates the configured customizers in the configured order
+func NewCustomizers(operator OperatorConfig, configs []CustomizerConfig) ([]Customizer, error) {
+	if len(configs) == 0 {
+		configs = DefaultCustomizers
+	}
+	customizers := make([]Customizer, 0, len(configs))
+	for _, config := range configs {
+		factory, ok := customizerFactories[config.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown customizer %q, available customizers: %v", config.Name, customizerNames())
+		}
+		customizer, err := factory(operator, config.Options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create customizer %q: %v", config.Name, err)
+		}
+		customizers = append(customizers, customizer)
+	}
+	return customizers, nil
+}
+
+func customizerNames() []string {
+	names := make([]string, 0, len(customizerFactories))
+	for name := range customizerFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func staticCustomizer(customizer Customizer) CustomizerFactory {
+	return func(_ OperatorConfig, _ map[string]interface{}) (Customizer, error) {
+		return customizer, nil
+	}
+}
+
+// DecodeOptions decodes the options of a customizer into a struct with json tags. Unknown options
+// are rejected.
+func DecodeOptions(options map[string]interface{}, into interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("failed to marshal options: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(into); err != nil {
+		return fmt.Errorf("invalid options: %v", err)
+	}
+	return nil
+}
+
+type imageRegistryOptions struct {
+	// Value is the key in values.yaml that replaces the registry of the images
+	Value string `json:"value"`
+}
+
+func decodeImageRegistryOptions(options map[string]interface{}) (string, error) {
+	opts := imageRegistryOptions{Value: imageRegistryParamName}
+	if err := DecodeOptions(options, &opts); err != nil {
+		return "", err
+	}
+	return opts.Value, nil
+}
+
+func newOperandImageRegistriesCustomizer(operator OperatorConfig, options map[string]interface{}) (Customizer, error) {
+	value, err := decodeImageRegistryOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return parameterizeOperandsImageRegistries(operator, value), nil
+}
+
+func newDeploymentImageRegistriesCustomizer(_ OperatorConfig, options map[string]interface{}) (Customizer, error) {
+	value, err := decodeImageRegistryOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return parameterizeDeployment(value), nil
+}
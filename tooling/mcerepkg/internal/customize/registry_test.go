@@ -0,0 +1,70 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNewCustomizersDefaults(t *testing.T) {
+	customizers, err := NewCustomizers(MulticlusterEngineOperator, nil)
+	assert.Nil(t, err)
+	assert.Len(t, customizers, len(DefaultCustomizers))
+
+	deployment, err := convertToUnstructured(buildMulticlusterEngineDeployment())
+	assert.Nil(t, err)
+	_, values, err := CustomizeManifests([]unstructured.Unstructured{deployment}, customizers)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{imageRegistryParamName: ""}, values)
+}
+
+func TestNewCustomizersUnknown(t *testing.T) {
+	_, err := NewCustomizers(MulticlusterEngineOperator, []CustomizerConfig{{Name: "unknown"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown customizer \"unknown\"")
+}
+
+func TestRegisterCustomizer(t *testing.T) {
+	RegisterCustomizer("label", func(_ OperatorConfig, options map[string]interface{}) (Customizer, error) {
+		opts := struct {
+			Key string `json:"key"`
+		}{}
+		if err := DecodeOptions(options, &opts); err != nil {
+			return nil, err
+		}
+		return func(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
+			obj.SetLabels(map[string]string{opts.Key: "{{ .Values.team }}"})
+			return obj, map[string]interface{}{"team": "hcp"}, nil
+		}, nil
+	})
+	defer delete(customizerFactories, "label")
+
+	customizers, err := NewCustomizers(MulticlusterEngineOperator, []CustomizerConfig{
+		{Name: "namespace"},
+		{Name: "label", Options: map[string]interface{}{"key": "owner"}},
+	})
+	assert.Nil(t, err)
+
+	obj := unstructured.Unstructured{}
+	obj.SetNamespace("test-namespace")
+	manifests, values, err := CustomizeManifests([]unstructured.Unstructured{obj}, customizers)
+	assert.Nil(t, err)
+	assert.Equal(t, "{{ .Release.Namespace }}", manifests[0].GetNamespace())
+	assert.Equal(t, map[string]string{"owner": "{{ .Values.team }}"}, manifests[0].GetLabels())
+	assert.Equal(t, map[string]interface{}{"team": "hcp"}, values)
+}
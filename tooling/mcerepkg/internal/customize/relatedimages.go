@@ -0,0 +1,101 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customize
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type relatedImagesOptions struct {
+	// Value is the key in values.yaml that holds the related images by name
+	Value string `json:"value"`
+	// RegistryValue is the key in values.yaml of the image registry, like for the image registry
+	// customizers. If set, the related images in values.yaml are without their registry.
+	RegistryValue string `json:"registryValue,omitempty"`
+}
+
+// newRelatedImagesCustomizer replaces the related images of the bundle in the containers and env
+// vars of all deployments with a value per image, defaulting to the image of the bundle. It needs
+// to run before the image registry customizers, which leave the templated images alone.
+func newRelatedImagesCustomizer(operator OperatorConfig, options map[string]interface{}) (Customizer, error) {
+	opts := relatedImagesOptions{Value: "relatedImages"}
+	if err := DecodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	if opts.Value == "" {
+		return nil, fmt.Errorf("value must not be empty")
+	}
+
+	// image -> values key
+	keys := make(map[string]string, len(operator.RelatedImages))
+	for _, relatedImage := range operator.RelatedImages {
+		if _, ok := keys[relatedImage.Image]; !ok {
+			// dots would nest the values
+			keys[relatedImage.Image] = opts.Value + "." + strings.ReplaceAll(relatedImage.Name, ".", "-")
+		}
+	}
+
+	return func(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
+		if !isDeployment(obj) {
+			return obj, nil, nil
+		}
+		spec, err := podSpec(obj)
+		if err != nil {
+			return unstructured.Unstructured{}, nil, err
+		}
+		params := make(map[string]interface{})
+		parameterize := func(image string) string {
+			key, ok := keys[image]
+			if !ok {
+				return image
+			}
+			if opts.RegistryValue == "" {
+				params[key] = image
+				return fmt.Sprintf("{{ %s }}", valuesRef(key))
+			}
+			_, path, _ := strings.Cut(image, "/")
+			params[opts.RegistryValue] = ""
+			params[key] = path
+			return fmt.Sprintf("{{ %s }}/{{ %s }}", valuesRef(opts.RegistryValue), valuesRef(key))
+		}
+
+		for _, field := range []string{"initContainers", "containers"} {
+			containers, _ := spec[field].([]interface{})
+			for _, c := range containers {
+				container, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if image, ok := container["image"].(string); ok {
+					container["image"] = parameterize(image)
+				}
+				env, _ := container["env"].([]interface{})
+				for _, e := range env {
+					envVar, ok := e.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					if value, ok := envVar["value"].(string); ok {
+						envVar["value"] = parameterize(value)
+					}
+				}
+			}
+		}
+		return obj, params, nil
+	}, nil
+}
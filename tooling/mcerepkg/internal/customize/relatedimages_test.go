@@ -0,0 +1,84 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customize
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func relatedImagesOperator() OperatorConfig {
+	operator := MulticlusterEngineOperator
+	operator.RelatedImages = []v1alpha1.RelatedImage{
+		{Name: "operator", Image: "registry.io/test-image:abcdef"},
+		{Name: "operand-1", Image: "registry.io/operand-image-1:abcdef"},
+	}
+	return operator
+}
+
+func TestRelatedImagesCustomizer(t *testing.T) {
+	customizers, err := NewCustomizers(relatedImagesOperator(), []CustomizerConfig{
+		{Name: "relatedImages"},
+		{Name: "operandImageRegistries"},
+		{Name: "deploymentImageRegistries"},
+	})
+	assert.Nil(t, err)
+
+	obj, err := convertToUnstructured(buildMulticlusterEngineDeployment())
+	assert.Nil(t, err)
+	manifests, values, err := CustomizeManifests([]unstructured.Unstructured{obj}, customizers)
+	assert.Nil(t, err)
+
+	deployment := &appsv1.Deployment{}
+	assert.Nil(t, convertFromUnstructured(manifests[0], deployment))
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "{{ .Values.relatedImages.operator }}", container.Image)
+	assert.Equal(t, `{{ (index .Values "relatedImages" "operand-1") }}`, container.Env[0].Value)
+	assert.Equal(t, "{{ .Values.imageRegistry }}/operand-image-2:abcdef", container.Env[1].Value)
+	assert.Equal(t, map[string]interface{}{
+		"imageRegistry": "",
+		"relatedImages": map[string]interface{}{
+			"operator":  "registry.io/test-image:abcdef",
+			"operand-1": "registry.io/operand-image-1:abcdef",
+		},
+	}, values)
+}
+
+func TestRelatedImagesCustomizerRegistryValue(t *testing.T) {
+	customizers, err := NewCustomizers(relatedImagesOperator(), []CustomizerConfig{
+		{Name: "relatedImages", Options: map[string]interface{}{"value": "images", "registryValue": "imageRegistry"}},
+	})
+	assert.Nil(t, err)
+
+	obj, err := convertToUnstructured(buildMulticlusterEngineDeployment())
+	assert.Nil(t, err)
+	manifests, values, err := CustomizeManifests([]unstructured.Unstructured{obj}, customizers)
+	assert.Nil(t, err)
+
+	deployment := &appsv1.Deployment{}
+	assert.Nil(t, convertFromUnstructured(manifests[0], deployment))
+	assert.Equal(t, "{{ .Values.imageRegistry }}/{{ .Values.images.operator }}", deployment.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, map[string]interface{}{
+		"imageRegistry": "",
+		"images": map[string]interface{}{
+			"operator":  "test-image:abcdef",
+			"operand-1": "operand-image-1:abcdef",
+		},
+	}, values)
+}
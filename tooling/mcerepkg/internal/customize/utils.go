@@ -41,11 +41,15 @@ func convertMapToUnstructured(objMap map[string]interface{}) unstructured.Unstru
 }
 
 func parameterizeImageRegistry(imageRef string, registryParamName string) string {
+	// images that are already templated, i.e. related images, are left alone
+	if strings.HasPrefix(imageRef, "{{") {
+		return imageRef
+	}
 	registry := strings.Split(imageRef, "/")[0]
 	return fmt.Sprintf("{{ .Values.%s }}%s", registryParamName, imageRef[len(registry):])
 }
 
-func makeNestedMap(flatMap map[string]string) map[string]interface{} {
+func makeNestedMap(flatMap map[string]interface{}) map[string]interface{} {
 	nestedMap := make(map[string]interface{})
 
 	for key, value := range flatMap {
@@ -78,8 +82,8 @@ func isDeployment(obj unstructured.Unstructured) bool {
 	return obj.GroupVersionKind() == deploymentGVK
 }
 
-func isOperatorDeployment(obj unstructured.Unstructured) bool {
-	return isDeployment(obj) && obj.GetName() == mceOperatorDeploymentName
+func isOperatorDeployment(obj unstructured.Unstructured, operator OperatorConfig) bool {
+	return isDeployment(obj) && obj.GetName() == operator.Deployment
 }
 
 func isRoleBinding(obj unstructured.Unstructured) bool {
@@ -21,20 +21,25 @@ import (
 	"k8s.io/apimachinery/pkg/util/errors"
 )
 
-func SanityCheck(objects []unstructured.Unstructured) error {
+// SanityCheck verifies that the bundle contains the operator deployment and that it references
+// operand images, if the operator has an operand image env var prefix
+func SanityCheck(objects []unstructured.Unstructured, operator OperatorConfig) error {
 	var errs []error
 	operatorDeploymentFound := false
 	for _, obj := range objects {
-		if isOperatorDeployment(obj) {
+		if isOperatorDeployment(obj, operator) {
 			deployment, err := deploymentFromUnstructured(obj)
 			if err != nil {
 				errs = append(errs, fmt.Errorf("deployment is invalid: %v", err))
 			}
 			operatorDeploymentFound = true
+			if operator.OperandImageEnvVarPrefix == "" {
+				continue
+			}
 			operandImageEnvVarsFound := false
 			for _, container := range deployment.Spec.Template.Spec.Containers {
 				for _, envVar := range container.Env {
-					if isOperandImageEnvVar(envVar.Name) {
+					if isOperandImageEnvVar(envVar.Name, operator) {
 						operandImageEnvVarsFound = true
 						break
 					}
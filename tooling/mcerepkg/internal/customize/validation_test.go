@@ -25,7 +25,7 @@ func TestSanityCheckOperatorDeployment(t *testing.T) {
 	deployment, err := convertToUnstructured(buildMulticlusterEngineDeployment())
 	assert.Nil(t, err)
 
-	err = SanityCheck([]unstructured.Unstructured{deployment})
+	err = SanityCheck([]unstructured.Unstructured{deployment}, MulticlusterEngineOperator)
 	assert.Nil(t, err)
 }
 
@@ -33,7 +33,7 @@ func TestSanityCheckNoOperatorDeployment(t *testing.T) {
 	deployment, err := convertToUnstructured(buildDeployment("some-deployment", "some-image", nil))
 	assert.Nil(t, err)
 
-	err = SanityCheck([]unstructured.Unstructured{deployment})
+	err = SanityCheck([]unstructured.Unstructured{deployment}, MulticlusterEngineOperator)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no operator deployment found in the bundle")
 }
@@ -44,7 +44,16 @@ func TestSanityCheckOperatorDeploymentNoOperandEnvVars(t *testing.T) {
 	obj, err := convertToUnstructured(deployment)
 	assert.Nil(t, err)
 
-	err = SanityCheck([]unstructured.Unstructured{obj})
+	err = SanityCheck([]unstructured.Unstructured{obj}, MulticlusterEngineOperator)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no operand image env vars found in the operator deployment")
 }
+
+func TestSanityCheckOperatorWithoutOperandImages(t *testing.T) {
+	deployment := buildDeployment("other-operator", "registry.io/other-operator:abcdef", nil)
+	obj, err := convertToUnstructured(deployment)
+	assert.Nil(t, err)
+
+	err = SanityCheck([]unstructured.Unstructured{obj}, OperatorConfig{Deployment: "other-operator"})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customize
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Azure/ARO-HCP/tooling/mcerepkg/internal/rukpak/convert"
+)
+
+const caCertKey = "ca.crt"
+
+// WebhookCertificatesTemplateFile is the chart file with the named template that generates the
+// serving certificates of webhook services
+const WebhookCertificatesTemplateFile = "templates/_webhook-certificates.tpl"
+
+// WebhookCertificatesTemplate generates a CA and a serving certificate per webhook service. Every
+// template that includes it renders the same certificate, because it is stored in .Values for the
+// rest of the rendering. The certificate of an installed release is reused, so upgrades don't
+// rotate it.
+const WebhookCertificatesTemplate = `{{/*
+webhookCertificate returns a base64 encoded field of the serving certificate of a webhook service:
+ca.crt, tls.crt or tls.key.
+Arguments: the root context, the name of the service, the name of the certificate secret and the field.
+*/}}
+{{- define "webhookCertificate" -}}
+{{- $root := index . 0 -}}
+{{- $service := index . 1 -}}
+{{- $secretName := index . 2 -}}
+{{- if not (hasKey $root.Values "_webhookCertificates") -}}
+{{- $_ := set $root.Values "_webhookCertificates" (dict) -}}
+{{- end -}}
+{{- $certificates := index $root.Values "_webhookCertificates" -}}
+{{- if not (hasKey $certificates $service) -}}
+{{- $secret := lookup "v1" "Secret" $root.Release.Namespace $secretName -}}
+{{- $data := dict -}}
+{{- if $secret -}}
+{{- $data = $secret.data | default (dict) -}}
+{{- end -}}
+{{- if not (and (index $data "ca.crt") (index $data "tls.crt") (index $data "tls.key")) -}}
+{{- $hostname := printf "%s.%s.svc" $service $root.Release.Namespace -}}
+{{- $ca := genCA (printf "%s-ca" $service) 3650 -}}
+{{- $cert := genSignedCert $hostname nil (list $service $hostname (printf "%s.cluster.local" $hostname)) 3650 $ca -}}
+{{- $data = dict "ca.crt" ($ca.Cert | b64enc) "tls.crt" ($cert.Cert | b64enc) "tls.key" ($cert.Key | b64enc) -}}
+{{- end -}}
+{{- $_ := set $certificates $service $data -}}
+{{- end -}}
+{{- index $certificates $service (index . 3) -}}
+{{- end -}}
+`
+
+// webhookCertificates fills the certificate secrets and the caBundles of the webhook configurations
+// and conversion webhooks created for the webhookDefinitions of the bundle with a certificate that
+// is generated at install time. The services of the webhooks are moved to the release namespace.
+func webhookCertificates(obj unstructured.Unstructured) (unstructured.Unstructured, map[string]interface{}, error) {
+	service, ok := obj.GetAnnotations()[convert.WebhookServiceAnnotation]
+	if !ok {
+		return obj, nil, nil
+	}
+	secretName := convert.WebhookCertSecretName(service)
+	certificateField := func(field string) string {
+		return fmt.Sprintf(`{{ include "webhookCertificate" (list $ %q %q %q) }}`, service, secretName, field)
+	}
+
+	switch obj.GetKind() {
+	case "Secret":
+		data := map[string]interface{}{}
+		for _, field := range []string{caCertKey, corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+			data[field] = certificateField(field)
+		}
+		if err := unstructured.SetNestedMap(obj.Object, data, "data"); err != nil {
+			return unstructured.Unstructured{}, nil, fmt.Errorf("failed to set certificate of secret %s: %v", obj.GetName(), err)
+		}
+	case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+		webhooks, _, err := unstructured.NestedSlice(obj.Object, "webhooks")
+		if err != nil {
+			return unstructured.Unstructured{}, nil, fmt.Errorf("failed to read webhooks of %s: %v", obj.GetName(), err)
+		}
+		for _, w := range webhooks {
+			webhook, ok := w.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			namespace, err := setClientConfig(webhook, certificateField(caCertKey), "clientConfig")
+			if err != nil {
+				return unstructured.Unstructured{}, nil, fmt.Errorf("failed to set client config of %s: %v", obj.GetName(), err)
+			}
+			releaseNamespaceSelector(webhook, namespace)
+		}
+		if err := unstructured.SetNestedSlice(obj.Object, webhooks, "webhooks"); err != nil {
+			return unstructured.Unstructured{}, nil, fmt.Errorf("failed to set webhooks of %s: %v", obj.GetName(), err)
+		}
+	case "CustomResourceDefinition":
+		if _, err := setClientConfig(obj.Object, certificateField(caCertKey), "spec", "conversion", "webhook", "clientConfig"); err != nil {
+			return unstructured.Unstructured{}, nil, fmt.Errorf("failed to set conversion webhook of %s: %v", obj.GetName(), err)
+		}
+	}
+	return obj, nil, nil
+}
+
+// setClientConfig sets the caBundle of a webhook client config, moves its service to the release
+// namespace and returns the namespace of the service in the bundle
+func setClientConfig(obj map[string]interface{}, caBundle string, fields ...string) (string, error) {
+	clientConfig, found, err := unstructured.NestedMap(obj, fields...)
+	if err != nil || !found {
+		return "", fmt.Errorf("%v has no client config", fields)
+	}
+	clientConfig["caBundle"] = caBundle
+	namespace, _, _ := unstructured.NestedString(clientConfig, "service", "namespace")
+	if namespace != "" {
+		if err := unstructured.SetNestedField(clientConfig, "{{ .Release.Namespace }}", "service", "namespace"); err != nil {
+			return "", err
+		}
+	}
+	return namespace, unstructured.SetNestedMap(obj, clientConfig, fields...)
+}
+
+// releaseNamespaceSelector replaces the install namespace of the bundle in the namespace selector of
+// an operator that watches its own namespace with the release namespace
+func releaseNamespaceSelector(webhook map[string]interface{}, namespace string) {
+	expressions, _, _ := unstructured.NestedSlice(webhook, "namespaceSelector", "matchExpressions")
+	for _, e := range expressions {
+		expression, ok := e.(map[string]interface{})
+		if !ok || expression["key"] != corev1.LabelMetadataName {
+			continue
+		}
+		values, _ := expression["values"].([]interface{})
+		for i, value := range values {
+			if value == namespace {
+				values[i] = "{{ .Release.Namespace }}"
+			}
+		}
+	}
+	if len(expressions) > 0 {
+		_ = unstructured.SetNestedSlice(webhook, expressions, "namespaceSelector", "matchExpressions")
+	}
+}
+
+// NeedsWebhookCertificates reports whether the manifests use WebhookCertificatesTemplate
+func NeedsWebhookCertificates(objects []unstructured.Unstructured) bool {
+	for _, obj := range objects {
+		if _, ok := obj.GetAnnotations()[convert.WebhookServiceAnnotation]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Azure/ARO-HCP/tooling/mcerepkg/internal/rukpak/convert"
+)
+
+const testCABundle = `{{ include "webhookCertificate" (list $ "engine-service" "engine-service-cert" "ca.crt") }}`
+
+func webhookObjectMeta(name, namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Annotations: map[string]string{convert.WebhookServiceAnnotation: "engine-service"},
+	}
+}
+
+func TestWebhookCertificatesSecret(t *testing.T) {
+	obj, err := convertToUnstructured(&v1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: webhookObjectMeta("engine-service-cert", "engine"),
+		Type:       v1.SecretTypeTLS,
+	})
+	assert.Nil(t, err)
+
+	modifiedObj, params, err := webhookCertificates(obj)
+	assert.Nil(t, err)
+	assert.Nil(t, params)
+	data, _, err := unstructured.NestedStringMap(modifiedObj.Object, "data")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"ca.crt":  testCABundle,
+		"tls.crt": `{{ include "webhookCertificate" (list $ "engine-service" "engine-service-cert" "tls.crt") }}`,
+		"tls.key": `{{ include "webhookCertificate" (list $ "engine-service" "engine-service-cert" "tls.key") }}`,
+	}, data)
+}
+
+func TestWebhookCertificatesWebhookConfiguration(t *testing.T) {
+	obj, err := convertToUnstructured(&admissionregistrationv1.ValidatingWebhookConfiguration{
+		TypeMeta:   metav1.TypeMeta{Kind: "ValidatingWebhookConfiguration", APIVersion: admissionregistrationv1.SchemeGroupVersion.String()},
+		ObjectMeta: webhookObjectMeta("vengine.example.com", ""),
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+			Name: "vengine.example.com",
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{Namespace: "engine", Name: "engine-service"},
+			},
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      v1.LabelMetadataName,
+					Operator: metav1.LabelSelectorOpIn,
+					Values:   []string{"engine"},
+				}},
+			},
+		}},
+	})
+	assert.Nil(t, err)
+
+	modifiedObj, _, err := webhookCertificates(obj)
+	assert.Nil(t, err)
+	webhooks, _, err := unstructured.NestedSlice(modifiedObj.Object, "webhooks")
+	assert.Nil(t, err)
+	webhook := webhooks[0].(map[string]interface{})
+	caBundle, _, _ := unstructured.NestedString(webhook, "clientConfig", "caBundle")
+	assert.Equal(t, testCABundle, caBundle)
+	namespace, _, _ := unstructured.NestedString(webhook, "clientConfig", "service", "namespace")
+	assert.Equal(t, "{{ .Release.Namespace }}", namespace)
+	expressions, _, _ := unstructured.NestedSlice(webhook, "namespaceSelector", "matchExpressions")
+	assert.Equal(t, []interface{}{"{{ .Release.Namespace }}"}, expressions[0].(map[string]interface{})["values"])
+}
+
+func TestWebhookCertificatesConversion(t *testing.T) {
+	obj, err := convertToUnstructured(&apiextensionsv1.CustomResourceDefinition{
+		TypeMeta:   metav1.TypeMeta{Kind: "CustomResourceDefinition", APIVersion: apiextensionsv1.SchemeGroupVersion.String()},
+		ObjectMeta: webhookObjectMeta("engines.example.com", ""),
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook: &apiextensionsv1.WebhookConversion{
+					ClientConfig: &apiextensionsv1.WebhookClientConfig{
+						Service: &apiextensionsv1.ServiceReference{Namespace: "engine", Name: "engine-service"},
+					},
+				},
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	modifiedObj, _, err := webhookCertificates(obj)
+	assert.Nil(t, err)
+	caBundle, _, _ := unstructured.NestedString(modifiedObj.Object, "spec", "conversion", "webhook", "clientConfig", "caBundle")
+	assert.Equal(t, testCABundle, caBundle)
+	namespace, _, _ := unstructured.NestedString(modifiedObj.Object, "spec", "conversion", "webhook", "clientConfig", "service", "namespace")
+	assert.Equal(t, "{{ .Release.Namespace }}", namespace)
+	assert.True(t, NeedsWebhookCertificates([]unstructured.Unstructured{modifiedObj}))
+}
+
+func TestWebhookCertificatesIgnoresOtherObjects(t *testing.T) {
+	obj, err := convertToUnstructured(buildMulticlusterEngineDeployment())
+	assert.Nil(t, err)
+
+	modifiedObj, _, err := webhookCertificates(obj)
+	assert.Nil(t, err)
+	assert.Equal(t, obj, modifiedObj)
+	assert.False(t, NeedsWebhookCertificates([]unstructured.Unstructured{modifiedObj}))
+}
@@ -37,7 +37,9 @@ const convertedManifestFile = "manifests/manifest.yaml"
 
 // ExtractOLMBundleImage takes an OLM registry v1 bundle OCI,
 // converts it into static manifests and returns a list all objects contained.
-func ExtractOLMBundleImage(_ context.Context, image containerregistrypkgv1.Image) (
+// The operator watches the given namespaces, an empty list selects all namespaces
+// or the install namespace, depending on the install modes of the bundle.
+func ExtractOLMBundleImage(_ context.Context, image containerregistrypkgv1.Image, watchNamespaces []string) (
 	objects []unstructured.Unstructured, reg convert.RegistryV1, err error,
 ) {
 	rawFS := fstest.MapFS{}
@@ -78,7 +80,7 @@ func ExtractOLMBundleImage(_ context.Context, image containerregistrypkgv1.Image
 		return nil, reg, fmt.Errorf("package image contains no files. Might be corrupted")
 	}
 
-	convertedFS, reg, err := convert.RegistryV1ToPlain(rawFS, "", nil)
+	convertedFS, reg, err := convert.RegistryV1ToPlain(rawFS, "", watchNamespaces)
 	if err != nil {
 		return nil, reg, fmt.Errorf("converting OLM Bundle to static manifests: %w", err)
 	}
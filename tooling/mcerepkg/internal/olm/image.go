@@ -0,0 +1,49 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package olm
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	containerregistrypkgv1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// bundlePlatform is pulled if a bundle image reference points to an index
+var bundlePlatform = &containerregistrypkgv1.Platform{OS: "linux", Architecture: "amd64"}
+
+// LoadBundleImage loads an OLM bundle image from a tarball, as written by podman save, or pulls
+// it from a registry if no such file exists. Registry credentials are read from the docker config.
+func LoadBundleImage(ctx context.Context, bundle string) (containerregistrypkgv1.Image, error) {
+	if _, err := os.Stat(bundle); err == nil {
+		img, err := crane.Load(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load bundle image from %s: %w", bundle, err)
+		}
+		return img, nil
+	}
+	img, err := crane.Pull(bundle,
+		crane.WithContext(ctx),
+		crane.WithAuthFromKeychain(authn.DefaultKeychain),
+		crane.WithPlatform(bundlePlatform),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull bundle image %s: %w", bundle, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package olm
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadBundleImageFromTarball(t *testing.T) {
+	img, err := random.Image(1024, 1)
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "bundle.tgz")
+	assert.Nil(t, crane.Save(img, "example.com/bundle:v1", path))
+
+	loaded, err := LoadBundleImage(context.Background(), path)
+	assert.Nil(t, err)
+	expected, err := img.Digest()
+	assert.Nil(t, err)
+	actual, err := loaded.Digest()
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestLoadBundleImageFromRegistry(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	assert.Nil(t, err)
+	ref := u.Host + "/bundle:v1"
+
+	img, err := random.Image(1024, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, crane.Push(img, ref))
+
+	loaded, err := LoadBundleImage(context.Background(), ref)
+	assert.Nil(t, err)
+	expected, err := img.Digest()
+	assert.Nil(t, err)
+	actual, err := loaded.Digest()
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+}
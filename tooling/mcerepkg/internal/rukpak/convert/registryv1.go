@@ -21,6 +21,7 @@ import (
 	"io"
 	"io/fs"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing/fstest"
 	"time"
@@ -214,15 +215,14 @@ func Convert(in RegistryV1, installNamespace string, targetNamespaces []string)
 		return nil, fmt.Errorf("apiServiceDefintions are not supported")
 	}
 
-	if len(in.CSV.Spec.WebhookDefinitions) > 0 {
-		return nil, fmt.Errorf("webhookDefinitions are not supported")
-	}
-
 	deployments := []appsv1.Deployment{}
 	serviceAccounts := map[string]corev1.ServiceAccount{}
 	for _, depSpec := range in.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
-		annotations := util.MergeMaps(in.CSV.Annotations, depSpec.Spec.Template.Annotations)
-		annotations["olm.targetNamespaces"] = strings.Join(targetNamespaces, ",")
+		targetNamespacesAnnotation := map[string]string{"olm.targetNamespaces": strings.Join(targetNamespaces, ",")}
+		annotations := util.MergeMaps(in.CSV.Annotations, depSpec.Spec.Template.Annotations, targetNamespacesAnnotation)
+		spec := depSpec.Spec.DeepCopy()
+		// operators read their target namespaces from the pod annotation with the downward API
+		spec.Template.Annotations = util.MergeMaps(spec.Template.Annotations, targetNamespacesAnnotation)
 		deployments = append(deployments, appsv1.Deployment{
 			TypeMeta: metav1.TypeMeta{
 				Kind:       "Deployment",
@@ -235,7 +235,7 @@ func Convert(in RegistryV1, installNamespace string, targetNamespaces []string)
 				Labels:      depSpec.Label,
 				Annotations: annotations,
 			},
-			Spec: depSpec.Spec,
+			Spec: *spec,
 		})
 		saName := saNameOrDefault(depSpec.Spec.Template.Spec.ServiceAccountName)
 		serviceAccounts[saName] = newServiceAccount(installNamespace, saName)
@@ -268,9 +268,10 @@ func Convert(in RegistryV1, installNamespace string, targetNamespaces []string)
 	}
 
 	// If we're in AllNamespaces mode, promote the permissions to clusterPermissions
-	if len(targetNamespaces) == 1 && targetNamespaces[0] == "" {
-		for _, p := range permissions {
-			p.Rules = append(p.Rules, rbacv1.PolicyRule{
+	if allNamespaces(targetNamespaces) {
+		permissions = slices.Clone(permissions)
+		for i, p := range permissions {
+			permissions[i].Rules = append(slices.Clone(p.Rules), rbacv1.PolicyRule{
 				Verbs:     []string{"get", "list", "watch"},
 				APIGroups: []string{corev1.GroupName},
 				Resources: []string{"namespaces"},
@@ -302,6 +303,12 @@ func Convert(in RegistryV1, installNamespace string, targetNamespaces []string)
 		clusterRoleBindings = append(clusterRoleBindings, newClusterRoleBinding(name, name, installNamespace, saName))
 	}
 
+	crds := slices.Clone(in.CRDs)
+	webhookObjs, err := convertWebhooks(in.CSV.Spec.WebhookDefinitions, installNamespace, targetNamespaces, deployments, crds)
+	if err != nil {
+		return nil, err
+	}
+
 	objs := []client.Object{}
 	for _, obj := range serviceAccounts {
 		obj := obj
@@ -325,10 +332,11 @@ func Convert(in RegistryV1, installNamespace string, targetNamespaces []string)
 		obj := obj
 		objs = append(objs, &obj)
 	}
-	for _, obj := range in.CRDs {
+	for _, obj := range crds {
 		obj := obj
 		objs = append(objs, &obj)
 	}
+	objs = append(objs, webhookObjs...)
 	for _, obj := range in.Others {
 		obj := obj
 		obj.SetNamespace(installNamespace)
@@ -0,0 +1,252 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+)
+
+func testBundle(installModes ...v1alpha1.InstallModeType) RegistryV1 {
+	reg := RegistryV1{
+		PackageName: "engine",
+		CSV: v1alpha1.ClusterServiceVersion{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "engine.v1.0.0",
+				Annotations: map[string]string{"operatorframework.io/suggested-namespace": "engine"},
+			},
+		},
+		CRDs: []apiextensionsv1.CustomResourceDefinition{{
+			ObjectMeta: metav1.ObjectMeta{Name: "engines.example.com"},
+		}},
+	}
+	for _, installMode := range installModes {
+		reg.CSV.Spec.InstallModes = append(reg.CSV.Spec.InstallModes, v1alpha1.InstallMode{Type: installMode, Supported: true})
+	}
+	reg.CSV.Spec.InstallStrategy.StrategySpec = v1alpha1.StrategyDetailsDeployment{
+		DeploymentSpecs: []v1alpha1.StrategyDeploymentSpec{{
+			Name: "engine-operator",
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "engine-operator"}},
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						ServiceAccountName: "engine-operator",
+						Containers: []corev1.Container{{
+							Name:  "operator",
+							Image: "registry.example.com/engine/operator:v1",
+							Env:   []corev1.EnvVar{{Name: "OPERAND_IMAGE_AGENT", Value: "registry.example.com/engine/agent:v1"}},
+						}},
+					},
+				},
+			},
+		}},
+		Permissions: []v1alpha1.StrategyDeploymentPermissions{{
+			ServiceAccountName: "engine-operator",
+			Rules: []rbacv1.PolicyRule{{
+				APIGroups: []string{""},
+				Resources: []string{"configmaps"},
+				Verbs:     []string{"get"},
+			}},
+		}},
+	}
+	return reg
+}
+
+func objectsOf[T client.Object](objs []client.Object) []T {
+	var typed []T
+	for _, obj := range objs {
+		if t, ok := obj.(T); ok {
+			typed = append(typed, t)
+		}
+	}
+	return typed
+}
+
+func TestConvertAllNamespaces(t *testing.T) {
+	plain, err := Convert(testBundle(v1alpha1.InstallModeTypeAllNamespaces, v1alpha1.InstallModeTypeOwnNamespace), "", nil)
+	assert.Nil(t, err)
+
+	assert.Empty(t, objectsOf[*rbacv1.Role](plain.Objects))
+	clusterRoles := objectsOf[*rbacv1.ClusterRole](plain.Objects)
+	assert.Len(t, clusterRoles, 1)
+	assert.Contains(t, clusterRoles[0].Rules, rbacv1.PolicyRule{
+		Verbs:     []string{"get", "list", "watch"},
+		APIGroups: []string{""},
+		Resources: []string{"namespaces"},
+	})
+
+	deployments := objectsOf[*appsv1.Deployment](plain.Objects)
+	assert.Len(t, deployments, 1)
+	assert.Equal(t, "engine", deployments[0].Namespace)
+	assert.Equal(t, "", deployments[0].Spec.Template.Annotations["olm.targetNamespaces"])
+}
+
+func TestConvertOwnNamespace(t *testing.T) {
+	reg := testBundle(v1alpha1.InstallModeTypeOwnNamespace)
+	plain, err := Convert(reg, "", nil)
+	assert.Nil(t, err)
+
+	assert.Empty(t, objectsOf[*rbacv1.ClusterRole](plain.Objects))
+	roles := objectsOf[*rbacv1.Role](plain.Objects)
+	assert.Len(t, roles, 1)
+	assert.Equal(t, "engine", roles[0].Namespace)
+	assert.Len(t, roles[0].Rules, 1)
+
+	deployments := objectsOf[*appsv1.Deployment](plain.Objects)
+	assert.Equal(t, "engine", deployments[0].Spec.Template.Annotations["olm.targetNamespaces"])
+	assert.Nil(t, reg.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Annotations)
+
+	_, err = Convert(reg, "", []string{""})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "do not support targeting all namespaces")
+}
+
+func TestConvertWebhooks(t *testing.T) {
+	reg := testBundle(v1alpha1.InstallModeTypeAllNamespaces)
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	validatePath := "/validate"
+	targetPort := intstr.FromInt32(9443)
+	reg.CSV.Spec.WebhookDefinitions = []v1alpha1.WebhookDescription{
+		{
+			GenerateName:            "vengine.example.com",
+			Type:                    v1alpha1.ValidatingAdmissionWebhook,
+			DeploymentName:          "engine-operator",
+			ContainerPort:           443,
+			TargetPort:              &targetPort,
+			SideEffects:             &sideEffects,
+			AdmissionReviewVersions: []string{"v1"},
+			WebhookPath:             &validatePath,
+		},
+		{
+			GenerateName:            "cengine.example.com",
+			Type:                    v1alpha1.ConversionWebhook,
+			DeploymentName:          "engine-operator",
+			ContainerPort:           443,
+			TargetPort:              &targetPort,
+			AdmissionReviewVersions: []string{"v1"},
+			ConversionCRDs:          []string{"engines.example.com"},
+		},
+	}
+
+	plain, err := Convert(reg, "", nil)
+	assert.Nil(t, err)
+
+	services := objectsOf[*corev1.Service](plain.Objects)
+	assert.Len(t, services, 1)
+	assert.Equal(t, "engine-operator-service", services[0].Name)
+	assert.Equal(t, map[string]string{"app": "engine-operator"}, services[0].Spec.Selector)
+	assert.Equal(t, []corev1.ServicePort{{Name: "443", Port: 443, TargetPort: targetPort}}, services[0].Spec.Ports)
+
+	secrets := objectsOf[*corev1.Secret](plain.Objects)
+	assert.Len(t, secrets, 1)
+	assert.Equal(t, "engine-operator-service-cert", secrets[0].Name)
+	assert.Equal(t, "engine-operator-service", secrets[0].Annotations[WebhookServiceAnnotation])
+
+	deployment := objectsOf[*appsv1.Deployment](plain.Objects)[0]
+	assert.Len(t, deployment.Spec.Template.Spec.Volumes, 2)
+	assert.Equal(t, []corev1.VolumeMount{
+		{Name: webhookCertVolume, MountPath: webhookCertMountPath},
+		{Name: apiServiceCertVolume, MountPath: apiServiceCertMountPath},
+	}, deployment.Spec.Template.Spec.Containers[0].VolumeMounts)
+	assert.Empty(t, reg.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.Volumes)
+
+	configs := objectsOf[*admissionregistrationv1.ValidatingWebhookConfiguration](plain.Objects)
+	assert.Len(t, configs, 1)
+	webhook := configs[0].Webhooks[0]
+	assert.Equal(t, "vengine.example.com", webhook.Name)
+	assert.Nil(t, webhook.NamespaceSelector)
+	assert.Equal(t, "engine", webhook.ClientConfig.Service.Namespace)
+	assert.Equal(t, "engine-operator-service", webhook.ClientConfig.Service.Name)
+	assert.Equal(t, &validatePath, webhook.ClientConfig.Service.Path)
+	assert.Equal(t, "engine-operator-service", configs[0].Annotations[WebhookServiceAnnotation])
+
+	crds := objectsOf[*apiextensionsv1.CustomResourceDefinition](plain.Objects)
+	assert.Len(t, crds, 1)
+	assert.Equal(t, apiextensionsv1.WebhookConverter, crds[0].Spec.Conversion.Strategy)
+	assert.Equal(t, "engine-operator-service", crds[0].Spec.Conversion.Webhook.ClientConfig.Service.Name)
+	assert.Equal(t, "engine-operator-service", crds[0].Annotations[WebhookServiceAnnotation])
+	assert.Nil(t, reg.CRDs[0].Spec.Conversion)
+}
+
+func TestConvertWebhooksOwnNamespace(t *testing.T) {
+	reg := testBundle(v1alpha1.InstallModeTypeOwnNamespace)
+	reg.CSV.Spec.WebhookDefinitions = []v1alpha1.WebhookDescription{{
+		GenerateName:   "mengine.example.com",
+		Type:           v1alpha1.MutatingAdmissionWebhook,
+		DeploymentName: "engine-operator",
+	}}
+	plain, err := Convert(reg, "", nil)
+	assert.Nil(t, err)
+
+	configs := objectsOf[*admissionregistrationv1.MutatingWebhookConfiguration](plain.Objects)
+	assert.Len(t, configs, 1)
+	assert.Equal(t, &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      corev1.LabelMetadataName,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{"engine"},
+		}},
+	}, configs[0].Webhooks[0].NamespaceSelector)
+	assert.Equal(t, int32(defaultWebhookPort), *configs[0].Webhooks[0].ClientConfig.Service.Port)
+
+	reg.CSV.Spec.WebhookDefinitions = []v1alpha1.WebhookDescription{{
+		GenerateName:   "cengine.example.com",
+		Type:           v1alpha1.ConversionWebhook,
+		DeploymentName: "engine-operator",
+		ConversionCRDs: []string{"engines.example.com"},
+	}}
+	_, err = Convert(reg, "", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "conversion webhooks require the AllNamespaces install mode")
+}
+
+func TestConvertWebhookUnknownDeployment(t *testing.T) {
+	reg := testBundle(v1alpha1.InstallModeTypeAllNamespaces)
+	reg.CSV.Spec.WebhookDefinitions = []v1alpha1.WebhookDescription{{
+		GenerateName:   "vengine.example.com",
+		Type:           v1alpha1.ValidatingAdmissionWebhook,
+		DeploymentName: "unknown",
+	}}
+	_, err := Convert(reg, "", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown deployment \"unknown\"")
+}
+
+func TestRelatedImages(t *testing.T) {
+	reg := testBundle(v1alpha1.InstallModeTypeAllNamespaces)
+	reg.CSV.Spec.RelatedImages = []v1alpha1.RelatedImage{
+		{Name: "operator", Image: "registry.example.com/engine/operator:v1"},
+		{Image: "registry.example.com/engine/agent@sha256:abc"},
+	}
+	reg.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.InitContainers = []corev1.Container{
+		{Name: "operator", Image: "registry.example.com/engine/init:v1"},
+	}
+	assert.Equal(t, []v1alpha1.RelatedImage{
+		{Name: "operator", Image: "registry.example.com/engine/operator:v1"},
+		{Name: "agent", Image: "registry.example.com/engine/agent@sha256:abc"},
+		{Name: "engine-operator-operator", Image: "registry.example.com/engine/init:v1"},
+	}, reg.RelatedImages())
+}
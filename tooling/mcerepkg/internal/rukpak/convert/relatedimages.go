@@ -0,0 +1,68 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+)
+
+// RelatedImages returns the spec.relatedImages of the CSV, completed with the container images of
+// the install strategy deployments that are not listed. Unlisted images are named after the container
+// that uses them, or after its deployment and container if the name is taken. Unnamed images of the
+// CSV are named after their repository.
+func (r RegistryV1) RelatedImages() []v1alpha1.RelatedImage {
+	var relatedImages []v1alpha1.RelatedImage
+	add := func(name, image string) {
+		if image == "" || slices.ContainsFunc(relatedImages, func(i v1alpha1.RelatedImage) bool { return i.Image == image }) {
+			return
+		}
+		relatedImages = append(relatedImages, v1alpha1.RelatedImage{Name: name, Image: image})
+	}
+	nameTaken := func(name string) bool {
+		return name == "" || slices.ContainsFunc(relatedImages, func(i v1alpha1.RelatedImage) bool { return i.Name == name })
+	}
+
+	for _, relatedImage := range r.CSV.Spec.RelatedImages {
+		add(relatedImage.Name, relatedImage.Image)
+	}
+	for _, depSpec := range r.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		podSpec := depSpec.Spec.Template.Spec
+		for _, container := range append(slices.Clone(podSpec.InitContainers), podSpec.Containers...) {
+			name := container.Name
+			if nameTaken(name) {
+				name = depSpec.Name + "-" + container.Name
+			}
+			add(name, container.Image)
+		}
+	}
+	for i := range relatedImages {
+		if relatedImages[i].Name == "" {
+			relatedImages[i].Name = relatedImageName(relatedImages[i].Image)
+		}
+	}
+	return relatedImages
+}
+
+// relatedImageName names an image after its repository, relatedImages allow to omit the name
+func relatedImageName(image string) string {
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.IndexAny(name, ":@"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,304 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"slices"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+
+	"github.com/Azure/ARO-HCP/tooling/mcerepkg/internal/rukpak/util"
+)
+
+// WebhookServiceAnnotation is set on all objects that need the serving certificate of a webhook
+// service: the certificate secret, the webhook configurations and CRDs with a conversion webhook.
+// Its value is the name of the service. OLM injects the certificate at install time, the
+// converted manifests leave the secret data and the caBundles empty for the installer to fill.
+const WebhookServiceAnnotation = "rukpak.io/webhook-service"
+
+const (
+	defaultWebhookPort = 443
+
+	// the certificate is mounted at the default locations of controller-runtime and of
+	// generic API servers, like OLM does
+	webhookCertVolume       = "webhook-cert"
+	webhookCertMountPath    = "/tmp/k8s-webhook-server/serving-certs"
+	apiServiceCertVolume    = "apiservice-cert"
+	apiServiceCertMountPath = "/apiserver.local.config/certificates"
+)
+
+func webhookServiceName(deploymentName string) string {
+	return deploymentName + "-service"
+}
+
+// WebhookCertSecretName returns the name of the secret with the serving certificate of a webhook service
+func WebhookCertSecretName(serviceName string) string {
+	return serviceName + "-cert"
+}
+
+// convertWebhooks creates a service and a certificate secret for every deployment that serves
+// webhooks, mounts the certificate into the deployment and creates the webhook configurations.
+// CRDs with a conversion webhook are changed to use the webhook strategy.
+func convertWebhooks(webhooks []v1alpha1.WebhookDescription, installNamespace string, targetNamespaces []string, deployments []appsv1.Deployment, crds []apiextensionsv1.CustomResourceDefinition) ([]client.Object, error) {
+	var services []*corev1.Service
+	var secrets []*corev1.Secret
+	var validatingConfigs []*admissionregistrationv1.ValidatingWebhookConfiguration
+	var mutatingConfigs []*admissionregistrationv1.MutatingWebhookConfiguration
+
+	for _, webhook := range webhooks {
+		i := slices.IndexFunc(deployments, func(d appsv1.Deployment) bool { return d.Name == webhook.DeploymentName })
+		if i < 0 {
+			return nil, fmt.Errorf("webhook %s: unknown deployment %q", webhook.GenerateName, webhook.DeploymentName)
+		}
+		deployment := &deployments[i]
+
+		serviceName := webhookServiceName(deployment.Name)
+		port := webhook.ContainerPort
+		if port == 0 {
+			port = defaultWebhookPort
+		}
+		targetPort := intstr.FromInt32(port)
+		if webhook.TargetPort != nil {
+			targetPort = *webhook.TargetPort
+		}
+
+		j := slices.IndexFunc(services, func(s *corev1.Service) bool { return s.Name == serviceName })
+		if j < 0 {
+			if deployment.Spec.Selector == nil || len(deployment.Spec.Selector.MatchLabels) == 0 {
+				return nil, fmt.Errorf("webhook %s: deployment %s has no matchLabels to select its pods", webhook.GenerateName, deployment.Name)
+			}
+			services = append(services, newService(installNamespace, serviceName, deployment.Spec.Selector.MatchLabels))
+			secrets = append(secrets, newWebhookCertSecret(installNamespace, serviceName))
+			mountWebhookCert(deployment, WebhookCertSecretName(serviceName))
+			j = len(services) - 1
+		}
+		addServicePort(services[j], port, targetPort)
+
+		clientConfig := admissionregistrationv1.WebhookClientConfig{
+			Service: &admissionregistrationv1.ServiceReference{
+				Namespace: installNamespace,
+				Name:      serviceName,
+				Path:      webhook.WebhookPath,
+				Port:      &port,
+			},
+		}
+
+		switch webhook.Type {
+		case v1alpha1.ValidatingAdmissionWebhook:
+			validatingConfigs = append(validatingConfigs, &admissionregistrationv1.ValidatingWebhookConfiguration{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "ValidatingWebhookConfiguration",
+					APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+				},
+				ObjectMeta: webhookObjectMeta(webhook.GenerateName, serviceName),
+				Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+					Name:                    webhook.GenerateName,
+					ClientConfig:            clientConfig,
+					Rules:                   webhook.Rules,
+					FailurePolicy:           webhook.FailurePolicy,
+					MatchPolicy:             webhook.MatchPolicy,
+					NamespaceSelector:       namespaceSelector(targetNamespaces),
+					ObjectSelector:          webhook.ObjectSelector,
+					SideEffects:             webhook.SideEffects,
+					TimeoutSeconds:          webhook.TimeoutSeconds,
+					AdmissionReviewVersions: webhook.AdmissionReviewVersions,
+				}},
+			})
+		case v1alpha1.MutatingAdmissionWebhook:
+			mutatingConfigs = append(mutatingConfigs, &admissionregistrationv1.MutatingWebhookConfiguration{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "MutatingWebhookConfiguration",
+					APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+				},
+				ObjectMeta: webhookObjectMeta(webhook.GenerateName, serviceName),
+				Webhooks: []admissionregistrationv1.MutatingWebhook{{
+					Name:                    webhook.GenerateName,
+					ClientConfig:            clientConfig,
+					Rules:                   webhook.Rules,
+					FailurePolicy:           webhook.FailurePolicy,
+					MatchPolicy:             webhook.MatchPolicy,
+					NamespaceSelector:       namespaceSelector(targetNamespaces),
+					ObjectSelector:          webhook.ObjectSelector,
+					SideEffects:             webhook.SideEffects,
+					TimeoutSeconds:          webhook.TimeoutSeconds,
+					AdmissionReviewVersions: webhook.AdmissionReviewVersions,
+					ReinvocationPolicy:      webhook.ReinvocationPolicy,
+				}},
+			})
+		case v1alpha1.ConversionWebhook:
+			// custom resources of all namespaces are converted, OLM has the same restriction
+			if !allNamespaces(targetNamespaces) {
+				return nil, fmt.Errorf("webhook %s: conversion webhooks require the %s install mode", webhook.GenerateName, v1alpha1.InstallModeTypeAllNamespaces)
+			}
+			for _, crdName := range webhook.ConversionCRDs {
+				k := slices.IndexFunc(crds, func(c apiextensionsv1.CustomResourceDefinition) bool { return c.Name == crdName })
+				if k < 0 {
+					return nil, fmt.Errorf("webhook %s: unknown conversion CRD %q", webhook.GenerateName, crdName)
+				}
+				crds[k].Annotations = util.MergeMaps(crds[k].Annotations, map[string]string{WebhookServiceAnnotation: serviceName})
+				crds[k].Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+					Strategy: apiextensionsv1.WebhookConverter,
+					Webhook: &apiextensionsv1.WebhookConversion{
+						ClientConfig: &apiextensionsv1.WebhookClientConfig{
+							Service: &apiextensionsv1.ServiceReference{
+								Namespace: installNamespace,
+								Name:      serviceName,
+								Path:      webhook.WebhookPath,
+								Port:      &port,
+							},
+						},
+						ConversionReviewVersions: webhook.AdmissionReviewVersions,
+					},
+				}
+			}
+		default:
+			return nil, fmt.Errorf("webhook %s: unsupported type %q", webhook.GenerateName, webhook.Type)
+		}
+	}
+
+	objs := []client.Object{}
+	for _, obj := range services {
+		objs = append(objs, obj)
+	}
+	for _, obj := range secrets {
+		objs = append(objs, obj)
+	}
+	for _, obj := range validatingConfigs {
+		objs = append(objs, obj)
+	}
+	for _, obj := range mutatingConfigs {
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+func allNamespaces(targetNamespaces []string) bool {
+	return len(targetNamespaces) == 1 && targetNamespaces[0] == ""
+}
+
+// namespaceSelector limits admission webhooks to the target namespaces of the operator
+func namespaceSelector(targetNamespaces []string) *metav1.LabelSelector {
+	if allNamespaces(targetNamespaces) {
+		return nil
+	}
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      corev1.LabelMetadataName,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   targetNamespaces,
+		}},
+	}
+}
+
+func webhookObjectMeta(name, serviceName string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        name,
+		Annotations: map[string]string{WebhookServiceAnnotation: serviceName},
+	}
+}
+
+func newService(namespace, name string, selector map[string]string) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: corev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+		},
+	}
+}
+
+func addServicePort(service *corev1.Service, port int32, targetPort intstr.IntOrString) {
+	if slices.ContainsFunc(service.Spec.Ports, func(p corev1.ServicePort) bool { return p.Port == port }) {
+		return
+	}
+	service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+		Name:       fmt.Sprintf("%d", port),
+		Port:       port,
+		TargetPort: targetPort,
+	})
+}
+
+func newWebhookCertSecret(namespace, serviceName string) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: corev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        WebhookCertSecretName(serviceName),
+			Annotations: map[string]string{WebhookServiceAnnotation: serviceName},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       {},
+			corev1.TLSPrivateKeyKey: {},
+		},
+	}
+}
+
+// mountWebhookCert mounts the certificate secret into all containers of the deployment. Volumes and
+// mount paths the deployment already uses are left alone.
+func mountWebhookCert(deployment *appsv1.Deployment, secretName string) {
+	podSpec := &deployment.Spec.Template.Spec
+	certVolumes := []struct {
+		name, mountPath, certFile, keyFile string
+	}{
+		{webhookCertVolume, webhookCertMountPath, "tls.crt", "tls.key"},
+		{apiServiceCertVolume, apiServiceCertMountPath, "apiserver.crt", "apiserver.key"},
+	}
+	for _, v := range certVolumes {
+		if slices.ContainsFunc(podSpec.Volumes, func(volume corev1.Volume) bool { return volume.Name == v.name }) {
+			continue
+		}
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: v.name,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName,
+					Items: []corev1.KeyToPath{
+						{Key: corev1.TLSCertKey, Path: v.certFile},
+						{Key: corev1.TLSPrivateKeyKey, Path: v.keyFile},
+					},
+				},
+			},
+		})
+		for c := range podSpec.Containers {
+			container := &podSpec.Containers[c]
+			if slices.ContainsFunc(container.VolumeMounts, func(m corev1.VolumeMount) bool { return m.MountPath == v.mountPath }) {
+				continue
+			}
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      v.name,
+				MountPath: v.mountPath,
+			})
+		}
+	}
+}
@@ -18,17 +18,21 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/Azure/ARO-HCP/tooling/mcerepkg/internal/chartdiff"
+	"github.com/Azure/ARO-HCP/tooling/mcerepkg/internal/config"
 	"github.com/Azure/ARO-HCP/tooling/mcerepkg/internal/customize"
 	"github.com/Azure/ARO-HCP/tooling/mcerepkg/internal/olm"
 
-	"github.com/google/go-containerregistry/pkg/crane"
 	yaml "gopkg.in/yaml.v3"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 var (
@@ -37,25 +41,46 @@ var (
 		Short: "mce-repkg",
 		Long:  "mce-repkg",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return buildChart(
-				outputDir, mceBundle, sourceLink, scaffoldDir,
-			)
+			if bundle == "" {
+				return fmt.Errorf("required flag \"bundle\" not set")
+			}
+			cfg := config.Default()
+			if configFile != "" {
+				var err error
+				cfg, err = config.Load(configFile)
+				if err != nil {
+					return err
+				}
+			}
+			helmChart, err := buildChart(cfg, bundle, sourceLink, scaffoldDir)
+			if err != nil {
+				return err
+			}
+			if diff {
+				return diffChart(helmChart, outputDir)
+			}
+			return saveChart(helmChart, outputDir)
 		},
 	}
-	mceBundle   string
+	bundle      string
+	configFile  string
 	outputDir   string
 	scaffoldDir string
 	sourceLink  string
+	diff        bool
 )
 
 func main() {
-	cmd.Flags().StringVarP(&mceBundle, "mce-bundle", "b", "", "MCE OLM bundle image tgz")
+	cmd.Flags().StringVarP(&bundle, "bundle", "b", "", "OLM bundle image, either a tarball saved with podman or an image reference to pull")
+	cmd.Flags().StringVar(&bundle, "mce-bundle", "", "MCE OLM bundle image tgz")
+	cmd.Flags().StringVarP(&configFile, "config", "c", "", "Config file with the chart name, customizers and values, defaults to the multicluster-engine config")
 	cmd.Flags().StringVarP(&scaffoldDir, "scaffold-dir", "s", "", "Directory containing additional templates to be added to the generated Helm Chart")
 	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Output directory for the generated Helm Chart")
 	cmd.Flags().StringVarP(&sourceLink, "source-link", "l", "", "Link to the Bundle image that is repackaged")
-	err := cmd.MarkFlagRequired("mce-bundle")
+	cmd.Flags().BoolVarP(&diff, "diff", "d", false, "Compare the generated Helm Chart with the one in the output directory instead of overwriting it")
+	err := cmd.Flags().MarkDeprecated("mce-bundle", "use --bundle instead")
 	if err != nil {
-		log.Fatalf("failed to mark flag as required: %v", err)
+		log.Fatalf("failed to mark flag as deprecated: %v", err)
 	}
 	err = cmd.MarkFlagRequired("output-dir")
 	if err != nil {
@@ -68,48 +93,62 @@ func main() {
 	}
 }
 
-func buildChart(outputDir, mceOlmBundle, sourceLink, scaffoldDir string) error {
+func buildChart(cfg *config.Config, olmBundle, sourceLink, scaffoldDir string) (*chart.Chart, error) {
 	ctx := context.Background()
 
 	// load OLM bundle manifests
-	img, err := crane.Load(mceOlmBundle)
+	img, err := olm.LoadBundleImage(ctx, olmBundle)
 	if err != nil {
-		return fmt.Errorf("failed to load OLM bundle image: %v", err)
+		return nil, fmt.Errorf("failed to load OLM bundle image: %v", err)
 	}
-	olmManifests, reg, err := olm.ExtractOLMBundleImage(ctx, img)
+	olmManifests, reg, err := olm.ExtractOLMBundleImage(ctx, img, cfg.Operator.WatchNamespaces)
 	if err != nil {
-		return fmt.Errorf("failed to extract OLM bundle image: %v", err)
+		return nil, fmt.Errorf("failed to extract OLM bundle image: %v", err)
 	}
 
 	// sanity check manifests
-	err = customize.SanityCheck(olmManifests)
+	err = customize.SanityCheck(olmManifests, cfg.Operator)
 	if err != nil {
-		return fmt.Errorf("failed sanity checks on manifests: %v", err)
+		return nil, fmt.Errorf("failed sanity checks on manifests: %v", err)
 	}
 
 	// load scaffolding manifests
-	scaffoldManifests, err := customize.LoadScaffoldTemplates(scaffoldDir)
-	if err != nil {
-		return fmt.Errorf("failed to load scaffold templates: %v", err)
+	var scaffoldManifests []unstructured.Unstructured
+	if scaffoldDir != "" {
+		scaffoldManifests, err = customize.LoadScaffoldTemplates(scaffoldDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load scaffold templates: %v", err)
+		}
 	}
 
 	// customize manifests
-	customizedManifests, values, err := customize.CustomizeManifests(append(olmManifests, scaffoldManifests...))
+	operator := cfg.Operator
+	operator.RelatedImages = reg.RelatedImages()
+	customizers, err := customize.NewCustomizers(operator, cfg.Customizers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create customizers: %v", err)
+	}
+	customizedManifests, values, err := customize.CustomizeManifests(append(olmManifests, scaffoldManifests...), customizers)
 	if err != nil {
-		return fmt.Errorf("failed to customize manifests: %v", err)
+		return nil, fmt.Errorf("failed to customize manifests: %v", err)
 	}
+	values = chartutil.CoalesceTables(chartutil.Values(cfg.Values).AsMap(), values)
 
 	// build chart
-	mceChart := &chart.Chart{
+	keywords := cfg.Chart.Keywords
+	if len(keywords) == 0 {
+		keywords = reg.CSV.Spec.Keywords
+	}
+	helmChart := &chart.Chart{
 		Metadata: &chart.Metadata{
 			APIVersion:  "v2",
-			Name:        "multicluster-engine",
-			Description: "A Helm chart for multicluster-engine",
+			Name:        cfg.Chart.Name,
+			Description: cfg.Chart.Description,
 			Version:     reg.CSV.Spec.Version.String(),
 			AppVersion:  reg.CSV.Spec.Version.String(),
 			Type:        "application",
 			Sources:     []string{sourceLink},
-			Keywords:    reg.CSV.Spec.Keywords,
+			Keywords:    keywords,
 		},
 	}
 	var chartFiles []*chart.File
@@ -117,7 +156,7 @@ func buildChart(outputDir, mceOlmBundle, sourceLink, scaffoldDir string) error {
 	// add values file
 	valuesYaml, err := yaml.Marshal(values)
 	if err != nil {
-		return fmt.Errorf("failed to marshal values to YAML: %v", err)
+		return nil, fmt.Errorf("failed to marshal values to YAML: %v", err)
 	}
 	chartFiles = append(chartFiles, &chart.File{
 		Name: "values.yaml",
@@ -129,11 +168,14 @@ func buildChart(outputDir, mceOlmBundle, sourceLink, scaffoldDir string) error {
 		yamlData, err := yaml.Marshal(manifest.Object)
 
 		if err != nil {
-			return fmt.Errorf("failed to marshal object to YAML: %v", err)
+			return nil, fmt.Errorf("failed to marshal object to YAML: %v", err)
 		}
 
+		yamlData = customize.TemplatizeBlocks(yamlData)
+
 		path := fmt.Sprintf("templates/%s.%s.yaml", manifest.GetName(), strings.ToLower(manifest.GetKind()))
-		if manifest.GetKind() == "CustomResourceDefinition" {
+		// CRDs with a conversion webhook are templates, their caBundle is rendered at install time
+		if manifest.GetKind() == "CustomResourceDefinition" && !customize.NeedsWebhookCertificates([]unstructured.Unstructured{manifest}) {
 			path = fmt.Sprintf("crds/%s.yaml", manifest.GetName())
 		}
 
@@ -142,13 +184,43 @@ func buildChart(outputDir, mceOlmBundle, sourceLink, scaffoldDir string) error {
 			Data: yamlData,
 		})
 	}
-	mceChart.Templates = chartFiles
+	// add the certificate template used by webhooks
+	if customize.NeedsWebhookCertificates(customizedManifests) {
+		chartFiles = append(chartFiles, &chart.File{
+			Name: customize.WebhookCertificatesTemplateFile,
+			Data: []byte(customize.WebhookCertificatesTemplate),
+		})
+	}
+	helmChart.Templates = chartFiles
 
-	// store chart
-	err = chartutil.SaveDir(mceChart, outputDir)
+	return helmChart, nil
+}
+
+func saveChart(helmChart *chart.Chart, outputDir string) error {
+	err := chartutil.SaveDir(helmChart, outputDir)
 	if err != nil {
 		return fmt.Errorf("failed to save chart to directory: %v", err)
 	}
+	return nil
+}
 
+// diffChart compares the generated chart with the chart previously saved to outputDir and fails
+// if upgrading would break existing custom resources
+func diffChart(helmChart *chart.Chart, outputDir string) error {
+	current, err := chartdiff.LoadDir(filepath.Join(outputDir, helmChart.Name()))
+	if err != nil {
+		return fmt.Errorf("failed to load current chart: %v", err)
+	}
+	report, err := chartdiff.Compare(current, helmChart.Templates)
+	if err != nil {
+		return fmt.Errorf("failed to compare charts: %v", err)
+	}
+	err = report.Write(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to write report: %v", err)
+	}
+	if report.Breaking() {
+		return fmt.Errorf("upgrading to %s breaks existing custom resources", helmChart.Metadata.Version)
+	}
 	return nil
 }
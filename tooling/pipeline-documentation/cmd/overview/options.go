@@ -19,34 +19,60 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/Azure/ARO-Tools/pkg/topology"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/yaml"
 
 	"github.com/Azure/ARO-HCP/tooling/pipeline-documentation/pkg/generator"
+	"github.com/Azure/ARO-HCP/tooling/pipeline-documentation/pkg/pipeline"
+	"github.com/Azure/ARO-HCP/tooling/pipeline-documentation/pkg/validation"
 )
 
+const (
+	FormatMarkdown = "markdown"
+	FormatMermaid  = "mermaid"
+	FormatGraphviz = "graphviz"
+	FormatJSON     = "json"
+)
+
+var formats = []string{FormatMarkdown, FormatMermaid, FormatGraphviz, FormatJSON}
+
 func DefaultGenerationOptions() *RawGenerationOptions {
-	return &RawGenerationOptions{}
+	return &RawGenerationOptions{
+		Format: FormatMarkdown,
+	}
 }
 
 func BindGenerationOptions(opts *RawGenerationOptions, cmd *cobra.Command) error {
 	cmd.Flags().StringVar(&opts.Input, "topology", opts.Input, "file holding topology configuration")
 	cmd.Flags().StringVar(&opts.Output, "output", opts.Output, "output file path for overview")
+	cmd.Flags().StringVar(&opts.Format, "format", opts.Format, fmt.Sprintf("format of the overview, one of %s", strings.Join(formats, ", ")))
+	cmd.Flags().StringVar(&opts.ServicesOutput, "services-output", opts.ServicesOutput, "output directory for a page per service group, no pages are generated if empty")
+	cmd.Flags().StringVar(&opts.RepoRoot, "repo-root", opts.RepoRoot, "directory the pipeline files in the topology are relative to, defaults to the directory of the topology file")
 
 	for _, flag := range []string{"topology", "output"} {
 		if err := cmd.MarkFlagFilename(flag); err != nil {
 			return fmt.Errorf("failed to mark flag %q as a file: %w", flag, err)
 		}
 	}
+	for _, flag := range []string{"services-output", "repo-root"} {
+		if err := cmd.MarkFlagDirname(flag); err != nil {
+			return fmt.Errorf("failed to mark flag %q as a directory: %w", flag, err)
+		}
+	}
 	return nil
 }
 
 // RawGenerationOptions holds input values.
 type RawGenerationOptions struct {
-	Input  string
-	Output string
+	Input          string
+	Output         string
+	Format         string
+	ServicesOutput string
+	RepoRoot       string
 }
 
 // validatedGenerationOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
@@ -62,7 +88,11 @@ type ValidatedGenerationOptions struct {
 // completedGenerationOptions is a private wrapper that enforces a call of Complete() before config generation can be invoked.
 type completedGenerationOptions struct {
 	Topology   topology.Topology
+	Pipelines  map[string]*pipeline.Pipeline
+	Format     string
 	OutputFile io.WriteCloser
+	// ServicesDir is the directory for the service pages, empty if none are generated
+	ServicesDir string
 }
 
 type GenerationOptions struct {
@@ -76,7 +106,11 @@ func (o *RawGenerationOptions) Validate() (*ValidatedGenerationOptions, error) {
 	}
 
 	if o.Output == "" {
-		return nil, fmt.Errorf("output markdown file is required")
+		return nil, fmt.Errorf("output file is required")
+	}
+
+	if !slices.Contains(formats, o.Format) {
+		return nil, fmt.Errorf("format %q is not one of %s", o.Format, strings.Join(formats, ", "))
 	}
 
 	if _, err := os.Stat(o.Input); os.IsNotExist(err) {
@@ -101,10 +135,28 @@ func (o *ValidatedGenerationOptions) Complete() (*GenerationOptions, error) {
 		return nil, fmt.Errorf("failed to unmarshal topology: %w", err)
 	}
 
+	repoRoot := o.RepoRoot
+	if repoRoot == "" {
+		repoRoot = filepath.Dir(o.Input)
+	}
+	if err := validation.Validate(t, repoRoot); err != nil {
+		return nil, fmt.Errorf("invalid topology:\n%w", err)
+	}
+	pipelines, err := pipeline.LoadAll(t, repoRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pipelines: %w", err)
+	}
+
 	if err := os.MkdirAll(filepath.Dir(o.Output), os.ModePerm); err != nil {
 		return nil, fmt.Errorf("failed to create output directory %s: %w", o.Output, err)
 	}
 
+	if o.ServicesOutput != "" {
+		if err := os.MkdirAll(o.ServicesOutput, os.ModePerm); err != nil {
+			return nil, fmt.Errorf("failed to create services output directory %s: %w", o.ServicesOutput, err)
+		}
+	}
+
 	outputFile, err := os.Create(o.Output)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create output file %s: %w", o.Input, err)
@@ -112,12 +164,35 @@ func (o *ValidatedGenerationOptions) Complete() (*GenerationOptions, error) {
 
 	return &GenerationOptions{
 		completedGenerationOptions: &completedGenerationOptions{
-			Topology:   t,
-			OutputFile: outputFile,
+			Topology:    t,
+			Pipelines:   pipelines,
+			Format:      o.Format,
+			OutputFile:  outputFile,
+			ServicesDir: o.ServicesOutput,
 		},
 	}, nil
 }
 
 func (opts *GenerationOptions) GenerateOverview() error {
-	return generator.Markdown(opts.Topology, opts.OutputFile)
+	var err error
+	switch opts.Format {
+	case FormatMermaid:
+		err = generator.Mermaid(opts.Topology, opts.OutputFile)
+	case FormatGraphviz:
+		err = generator.Graphviz(opts.Topology, opts.OutputFile)
+	case FormatJSON:
+		err = generator.JSON(opts.Topology, opts.Pipelines, opts.OutputFile)
+	default:
+		err = generator.Markdown(opts.Topology, opts.OutputFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.ServicesDir == "" {
+		return nil
+	}
+	return generator.ServicePages(opts.Topology, opts.Pipelines, func(name string) (io.WriteCloser, error) {
+		return os.Create(filepath.Join(opts.ServicesDir, name))
+	})
 }
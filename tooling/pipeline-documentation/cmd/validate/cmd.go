@@ -0,0 +1,43 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewCommand() (*cobra.Command, error) {
+	opts := DefaultValidationOptions()
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "validate",
+		Long:  "Validate the topology configuration and the pipeline files it references.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			validated, err := opts.Validate()
+			if err != nil {
+				return err
+			}
+			completed, err := validated.Complete()
+			if err != nil {
+				return err
+			}
+			return completed.ValidateTopology()
+		},
+	}
+	if err := BindValidationOptions(opts, cmd); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
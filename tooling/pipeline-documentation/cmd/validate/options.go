@@ -0,0 +1,118 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/ARO-Tools/pkg/topology"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/Azure/ARO-HCP/tooling/pipeline-documentation/pkg/validation"
+)
+
+func DefaultValidationOptions() *RawValidationOptions {
+	return &RawValidationOptions{}
+}
+
+func BindValidationOptions(opts *RawValidationOptions, cmd *cobra.Command) error {
+	cmd.Flags().StringVar(&opts.Input, "topology", opts.Input, "file holding topology configuration")
+	cmd.Flags().StringVar(&opts.RepoRoot, "repo-root", opts.RepoRoot, "directory the pipeline files in the topology are relative to, defaults to the directory of the topology file")
+
+	if err := cmd.MarkFlagFilename("topology"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as a file: %w", "topology", err)
+	}
+	if err := cmd.MarkFlagDirname("repo-root"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as a directory: %w", "repo-root", err)
+	}
+	return nil
+}
+
+// RawValidationOptions holds input values.
+type RawValidationOptions struct {
+	Input    string
+	RepoRoot string
+}
+
+// validatedValidationOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
+type validatedValidationOptions struct {
+	*RawValidationOptions
+}
+
+type ValidatedValidationOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*validatedValidationOptions
+}
+
+// completedValidationOptions is a private wrapper that enforces a call of Complete() before validation can be invoked.
+type completedValidationOptions struct {
+	Topology topology.Topology
+	RepoRoot string
+}
+
+type ValidationOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*completedValidationOptions
+}
+
+func (o *RawValidationOptions) Validate() (*ValidatedValidationOptions, error) {
+	if o.Input == "" {
+		return nil, fmt.Errorf("topology configuration file is required")
+	}
+
+	if _, err := os.Stat(o.Input); os.IsNotExist(err) {
+		return nil, fmt.Errorf("input file %s does not exist", o.Input)
+	}
+
+	return &ValidatedValidationOptions{
+		validatedValidationOptions: &validatedValidationOptions{
+			RawValidationOptions: o,
+		},
+	}, nil
+}
+
+func (o *ValidatedValidationOptions) Complete() (*ValidationOptions, error) {
+	rawInput, err := os.ReadFile(o.Input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file %s: %w", o.Input, err)
+	}
+
+	var t topology.Topology
+	if err := yaml.Unmarshal(rawInput, &t); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal topology: %w", err)
+	}
+
+	repoRoot := o.RepoRoot
+	if repoRoot == "" {
+		repoRoot = filepath.Dir(o.Input)
+	}
+
+	return &ValidationOptions{
+		completedValidationOptions: &completedValidationOptions{
+			Topology: t,
+			RepoRoot: repoRoot,
+		},
+	}, nil
+}
+
+func (opts *ValidationOptions) ValidateTopology() error {
+	if err := validation.Validate(opts.Topology, opts.RepoRoot); err != nil {
+		return fmt.Errorf("invalid topology:\n%w", err)
+	}
+	return nil
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/dusted-go/logging/prettylog"
 
 	"github.com/Azure/ARO-HCP/tooling/pipeline-documentation/cmd/overview"
+	"github.com/Azure/ARO-HCP/tooling/pipeline-documentation/cmd/validate"
 )
 
 func main() {
@@ -57,6 +58,7 @@ func main() {
 
 	commands := []func() (*cobra.Command, error){
 		overview.NewCommand,
+		validate.NewCommand,
 	}
 	for _, newCmd := range commands {
 		c, err := newCmd()
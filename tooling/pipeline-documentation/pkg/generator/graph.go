@@ -0,0 +1,93 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/ARO-Tools/pkg/topology"
+)
+
+// The graphs have an edge from every service group to its children, which are rolled out after
+// their parent. Service groups that are entrypoints are labelled with the entrypoint name.
+
+// Mermaid renders the dependency graph of the service groups as a Mermaid flowchart
+func Mermaid(topo topology.Topology, into io.WriteCloser) error {
+	graph := bytes.Buffer{}
+	graph.WriteString("flowchart TD\n")
+	walk(topo.Services, nil, func(service topology.Service, parent *topology.Service) {
+		graph.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", mermaidID(service.ServiceGroup), strings.Join(nodeLabel(topo.Entrypoints, service), "<br/>")))
+		if parent != nil {
+			graph.WriteString(fmt.Sprintf("    %s --> %s\n", mermaidID(parent.ServiceGroup), mermaidID(service.ServiceGroup)))
+		}
+	})
+	if _, err := into.Write(graph.Bytes()); err != nil {
+		return fmt.Errorf("failed to write graph: %w", err)
+	}
+	return into.Close()
+}
+
+// Graphviz renders the dependency graph of the service groups in the DOT language
+func Graphviz(topo topology.Topology, into io.WriteCloser) error {
+	graph := bytes.Buffer{}
+	graph.WriteString("digraph topology {\n    rankdir=LR;\n    node [shape=box];\n")
+	walk(topo.Services, nil, func(service topology.Service, parent *topology.Service) {
+		graph.WriteString(fmt.Sprintf("    %s [label=%s];\n", strconv.Quote(service.ServiceGroup), strconv.Quote(strings.Join(nodeLabel(topo.Entrypoints, service), "\n"))))
+		if parent != nil {
+			graph.WriteString(fmt.Sprintf("    %s -> %s;\n", strconv.Quote(parent.ServiceGroup), strconv.Quote(service.ServiceGroup)))
+		}
+	})
+	graph.WriteString("}\n")
+	if _, err := into.Write(graph.Bytes()); err != nil {
+		return fmt.Errorf("failed to write graph: %w", err)
+	}
+	return into.Close()
+}
+
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func mermaidID(serviceGroup string) string {
+	return mermaidUnsafe.ReplaceAllString(serviceGroup, "_")
+}
+
+func nodeLabel(entrypoints []topology.Entrypoint, service topology.Service) []string {
+	label := []string{service.ServiceGroup}
+	if name := entrypointName(entrypoints, service.ServiceGroup); name != "" {
+		label = append(label, name)
+	}
+	return label
+}
+
+func entrypointName(entrypoints []topology.Entrypoint, serviceGroup string) string {
+	for _, entrypoint := range entrypoints {
+		if entrypoint.Identifier == serviceGroup {
+			return entrypoint.Metadata["name"]
+		}
+	}
+	return ""
+}
+
+// walk visits the services depth first, parents before their children
+func walk(services []topology.Service, parent *topology.Service, visit func(service topology.Service, parent *topology.Service)) {
+	for i := range services {
+		visit(services[i], parent)
+		walk(services[i].Children, &services[i], visit)
+	}
+}
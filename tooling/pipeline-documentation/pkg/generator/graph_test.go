@@ -0,0 +1,124 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Azure/ARO-Tools/pkg/topology"
+)
+
+// testTopology has a global service group with a regional child that has a pipeline
+var testTopology = topology.Topology{
+	Entrypoints: []topology.Entrypoint{
+		{Identifier: "Microsoft.Azure.ARO.HCP.Global", Metadata: map[string]string{"name": "Global"}},
+	},
+	Services: []topology.Service{
+		{
+			ServiceGroup: "Microsoft.Azure.ARO.HCP.Global",
+			Metadata:     map[string]string{"purpose": "Deploy global infrastructure."},
+			Children: []topology.Service{
+				{
+					ServiceGroup: "Microsoft.Azure.ARO.HCP.Region",
+					Metadata: map[string]string{
+						"pipeline":      "dev-infrastructure/region-pipeline.yaml",
+						"purpose":       "Deploy regional infrastructure.",
+						"intPipelineId": "1",
+						"stgPipelineId": "2",
+					},
+				},
+			},
+		},
+	},
+}
+
+// closeRecorder is a buffer that records whether it was closed
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestGraphs(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		render   func(topo topology.Topology, into io.WriteCloser) error
+		topo     topology.Topology
+		expected string
+	}{
+		{
+			name:   "mermaid",
+			render: Mermaid,
+			topo:   testTopology,
+			expected: "flowchart TD\n" +
+				"    Microsoft_Azure_ARO_HCP_Global[\"Microsoft.Azure.ARO.HCP.Global<br/>Global\"]\n" +
+				"    Microsoft_Azure_ARO_HCP_Region[\"Microsoft.Azure.ARO.HCP.Region\"]\n" +
+				"    Microsoft_Azure_ARO_HCP_Global --> Microsoft_Azure_ARO_HCP_Region\n",
+		},
+		{
+			name:     "mermaid without services",
+			render:   Mermaid,
+			expected: "flowchart TD\n",
+		},
+		{
+			name:   "graphviz",
+			render: Graphviz,
+			topo:   testTopology,
+			expected: "digraph topology {\n" +
+				"    rankdir=LR;\n" +
+				"    node [shape=box];\n" +
+				"    \"Microsoft.Azure.ARO.HCP.Global\" [label=\"Microsoft.Azure.ARO.HCP.Global\\nGlobal\"];\n" +
+				"    \"Microsoft.Azure.ARO.HCP.Region\" [label=\"Microsoft.Azure.ARO.HCP.Region\"];\n" +
+				"    \"Microsoft.Azure.ARO.HCP.Global\" -> \"Microsoft.Azure.ARO.HCP.Region\";\n" +
+				"}\n",
+		},
+		{
+			name:     "graphviz without services",
+			render:   Graphviz,
+			expected: "digraph topology {\n    rankdir=LR;\n    node [shape=box];\n}\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			into := &closeRecorder{}
+			if err := tc.render(tc.topo, into); err != nil {
+				t.Fatalf("failed to render: %v", err)
+			}
+			if into.String() != tc.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tc.expected, into.String())
+			}
+			if !into.closed {
+				t.Error("expected the writer to be closed")
+			}
+		})
+	}
+}
+
+func TestMermaidID(t *testing.T) {
+	for serviceGroup, expected := range map[string]string{
+		"Microsoft.Azure.ARO.HCP.Global": "Microsoft_Azure_ARO_HCP_Global",
+		"a-b c":                          "a_b_c",
+		"plain_id1":                      "plain_id1",
+	} {
+		if id := mermaidID(serviceGroup); id != expected {
+			t.Errorf("expected ID %q for %q, got %q", expected, serviceGroup, id)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Azure/ARO-Tools/pkg/topology"
+
+	"github.com/Azure/ARO-HCP/tooling/pipeline-documentation/pkg/pipeline"
+)
+
+// ServiceExport is a service group in the JSON export. The tree is flattened, services reference
+// their parent and children by service group.
+type ServiceExport struct {
+	ServiceGroup string   `json:"serviceGroup"`
+	Parent       string   `json:"parent,omitempty"`
+	Children     []string `json:"children,omitempty"`
+	// Entrypoint is the name of the entrypoint for this service group, if it is one
+	Entrypoint string `json:"entrypoint,omitempty"`
+	Purpose    string `json:"purpose,omitempty"`
+	// PipelineFile is the path of the pipeline file in the repository
+	PipelineFile string `json:"pipelineFile,omitempty"`
+	SourceURL    string `json:"sourceURL,omitempty"`
+	// Runs links the build pipelines by environment
+	Runs     map[string]string  `json:"runs,omitempty"`
+	Pipeline *pipeline.Pipeline `json:"pipeline,omitempty"`
+	Metadata map[string]string  `json:"metadata,omitempty"`
+}
+
+// JSON exports the services of the topology, with the content of their pipeline files, for portals
+func JSON(topo topology.Topology, pipelines map[string]*pipeline.Pipeline, into io.WriteCloser) error {
+	services := []ServiceExport{}
+	walk(topo.Services, nil, func(service topology.Service, parent *topology.Service) {
+		services = append(services, exportService(topo.Entrypoints, service, parent, pipelines[service.ServiceGroup]))
+	})
+
+	encoder := json.NewEncoder(into)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(struct {
+		Services []ServiceExport `json:"services"`
+	}{Services: services}); err != nil {
+		return fmt.Errorf("failed to encode topology: %w", err)
+	}
+	return into.Close()
+}
+
+func exportService(entrypoints []topology.Entrypoint, service topology.Service, parent *topology.Service, p *pipeline.Pipeline) ServiceExport {
+	export := ServiceExport{
+		ServiceGroup: service.ServiceGroup,
+		Entrypoint:   entrypointName(entrypoints, service.ServiceGroup),
+		Purpose:      service.Metadata["purpose"],
+		PipelineFile: service.Metadata["pipeline"],
+		Pipeline:     p,
+		Metadata:     service.Metadata,
+	}
+	if parent != nil {
+		export.Parent = parent.ServiceGroup
+	}
+	for _, child := range service.Children {
+		export.Children = append(export.Children, child.ServiceGroup)
+	}
+	if export.PipelineFile != "" {
+		export.SourceURL = sourceURL(export.PipelineFile)
+	}
+	for _, env := range environments {
+		if id := service.Metadata[env.key]; id != "" {
+			if export.Runs == nil {
+				export.Runs = map[string]string{}
+			}
+			export.Runs[env.acronym] = pipelineURL(id)
+		}
+	}
+	return export
+}
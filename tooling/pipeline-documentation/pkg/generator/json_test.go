@@ -0,0 +1,83 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/ARO-Tools/pkg/topology"
+
+	"github.com/Azure/ARO-HCP/tooling/pipeline-documentation/pkg/pipeline"
+)
+
+func TestJSON(t *testing.T) {
+	regionPipeline := &pipeline.Pipeline{
+		ServiceGroup: "Microsoft.Azure.ARO.HCP.Region",
+		ResourceGroups: []pipeline.ResourceGroup{
+			{Name: "{{ .regionRG }}", Steps: []pipeline.Step{{Name: "infra", Action: "ARM"}}},
+		},
+	}
+	into := &closeRecorder{}
+	if err := JSON(testTopology, map[string]*pipeline.Pipeline{"Microsoft.Azure.ARO.HCP.Region": regionPipeline}, into); err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	if !into.closed {
+		t.Error("expected the writer to be closed")
+	}
+
+	var export struct {
+		Services []ServiceExport `json:"services"`
+	}
+	if err := json.Unmarshal(into.Bytes(), &export); err != nil {
+		t.Fatalf("failed to unmarshal export: %v", err)
+	}
+	expected := []ServiceExport{
+		{
+			ServiceGroup: "Microsoft.Azure.ARO.HCP.Global",
+			Children:     []string{"Microsoft.Azure.ARO.HCP.Region"},
+			Entrypoint:   "Global",
+			Purpose:      "Deploy global infrastructure.",
+			Metadata:     testTopology.Services[0].Metadata,
+		},
+		{
+			ServiceGroup: "Microsoft.Azure.ARO.HCP.Region",
+			Parent:       "Microsoft.Azure.ARO.HCP.Global",
+			Purpose:      "Deploy regional infrastructure.",
+			PipelineFile: "dev-infrastructure/region-pipeline.yaml",
+			SourceURL:    "https://github.com/Azure/ARO-HCP/tree/main/dev-infrastructure/region-pipeline.yaml",
+			Runs: map[string]string{
+				"INT": "https://msazure.visualstudio.com/AzureRedHatOpenShift/_build?definitionId=1",
+				"STG": "https://msazure.visualstudio.com/AzureRedHatOpenShift/_build?definitionId=2",
+			},
+			Pipeline: regionPipeline,
+			Metadata: testTopology.Services[0].Children[0].Metadata,
+		},
+	}
+	if !reflect.DeepEqual(export.Services, expected) {
+		t.Errorf("expected services\n%+v\ngot\n%+v", expected, export.Services)
+	}
+}
+
+func TestJSONWithoutServices(t *testing.T) {
+	into := &closeRecorder{}
+	if err := JSON(topology.Topology{}, nil, into); err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	if expected := "{\n  \"services\": []\n}\n"; into.String() != expected {
+		t.Errorf("expected %q, got %q", expected, into.String())
+	}
+}
@@ -47,7 +47,7 @@ func writeDetails(entrypoints []topology.Entrypoint, service topology.Service, i
 	}
 	summary.WriteString(fmt.Sprintf("- %s", service.ServiceGroup))
 	if pipeline, ok := service.Metadata["pipeline"]; ok {
-		summary.WriteString(fmt.Sprintf(" ([ref](https://github.com/Azure/ARO-HCP/tree/main/%s))", pipeline))
+		summary.WriteString(fmt.Sprintf(" ([ref](%s))", sourceURL(pipeline)))
 	}
 	if purpose, ok := service.Metadata["purpose"]; ok {
 		summary.WriteString(fmt.Sprintf(": %s", purpose))
@@ -85,18 +85,36 @@ func writeDetails(entrypoints []topology.Entrypoint, service topology.Service, i
 	return nil
 }
 
-func formatPipelineLinks(metadata map[string]string) (string, bool) {
+// environments are the environments with a pipeline ID in the service metadata, in rollout order
+var environments = []struct {
+	acronym string
+	key     string
+}{
+	{acronym: "INT", key: "intPipelineId"},
+	{acronym: "STG", key: "stgPipelineId"},
+	{acronym: "PROD", key: "prodPipelineId"},
+}
+
+func sourceURL(path string) string {
+	return fmt.Sprintf("https://github.com/Azure/ARO-HCP/tree/main/%s", path)
+}
+
+func pipelineURL(id string) string {
+	return fmt.Sprintf("https://msazure.visualstudio.com/AzureRedHatOpenShift/_build?definitionId=%s", id)
+}
+
+func pipelineLinks(metadata map[string]string) []string {
 	var pipelines []string
-	for acronym, key := range map[string]string{
-		"INT":  "intPipelineId",
-		"STG":  "stgPipelineId",
-		"PROD": "prodPipelineId",
-	} {
-		if link, ok := metadata[key]; ok && link != "" {
-			pipelines = append(pipelines, fmt.Sprintf("[%s](https://msazure.visualstudio.com/AzureRedHatOpenShift/_build?definitionId=%s)", acronym, link))
+	for _, env := range environments {
+		if link, ok := metadata[env.key]; ok && link != "" {
+			pipelines = append(pipelines, fmt.Sprintf("[%s](%s)", env.acronym, pipelineURL(link)))
 		}
 	}
-	if len(pipelines) > 0 {
+	return pipelines
+}
+
+func formatPipelineLinks(metadata map[string]string) (string, bool) {
+	if pipelines := pipelineLinks(metadata); len(pipelines) > 0 {
 		return fmt.Sprintf("[%s]", strings.Join(pipelines, ", ")), true
 	}
 	return "", false
@@ -0,0 +1,108 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/ARO-Tools/pkg/topology"
+
+	"github.com/Azure/ARO-HCP/tooling/pipeline-documentation/pkg/pipeline"
+)
+
+// ServicePageName is the file name of the page of a service group, pages link each other by it
+func ServicePageName(serviceGroup string) string {
+	return serviceGroup + ".md"
+}
+
+// ServicePages writes a page for every service group of the topology into the writers returned
+// by create, which is called with the page name
+func ServicePages(topo topology.Topology, pipelines map[string]*pipeline.Pipeline, create func(name string) (io.WriteCloser, error)) error {
+	var err error
+	walk(topo.Services, nil, func(service topology.Service, parent *topology.Service) {
+		if err != nil {
+			return
+		}
+		var into io.WriteCloser
+		into, err = create(ServicePageName(service.ServiceGroup))
+		if err != nil {
+			err = fmt.Errorf("failed to create page for %s: %w", service.ServiceGroup, err)
+			return
+		}
+		err = ServicePage(topo.Entrypoints, service, parent, pipelines[service.ServiceGroup], into)
+	})
+	return err
+}
+
+// ServicePage documents a service group with the resource groups and steps of its pipeline
+func ServicePage(entrypoints []topology.Entrypoint, service topology.Service, parent *topology.Service, p *pipeline.Pipeline, into io.WriteCloser) error {
+	page := bytes.Buffer{}
+	page.WriteString(fmt.Sprintf("# %s\n\n", service.ServiceGroup))
+	if purpose, ok := service.Metadata["purpose"]; ok {
+		page.WriteString(purpose + "\n\n")
+	}
+
+	if name := entrypointName(entrypoints, service.ServiceGroup); name != "" {
+		page.WriteString(fmt.Sprintf("- Entrypoint: %s\n", name))
+	}
+	if path, ok := service.Metadata["pipeline"]; ok {
+		page.WriteString(fmt.Sprintf("- Pipeline: [%s](%s)\n", path, sourceURL(path)))
+	}
+	if links := pipelineLinks(service.Metadata); len(links) > 0 {
+		page.WriteString(fmt.Sprintf("- Runs: %s\n", strings.Join(links, ", ")))
+	}
+	if parent != nil {
+		page.WriteString(fmt.Sprintf("- Rolled out after: %s\n", pageLink(parent.ServiceGroup)))
+	}
+	if len(service.Children) > 0 {
+		var children []string
+		for _, child := range service.Children {
+			children = append(children, pageLink(child.ServiceGroup))
+		}
+		page.WriteString(fmt.Sprintf("- Rolled out before: %s\n", strings.Join(children, ", ")))
+	}
+
+	if p != nil {
+		page.WriteString("\n## Resource Groups\n")
+		if p.RolloutName != "" {
+			page.WriteString(fmt.Sprintf("\nRollout: %s\n", p.RolloutName))
+		}
+		for _, rg := range p.ResourceGroups {
+			page.WriteString(fmt.Sprintf("\n### `%s`\n\n", rg.Name))
+			if rg.Subscription != "" {
+				page.WriteString(fmt.Sprintf("- Subscription: `%s`\n", rg.Subscription))
+			}
+			if rg.AKSCluster != "" {
+				page.WriteString(fmt.Sprintf("- AKS cluster: `%s`\n", rg.AKSCluster))
+			}
+			page.WriteString("\n| Step | Action | Depends on |\n| --- | --- | --- |\n")
+			for _, step := range rg.Steps {
+				page.WriteString(fmt.Sprintf("| %s | %s | %s |\n", step.Name, step.Action, strings.Join(step.DependsOn, ", ")))
+			}
+		}
+	}
+
+	if _, err := into.Write(page.Bytes()); err != nil {
+		return fmt.Errorf("failed to write page for %s: %w", service.ServiceGroup, err)
+	}
+	return into.Close()
+}
+
+func pageLink(serviceGroup string) string {
+	return fmt.Sprintf("[%s](%s)", serviceGroup, ServicePageName(serviceGroup))
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Azure/ARO-HCP/tooling/pipeline-documentation/pkg/pipeline"
+)
+
+func TestServicePage(t *testing.T) {
+	global := testTopology.Services[0]
+	region := global.Children[0]
+	for _, tc := range []struct {
+		name     string
+		page     func(into *closeRecorder) error
+		expected string
+	}{
+		{
+			name: "entrypoint without pipeline",
+			page: func(into *closeRecorder) error {
+				return ServicePage(testTopology.Entrypoints, global, nil, nil, into)
+			},
+			expected: "# Microsoft.Azure.ARO.HCP.Global\n\n" +
+				"Deploy global infrastructure.\n\n" +
+				"- Entrypoint: Global\n" +
+				"- Rolled out before: [Microsoft.Azure.ARO.HCP.Region](Microsoft.Azure.ARO.HCP.Region.md)\n",
+		},
+		{
+			name: "child with pipeline",
+			page: func(into *closeRecorder) error {
+				return ServicePage(testTopology.Entrypoints, region, &global, &pipeline.Pipeline{
+					RolloutName: "Region Rollout",
+					ResourceGroups: []pipeline.ResourceGroup{
+						{
+							Name:         "{{ .regionRG }}",
+							Subscription: "{{ .svc.subscription }}",
+							AKSCluster:   "{{ .svc.aks.name }}",
+							Steps: []pipeline.Step{
+								{Name: "infra", Action: "ARM"},
+								{Name: "deploy", Action: "Shell", DependsOn: []string{"infra", "dns"}},
+							},
+						},
+					},
+				}, into)
+			},
+			expected: "# Microsoft.Azure.ARO.HCP.Region\n\n" +
+				"Deploy regional infrastructure.\n\n" +
+				"- Pipeline: [dev-infrastructure/region-pipeline.yaml](https://github.com/Azure/ARO-HCP/tree/main/dev-infrastructure/region-pipeline.yaml)\n" +
+				"- Runs: [INT](https://msazure.visualstudio.com/AzureRedHatOpenShift/_build?definitionId=1), [STG](https://msazure.visualstudio.com/AzureRedHatOpenShift/_build?definitionId=2)\n" +
+				"- Rolled out after: [Microsoft.Azure.ARO.HCP.Global](Microsoft.Azure.ARO.HCP.Global.md)\n" +
+				"\n## Resource Groups\n" +
+				"\nRollout: Region Rollout\n" +
+				"\n### `{{ .regionRG }}`\n\n" +
+				"- Subscription: `{{ .svc.subscription }}`\n" +
+				"- AKS cluster: `{{ .svc.aks.name }}`\n" +
+				"\n| Step | Action | Depends on |\n| --- | --- | --- |\n" +
+				"| infra | ARM |  |\n" +
+				"| deploy | Shell | infra, dns |\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			into := &closeRecorder{}
+			if err := tc.page(into); err != nil {
+				t.Fatalf("failed to write page: %v", err)
+			}
+			if into.String() != tc.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tc.expected, into.String())
+			}
+			if !into.closed {
+				t.Error("expected the writer to be closed")
+			}
+		})
+	}
+}
+
+func TestServicePages(t *testing.T) {
+	pages := map[string]*closeRecorder{}
+	if err := ServicePages(testTopology, nil, func(name string) (io.WriteCloser, error) {
+		pages[name] = &closeRecorder{}
+		return pages[name], nil
+	}); err != nil {
+		t.Fatalf("failed to write pages: %v", err)
+	}
+	for _, name := range []string{"Microsoft.Azure.ARO.HCP.Global.md", "Microsoft.Azure.ARO.HCP.Region.md"} {
+		page, ok := pages[name]
+		if !ok {
+			t.Errorf("expected page %s", name)
+			continue
+		}
+		if !page.closed {
+			t.Errorf("expected page %s to be closed", name)
+		}
+	}
+	if len(pages) != 2 {
+		t.Errorf("expected 2 pages, got %d", len(pages))
+	}
+
+	if err := ServicePages(testTopology, nil, func(name string) (io.WriteCloser, error) {
+		return nil, errors.New("read-only")
+	}); err == nil || err.Error() != "failed to create page for Microsoft.Azure.ARO.HCP.Global: read-only" {
+		t.Errorf("expected the create error, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/Azure/ARO-Tools/pkg/topology"
+	"sigs.k8s.io/yaml"
+)
+
+// Pipeline holds the parts of a pipeline file that are documented. Templated values, like the
+// names of resource groups, are kept as they are written in the file.
+type Pipeline struct {
+	ServiceGroup   string          `json:"serviceGroup"`
+	RolloutName    string          `json:"rolloutName,omitempty"`
+	ResourceGroups []ResourceGroup `json:"resourceGroups"`
+}
+
+type ResourceGroup struct {
+	Name         string `json:"name"`
+	Subscription string `json:"subscription,omitempty"`
+	AKSCluster   string `json:"aksCluster,omitempty"`
+	Steps        []Step `json:"steps"`
+}
+
+type Step struct {
+	Name      string   `json:"name"`
+	Action    string   `json:"action"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// controlActionPattern matches template actions like {{- if .dns.enabled }} on a line of their own
+var controlActionPattern = regexp.MustCompile(`(?m)^[ \t]*\{\{-?\s*(if|else|end|range|with)\b[^}]*\}\}[ \t]*\n?`)
+
+// Load reads a pipeline file, relative paths are resolved against the repository root. Pipeline
+// files are templates of the service config. Control actions on lines of their own are dropped,
+// so the pipeline holds the steps of all branches.
+func Load(repoRoot, path string) (*Pipeline, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repoRoot, path)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pipeline file %s: %w", path, err)
+	}
+	var p Pipeline
+	if err := yaml.Unmarshal(controlActionPattern.ReplaceAll(raw, nil), &p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pipeline file %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// LoadAll reads the pipeline files of all services in the topology, keyed by service group.
+// Services without a pipeline are skipped.
+func LoadAll(topo topology.Topology, repoRoot string) (map[string]*Pipeline, error) {
+	pipelines := map[string]*Pipeline{}
+	var load func(services []topology.Service) error
+	load = func(services []topology.Service) error {
+		for _, service := range services {
+			if path, ok := service.Metadata["pipeline"]; ok && path != "" {
+				p, err := Load(repoRoot, path)
+				if err != nil {
+					return fmt.Errorf("service group %s: %w", service.ServiceGroup, err)
+				}
+				pipelines[service.ServiceGroup] = p
+			}
+			if err := load(service.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := load(topo.Services); err != nil {
+		return nil, err
+	}
+	return pipelines, nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestControlActionPattern(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "no actions",
+			input:    "steps:\n- name: a\n",
+			expected: "steps:\n- name: a\n",
+		},
+		{
+			name:     "if and end",
+			input:    "steps:\n{{- if .dns.enabled }}\n- name: dns\n{{- end }}\n- name: a\n",
+			expected: "steps:\n- name: dns\n- name: a\n",
+		},
+		{
+			name:     "indented else",
+			input:    "  {{ if .a }}\n  x: 1\n  {{ else }}\n  x: 2\n  {{ end }}\n",
+			expected: "  x: 1\n  x: 2\n",
+		},
+		{
+			name:     "range and with",
+			input:    "{{ range .items }}\n- item\n{{ end }}\n{{- with .b }}\nb: true\n{{- end }}",
+			expected: "- item\nb: true\n",
+		},
+		{
+			name:     "values are kept",
+			input:    "name: '{{ .regionRG }}'\nsubscription: {{ .svc.subscription }}\n",
+			expected: "name: '{{ .regionRG }}'\nsubscription: {{ .svc.subscription }}\n",
+		},
+		{
+			name:     "actions sharing a line with content are kept",
+			input:    "enabled: {{ if .a }}true{{ end }}\n",
+			expected: "enabled: {{ if .a }}true{{ end }}\n",
+		},
+		{
+			name:     "identifiers starting with a keyword are kept",
+			input:    "{{ endpoint }}\n",
+			expected: "{{ endpoint }}\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if output := controlActionPattern.ReplaceAllString(tc.input, ""); output != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, output)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	repoRoot := t.TempDir()
+	content := `serviceGroup: Microsoft.Azure.ARO.HCP.Region
+rolloutName: Region Rollout
+resourceGroups:
+- name: '{{ .regionRG }}'
+  subscription: '{{ .svc.subscription }}'
+  steps:
+  - name: infra
+    action: ARM
+{{- if .dns.enabled }}
+  - name: dns
+    action: ARM
+    dependsOn:
+    - infra
+{{- end }}
+- name: '{{ .svc.rg }}'
+  aksCluster: '{{ .svc.aks.name }}'
+  steps:
+  - name: deploy
+    action: Shell
+`
+	if err := os.MkdirAll(filepath.Join(repoRoot, "region"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoRoot, "region", "pipeline.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Pipeline{
+		ServiceGroup: "Microsoft.Azure.ARO.HCP.Region",
+		RolloutName:  "Region Rollout",
+		ResourceGroups: []ResourceGroup{
+			{
+				Name:         "{{ .regionRG }}",
+				Subscription: "{{ .svc.subscription }}",
+				Steps: []Step{
+					{Name: "infra", Action: "ARM"},
+					{Name: "dns", Action: "ARM", DependsOn: []string{"infra"}},
+				},
+			},
+			{
+				Name:       "{{ .svc.rg }}",
+				AKSCluster: "{{ .svc.aks.name }}",
+				Steps:      []Step{{Name: "deploy", Action: "Shell"}},
+			},
+		},
+	}
+	for _, path := range []string{"region/pipeline.yaml", filepath.Join(repoRoot, "region", "pipeline.yaml")} {
+		p, err := Load(repoRoot, path)
+		if err != nil {
+			t.Fatalf("failed to load %s: %v", path, err)
+		}
+		if !reflect.DeepEqual(p, expected) {
+			t.Errorf("loading %s: expected %+v, got %+v", path, expected, p)
+		}
+	}
+
+	if _, err := Load(repoRoot, "missing.yaml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/Azure/ARO-Tools/pkg/topology"
+
+	"github.com/Azure/ARO-HCP/tooling/pipeline-documentation/pkg/pipeline"
+)
+
+// Validate checks the topology and the pipeline files it references. It reports service groups
+// that are their own ancestors or are defined more than once, missing pipeline files, cycles in
+// the step dependencies of pipelines and unused entrypoints, which identify no service group or
+// roll out no pipeline. All issues are returned joined in one error.
+func Validate(topo topology.Topology, repoRoot string) error {
+	var issues []error
+	serviceGroups := map[string]int{}
+	definitions := map[string]topology.Service{}
+
+	var walk func(services []topology.Service, ancestors []string)
+	walk = func(services []topology.Service, ancestors []string) {
+		for _, service := range services {
+			serviceGroups[service.ServiceGroup]++
+			if _, ok := definitions[service.ServiceGroup]; !ok {
+				definitions[service.ServiceGroup] = service
+			}
+			if i := slices.Index(ancestors, service.ServiceGroup); i >= 0 {
+				cycle := append(slices.Clone(ancestors[i:]), service.ServiceGroup)
+				issues = append(issues, fmt.Errorf("service group %s depends on itself: %s", service.ServiceGroup, strings.Join(cycle, " -> ")))
+				// the subtree repeats the cycle
+				continue
+			}
+			issues = append(issues, validatePipeline(service, repoRoot)...)
+			walk(service.Children, append(ancestors, service.ServiceGroup))
+		}
+	}
+	walk(topo.Services, nil)
+
+	for _, serviceGroup := range sortedKeys(serviceGroups) {
+		if serviceGroups[serviceGroup] > 1 {
+			issues = append(issues, fmt.Errorf("service group %s is defined %d times", serviceGroup, serviceGroups[serviceGroup]))
+		}
+	}
+	for _, entrypoint := range topo.Entrypoints {
+		service, ok := definitions[entrypoint.Identifier]
+		if !ok {
+			issues = append(issues, fmt.Errorf("entrypoint %s is unused: it identifies no service group", entrypoint.Identifier))
+			continue
+		}
+		if !rollsOutPipeline(service) {
+			issues = append(issues, fmt.Errorf("entrypoint %s is unused: no service group it rolls out has a pipeline file", entrypoint.Identifier))
+		}
+	}
+	return errors.Join(issues...)
+}
+
+func validatePipeline(service topology.Service, repoRoot string) []error {
+	path, ok := service.Metadata["pipeline"]
+	if !ok || path == "" {
+		return nil
+	}
+	p, err := pipeline.Load(repoRoot, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []error{fmt.Errorf("service group %s: pipeline file %s does not exist", service.ServiceGroup, path)}
+	}
+	if err != nil {
+		return []error{fmt.Errorf("service group %s: %w", service.ServiceGroup, err)}
+	}
+
+	var issues []error
+	if p.ServiceGroup != "" && p.ServiceGroup != service.ServiceGroup {
+		issues = append(issues, fmt.Errorf("service group %s: pipeline file %s is for service group %s", service.ServiceGroup, path, p.ServiceGroup))
+	}
+	dependencies := map[string][]string{}
+	for _, rg := range p.ResourceGroups {
+		for _, step := range rg.Steps {
+			dependencies[step.Name] = step.DependsOn
+		}
+	}
+	for _, step := range sortedKeys(dependencies) {
+		for _, dependency := range dependencies[step] {
+			if _, ok := dependencies[dependency]; !ok {
+				issues = append(issues, fmt.Errorf("service group %s: step %s depends on unknown step %s", service.ServiceGroup, step, dependency))
+			}
+		}
+	}
+	if cycle := findCycle(dependencies); cycle != nil {
+		issues = append(issues, fmt.Errorf("service group %s: steps depend on each other: %s", service.ServiceGroup, strings.Join(cycle, " -> ")))
+	}
+	return issues
+}
+
+// rollsOutPipeline tells whether the service or any of its descendants has a pipeline file
+func rollsOutPipeline(service topology.Service) bool {
+	if service.Metadata["pipeline"] != "" {
+		return true
+	}
+	return slices.ContainsFunc(service.Children, rollsOutPipeline)
+}
+
+// findCycle returns the steps of the first dependency cycle, starting and ending with the same step
+func findCycle(dependencies map[string][]string) []string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(step string) []string
+	visit = func(step string) []string {
+		switch state[step] {
+		case visiting:
+			return append(slices.Clone(path[slices.Index(path, step):]), step)
+		case done:
+			return nil
+		}
+		state[step] = visiting
+		path = append(path, step)
+		for _, dependency := range dependencies[step] {
+			if _, ok := dependencies[dependency]; !ok {
+				continue
+			}
+			if cycle := visit(dependency); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[step] = done
+		return nil
+	}
+	for _, step := range sortedKeys(dependencies) {
+		if cycle := visit(step); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,261 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Azure/ARO-Tools/pkg/topology"
+)
+
+func TestFindCycle(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		dependencies map[string][]string
+		expected     []string
+	}{
+		{
+			name: "no steps",
+		},
+		{
+			name: "independent steps",
+			dependencies: map[string][]string{
+				"a": nil,
+				"b": nil,
+			},
+		},
+		{
+			name: "chain",
+			dependencies: map[string][]string{
+				"a": nil,
+				"b": {"a"},
+				"c": {"b", "a"},
+			},
+		},
+		{
+			name: "unknown dependencies are ignored",
+			dependencies: map[string][]string{
+				"a": {"missing"},
+			},
+		},
+		{
+			name: "self dependency",
+			dependencies: map[string][]string{
+				"a": {"a"},
+			},
+			expected: []string{"a", "a"},
+		},
+		{
+			name: "cycle",
+			dependencies: map[string][]string{
+				"a": {"b"},
+				"b": {"c"},
+				"c": {"a"},
+			},
+			expected: []string{"a", "b", "c", "a"},
+		},
+		{
+			name: "cycle behind a chain",
+			dependencies: map[string][]string{
+				"a": {"b"},
+				"b": {"c"},
+				"c": {"b"},
+			},
+			expected: []string{"b", "c", "b"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if cycle := findCycle(tc.dependencies); !slices.Equal(cycle, tc.expected) {
+				t.Errorf("expected cycle %v, got %v", tc.expected, cycle)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	repoRoot := t.TempDir()
+	for path, content := range map[string]string{
+		"valid.yaml": `serviceGroup: Microsoft.Azure.ARO.HCP.Region
+resourceGroups:
+- name: '{{ .regionRG }}'
+  steps:
+  - name: infra
+    action: ARM
+{{- if .dns.enabled }}
+  - name: dns
+    action: ARM
+    dependsOn:
+    - infra
+{{- end }}
+`,
+		"other.yaml": `serviceGroup: Microsoft.Azure.ARO.HCP.Other
+resourceGroups: []
+`,
+		"unknown.yaml": `serviceGroup: Microsoft.Azure.ARO.HCP.Region
+resourceGroups:
+- name: rg
+  steps:
+  - name: deploy
+    action: Shell
+    dependsOn:
+    - build
+`,
+		"cycle.yaml": `serviceGroup: Microsoft.Azure.ARO.HCP.Region
+resourceGroups:
+- name: rg
+  steps:
+  - name: a
+    action: Shell
+    dependsOn:
+    - b
+- name: other
+  steps:
+  - name: b
+    action: Shell
+    dependsOn:
+    - a
+`,
+	} {
+		if err := os.WriteFile(filepath.Join(repoRoot, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service := func(serviceGroup, pipeline string, children ...topology.Service) topology.Service {
+		s := topology.Service{ServiceGroup: serviceGroup, Children: children}
+		if pipeline != "" {
+			s.Metadata = map[string]string{"pipeline": pipeline}
+		}
+		return s
+	}
+	entrypoint := func(identifier string) topology.Entrypoint {
+		return topology.Entrypoint{Identifier: identifier}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		topo     topology.Topology
+		expected []string
+	}{
+		{
+			name: "valid",
+			topo: topology.Topology{
+				Entrypoints: []topology.Entrypoint{entrypoint("Microsoft.Azure.ARO.HCP.Global"), entrypoint("Microsoft.Azure.ARO.HCP.Region")},
+				Services: []topology.Service{
+					service("Microsoft.Azure.ARO.HCP.Global", "",
+						service("Microsoft.Azure.ARO.HCP.Region", "valid.yaml"),
+					),
+				},
+			},
+		},
+		{
+			name: "service group is its own ancestor",
+			topo: topology.Topology{
+				Services: []topology.Service{
+					service("a", "",
+						service("b", "",
+							service("a", ""),
+						),
+					),
+				},
+			},
+			expected: []string{
+				"service group a depends on itself: a -> b -> a",
+				"service group a is defined 2 times",
+			},
+		},
+		{
+			name: "service group defined twice",
+			topo: topology.Topology{
+				Services: []topology.Service{
+					service("a", "", service("b", "")),
+					service("c", "", service("b", "")),
+				},
+			},
+			expected: []string{"service group b is defined 2 times"},
+		},
+		{
+			name: "missing pipeline file",
+			topo: topology.Topology{
+				Services: []topology.Service{service("Microsoft.Azure.ARO.HCP.Region", "missing.yaml")},
+			},
+			expected: []string{"service group Microsoft.Azure.ARO.HCP.Region: pipeline file missing.yaml does not exist"},
+		},
+		{
+			name: "pipeline file of another service group",
+			topo: topology.Topology{
+				Services: []topology.Service{service("Microsoft.Azure.ARO.HCP.Region", "other.yaml")},
+			},
+			expected: []string{"service group Microsoft.Azure.ARO.HCP.Region: pipeline file other.yaml is for service group Microsoft.Azure.ARO.HCP.Other"},
+		},
+		{
+			name: "unknown step dependency",
+			topo: topology.Topology{
+				Services: []topology.Service{service("Microsoft.Azure.ARO.HCP.Region", "unknown.yaml")},
+			},
+			expected: []string{"service group Microsoft.Azure.ARO.HCP.Region: step deploy depends on unknown step build"},
+		},
+		{
+			name: "step dependency cycle across resource groups",
+			topo: topology.Topology{
+				Services: []topology.Service{service("Microsoft.Azure.ARO.HCP.Region", "cycle.yaml")},
+			},
+			expected: []string{"service group Microsoft.Azure.ARO.HCP.Region: steps depend on each other: a -> b -> a"},
+		},
+		{
+			name: "entrypoint without service group",
+			topo: topology.Topology{
+				Entrypoints: []topology.Entrypoint{entrypoint("Microsoft.Azure.ARO.HCP.Missing")},
+				Services:    []topology.Service{service("Microsoft.Azure.ARO.HCP.Region", "valid.yaml")},
+			},
+			expected: []string{"entrypoint Microsoft.Azure.ARO.HCP.Missing is unused: it identifies no service group"},
+		},
+		{
+			name: "entrypoint without pipelines",
+			topo: topology.Topology{
+				Entrypoints: []topology.Entrypoint{entrypoint("Microsoft.Azure.ARO.HCP.Global"), entrypoint("Microsoft.Azure.ARO.HCP.Empty")},
+				Services: []topology.Service{
+					service("Microsoft.Azure.ARO.HCP.Global", "",
+						service("Microsoft.Azure.ARO.HCP.Region", "valid.yaml"),
+						service("Microsoft.Azure.ARO.HCP.Empty", "",
+							service("Microsoft.Azure.ARO.HCP.Empty.Child", ""),
+						),
+					),
+				},
+			},
+			expected: []string{"entrypoint Microsoft.Azure.ARO.HCP.Empty is unused: no service group it rolls out has a pipeline file"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.topo, repoRoot)
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Errorf("expected no issues, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected issues %v, got none", tc.expected)
+			}
+			if issues := strings.Split(err.Error(), "\n"); !slices.Equal(issues, tc.expected) {
+				t.Errorf("expected issues\n%s\ngot\n%s", strings.Join(tc.expected, "\n"), err)
+			}
+		})
+	}
+}
@@ -19,9 +19,10 @@ import (
 	"io"
 	"io/fs"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 
@@ -40,17 +41,61 @@ type alertingRuleFile struct {
 	TestFileBaseName string
 	Rules            monitoringv1.PrometheusRule
 	TestFileContent  []byte
+	// Path is the rules file as configured, used to report lint issues
+	Path  string
+	Lines ruleLines
 }
 
 type Options struct {
-	outputBicep string
-	ruleFiles   []alertingRuleFile
+	outputBicep    string
+	ruleFiles      []alertingRuleFile
+	targets        []ruleTarget
+	untestedAlerts map[string]bool
 }
 
 type PrometheusRulesConfig struct {
 	RulesFolders  []string `yaml:"rulesFolders"`
 	UntestedRules []string `yaml:"untestedRules,omitempty"`
-	OutputBicep   string   `yaml:"outputBicep"`
+	// UntestedAlerts are alerts in the rules folders that do not need an alert_rule_test. Unlike
+	// UntestedRules, which skips the tests of whole files, the other alerts of the file are still
+	// tested and need coverage.
+	UntestedAlerts []string     `yaml:"untestedAlerts,omitempty"`
+	OutputBicep    string       `yaml:"outputBicep"`
+	Targets        []RuleTarget `yaml:"targets,omitempty"`
+}
+
+// RuleTarget routes rule groups to a separate bicep module, i.e. to deploy them to another
+// Azure Monitor workspace or to scope them to a single cluster. Rule groups that match no
+// target are written to OutputBicep of the PrometheusRulesConfig.
+type RuleTarget struct {
+	Name string `yaml:"name"`
+	// OutputBicep is the bicep module the rule groups are written to, relative to the config file
+	OutputBicep string `yaml:"outputBicep"`
+	// RuleGroups are regular expressions matching the names of the rule groups of this target
+	RuleGroups []string `yaml:"ruleGroups"`
+	// ClusterScoped applies the rule groups only to the data of a single AKS cluster. The
+	// bicep module gets the additional parameters clusterName and aksClusterId.
+	ClusterScoped bool `yaml:"clusterScoped,omitempty"`
+}
+
+type ruleTarget struct {
+	RuleTarget
+	outputBicep string
+	ruleGroups  []*regexp.Regexp
+}
+
+// matches returns true if the rule group belongs to the target, a target without rule
+// group patterns matches all rule groups
+func (t *ruleTarget) matches(groupName string) bool {
+	if len(t.ruleGroups) == 0 {
+		return true
+	}
+	for _, re := range t.ruleGroups {
+		if re.MatchString(groupName) {
+			return true
+		}
+	}
+	return false
 }
 
 type CliConfig struct {
@@ -62,16 +107,20 @@ func NewOptions() *Options {
 	return o
 }
 
-func readRulesFile(filename string) (*monitoringv1.PrometheusRule, error) {
+func readRulesFile(filename string) (*monitoringv1.PrometheusRule, ruleLines, error) {
 	rawRules, err := os.ReadFile(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read input rules: %v", err)
+		return nil, ruleLines{}, fmt.Errorf("failed to read input rules: %v", err)
 	}
 	var rules monitoringv1.PrometheusRule
 	if err := yaml.Unmarshal(rawRules, &rules); err != nil {
-		return nil, fmt.Errorf("failed to parse input rules: %v", err)
+		return nil, ruleLines{}, fmt.Errorf("failed to parse input rules: %v", err)
 	}
-	return &rules, nil
+	lines, err := findRuleLines(rawRules)
+	if err != nil {
+		return nil, ruleLines{}, fmt.Errorf("failed to parse input rules: %v", err)
+	}
+	return &rules, lines, nil
 }
 
 func (o *Options) Complete(configFilePath string) error {
@@ -92,15 +141,50 @@ func (o *Options) Complete(configFilePath string) error {
 
 	o.outputBicep = path.Join(baseDirectory, config.PrometheusRules.OutputBicep)
 
+	o.untestedAlerts = make(map[string]bool)
+	for _, alert := range config.PrometheusRules.UntestedAlerts {
+		o.untestedAlerts[alert] = true
+	}
+
+	outputs := map[string]bool{o.outputBicep: true}
+	for _, target := range config.PrometheusRules.Targets {
+		if target.OutputBicep == "" || len(target.RuleGroups) == 0 {
+			return fmt.Errorf("target %q requires outputBicep and ruleGroups", target.Name)
+		}
+		t := ruleTarget{
+			RuleTarget:  target,
+			outputBicep: path.Join(baseDirectory, target.OutputBicep),
+		}
+		if outputs[t.outputBicep] {
+			return fmt.Errorf("target %q: output %s is used more than once", target.Name, target.OutputBicep)
+		}
+		outputs[t.outputBicep] = true
+		for _, pattern := range target.RuleGroups {
+			re, err := regexp.Compile("^(?:" + pattern + ")$")
+			if err != nil {
+				return fmt.Errorf("target %q: invalid rule group pattern %q: %v", target.Name, pattern, err)
+			}
+			t.ruleGroups = append(t.ruleGroups, re)
+		}
+		o.targets = append(o.targets, t)
+	}
+	// the default target is last, it matches all rule groups not routed to another target
+	o.targets = append(o.targets, ruleTarget{
+		RuleTarget:  RuleTarget{Name: "default"},
+		outputBicep: o.outputBicep,
+	})
+
 	for _, untestedRules := range config.PrometheusRules.UntestedRules {
 		filePath := path.Join(baseDirectory, untestedRules)
-		rules, err := readRulesFile(filePath)
+		rules, lines, err := readRulesFile(filePath)
 		if err != nil {
 			return fmt.Errorf("error reading rules file %v", err)
 		}
 		o.ruleFiles = append(o.ruleFiles, alertingRuleFile{
 			FileBaseName: filePath,
 			Rules:        *rules,
+			Path:         filePath,
+			Lines:        lines,
 		})
 	}
 
@@ -118,7 +202,7 @@ func (o *Options) Complete(configFilePath string) error {
 				folderName := filepath.Dir(path)
 				fileBaseName := filepath.Base(path)
 
-				rules, err := readRulesFile(path)
+				rules, lines, err := readRulesFile(path)
 				if err != nil {
 					return fmt.Errorf("error reading rules file %v", err)
 				}
@@ -143,6 +227,8 @@ func (o *Options) Complete(configFilePath string) error {
 					TestFileBaseName: filepath.Base(testFile),
 					TestFileContent:  testFileContent,
 					Rules:            *rules,
+					Path:             path,
+					Lines:            lines,
 				})
 			}
 			return nil
@@ -155,6 +241,9 @@ func (o *Options) Complete(configFilePath string) error {
 	return nil
 }
 
+// RunTests runs the promtool test files of the rule files in-process. It fails if a test
+// fails or if an alert has no alert_rule_test and is not listed in untestedAlerts. Branches
+// of `or` expressions that no test exercises are reported as warnings.
 func (o *Options) RunTests() error {
 	dir, err := os.MkdirTemp("/tmp", "prom-rule-test")
 	if err != nil {
@@ -166,6 +255,8 @@ func (o *Options) RunTests() error {
 
 	logrus.Debugf("Created tempdir %s", dir)
 
+	var failures []string
+	var untested []string
 	for _, irf := range o.ruleFiles {
 		if irf.TestFileBaseName == "" {
 			continue
@@ -182,115 +273,194 @@ func (o *Options) RunTests() error {
 			return fmt.Errorf("error writing rule groups file %v", err)
 		}
 
-		fileNameParts := strings.Split(irf.FileBaseName, ".")
-		if len(fileNameParts) != 2 {
-			return fmt.Errorf("missing filename extension or using '.' in filename")
-		}
-
 		testFile := filepath.Join(dir, irf.TestFileBaseName)
 		err = os.WriteFile(testFile, irf.TestFileContent, 0644)
 		if err != nil {
 			return fmt.Errorf("error writing rule groups test file %v", err)
 		}
 		logrus.Debugf("running test %s", irf.TestFileBaseName)
-		cmd := exec.Command("promtool", "test", "rules", testFile)
-		output, err := cmd.CombinedOutput()
+		result, err := runRuleTestFile(testFile)
 		if err != nil {
-			logrus.Error(string(output))
-			return fmt.Errorf("error running promtool %v", err)
+			return fmt.Errorf("error running test %s: %w", irf.TestFileBaseName, err)
 		}
+		failures = append(failures, result.failures...)
 
+		for _, alert := range sortedKeys(result.alerts) {
+			if !result.alerts[alert] && !o.untestedAlerts[alert] {
+				untested = append(untested, fmt.Sprintf("%s (%s)", alert, irf.FileBaseName))
+			}
+			for _, branch := range result.branches[alert] {
+				if len(result.branches[alert]) > 1 && !branch.exercised {
+					logrus.WithFields(logrus.Fields{
+						"alert":  alert,
+						"branch": branch.expr.String(),
+					}).Warn("expression branch is never exercised by the tests")
+				}
+			}
+		}
 	}
 
+	if len(failures) > 0 {
+		return fmt.Errorf("%d rule tests failed:\n  %s", len(failures), strings.Join(failures, "\n  "))
+	}
+	if len(untested) > 0 {
+		return fmt.Errorf("alerts without alert_rule_test, add a test or list them in untestedAlerts: %s", strings.Join(untested, ", "))
+	}
 	return nil
 }
 
-func (o *Options) Generate() error {
-	output, err := os.Create(o.outputBicep)
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create output file")
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
-	defer func() {
-		if err := output.Close(); err != nil {
-			logrus.WithError(err).Error("failed to close output file")
-		}
-	}()
+	sort.Strings(keys)
+	return keys
+}
 
-	if _, err := output.Write([]byte(`param azureMonitoring string
-`)); err != nil {
-		return err
+func (o *Options) Generate() error {
+	if issues := o.Lint(); len(issues) > 0 {
+		messages := make([]string, 0, len(issues))
+		for _, issue := range issues {
+			messages = append(messages, issue.String())
+		}
+		return fmt.Errorf("%d lint issues found in rules:\n  %s", len(issues), strings.Join(messages, "\n  "))
 	}
 
+	groupsByTarget := make(map[string][]armalertsmanagement.PrometheusRuleGroupResource)
 	for _, irf := range o.ruleFiles {
 		for _, group := range irf.Rules.Spec.Groups {
-			logger := logrus.WithFields(logrus.Fields{
-				"group": group.Name,
-			})
-			if group.QueryOffset != nil {
-				logger.Warn("query offset is not supported in Microsoft.AlertsManagement/prometheusRuleGroups")
+			armGroup := convertGroup(group)
+			if len(armGroup.Properties.Rules) == 0 {
+				continue
 			}
-			if group.Limit != nil {
-				logger.Warn("alert limit is not supported in Microsoft.AlertsManagement/prometheusRuleGroups")
-			}
-			armGroup := armalertsmanagement.PrometheusRuleGroupResource{
-				Name: ptr.To(group.Name),
-				Properties: &armalertsmanagement.PrometheusRuleGroupProperties{
-					Interval: formatDuration(group.Interval),
-					Enabled:  ptr.To(true),
-				},
+			for _, target := range o.targets {
+				if target.matches(group.Name) {
+					logrus.WithFields(logrus.Fields{"group": group.Name, "target": target.Name}).Debug("routing rule group")
+					groupsByTarget[target.outputBicep] = append(groupsByTarget[target.outputBicep], armGroup)
+					break
+				}
 			}
+		}
+	}
 
-			for _, rule := range group.Rules {
-				labels := map[string]*string{}
-				for k, v := range group.Labels {
-					labels[k] = ptr.To(strings.ReplaceAll(v, "'", "\\'"))
-				}
-				for k, v := range rule.Labels {
-					labels[k] = ptr.To(strings.ReplaceAll(v, "'", "\\'"))
-				}
+	for _, target := range o.targets {
+		if err := writeTarget(target, groupsByTarget[target.outputBicep]); err != nil {
+			return fmt.Errorf("failed to write target %s: %w", target.Name, err)
+		}
+	}
+	return nil
+}
 
-				annotations := map[string]*string{}
-				for k, v := range rule.Annotations {
-					annotations[k] = ptr.To(strings.ReplaceAll(v, "'", "\\'"))
-				}
-				if rule.Alert != "" {
-					armGroup.Properties.Rules = append(armGroup.Properties.Rules, &armalertsmanagement.PrometheusRule{
-						Alert:       ptr.To(rule.Alert),
-						Enabled:     ptr.To(true),
-						Labels:      labels,
-						Annotations: annotations,
-						For:         formatDuration(rule.For),
-						Expression: ptr.To(
-							strings.TrimSpace(
-								strings.ReplaceAll(rule.Expr.String(), "\n", " "),
-							),
-						),
-						Severity: severityFor(labels),
-					})
-				}
-			}
+func convertGroup(group monitoringv1.RuleGroup) armalertsmanagement.PrometheusRuleGroupResource {
+	logger := logrus.WithFields(logrus.Fields{
+		"group": group.Name,
+	})
+	if group.QueryOffset != nil {
+		logger.Warn("query offset is not supported in Microsoft.AlertsManagement/prometheusRuleGroups")
+	}
+	if group.Limit != nil {
+		logger.Warn("alert limit is not supported in Microsoft.AlertsManagement/prometheusRuleGroups")
+	}
+	armGroup := armalertsmanagement.PrometheusRuleGroupResource{
+		Name: ptr.To(group.Name),
+		Properties: &armalertsmanagement.PrometheusRuleGroupProperties{
+			Interval: formatDuration(group.Interval),
+			Enabled:  ptr.To(true),
+		},
+	}
 
-			if len(armGroup.Properties.Rules) > 0 {
-				if err := writeGroups(armGroup, output); err != nil {
-					return err
-				}
+	for _, rule := range group.Rules {
+		labels := map[string]*string{}
+		for k, v := range group.Labels {
+			labels[k] = ptr.To(strings.ReplaceAll(v, "'", "\\'"))
+		}
+		for k, v := range rule.Labels {
+			labels[k] = ptr.To(strings.ReplaceAll(v, "'", "\\'"))
+		}
+		expression := ptr.To(
+			strings.TrimSpace(
+				strings.ReplaceAll(rule.Expr.String(), "\n", " "),
+			),
+		)
+
+		switch {
+		case rule.Alert != "":
+			annotations := map[string]*string{}
+			for k, v := range rule.Annotations {
+				annotations[k] = ptr.To(strings.ReplaceAll(v, "'", "\\'"))
+			}
+			armGroup.Properties.Rules = append(armGroup.Properties.Rules, &armalertsmanagement.PrometheusRule{
+				Alert:       ptr.To(rule.Alert),
+				Enabled:     ptr.To(true),
+				Labels:      labels,
+				Annotations: annotations,
+				For:         formatDuration(rule.For),
+				Expression:  expression,
+				Severity:    severityFor(labels),
+			})
+		case rule.Record != "":
+			if len(rule.Annotations) > 0 || rule.For != nil {
+				logger.Warnf("annotations and for are ignored for recording rule %s", rule.Record)
 			}
+			armGroup.Properties.Rules = append(armGroup.Properties.Rules, &armalertsmanagement.PrometheusRule{
+				Record:     ptr.To(rule.Record),
+				Enabled:    ptr.To(true),
+				Labels:     labels,
+				Expression: expression,
+			})
 		}
+	}
+	return armGroup
+}
+
+func writeTarget(target ruleTarget, groups []armalertsmanagement.PrometheusRuleGroupResource) error {
+	output, err := os.Create(target.outputBicep)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer func() {
+		if err := output.Close(); err != nil {
+			logrus.WithError(err).Error("failed to close output file")
+		}
+	}()
 
+	params := "param azureMonitoring string\n"
+	if target.ClusterScoped {
+		params += "param clusterName string\nparam aksClusterId string\n"
+	}
+	if _, err := output.Write([]byte(params)); err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		if err := writeGroups(group, target.ClusterScoped, output); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func writeGroups(groups armalertsmanagement.PrometheusRuleGroupResource, into io.Writer) error {
+func writeGroups(groups armalertsmanagement.PrometheusRuleGroupResource, clusterScoped bool, into io.Writer) error {
 	tmpl, err := template.New("prometheusRuleGroup").Parse(`
 resource {{.name}} 'Microsoft.AlertsManagement/prometheusRuleGroups@2023-03-01' = {
   name: '{{.groups.Name}}'
   location: resourceGroup().location
   properties: {
+{{- if .clusterScoped}}
+    clusterName: clusterName
+{{- end}}
+{{- with .groups.Properties.Interval}}
+    interval: '{{.}}'
+{{- end}}
     rules: [
 {{- range .groups.Properties.Rules}}
       {
+{{- if .Record}}
+        record: '{{.Record}}'
+{{- else}}
         alert: '{{.Alert}}'
+{{- end}}
         enabled: {{.Enabled}}
 {{- if .Labels}}
         labels: {
@@ -310,12 +480,17 @@ resource {{.name}} 'Microsoft.AlertsManagement/prometheusRuleGroups@2023-03-01'
 {{- if .For }}
         for: '{{.For}}'
 {{- end }}
+{{- if .Severity }}
         severity: {{.Severity}}
+{{- end }}
       }
 {{- end}}
     ]
     scopes: [
       azureMonitoring
+{{- if .clusterScoped}}
+      aksClusterId
+{{- end}}
     ]
   }
 }
@@ -325,8 +500,9 @@ resource {{.name}} 'Microsoft.AlertsManagement/prometheusRuleGroups@2023-03-01'
 	}
 
 	return tmpl.Execute(into, map[string]any{
-		"name":   bicepName(groups.Name),
-		"groups": groups,
+		"name":          bicepName(groups.Name),
+		"groups":        groups,
+		"clusterScoped": clusterScoped,
 	})
 }
 
@@ -0,0 +1,203 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// requiredAnnotations must be set on every alert
+var requiredAnnotations = []string{"summary", "description", "runbook_url"}
+
+// supportedSeverities are the severity labels severityFor maps to Azure severities
+var supportedSeverities = []string{"critical", "warning", "info"}
+
+// ruleLines are the line numbers of the rule groups and their rules in a rules file
+type ruleLines struct {
+	groups []int
+	rules  [][]int
+}
+
+func (l ruleLines) group(i int) int {
+	if i < len(l.groups) {
+		return l.groups[i]
+	}
+	return 0
+}
+
+func (l ruleLines) rule(group, rule int) int {
+	if group < len(l.rules) && rule < len(l.rules[group]) {
+		return l.rules[group][rule]
+	}
+	return 0
+}
+
+// LintIssue is a problem of a rule that would lead to a wrong or missing alert in Azure
+type LintIssue struct {
+	File    string
+	Line    int
+	Rule    string
+	Message string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", i.File, i.Line, i.Rule, i.Message)
+}
+
+// findRuleLines finds the lines of spec.groups[*] and spec.groups[*].rules[*]
+func findRuleLines(raw []byte) (ruleLines, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(raw, &doc); err != nil {
+		return ruleLines{}, err
+	}
+	lines := ruleLines{}
+	if len(doc.Content) == 0 {
+		return lines, nil
+	}
+	groups := mappingValue(mappingValue(doc.Content[0], "spec"), "groups")
+	if groups == nil || groups.Kind != yamlv3.SequenceNode {
+		return lines, nil
+	}
+	for _, group := range groups.Content {
+		lines.groups = append(lines.groups, group.Line)
+		var ruleLines []int
+		if rules := mappingValue(group, "rules"); rules != nil && rules.Kind == yamlv3.SequenceNode {
+			for _, rule := range rules.Content {
+				ruleLines = append(ruleLines, rule.Line)
+			}
+		}
+		lines.rules = append(lines.rules, ruleLines)
+	}
+	return lines, nil
+}
+
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// checkAzureDuration verifies that formatDuration can convert the duration into an ISO 8601
+// duration Azure accepts
+func checkAzureDuration(d *monitoringv1.Duration) string {
+	if d == nil {
+		return ""
+	}
+	parsed, err := model.ParseDuration(string(*d))
+	if err != nil {
+		return fmt.Sprintf("invalid duration %q", string(*d))
+	}
+	if time.Duration(parsed) < time.Minute {
+		return fmt.Sprintf("duration %s is shorter than 1m, Azure would use PT1M", parsed)
+	}
+	formatted := parsed.String()
+	if strings.ContainsAny(formatted, "dwy") || strings.HasSuffix(formatted, "ms") {
+		return fmt.Sprintf("duration %s cannot be represented in Azure, use hours, minutes and seconds only", parsed)
+	}
+	return ""
+}
+
+// Lint checks all rules for problems that would lead to wrong or missing alerts in Azure
+func (o *Options) Lint() []LintIssue {
+	var issues []LintIssue
+	alertFiles := make(map[string]map[string]int)
+
+	for _, irf := range o.ruleFiles {
+		for gi, group := range irf.Rules.Spec.Groups {
+			if msg := checkAzureDuration(group.Interval); msg != "" {
+				issues = append(issues, LintIssue{File: irf.Path, Line: irf.Lines.group(gi), Rule: group.Name, Message: "interval: " + msg})
+			}
+			for ri, rule := range group.Rules {
+				line := irf.Lines.rule(gi, ri)
+				name := rule.Alert
+				if name == "" {
+					name = rule.Record
+				}
+				report := func(format string, args ...any) {
+					issues = append(issues, LintIssue{File: irf.Path, Line: line, Rule: name, Message: fmt.Sprintf(format, args...)})
+				}
+
+				if _, err := parser.ParseExpr(rule.Expr.String()); err != nil {
+					report("invalid expression: %v", err)
+				}
+				if rule.Alert == "" {
+					continue
+				}
+
+				if _, ok := alertFiles[rule.Alert]; !ok {
+					alertFiles[rule.Alert] = make(map[string]int)
+				}
+				if _, ok := alertFiles[rule.Alert][irf.Path]; !ok {
+					alertFiles[rule.Alert][irf.Path] = line
+				}
+
+				severity, ok := rule.Labels["severity"]
+				if !ok {
+					severity, ok = group.Labels["severity"]
+				}
+				switch {
+				case !ok:
+					report("missing label severity")
+				case !slices.Contains(supportedSeverities, severity):
+					report("unknown severity %q, expected one of %s", severity, strings.Join(supportedSeverities, ", "))
+				}
+				for _, annotation := range requiredAnnotations {
+					if rule.Annotations[annotation] == "" {
+						report("missing annotation %s", annotation)
+					}
+				}
+				if msg := checkAzureDuration(rule.For); msg != "" {
+					report("for: %s", msg)
+				}
+			}
+		}
+	}
+
+	// the same alert may be defined several times in a file, i.e. with different severities,
+	// but not in several files
+	for _, alert := range sortedKeys(alertFiles) {
+		files := alertFiles[alert]
+		if len(files) < 2 {
+			continue
+		}
+		paths := sortedKeys(files)
+		for _, path := range paths {
+			issues = append(issues, LintIssue{File: path, Line: files[path], Rule: alert, Message: fmt.Sprintf("alert is defined in several files: %s", strings.Join(paths, ", "))})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues
+}
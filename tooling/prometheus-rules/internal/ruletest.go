@@ -0,0 +1,383 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/promql/promqltest"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/storage"
+	"sigs.k8s.io/yaml"
+)
+
+// The types below follow the promtool unit test format, see
+// https://prometheus.io/docs/prometheus/latest/configuration/unit_testing_rules/
+
+type ruleTestFile struct {
+	RuleFiles          []string        `json:"rule_files"`
+	EvaluationInterval model.Duration  `json:"evaluation_interval,omitempty"`
+	Tests              []ruleTestGroup `json:"tests"`
+}
+
+type ruleTestGroup struct {
+	Name            string            `json:"name,omitempty"`
+	Interval        model.Duration    `json:"interval,omitempty"`
+	InputSeries     []inputSeries     `json:"input_series"`
+	AlertRuleTests  []alertTestCase   `json:"alert_rule_test,omitempty"`
+	PromqlExprTests []promqlTestCase  `json:"promql_expr_test,omitempty"`
+	ExternalLabels  map[string]string `json:"external_labels,omitempty"`
+}
+
+type inputSeries struct {
+	Series string `json:"series"`
+	Values string `json:"values"`
+}
+
+type alertTestCase struct {
+	EvalTime  model.Duration  `json:"eval_time"`
+	Alertname string          `json:"alertname"`
+	ExpAlerts []expectedAlert `json:"exp_alerts"`
+}
+
+type expectedAlert struct {
+	ExpLabels      map[string]string `json:"exp_labels"`
+	ExpAnnotations map[string]string `json:"exp_annotations"`
+}
+
+type promqlTestCase struct {
+	Expr       string           `json:"expr"`
+	EvalTime   model.Duration   `json:"eval_time"`
+	ExpSamples []expectedSample `json:"exp_samples"`
+}
+
+type expectedSample struct {
+	Labels string  `json:"labels"`
+	Value  float64 `json:"value"`
+}
+
+// exprBranch is an operand of a top level `or` in an alert expression
+type exprBranch struct {
+	expr      parser.Expr
+	exercised bool
+}
+
+// ruleTestResult collects the outcome of a test file
+type ruleTestResult struct {
+	failures []string
+	// alerts are all alerting rules of the rule files, true if an alert_rule_test covers them
+	alerts map[string]bool
+	// branches are the `or` branches of the alert expressions, by alert name
+	branches map[string][]*exprBranch
+}
+
+func readRuleTestFile(filename string) (*ruleTestFile, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test file: %v", err)
+	}
+	var testFile ruleTestFile
+	if err := yaml.UnmarshalStrict(raw, &testFile); err != nil {
+		return nil, fmt.Errorf("failed to parse test file %s: %v", filename, err)
+	}
+	if testFile.EvaluationInterval == 0 {
+		testFile.EvaluationInterval = model.Duration(time.Minute)
+	}
+	return &testFile, nil
+}
+
+// runRuleTestFile evaluates a promtool test file in-process. Rule files are resolved relative
+// to the test file.
+func runRuleTestFile(filename string) (*ruleTestResult, error) {
+	testFile, err := readRuleTestFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	ruleFiles := make([]string, 0, len(testFile.RuleFiles))
+	for _, rf := range testFile.RuleFiles {
+		ruleFiles = append(ruleFiles, filepath.Join(filepath.Dir(filename), rf))
+	}
+
+	result := &ruleTestResult{
+		alerts:   make(map[string]bool),
+		branches: make(map[string][]*exprBranch),
+	}
+	for i, tg := range testFile.Tests {
+		name := tg.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		failures, err := tg.run(time.Duration(testFile.EvaluationInterval), ruleFiles, result)
+		if err != nil {
+			return nil, fmt.Errorf("%s: test %s: %w", filepath.Base(filename), name, err)
+		}
+		for _, f := range failures {
+			result.failures = append(result.failures, fmt.Sprintf("%s: test %s: %s", filepath.Base(filename), name, f))
+		}
+	}
+	return result, nil
+}
+
+func (tg *ruleTestGroup) loadCommand() string {
+	interval := tg.Interval
+	if interval == 0 {
+		interval = model.Duration(time.Minute)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "load %s\n", interval)
+	for _, s := range tg.InputSeries {
+		fmt.Fprintf(&b, "  %s %s\n", s.Series, s.Values)
+	}
+	return b.String()
+}
+
+func (tg *ruleTestGroup) maxEvalTime() time.Duration {
+	var maxEvalTime time.Duration
+	for _, t := range tg.AlertRuleTests {
+		maxEvalTime = max(maxEvalTime, time.Duration(t.EvalTime))
+	}
+	for _, t := range tg.PromqlExprTests {
+		maxEvalTime = max(maxEvalTime, time.Duration(t.EvalTime))
+	}
+	return maxEvalTime
+}
+
+// run evaluates the rule groups every evaluation interval up to the last eval_time of the
+// tests, checking the alert tests along the way and the promql tests at the end
+func (tg *ruleTestGroup) run(evalInterval time.Duration, ruleFiles []string, result *ruleTestResult) ([]string, error) {
+	suite, err := promqltest.NewLazyLoader(tg.loadCommand(), promqltest.LazyLoaderOpts{
+		EnableAtModifier:     true,
+		EnableNegativeOffset: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load input series: %v", err)
+	}
+	defer suite.Close()
+	suite.SubqueryInterval = evalInterval
+
+	manager := rules.NewManager(&rules.ManagerOptions{
+		QueryFunc:  rules.EngineQueryFunc(suite.QueryEngine(), suite.Storage()),
+		Appendable: suite.Storage(),
+		Queryable:  suite.Storage(),
+		Context:    context.Background(),
+		NotifyFunc: func(context.Context, string, ...*rules.Alert) {},
+		Logger:     slog.New(slog.DiscardHandler),
+	})
+	groupsMap, errs := manager.LoadGroups(evalInterval, labels.FromMap(tg.ExternalLabels), "", nil, false, ruleFiles...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to load rule files: %v", errs)
+	}
+	groups := make([]*rules.Group, 0, len(groupsMap))
+	for _, g := range groupsMap {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name() < groups[j].Name()
+	})
+
+	var alertingRules []*rules.AlertingRule
+	for _, g := range groups {
+		for _, ar := range g.AlertingRules() {
+			alertingRules = append(alertingRules, ar)
+			if _, ok := result.alerts[ar.Name()]; !ok {
+				result.alerts[ar.Name()] = false
+				result.branches[ar.Name()] = orBranches(ar.Query())
+			}
+		}
+	}
+	for _, t := range tg.AlertRuleTests {
+		if _, ok := result.alerts[t.Alertname]; ok {
+			result.alerts[t.Alertname] = true
+		}
+	}
+
+	var failures []string
+	mint := time.Unix(0, 0).UTC()
+	maxt := mint.Add(tg.maxEvalTime())
+	for ts := mint; !ts.After(maxt); ts = ts.Add(evalInterval) {
+		var loadErr error
+		suite.WithSamplesTill(ts, func(err error) {
+			loadErr = err
+		})
+		if loadErr != nil {
+			return nil, fmt.Errorf("failed to load samples: %v", loadErr)
+		}
+		for _, g := range groups {
+			g.Eval(suite.Context(), ts)
+		}
+		if err := recordBranchCoverage(suite.Context(), suite.QueryEngine(), suite.Storage(), ts, result.branches); err != nil {
+			return nil, err
+		}
+
+		// alert tests see the state of the last evaluation at or before their eval_time
+		for _, t := range tg.AlertRuleTests {
+			evalTime := mint.Add(time.Duration(t.EvalTime))
+			if evalTime.Before(ts) || !evalTime.Before(ts.Add(evalInterval)) {
+				continue
+			}
+			if failure := checkAlerts(t, alertingRules); failure != "" {
+				failures = append(failures, failure)
+			}
+		}
+	}
+
+	for _, t := range tg.PromqlExprTests {
+		failure, err := checkExpression(suite.Context(), suite.QueryEngine(), suite.Storage(), mint, t)
+		if err != nil {
+			return nil, err
+		}
+		if failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+	return failures, nil
+}
+
+// orBranches splits an expression at its top level `or` operators
+func orBranches(expr parser.Expr) []*exprBranch {
+	switch e := expr.(type) {
+	case *parser.ParenExpr:
+		return orBranches(e.Expr)
+	case *parser.BinaryExpr:
+		if e.Op == parser.LOR {
+			return append(orBranches(e.LHS), orBranches(e.RHS)...)
+		}
+	}
+	return []*exprBranch{{expr: expr}}
+}
+
+// recordBranchCoverage marks branches as exercised that return a result at ts
+func recordBranchCoverage(ctx context.Context, engine *promql.Engine, queryable storage.Queryable, ts time.Time, branches map[string][]*exprBranch) error {
+	for _, alertBranches := range branches {
+		if len(alertBranches) < 2 {
+			continue
+		}
+		for _, branch := range alertBranches {
+			if branch.exercised {
+				continue
+			}
+			vector, err := instantQuery(ctx, engine, queryable, branch.expr.String(), ts)
+			if err != nil {
+				return err
+			}
+			branch.exercised = len(vector) > 0
+		}
+	}
+	return nil
+}
+
+func instantQuery(ctx context.Context, engine *promql.Engine, queryable storage.Queryable, expr string, ts time.Time) (promql.Vector, error) {
+	q, err := engine.NewInstantQuery(ctx, queryable, nil, expr, ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create query %q: %v", expr, err)
+	}
+	defer q.Close()
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		return nil, fmt.Errorf("failed to evaluate %q: %v", expr, res.Err)
+	}
+	switch v := res.Value.(type) {
+	case promql.Vector:
+		return v, nil
+	case promql.Scalar:
+		return promql.Vector{promql.Sample{T: v.T, F: v.V, Metric: labels.EmptyLabels()}}, nil
+	default:
+		return nil, fmt.Errorf("expression %q does not return a vector or scalar", expr)
+	}
+}
+
+// checkAlerts compares the firing alerts with the expected alerts
+func checkAlerts(t alertTestCase, alertingRules []*rules.AlertingRule) string {
+	var got []string
+	for _, ar := range alertingRules {
+		if ar.Name() != t.Alertname {
+			continue
+		}
+		for _, a := range ar.ActiveAlerts() {
+			if a.State == rules.StateFiring {
+				got = append(got, fmt.Sprintf("labels:%s annotations:%s", a.Labels, a.Annotations))
+			}
+		}
+	}
+
+	expected := make([]string, 0, len(t.ExpAlerts))
+	for _, exp := range t.ExpAlerts {
+		lbls := labels.NewBuilder(labels.FromMap(exp.ExpLabels))
+		lbls.Set(labels.AlertName, t.Alertname)
+		expected = append(expected, fmt.Sprintf("labels:%s annotations:%s", lbls.Labels(), labels.FromMap(exp.ExpAnnotations)))
+	}
+
+	sort.Strings(got)
+	sort.Strings(expected)
+	if strings.Join(got, "\n") == strings.Join(expected, "\n") {
+		return ""
+	}
+	return fmt.Sprintf("alertname: %s, time: %s,\n        exp:%v,\n        got:%v", t.Alertname, t.EvalTime, expected, got)
+}
+
+// checkExpression compares the result of a promql test with the expected samples
+func checkExpression(ctx context.Context, engine *promql.Engine, queryable storage.Queryable, mint time.Time, t promqlTestCase) (string, error) {
+	vector, err := instantQuery(ctx, engine, queryable, t.Expr, mint.Add(time.Duration(t.EvalTime)))
+	if err != nil {
+		return fmt.Sprintf("expr: %q, time: %s, err: %v", t.Expr, t.EvalTime, err), nil
+	}
+
+	got := make(map[string]float64, len(vector))
+	for _, s := range vector {
+		got[s.Metric.String()] = s.F
+	}
+	expected := make(map[string]float64, len(t.ExpSamples))
+	for _, s := range t.ExpSamples {
+		lbls, err := parser.ParseMetric(s.Labels)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse labels %q of expected sample: %v", s.Labels, err)
+		}
+		expected[lbls.String()] = s.Value
+	}
+
+	matches := len(got) == len(expected)
+	for lbls, value := range expected {
+		actual, ok := got[lbls]
+		if !ok || !almostEqual(actual, value) {
+			matches = false
+		}
+	}
+	if matches {
+		return "", nil
+	}
+	return fmt.Sprintf("expr: %q, time: %s,\n        exp: %v\n        got: %v", t.Expr, t.EvalTime, expected, got), nil
+}
+
+func almostEqual(a, b float64) bool {
+	if math.IsNaN(a) && math.IsNaN(b) {
+		return true
+	}
+	if a == b {
+		return true
+	}
+	return math.Abs(a-b) <= 1e-9*math.Max(math.Abs(a), math.Abs(b))
+}
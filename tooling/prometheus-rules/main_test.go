@@ -17,13 +17,13 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func setupTestFiles(tmpDir string) error {
-	config := `
+const defaultConfig = `
 prometheusRules:
   rulesFolders:
   - ./alerts
@@ -31,6 +31,11 @@ prometheusRules:
   outputBicep: zzz_generated.bicep
 `
 
+func setupTestFiles(tmpDir string) error {
+	return setupTestFilesWithConfig(tmpDir, defaultConfig)
+}
+
+func setupTestFilesWithConfig(tmpDir, config string) error {
 	err := os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(config), 0660)
 	if err != nil {
 		return err
@@ -78,3 +83,186 @@ func TestPrometheusRulesMissingTest(t *testing.T) {
 	err := runGenerator(filepath.Join(tmpDir, "config.yaml"))
 	assert.ErrorContains(t, err, "missing testfile")
 }
+
+func TestPrometheusRulesTargets(t *testing.T) {
+	tmpDir := t.TempDir()
+	assert.NoError(t, setupTestFilesWithConfig(tmpDir, defaultConfig+`  targets:
+  - name: slo
+    outputBicep: zzz_generated_slo.bicep
+    ruleGroups:
+    - slo-.*
+    clusterScoped: true
+`))
+
+	for _, testfile := range []string{
+		"./testdata/alerts/testing-prometheusRule_test.yaml",
+		"./testdata/alerts/testing-prometheusRule.yaml",
+		"./testdata/alerts/testing-recordingRule_test.yaml",
+		"./testdata/alerts/testing-recordingRule.yaml"} {
+		assert.NoError(t, copyFile(testfile, filepath.Join(tmpDir, "alerts")))
+	}
+	err := runGenerator(filepath.Join(tmpDir, "config.yaml"))
+	assert.NoError(t, err)
+
+	for generated, expected := range map[string]string{
+		"zzz_generated.bicep":     "generated.bicep",
+		"zzz_generated_slo.bicep": "generated-slo.bicep",
+	} {
+		generatedFile, err := os.ReadFile(filepath.Join(tmpDir, generated))
+		assert.NoError(t, err)
+
+		expectedContent, err := os.ReadFile(filepath.Join("testdata", expected))
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(expectedContent), string(generatedFile))
+	}
+}
+
+const coverageRules = `
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: coverage-rules
+spec:
+  groups:
+  - name: coverage
+    rules:
+    - alert: InstancesDownV1
+      expr: sum(up{job="app"}) == 0 or absent(up{job="app"})
+      labels:
+        severity: critical
+      annotations:
+        summary: "All instances of the App are down"
+        description: "All instances of the App are down"
+        runbook_url: "https://example.com/runbooks/InstancesDownV1"
+    - alert: InstanceFlappingV1
+      expr: changes(up{job="app"}[10m]) > 3
+      labels:
+        severity: warning
+      annotations:
+        summary: "An instance of the App is flapping"
+        description: "An instance of the App is flapping"
+        runbook_url: "https://example.com/runbooks/InstanceFlappingV1"
+`
+
+const coverageTest = `
+rule_files:
+- coverage-prometheusRule.yaml
+evaluation_interval: 1m
+tests:
+- interval: 1m
+  input_series:
+  - series: 'up{job="app", instance="app-1:2223"}'
+    values: "1x4 0x9"
+  alert_rule_test:
+  - eval_time: 5m
+    alertname: InstancesDownV1
+    exp_alerts:
+    - exp_labels:
+        severity: critical
+      exp_annotations:
+        summary: "All instances of the App are down"
+        description: "All instances of the App are down"
+        runbook_url: "https://example.com/runbooks/InstancesDownV1"
+`
+
+func writeRuleFiles(t *testing.T, tmpDir, rules, tests string) {
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "alerts", "coverage-prometheusRule.yaml"), []byte(rules), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "alerts", "coverage-prometheusRule_test.yaml"), []byte(tests), 0644))
+}
+
+func TestPrometheusRulesUntestedAlert(t *testing.T) {
+	tmpDir := t.TempDir()
+	assert.NoError(t, setupTestFiles(tmpDir))
+	writeRuleFiles(t, tmpDir, coverageRules, coverageTest)
+
+	err := runGenerator(filepath.Join(tmpDir, "config.yaml"))
+	assert.ErrorContains(t, err, "alerts without alert_rule_test, add a test or list them in untestedAlerts: InstanceFlappingV1 (coverage-prometheusRule.yaml)")
+
+	tmpDir = t.TempDir()
+	assert.NoError(t, setupTestFilesWithConfig(tmpDir, defaultConfig+`  untestedAlerts:
+  - InstanceFlappingV1
+`))
+	writeRuleFiles(t, tmpDir, coverageRules, coverageTest)
+	assert.NoError(t, runGenerator(filepath.Join(tmpDir, "config.yaml")))
+}
+
+func TestPrometheusRulesFailingTest(t *testing.T) {
+	tmpDir := t.TempDir()
+	assert.NoError(t, setupTestFilesWithConfig(tmpDir, defaultConfig+`  untestedAlerts:
+  - InstanceFlappingV1
+`))
+	writeRuleFiles(t, tmpDir, coverageRules, strings.ReplaceAll(coverageTest, "eval_time: 5m", "eval_time: 4m"))
+
+	err := runGenerator(filepath.Join(tmpDir, "config.yaml"))
+	assert.ErrorContains(t, err, "1 rule tests failed")
+	assert.ErrorContains(t, err, "alertname: InstancesDownV1, time: 4m")
+}
+
+func TestPrometheusRulesLint(t *testing.T) {
+	tmpDir := t.TempDir()
+	assert.NoError(t, setupTestFilesWithConfig(tmpDir, `
+prometheusRules:
+  rulesFolders: []
+  untestedRules:
+  - ./lint-prometheusRule.yaml
+  - ./duplicate-prometheusRule.yaml
+  outputBicep: zzz_generated.bicep
+`))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "lint-prometheusRule.yaml"), []byte(`
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: lint-rules
+spec:
+  groups:
+  - name: lint
+    rules:
+    - alert: MissingSeverity
+      expr: up == 0
+      annotations:
+        summary: "summary"
+        description: "description"
+        runbook_url: "https://example.com"
+    - alert: InvalidExpression
+      expr: sum(up{job="app"} == 0
+      for: 1d
+      labels:
+        severity: page
+    - record: job:up:sum
+      expr: sum by (job) (up)
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "duplicate-prometheusRule.yaml"), []byte(`
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: duplicate-rules
+spec:
+  groups:
+  - name: duplicate
+    rules:
+    - alert: MissingSeverity
+      expr: up == 0
+      labels:
+        severity: info
+      annotations:
+        summary: "summary"
+        description: "description"
+        runbook_url: "https://example.com"
+`), 0644))
+
+	err := runGenerator(filepath.Join(tmpDir, "config.yaml"))
+	assert.ErrorContains(t, err, "9 lint issues found in rules")
+	for _, issue := range []string{
+		"duplicate-prometheusRule.yaml:10: MissingSeverity: alert is defined in several files",
+		"lint-prometheusRule.yaml:10: MissingSeverity: missing label severity",
+		"lint-prometheusRule.yaml:16: InvalidExpression: invalid expression",
+		`lint-prometheusRule.yaml:16: InvalidExpression: unknown severity "page", expected one of critical, warning, info`,
+		"lint-prometheusRule.yaml:16: InvalidExpression: missing annotation summary",
+		"lint-prometheusRule.yaml:16: InvalidExpression: missing annotation runbook_url",
+		"lint-prometheusRule.yaml:16: InvalidExpression: for: duration 1d cannot be represented in Azure",
+	} {
+		assert.ErrorContains(t, err, issue)
+	}
+	assert.NotContains(t, err.Error(), "job:up:sum")
+}
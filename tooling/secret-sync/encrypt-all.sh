@@ -22,8 +22,16 @@ do
     [[ ! -d ${targetFolder} ]] && mkdir -p ${targetFolder}
     targetFile=${DATADIRPREFIX}/encryptedsecrets/${deployEnv}/${keyVault}/${outputFile}
 
+    versionFile=${DATADIRPREFIX}/keys/${keyfile%.pem}.version
+    if [[ ! -f ${versionFile} ]]; then
+        echo "missing key version file ${versionFile}, skipping ${keyfile}" >&2
+        continue
+    fi
+    keyVersion=$(cat ${versionFile})
+
     echo ${secret} | \
     PUBLIC_KEY_FILE=${DATADIRPREFIX}/keys/${keyfile} \
+    KEY_VERSION=${keyVersion} \
     OUTPUT_FILE=${targetFile} \
     ${dir_prefix}/secret-sync encrypt
 done
@@ -0,0 +1,267 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envelopeFormat is the first line of files using envelope encryption. Files without it
+// contain RSA encrypted chunks, one base64 encoded chunk per line.
+const envelopeFormat = "secret-sync-format: 2"
+
+const (
+	wrapAlgorithm = "RSA-OAEP-256"
+	dataAlgorithm = "A256GCM"
+
+	dataKeySizeBytes = 32
+)
+
+// envelope is a secret encrypted with a random AES-GCM data key. The data key is wrapped
+// with the public part of the Key Vault key, so only Key Vault can unwrap it.
+type envelope struct {
+	// KeyVersion is the version of the Key Vault key that wrapped the data key, empty for the latest version
+	KeyVersion string
+	// KeyFingerprint is the SHA-256 fingerprint of the public key that wrapped the data key
+	KeyFingerprint string
+	WrappedKey     []byte
+	Nonce          []byte
+	Ciphertext     []byte
+}
+
+// unwrapKeyFunc unwraps a data key with the given version of the Key Vault key
+type unwrapKeyFunc func(keyVersion string, wrappedKey []byte) ([]byte, error)
+
+func isEnvelope(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(envelopeFormat+"\n"))
+}
+
+func loadPublicKey(path string) (*rsa.PublicKey, error) {
+	pubPEMData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading public key file %s: %v", path, err)
+	}
+
+	block, _ := pem.Decode(pubPEMData)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("failed to decode PEM block containing public key")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing public key %v", err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key in %s is not an RSA key", path)
+	}
+	return rsaPub, nil
+}
+
+func keyFingerprint(pub *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling public key %v", err)
+	}
+	sum := sha256.Sum256(der)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// wrapKey wraps the data key with the public key of the given key version. The version is
+// required, unwrapping with the latest version fails once the key was rotated.
+func wrapKey(pub *rsa.PublicKey, keyVersion string, dataKey []byte, e *envelope) error {
+	if keyVersion == "" {
+		return fmt.Errorf("key version is required to wrap the data key")
+	}
+	fingerprint, err := keyFingerprint(pub)
+	if err != nil {
+		return err
+	}
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, dataKey, []byte{})
+	if err != nil {
+		return fmt.Errorf("error wrapping data key %v", err)
+	}
+	e.KeyVersion = keyVersion
+	e.KeyFingerprint = fingerprint
+	e.WrappedKey = wrapped
+	return nil
+}
+
+// additionalData is the part of the header that AES-GCM authenticates along with the secret,
+// so that the ciphertext can't be presented under another format or algorithm. The key
+// fields are not covered, a key rotation rewraps the data key without touching the ciphertext.
+func additionalData() []byte {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, envelopeFormat)
+	fmt.Fprintf(&b, "wrapAlgorithm: %s\n", wrapAlgorithm)
+	fmt.Fprintf(&b, "dataAlgorithm: %s\n", dataAlgorithm)
+	return b.Bytes()
+}
+
+func newGCM(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the secret with a new data key and wraps the data key with the public key
+func seal(secret []byte, pub *rsa.PublicKey, keyVersion string) (*envelope, error) {
+	dataKey := make([]byte, dataKeySizeBytes)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("error generating data key %v", err)
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	e := &envelope{Nonce: make([]byte, gcm.NonceSize())}
+	if _, err := rand.Read(e.Nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce %v", err)
+	}
+	e.Ciphertext = gcm.Seal(nil, e.Nonce, secret, additionalData())
+	if err := wrapKey(pub, keyVersion, dataKey, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// open unwraps the data key and decrypts the secret
+func (e *envelope) open(unwrap unwrapKeyFunc) ([]byte, error) {
+	dataKey, err := unwrap(e.KeyVersion, e.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := gcm.Open(nil, e.Nonce, e.Ciphertext, additionalData())
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting secret %v", err)
+	}
+	return secret, nil
+}
+
+// rewrap wraps the data key with another key version. The encrypted secret stays the same,
+// so the plaintext is never needed for a key rotation.
+func (e *envelope) rewrap(unwrap unwrapKeyFunc, pub *rsa.PublicKey, keyVersion string) error {
+	dataKey, err := unwrap(e.KeyVersion, e.WrappedKey)
+	if err != nil {
+		return err
+	}
+	return wrapKey(pub, keyVersion, dataKey, e)
+}
+
+// rotateFile re-encrypts a file for the given public key and key version. Envelopes only get
+// their data key rewrapped, files with RSA encrypted chunks are converted to an envelope.
+// Returns nil if the file is already encrypted with the key version.
+func rotateFile(data []byte, unwrap unwrapKeyFunc, decryptLegacy func([]byte) ([]byte, error), pub *rsa.PublicKey, keyVersion string) (*envelope, error) {
+	if !isEnvelope(data) {
+		secret, err := decryptLegacy(data)
+		if err != nil {
+			return nil, err
+		}
+		return seal(secret, pub, keyVersion)
+	}
+
+	e, err := parseEnvelope(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing encrypted file %v", err)
+	}
+	fingerprint, err := keyFingerprint(pub)
+	if err != nil {
+		return nil, err
+	}
+	if e.KeyFingerprint == fingerprint && e.KeyVersion == keyVersion {
+		return nil, nil
+	}
+	if err := e.rewrap(unwrap, pub, keyVersion); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *envelope) marshal() []byte {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, envelopeFormat)
+	fmt.Fprintf(&b, "keyVersion: %s\n", e.KeyVersion)
+	fmt.Fprintf(&b, "keyFingerprint: %s\n", e.KeyFingerprint)
+	fmt.Fprintf(&b, "wrapAlgorithm: %s\n", wrapAlgorithm)
+	fmt.Fprintf(&b, "dataAlgorithm: %s\n", dataAlgorithm)
+	fmt.Fprintf(&b, "wrappedKey: %s\n", base64.StdEncoding.EncodeToString(e.WrappedKey))
+	fmt.Fprintf(&b, "nonce: %s\n", base64.StdEncoding.EncodeToString(e.Nonce))
+	fmt.Fprintf(&b, "ciphertext: %s\n", base64.StdEncoding.EncodeToString(e.Ciphertext))
+	return b.Bytes()
+}
+
+func parseEnvelope(data []byte) (*envelope, error) {
+	if !isEnvelope(data) {
+		return nil, fmt.Errorf("missing header %q", envelopeFormat)
+	}
+	e := &envelope{}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for _, line := range lines[1:] {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch key {
+		case "keyVersion":
+			e.KeyVersion = value
+		case "keyFingerprint":
+			e.KeyFingerprint = value
+		case "wrapAlgorithm":
+			if value != wrapAlgorithm {
+				return nil, fmt.Errorf("unsupported wrap algorithm %s", value)
+			}
+		case "dataAlgorithm":
+			if value != dataAlgorithm {
+				return nil, fmt.Errorf("unsupported data algorithm %s", value)
+			}
+		case "wrappedKey":
+			e.WrappedKey, err = base64.StdEncoding.DecodeString(value)
+		case "nonce":
+			e.Nonce, err = base64.StdEncoding.DecodeString(value)
+		case "ciphertext":
+			e.Ciphertext, err = base64.StdEncoding.DecodeString(value)
+		default:
+			return nil, fmt.Errorf("unknown field %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding %s %v", key, err)
+		}
+	}
+	if len(e.WrappedKey) == 0 || len(e.Nonce) == 0 || len(e.Ciphertext) == 0 {
+		return nil, fmt.Errorf("incomplete envelope, wrappedKey, nonce and ciphertext are required")
+	}
+	return e, nil
+}
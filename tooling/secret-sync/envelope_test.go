@@ -0,0 +1,162 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// testKeyVault stands in for Key Vault, holding the private keys per key version
+type testKeyVault map[string]*rsa.PrivateKey
+
+func (k testKeyVault) addVersion(t *testing.T, version string) *rsa.PublicKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	k[version] = key
+	return &key.PublicKey
+}
+
+func (k testKeyVault) unwrapKey(keyVersion string, wrappedKey []byte) ([]byte, error) {
+	key, ok := k[keyVersion]
+	if !ok {
+		return nil, fmt.Errorf("unknown key version %s", keyVersion)
+	}
+	return rsa.DecryptOAEP(sha256.New(), rand.Reader, key, wrappedKey, []byte{})
+}
+
+func TestLoadPublicKey(t *testing.T) {
+	keyVault := testKeyVault{}
+	pub := keyVault.addVersion(t, "v1")
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	assert.NilError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	assert.NilError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+
+	loaded, err := loadPublicKey(keyFile)
+	assert.NilError(t, err)
+	assert.Assert(t, pub.Equal(loaded))
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	keyVault := testKeyVault{}
+	pub := keyVault.addVersion(t, "v1")
+
+	// larger than a single RSA block
+	secret := make([]byte, 4096)
+	for i := range secret {
+		secret[i] = byte('a' + i%26)
+	}
+	e, err := seal(secret, pub, "v1")
+	assert.NilError(t, err)
+
+	parsed, err := parseEnvelope(e.marshal())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, e, parsed)
+	assert.Equal(t, "v1", parsed.KeyVersion)
+
+	decrypted, err := parsed.open(keyVault.unwrapKey)
+	assert.NilError(t, err)
+	assert.Equal(t, string(secret), string(decrypted))
+}
+
+func TestSealRequiresKeyVersion(t *testing.T) {
+	keyVault := testKeyVault{}
+	pub := keyVault.addVersion(t, "v1")
+	_, err := seal([]byte("secret"), pub, "")
+	assert.Error(t, err, "key version is required to wrap the data key")
+
+	e, err := seal([]byte("secret"), pub, "v1")
+	assert.NilError(t, err)
+	_, err = rotateFile(e.marshal(), keyVault.unwrapKey, nil, pub, "")
+	assert.Error(t, err, "key version is required to wrap the data key")
+}
+
+func TestEnvelopeAuthenticatesHeader(t *testing.T) {
+	keyVault := testKeyVault{}
+	pub := keyVault.addVersion(t, "v1")
+	e, err := seal([]byte("secret"), pub, "v1")
+	assert.NilError(t, err)
+
+	// the same ciphertext sealed without the header as additional data is rejected
+	dataKey, err := keyVault.unwrapKey(e.KeyVersion, e.WrappedKey)
+	assert.NilError(t, err)
+	gcm, err := newGCM(dataKey)
+	assert.NilError(t, err)
+	e.Ciphertext = gcm.Seal(nil, e.Nonce, []byte("secret"), nil)
+	_, err = e.open(keyVault.unwrapKey)
+	assert.ErrorContains(t, err, "error decrypting secret")
+}
+
+func TestRotateFile(t *testing.T) {
+	keyVault := testKeyVault{}
+	oldPub := keyVault.addVersion(t, "v1")
+	newPub := keyVault.addVersion(t, "v2")
+	noLegacy := func([]byte) ([]byte, error) {
+		return nil, fmt.Errorf("not a legacy file")
+	}
+
+	e, err := seal([]byte("secret"), oldPub, "v1")
+	assert.NilError(t, err)
+
+	rotated, err := rotateFile(e.marshal(), keyVault.unwrapKey, noLegacy, newPub, "v2")
+	assert.NilError(t, err)
+	assert.Equal(t, "v2", rotated.KeyVersion)
+	assert.DeepEqual(t, e.Ciphertext, rotated.Ciphertext)
+	newFingerprint, err := keyFingerprint(newPub)
+	assert.NilError(t, err)
+	assert.Equal(t, newFingerprint, rotated.KeyFingerprint)
+
+	// the old key version is not needed anymore
+	delete(keyVault, "v1")
+	decrypted, err := rotated.open(keyVault.unwrapKey)
+	assert.NilError(t, err)
+	assert.Equal(t, "secret", string(decrypted))
+
+	unchanged, err := rotateFile(rotated.marshal(), keyVault.unwrapKey, noLegacy, newPub, "v2")
+	assert.NilError(t, err)
+	assert.Assert(t, unchanged == nil)
+
+	legacy, err := rotateFile([]byte("YQ==\n"), keyVault.unwrapKey, func([]byte) ([]byte, error) {
+		return []byte("legacy secret"), nil
+	}, newPub, "v2")
+	assert.NilError(t, err)
+	decrypted, err = legacy.open(keyVault.unwrapKey)
+	assert.NilError(t, err)
+	assert.Equal(t, "legacy secret", string(decrypted))
+}
+
+func TestParseEnvelopeErrors(t *testing.T) {
+	_, err := parseEnvelope([]byte("YQ==\n"))
+	assert.Error(t, err, `missing header "secret-sync-format: 2"`)
+
+	_, err = parseEnvelope([]byte("secret-sync-format: 2\nwrapAlgorithm: RSA1_5\n"))
+	assert.Error(t, err, "unsupported wrap algorithm RSA1_5")
+
+	_, err = parseEnvelope([]byte("secret-sync-format: 2\nkeyVersion: v1\n"))
+	assert.Error(t, err, "incomplete envelope, wrappedKey, nonce and ciphertext are required")
+
+	_, err = parseEnvelope([]byte("secret-sync-format: 2\nfoo: bar\n"))
+	assert.Error(t, err, "unknown field foo")
+}
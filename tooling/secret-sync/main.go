@@ -15,21 +15,17 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/sha256"
-	"crypto/x509"
 	"encoding/base64"
-	"encoding/pem"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
@@ -37,79 +33,33 @@ import (
 	"github.com/Azure/ARO-HCP/tooling/templatize/pkg/azauth"
 )
 
-// conservative
-var chunkSizeBytes = 400
 var chunkDelemiter = "\n"
 
 const (
 	dryRunEnvKey        = "DRY_RUN"
 	inputFileKeyEnv     = "INPUT_FILE"
+	inputDirEnvKey      = "INPUT_DIR"
 	outputFileEnvKey    = "OUTPUT_FILE"
 	publicKetFileEnvKey = "PUBLIC_KEY_FILE"
+	keyVersionEnvKey    = "KEY_VERSION"
 	encryptionKeyEnvKey = "ENCRYPTION_KEY"
 	secretToSetEnvKey   = "SECRET_TO_SET"
 	vaultNameEnvKey     = "KEYVAULT"
 )
 
-func readAndChunkData(inputReader io.Reader) ([][]byte, error) {
-	returnBytes := make([][]byte, 0)
-	reader := bufio.NewReader(inputReader)
-
-	for {
-		data := make([]byte, chunkSizeBytes)
-		n, err := reader.Read(data)
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return nil, fmt.Errorf("problems reading from input: %v", err)
-		}
-		returnBytes = append(returnBytes, data[:n])
-	}
-	return returnBytes, nil
-}
-
-func persistEncryptedChunks(encryptedChunks [][]byte) error {
-	outputFile, err := os.Create(os.Getenv(outputFileEnvKey))
-	if err != nil {
-		return fmt.Errorf("error creating output file %v", err)
-	}
-	defer outputFile.Close()
-
-	for _, c := range encryptedChunks {
-		encodedChunk := make([]byte, base64.StdEncoding.EncodedLen(len(c)))
-		base64.StdEncoding.Encode(encodedChunk, c)
-		_, err := outputFile.Write(encodedChunk)
-		if err != nil {
-			return fmt.Errorf("error writing encoded chunk %v", err)
-		}
-		_, err = outputFile.Write([]byte(chunkDelemiter))
-		if err != nil {
-			return fmt.Errorf("error writing delimiter %v", err)
-		}
+func persistEnvelope(outputFile string, e *envelope) error {
+	if err := os.WriteFile(outputFile, e.marshal(), 0644); err != nil {
+		return fmt.Errorf("error writing output file %v", err)
 	}
 	return nil
 }
 
-func encryptData(secretMessage []byte) ([]byte, error) {
-	pubPEMData, err := os.ReadFile(os.Getenv("PUBLIC_KEY_FILE"))
+func encryptData(secretMessage []byte) (*envelope, error) {
+	pub, err := loadPublicKey(os.Getenv(publicKetFileEnvKey))
 	if err != nil {
-		return nil, fmt.Errorf("error while reading public key file %s: %v", os.Getenv("PUBLIC_KEY_FILE"), err)
+		return nil, err
 	}
-
-	block, _ := pem.Decode(pubPEMData)
-	if block == nil || block.Type != "PUBLIC KEY" {
-		return nil, fmt.Errorf("failed to decode PEM block containing public key")
-	}
-
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("error while parsing public key %v", err)
-	}
-
-	label := []byte{}
-	rng := rand.Reader
-
-	return rsa.EncryptOAEP(sha256.New(), rng, pub.(*rsa.PublicKey), secretMessage, label)
+	return seal(secretMessage, pub, os.Getenv(keyVersionEnvKey))
 }
 
 func decryptData(client *azkeys.Client, encryptedMessage []byte) ([]byte, error) {
@@ -130,6 +80,26 @@ func decryptData(client *azkeys.Client, encryptedMessage []byte) ([]byte, error)
 	return d.Result, nil
 }
 
+// keyVaultUnwrapKey unwraps data keys using the Key Vault key, the private part never leaves Key Vault
+func keyVaultUnwrapKey(client *azkeys.Client) unwrapKeyFunc {
+	return func(keyVersion string, wrappedKey []byte) ([]byte, error) {
+		d, err := client.UnwrapKey(
+			context.Background(),
+			os.Getenv(encryptionKeyEnvKey),
+			keyVersion,
+			azkeys.KeyOperationParameters{
+				Algorithm: to.Ptr(azkeys.EncryptionAlgorithmRSAOAEP256),
+				Value:     wrappedKey,
+			},
+			&azkeys.UnwrapKeyOptions{},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error unwrapping data key with key version %q %v", keyVersion, err)
+		}
+		return d.Result, nil
+	}
+}
+
 func persistSecret(client *azsecrets.Client, secret []byte) error {
 	secretToSet := os.Getenv(secretToSetEnvKey)
 	currentSecret, err := client.GetSecret(
@@ -164,40 +134,77 @@ func persistSecret(client *azsecrets.Client, secret []byte) error {
 	return nil
 }
 
-func readEncryptedChunks() ([][]byte, error) {
-	chunkedData, err := os.ReadFile(os.Getenv(inputFileKeyEnv))
+func readEncryptedChunks(chunkedData []byte) [][]byte {
+	return bytes.Split(chunkedData, []byte(chunkDelemiter))
+}
+
+// decryptChunks decrypts files written before envelope encryption, that contain the secret
+// in RSA encrypted chunks
+func decryptChunks(client *azkeys.Client, chunkedData []byte) ([]byte, error) {
+	decryptedChunks := make([][]byte, 0)
+	for _, c := range readEncryptedChunks(chunkedData) {
+		if len(c) > 0 {
+			dst := make([]byte, base64.StdEncoding.DecodedLen(len(c)))
+			n, err := base64.StdEncoding.Decode(dst, c)
+			if err != nil {
+				return nil, err
+			}
+			decryptedChunk, err := decryptData(client, dst[:n])
+			if err != nil {
+				return nil, err
+			}
+			decryptedChunks = append(decryptedChunks, decryptedChunk)
+		}
+	}
+	return bytes.Join(decryptedChunks, []byte{}), nil
+}
+
+func decryptFile(client *azkeys.Client, data []byte) ([]byte, error) {
+	if !isEnvelope(data) {
+		return decryptChunks(client, data)
+	}
+	e, err := parseEnvelope(data)
 	if err != nil {
-		return nil, fmt.Errorf("error reading input file %v", err)
+		return nil, fmt.Errorf("error parsing encrypted file %v", err)
 	}
-	return bytes.Split(chunkedData, []byte(chunkDelemiter)), nil
+	return e.open(keyVaultUnwrapKey(client))
+}
+
+func newKeyClient() (*azkeys.Client, azcore.TokenCredential, error) {
+	chain, err := azauth.GetAzureTokenCredentials()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting credentials %v", err)
+	}
+
+	keyClient, err := azkeys.NewClient(fmt.Sprintf("https://%s.vault.azure.net", os.Getenv(vaultNameEnvKey)), chain, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting azkeys client %v", err)
+	}
+	return keyClient, chain, nil
 }
 
 func main() {
 	if len(os.Args) != 2 {
-		log.Fatal("Need to provide mode parameter encrypt/decrypt")
+		log.Fatal("Need to provide mode parameter encrypt/decrypt/rotate/verify")
 	}
 	mode := os.Args[1]
 
 	switch mode {
 	case "encrypt":
 		{
-			encryptedChunks := make([][]byte, 0)
-			plainChunks, err := readAndChunkData(os.Stdin)
+			secret, err := io.ReadAll(os.Stdin)
 			if err != nil {
-				log.Fatal(err)
+				log.Fatal(fmt.Errorf("problems reading from input: %v", err))
 			}
-			for _, c := range plainChunks {
-				encryptedChunk, err := encryptData(c)
-				if err != nil {
-					log.Fatal(err)
-				}
-				encryptedChunks = append(encryptedChunks, encryptedChunk)
+			encrypted, err := encryptData(secret)
+			if err != nil {
+				log.Fatal(err)
 			}
 			fmt.Printf("Encrypted data, persisting to: %s\n", os.Getenv(outputFileEnvKey))
 			if os.Getenv(dryRunEnvKey) == "true" {
 				fmt.Println("... skiped due to dry run")
 			} else {
-				if err := persistEncryptedChunks(encryptedChunks); err != nil {
+				if err := persistEnvelope(os.Getenv(outputFileEnvKey), encrypted); err != nil {
 					log.Fatal(err)
 				}
 			}
@@ -205,42 +212,90 @@ func main() {
 		}
 	case "decrypt":
 		{
-			chain, err := azauth.GetAzureTokenCredentials()
+			keyClient, chain, err := newKeyClient()
+			if err != nil {
+				log.Fatal(err)
+			}
+			data, err := os.ReadFile(os.Getenv(inputFileKeyEnv))
 			if err != nil {
-				log.Fatal(fmt.Errorf("error getting credentials %v", err))
+				log.Fatal(fmt.Errorf("error reading input file %v", err))
 			}
-
-			keyClient, err := azkeys.NewClient(fmt.Sprintf("https://%s.vault.azure.net", os.Getenv(vaultNameEnvKey)), chain, nil)
+			secret, err := decryptFile(keyClient, data)
+			if err != nil {
+				log.Fatal(err)
+			}
+			secretsClient, err := azsecrets.NewClient(fmt.Sprintf("https://%s.vault.azure.net", os.Getenv(vaultNameEnvKey)), chain, nil)
+			if err != nil {
+				log.Fatal(fmt.Errorf("error getting azsecrets client %v", err))
+			}
+			fmt.Printf("Data decrypted, persisting to: %s\n", os.Getenv(secretToSetEnvKey))
+			if err := persistSecret(secretsClient, secret); err != nil {
+				log.Fatal(err)
+			}
+			os.Exit(0)
+		}
+	case "rotate":
+		{
+			keyClient, _, err := newKeyClient()
 			if err != nil {
-				log.Fatal(fmt.Errorf("error getting azkeys client %v", err))
+				log.Fatal(err)
 			}
-			decryptedChunks := make([][]byte, 0)
-			encryptedChunks, err := readEncryptedChunks()
+			pub, err := loadPublicKey(os.Getenv(publicKetFileEnvKey))
 			if err != nil {
 				log.Fatal(err)
 			}
-			for _, c := range encryptedChunks {
-				if len(c) > 0 {
-					dst := make([]byte, base64.StdEncoding.DecodedLen(len(c)))
-					if _, err = base64.StdEncoding.Decode(dst, c); err != nil {
-						log.Fatal(err)
-					}
-					decryptedChunk, err := decryptData(keyClient, dst)
-					if err != nil {
-						log.Fatal(err)
-					}
-					decryptedChunks = append(decryptedChunks, decryptedChunk)
+			inputFile := os.Getenv(inputFileKeyEnv)
+			data, err := os.ReadFile(inputFile)
+			if err != nil {
+				log.Fatal(fmt.Errorf("error reading input file %v", err))
+			}
+			rotated, err := rotateFile(data, keyVaultUnwrapKey(keyClient), func(chunkedData []byte) ([]byte, error) {
+				return decryptChunks(keyClient, chunkedData)
+			}, pub, os.Getenv(keyVersionEnvKey))
+			if err != nil {
+				log.Fatal(err)
+			}
+			if rotated == nil {
+				fmt.Printf("%s is already encrypted with key version %q\n", inputFile, os.Getenv(keyVersionEnvKey))
+				os.Exit(0)
+			}
+			outputFile := os.Getenv(outputFileEnvKey)
+			if outputFile == "" {
+				outputFile = inputFile
+			}
+			fmt.Printf("Re-encrypted data with key version %q, persisting to: %s\n", os.Getenv(keyVersionEnvKey), outputFile)
+			if os.Getenv(dryRunEnvKey) == "true" {
+				fmt.Println("... skiped due to dry run")
+			} else {
+				if err := persistEnvelope(outputFile, rotated); err != nil {
+					log.Fatal(err)
 				}
 			}
+			os.Exit(0)
+		}
+	case "verify":
+		{
+			keyClient, chain, err := newKeyClient()
+			if err != nil {
+				log.Fatal(err)
+			}
 			secretsClient, err := azsecrets.NewClient(fmt.Sprintf("https://%s.vault.azure.net", os.Getenv(vaultNameEnvKey)), chain, nil)
 			if err != nil {
 				log.Fatal(fmt.Errorf("error getting azsecrets client %v", err))
 			}
-			joinedMessage := bytes.Join(decryptedChunks, []byte{})
-			fmt.Printf("Data decrypted, persisting to: %s\n", os.Getenv(secretToSetEnvKey))
-			if err := persistSecret(secretsClient, joinedMessage); err != nil {
+			results, err := verifyDirectory(os.Getenv(inputDirEnvKey), func(data []byte) ([]byte, error) {
+				return decryptFile(keyClient, data)
+			}, keyVaultGetSecret(secretsClient), time.Now())
+			if err != nil {
 				log.Fatal(err)
 			}
+			upToDate, err := printReport(os.Stdout, results)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !upToDate {
+				os.Exit(1)
+			}
 			os.Exit(0)
 		}
 	default:
@@ -15,45 +15,39 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
 	"os"
-	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
 )
 
-func TestReadAndChunkData(t *testing.T) {
-	testMsg := "test"
-	data, err := readAndChunkData(strings.NewReader(testMsg))
-	assert.NilError(t, err)
-
-	assert.Equal(t, string(data[0]), testMsg)
-
-	testBytes := make([]byte, 800)
-	for i := range testBytes {
-		testBytes[i] = byte('a')
-	}
-	data, err = readAndChunkData(bytes.NewReader(testBytes))
-	assert.NilError(t, err)
-
-	assert.Equal(t, string(bytes.Join(data, []byte{})), string(testBytes))
-	assert.Equal(t, len(data), 2)
+func TestReadEncryptedChunks(t *testing.T) {
+	data := readEncryptedChunks([]byte(fmt.Sprintf("YQ==%sYg==%s", chunkDelemiter, chunkDelemiter)))
+	assert.Equal(t, len(data), 3)
+	assert.Equal(t, string(data[0]), "YQ==")
+	assert.Equal(t, string(data[1]), "Yg==")
+	assert.Equal(t, len(data[2]), 0)
 }
 
-func TestPersistEncryptedChunk(t *testing.T) {
+func TestPersistEnvelope(t *testing.T) {
 	tempdir := t.TempDir()
 	outputFile := fmt.Sprintf("%s/output", tempdir)
-	os.Setenv(outputFileEnvKey, outputFile)
 
-	testData := make([][]byte, 1)
-	testData[0] = []byte{'a'}
-	err := persistEncryptedChunks(testData)
+	testData := &envelope{KeyVersion: "v1", KeyFingerprint: "sha256:abc", WrappedKey: []byte{'a'}, Nonce: []byte{'b'}, Ciphertext: []byte{'c'}}
+	err := persistEnvelope(outputFile, testData)
 	assert.NilError(t, err)
 
 	data, err := os.ReadFile(outputFile)
 	assert.NilError(t, err)
 
-	assert.Equal(t, string(data), fmt.Sprintf("YQ==%s", chunkDelemiter))
+	assert.Equal(t, string(data), `secret-sync-format: 2
+keyVersion: v1
+keyFingerprint: sha256:abc
+wrapAlgorithm: RSA-OAEP-256
+dataAlgorithm: A256GCM
+wrappedKey: YQ==
+nonce: Yg==
+ciphertext: Yw==
+`)
 }
@@ -0,0 +1,45 @@
+#!/bin/bash
+
+function usage {
+    echo "Need to set following environment variables"
+    echo "\$SECRETFOLDER Folder containing secrets to re-encrypt, i.e. dev/arohcpdev-global"
+    echo "\$KEYVAULT keyvault containing the sync key"
+    echo "\$PUBLIC_KEY_FILE public key of the new key version"
+    echo "\$KEY_VERSION version of the new key, defaults to the content of \${PUBLIC_KEY_FILE%.pem}.version"
+    echo "Optional: \$SECRETSYNCKEY sync key, defaults to: secretSyncKey"
+    echo "Optional: \$DATADIRPREFIX, path to read encrypted data from defaults to: dev-infrastructure/data"
+    exit 1
+}
+
+if [ -z ${SECRETFOLDER} ] || [ -z ${KEYVAULT} ] || [ -z ${PUBLIC_KEY_FILE} ]; then
+    usage
+fi
+
+if [ -z ${KEY_VERSION} ] && [ -f ${PUBLIC_KEY_FILE%.pem}.version ]; then
+    export KEY_VERSION=$(cat ${PUBLIC_KEY_FILE%.pem}.version)
+fi
+
+if [ -z ${KEY_VERSION} ]; then
+    usage
+fi
+
+if [ -z ${SECRETSYNCKEY} ]; then
+    export SECRETSYNCKEY="secretSyncKey"
+fi
+
+if [ -z ${DATADIRPREFIX} ]; then
+    export DATADIRPREFIX="dev-infrastructure/data"
+fi
+
+dir_prefix=$(dirname $0)
+
+cd ${dir_prefix}
+make secret-sync
+cd -
+
+ls -1 ${DATADIRPREFIX}/encryptedsecrets/${SECRETFOLDER} | while  read fileName
+do
+    ENCRYPTION_KEY=${SECRETSYNCKEY} \
+    INPUT_FILE=${DATADIRPREFIX}/encryptedsecrets/${SECRETFOLDER}/${fileName} \
+    ${dir_prefix}/secret-sync rotate
+done
@@ -0,0 +1,143 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+type secretStatus string
+
+const (
+	statusUpToDate secretStatus = "up-to-date"
+	statusDrifted  secretStatus = "drifted"
+	statusMissing  secretStatus = "missing"
+	statusError    secretStatus = "error"
+)
+
+// verifyResult is the state of a single encrypted file compared to Key Vault
+type verifyResult struct {
+	Secret string
+	File   string
+	Status secretStatus
+	Reason string
+}
+
+// getSecretFunc returns the current version of a Key Vault secret, nil if it does not exist
+type getSecretFunc func(name string) (*azsecrets.Secret, error)
+
+// keyVaultGetSecret reads secrets from Key Vault
+func keyVaultGetSecret(client *azsecrets.Client) getSecretFunc {
+	return func(name string) (*azsecrets.Secret, error) {
+		resp, err := client.GetSecret(context.Background(), name, "", &azsecrets.GetSecretOptions{})
+		if err != nil {
+			if strings.Contains(err.Error(), "SecretNotFound") {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("error getting secret %v", err)
+		}
+		return &resp.Secret, nil
+	}
+}
+
+// compareSecret compares the decrypted content of a file with the deployed secret. A secret
+// that is disabled, expired or not yet valid is considered drifted, even if the value matches.
+func compareSecret(name string, expected []byte, deployed *azsecrets.Secret, now time.Time) verifyResult {
+	result := verifyResult{Secret: name, Status: statusDrifted}
+	switch {
+	case deployed == nil:
+		result.Status = statusMissing
+		result.Reason = "secret does not exist in Key Vault"
+	case deployed.Value == nil || *deployed.Value != string(expected):
+		result.Reason = "value differs from encrypted file"
+	case deployed.Attributes != nil && deployed.Attributes.Enabled != nil && !*deployed.Attributes.Enabled:
+		result.Reason = "secret is disabled"
+	case deployed.Attributes != nil && deployed.Attributes.Expires != nil && !now.Before(*deployed.Attributes.Expires):
+		result.Reason = fmt.Sprintf("secret expired at %s", deployed.Attributes.Expires.Format(time.RFC3339))
+	case deployed.Attributes != nil && deployed.Attributes.NotBefore != nil && now.Before(*deployed.Attributes.NotBefore):
+		result.Reason = fmt.Sprintf("secret is not valid before %s", deployed.Attributes.NotBefore.Format(time.RFC3339))
+	default:
+		result.Status = statusUpToDate
+	}
+	return result
+}
+
+// verifyDirectory decrypts every file in the directory and compares it with the Key Vault
+// secret of the same name, without the .enc suffix. Nothing is written.
+func verifyDirectory(dir string, decrypt func([]byte) ([]byte, error), getSecret getSecretFunc, now time.Time) ([]verifyResult, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading input directory %v", err)
+	}
+
+	results := make([]verifyResult, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		file := filepath.Join(dir, entry.Name())
+		name := strings.TrimSuffix(entry.Name(), ".enc")
+
+		result, err := verifyFile(name, file, decrypt, getSecret, now)
+		if err != nil {
+			result = verifyResult{Secret: name, Status: statusError, Reason: err.Error()}
+		}
+		result.File = file
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func verifyFile(name, file string, decrypt func([]byte) ([]byte, error), getSecret getSecretFunc, now time.Time) (verifyResult, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return verifyResult{}, fmt.Errorf("error reading input file %v", err)
+	}
+	expected, err := decrypt(data)
+	if err != nil {
+		return verifyResult{}, err
+	}
+	deployed, err := getSecret(name)
+	if err != nil {
+		return verifyResult{}, err
+	}
+	return compareSecret(name, expected, deployed, now), nil
+}
+
+// printReport writes the results and returns whether all secrets are up to date
+func printReport(w io.Writer, results []verifyResult) (bool, error) {
+	counts := make(map[secretStatus]int)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SECRET\tSTATUS\tFILE\tREASON")
+	for _, r := range results {
+		counts[r.Status]++
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Secret, r.Status, r.File, r.Reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return false, err
+	}
+	_, err := fmt.Fprintf(w, "%d up-to-date, %d drifted, %d missing, %d errors\n",
+		counts[statusUpToDate], counts[statusDrifted], counts[statusMissing], counts[statusError])
+	return counts[statusUpToDate] == len(results), err
+}
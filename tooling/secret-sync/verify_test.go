@@ -0,0 +1,130 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"gotest.tools/v3/assert"
+)
+
+func TestCompareSecret(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		deployed *azsecrets.Secret
+		status   secretStatus
+		reason   string
+	}{
+		{
+			name:   "missing",
+			status: statusMissing,
+			reason: "secret does not exist in Key Vault",
+		},
+		{
+			name:     "up to date",
+			deployed: &azsecrets.Secret{Value: to.Ptr("foo"), Attributes: &azsecrets.SecretAttributes{Enabled: to.Ptr(true)}},
+			status:   statusUpToDate,
+		},
+		{
+			name:     "value differs",
+			deployed: &azsecrets.Secret{Value: to.Ptr("bar")},
+			status:   statusDrifted,
+			reason:   "value differs from encrypted file",
+		},
+		{
+			name:     "disabled",
+			deployed: &azsecrets.Secret{Value: to.Ptr("foo"), Attributes: &azsecrets.SecretAttributes{Enabled: to.Ptr(false)}},
+			status:   statusDrifted,
+			reason:   "secret is disabled",
+		},
+		{
+			name:     "expired",
+			deployed: &azsecrets.Secret{Value: to.Ptr("foo"), Attributes: &azsecrets.SecretAttributes{Expires: to.Ptr(now.Add(-time.Hour))}},
+			status:   statusDrifted,
+			reason:   "secret expired at 2025-05-31T23:00:00Z",
+		},
+		{
+			name:     "not yet valid",
+			deployed: &azsecrets.Secret{Value: to.Ptr("foo"), Attributes: &azsecrets.SecretAttributes{NotBefore: to.Ptr(now.Add(time.Hour))}},
+			status:   statusDrifted,
+			reason:   "secret is not valid before 2025-06-01T01:00:00Z",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := compareSecret("test", []byte("foo"), tc.deployed, now)
+			assert.Equal(t, tc.status, result.Status)
+			assert.Equal(t, tc.reason, result.Reason)
+		})
+	}
+}
+
+func TestVerifyDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"current.enc": "foo",
+		"drifted.enc": "foo",
+		"missing.enc": "foo",
+		"broken.enc":  "broken",
+	} {
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	decrypt := func(data []byte) ([]byte, error) {
+		if string(data) == "broken" {
+			return nil, fmt.Errorf("error decoding secret")
+		}
+		return data, nil
+	}
+	deployed := map[string]*azsecrets.Secret{
+		"current": {Value: to.Ptr("foo")},
+		"drifted": {Value: to.Ptr("bar")},
+		"broken":  {Value: to.Ptr("foo")},
+	}
+	getSecret := func(name string) (*azsecrets.Secret, error) {
+		return deployed[name], nil
+	}
+
+	results, err := verifyDirectory(dir, decrypt, getSecret, time.Now())
+	assert.NilError(t, err)
+	statuses := make(map[string]secretStatus)
+	for _, r := range results {
+		statuses[r.Secret] = r.Status
+	}
+	assert.DeepEqual(t, map[string]secretStatus{
+		"broken":  statusError,
+		"current": statusUpToDate,
+		"drifted": statusDrifted,
+		"missing": statusMissing,
+	}, statuses)
+
+	var report bytes.Buffer
+	upToDate, err := printReport(&report, results)
+	assert.NilError(t, err)
+	assert.Assert(t, !upToDate)
+	assert.Assert(t, bytes.HasSuffix(report.Bytes(), []byte("1 up-to-date, 1 drifted, 1 missing, 1 errors\n")))
+
+	upToDate, err = printReport(&report, results[1:2])
+	assert.NilError(t, err)
+	assert.Assert(t, upToDate)
+}
@@ -18,7 +18,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Azure/ARO-HCP/tooling/templatize/cmd/pipeline/inspect"
+	"github.com/Azure/ARO-HCP/tooling/templatize/cmd/pipeline/rollout"
 	"github.com/Azure/ARO-HCP/tooling/templatize/cmd/pipeline/run"
+	"github.com/Azure/ARO-HCP/tooling/templatize/cmd/pipeline/validate"
 )
 
 func NewCommand() (*cobra.Command, error) {
@@ -35,7 +37,9 @@ func NewCommand() (*cobra.Command, error) {
 
 	commands := []func() (*cobra.Command, error){
 		run.NewCommand,
+		rollout.NewCommand,
 		inspect.NewCommand,
+		validate.NewCommand,
 	}
 	for _, newCmd := range commands {
 		c, err := newCmd()
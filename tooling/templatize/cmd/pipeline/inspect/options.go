@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -40,8 +41,8 @@ func BindOptions(opts *RawInspectOptions, cmd *cobra.Command) error {
 	if err != nil {
 		return fmt.Errorf("failed to bind options: %w", err)
 	}
-	cmd.Flags().StringVar(&opts.Scope, "scope", opts.Scope, "scope of the pipeline to inspect")
-	cmd.Flags().StringVar(&opts.Format, "format", opts.Format, "output format")
+	cmd.Flags().StringVar(&opts.Scope, "scope", opts.Scope, "scope of the pipeline to inspect, step scopes inspect every step if no step is given and combine JSON outputs into one object keyed by step")
+	cmd.Flags().StringVar(&opts.Format, "format", opts.Format, "output format, shell or makefile for the vars scope, text (default), dot or mermaid for the graph scope")
 	cmd.Flags().StringVar(&opts.Output, "output", opts.Output, "output file")
 
 	if err := cmd.MarkFlagFilename("output"); err != nil {
@@ -89,11 +90,18 @@ func (o *RawInspectOptions) Validate() (*ValidatedInspectOptions, error) {
 	}
 
 	inspectScopes := pipeline.NewStepInspectScopes()
-	if _, ok := inspectScopes[o.Scope]; !ok {
-		scopes := make([]string, 0, len(inspectScopes))
+	pipelineInspectScopes := pipeline.NewPipelineInspectScopes()
+	_, isStepScope := inspectScopes[o.Scope]
+	_, isPipelineScope := pipelineInspectScopes[o.Scope]
+	if !isStepScope && !isPipelineScope {
+		scopes := make([]string, 0, len(inspectScopes)+len(pipelineInspectScopes))
 		for scope := range inspectScopes {
 			scopes = append(scopes, scope)
 		}
+		for scope := range pipelineInspectScopes {
+			scopes = append(scopes, scope)
+		}
+		slices.Sort(scopes)
 		availableScopes := strings.Join(scopes, ", ")
 		return nil, fmt.Errorf("unknown inspect scope %q, valid scopes are: (%v)", o.Scope, availableScopes)
 	}
@@ -144,6 +152,6 @@ func (o *InspectOptions) RunInspect(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	inspectOptions := pipeline.NewInspectOptions(variables, rolloutOptions.Region, o.PipelineOptions.Step, o.Scope, o.Format, o.OutputFile)
+	inspectOptions := pipeline.NewInspectOptions(variables, rolloutOptions.Region, o.PipelineOptions.PipelineFilePath, o.PipelineOptions.Step, o.Scope, o.Format, o.OutputFile)
 	return pipeline.Inspect(o.PipelineOptions.Pipeline, ctx, inspectOptions)
 }
@@ -99,6 +99,16 @@ func (o *RawPipelineOptions) Validate() (*ValidatedPipelineOptions, error) {
 	}, nil
 }
 
+// WithTarget returns a copy of the options that targets the given region and stamp instead of the ones set by flags
+func (o *ValidatedPipelineOptions) WithTarget(region, regionShort, stamp string) *ValidatedPipelineOptions {
+	return &ValidatedPipelineOptions{
+		validatedPipelineOptions: &validatedPipelineOptions{
+			RawPipelineOptions:      o.RawPipelineOptions,
+			ValidatedRolloutOptions: o.ValidatedRolloutOptions.WithTarget(region, regionShort, stamp),
+		},
+	}
+}
+
 func (o *ValidatedPipelineOptions) Complete() (*PipelineOptions, error) {
 	completed, err := o.ValidatedRolloutOptions.Complete()
 	if err != nil {
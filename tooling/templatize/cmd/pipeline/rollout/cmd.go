@@ -0,0 +1,55 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollout
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-HCP/tooling/templatize/cmd/pipeline/run"
+)
+
+func NewCommand() (*cobra.Command, error) {
+	opts := DefaultOptions()
+	cmd := &cobra.Command{
+		Use:   "rollout",
+		Short: "run a pipeline.yaml file for multiple regions and stamps in waves",
+		Long:  "run a pipeline.yaml file for the regions and stamps of a rollout plan, wave by wave with health gates in between, halting on the first failure",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRollout(cmd.Context(), opts)
+		},
+	}
+	if err := BindOptions(opts, cmd); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func runRollout(ctx context.Context, opts *RawRolloutOptions) error {
+	validated, err := opts.Validate()
+	if err != nil {
+		return err
+	}
+	completed, err := validated.Complete()
+	if err != nil {
+		return err
+	}
+	err = run.PrepareEnvironment(ctx, completed.AzureAuth)
+	if err != nil {
+		return err
+	}
+	return completed.Rollout(ctx)
+}
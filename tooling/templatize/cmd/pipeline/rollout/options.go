@@ -0,0 +1,175 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-HCP/tooling/templatize/cmd/pipeline/run"
+	"github.com/Azure/ARO-HCP/tooling/templatize/pkg/azauth"
+	"github.com/Azure/ARO-HCP/tooling/templatize/pkg/rollout"
+)
+
+func DefaultOptions() *RawRolloutOptions {
+	return &RawRolloutOptions{
+		RunOptions: run.DefaultOptions(),
+	}
+}
+
+func BindOptions(opts *RawRolloutOptions, cmd *cobra.Command) error {
+	err := run.BindOptions(opts.RunOptions, cmd)
+	if err != nil {
+		return fmt.Errorf("failed to bind options: %w", err)
+	}
+	cmd.Flags().StringVar(&opts.PlanFile, "rollout-plan", opts.PlanFile, "rollout plan file declaring the waves of regions and stamps to roll out")
+	cmd.Flags().StringVar(&opts.StateFile, "rollout-state", opts.StateFile, "file the rollout state and combined report are written to, required to pause and resume rollouts")
+	cmd.Flags().BoolVar(&opts.Resume, "resume", opts.Resume, "resume the paused or failed rollout recorded in the rollout state file")
+
+	for _, flag := range []string{"rollout-plan", "rollout-state"} {
+		if err := cmd.MarkFlagFilename(flag); err != nil {
+			return fmt.Errorf("failed to mark flag %q as a file: %w", flag, err)
+		}
+	}
+	if err := cmd.MarkFlagRequired("rollout-plan"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as required: %w", "rollout-plan", err)
+	}
+	return nil
+}
+
+// RawRolloutOptions holds input values.
+type RawRolloutOptions struct {
+	RunOptions *run.RawRunOptions
+	PlanFile   string
+	StateFile  string
+	Resume     bool
+}
+
+// validatedRolloutOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
+type validatedRolloutOptions struct {
+	*RawRolloutOptions
+	ValidatedRunOptions *run.ValidatedRunOptions
+}
+
+type ValidatedRolloutOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*validatedRolloutOptions
+}
+
+// completedRolloutOptions is a private wrapper that enforces a call of Complete() before the rollout can be invoked.
+type completedRolloutOptions struct {
+	RunOptions *run.ValidatedRunOptions
+	AzureAuth  *azauth.Options
+	Plan       *rollout.Plan
+	Report     *rollout.Report
+	StateFile  string
+	Out        io.Writer
+}
+
+type RolloutOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*completedRolloutOptions
+}
+
+func (o *RawRolloutOptions) Validate() (*ValidatedRolloutOptions, error) {
+	target := o.RunOptions.PipelineOptions.RolloutOptions
+	if target.Region != "" || target.RegionShort != "" || target.Stamp != "" {
+		return nil, fmt.Errorf("regions and stamps are defined by the rollout plan, --region, --region-short and --stamp must not be set")
+	}
+
+	validatedRunOptions, err := o.RunOptions.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(o.PlanFile); os.IsNotExist(err) {
+		return nil, fmt.Errorf("rollout plan %s does not exist", o.PlanFile)
+	}
+	if o.Resume && o.StateFile == "" {
+		return nil, fmt.Errorf("resuming a rollout requires --rollout-state")
+	}
+
+	return &ValidatedRolloutOptions{
+		validatedRolloutOptions: &validatedRolloutOptions{
+			RawRolloutOptions:   o,
+			ValidatedRunOptions: validatedRunOptions,
+		},
+	}, nil
+}
+
+func (o *ValidatedRolloutOptions) Complete() (*RolloutOptions, error) {
+	plan, err := rollout.NewPlanFromFile(o.PlanFile)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Pauses() && o.StateFile == "" {
+		return nil, fmt.Errorf("rollout plan %s pauses between waves, which requires --rollout-state", o.PlanFile)
+	}
+
+	var report *rollout.Report
+	if o.Resume {
+		report, err = rollout.LoadReport(o.StateFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := report.Matches(plan); err != nil {
+			return nil, fmt.Errorf("rollout state %s does not belong to rollout plan %s: %w", o.StateFile, o.PlanFile, err)
+		}
+	} else {
+		if o.StateFile != "" {
+			// do not silently discard the progress of an unfinished rollout
+			if existing, err := rollout.LoadReport(o.StateFile); err == nil && existing.Status != rollout.StatusSucceeded {
+				return nil, fmt.Errorf("rollout state %s holds a %s rollout, pass --resume to continue it or remove the file", o.StateFile, existing.Status)
+			}
+		}
+		report = rollout.NewReport(plan)
+	}
+
+	return &RolloutOptions{
+		completedRolloutOptions: &completedRolloutOptions{
+			RunOptions: o.ValidatedRunOptions,
+			AzureAuth:  o.ValidatedRunOptions.AzureAuth,
+			Plan:       plan,
+			Report:     report,
+			StateFile:  o.StateFile,
+			Out:        os.Stdout,
+		},
+	}, nil
+}
+
+// Rollout runs the pipeline for every target of the plan and writes the combined report once done
+func (o *RolloutOptions) Rollout(ctx context.Context) error {
+	runTarget := func(ctx context.Context, target rollout.Target) error {
+		completed, err := o.RunOptions.WithTarget(target.Region, target.RegionShort, target.Stamp).Complete()
+		if err != nil {
+			return err
+		}
+		return completed.RunPipeline(ctx)
+	}
+
+	err := rollout.NewRollout(o.Plan, runTarget, o.StateFile).Run(ctx, o.Report)
+	fmt.Fprintln(o.Out, "\n---------------------")
+	if summaryErr := o.Report.WriteSummary(o.Out); summaryErr != nil {
+		return fmt.Errorf("failed to write rollout report: %w", summaryErr)
+	}
+	if err == nil && o.Report.Status == rollout.StatusPaused {
+		fmt.Fprintln(o.Out, "Resume the rollout with --resume once the paused wave was verified")
+	}
+	return err
+}
@@ -95,13 +95,18 @@ func runPipeline(ctx context.Context, opts *RawRunOptions) error {
 	if err != nil {
 		return err
 	}
-	err = azauth.SetupAzureAuth(ctx)
+	err = PrepareEnvironment(ctx, completed.AzureAuth)
 	if err != nil {
 		return err
 	}
-	err = ensureDependencies(ctx)
+	return completed.RunPipeline(ctx)
+}
+
+// PrepareEnvironment sets up Azure authentication and verifies the tools used by pipeline steps
+func PrepareEnvironment(ctx context.Context, azureAuth *azauth.Options) error {
+	err := azauth.SetupAzureAuth(ctx, azureAuth)
 	if err != nil {
 		return err
 	}
-	return completed.RunPipeline(ctx)
+	return ensureDependencies(ctx)
 }
@@ -17,18 +17,28 @@ package run
 import (
 	"context"
 	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/Azure/ARO-Tools/pkg/config"
 
 	"github.com/Azure/ARO-HCP/tooling/templatize/cmd/pipeline/options"
+	"github.com/Azure/ARO-HCP/tooling/templatize/pkg/aks"
+	"github.com/Azure/ARO-HCP/tooling/templatize/pkg/azauth"
 	"github.com/Azure/ARO-HCP/tooling/templatize/pkg/pipeline"
 )
 
 func DefaultOptions() *RawRunOptions {
 	return &RawRunOptions{
-		PipelineOptions: options.DefaultOptions(),
+		PipelineOptions:       options.DefaultOptions(),
+		ShellStepRetryBackoff: 10 * time.Second,
+		ClusterAccessRole:     aks.DefaultRole,
+		ClusterAccessTTL:      aks.DefaultAssignmentTTL,
 	}
 }
 
@@ -40,20 +50,71 @@ func BindOptions(opts *RawRunOptions, cmd *cobra.Command) error {
 	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", opts.DryRun, "validate the pipeline without executing it")
 	cmd.Flags().BoolVar(&opts.NoPersist, "no-persist-tag", opts.NoPersist, "toggle if persist tag should not be set")
 	cmd.Flags().IntVar(&opts.DeploymentTimeoutSeconds, "deployment-timeout-seconds", pipeline.DefaultDeploymentTimeoutSeconds, "Timeout in Seconds to wait for previous deployments of the pipeline to finish")
+	cmd.Flags().IntVar(&opts.DeploymentRetries, "deployment-retries", pipeline.DefaultDeploymentRetries, "number of retries for ARM deployments failing with transient errors")
+	cmd.Flags().DurationVar(&opts.DeploymentRetryBackoff, "deployment-retry-backoff", pipeline.DefaultDeploymentRetryBackoff, "wait time before the first retry of an ARM deployment, doubled for every further retry")
+	cmd.Flags().StringVar(&opts.WhatIfReportDir, "what-if-report-dir", opts.WhatIfReportDir, "directory to write the WhatIf changes of each ARM step to as JSON during dry-runs, nested by region and resource group")
+	cmd.Flags().StringVar(&opts.ChangePolicyFile, "change-policy-file", opts.ChangePolicyFile, "policy file declaring WhatIf changes that fail a dry-run")
+	cmd.Flags().DurationVar(&opts.ShellStepTimeout, "shell-step-timeout", opts.ShellStepTimeout, "timeout for a single attempt of a shell step, 0 disables the timeout")
+	cmd.Flags().IntVar(&opts.ShellStepRetries, "shell-step-retries", opts.ShellStepRetries, "number of retries for failed shell steps")
+	cmd.Flags().DurationVar(&opts.ShellStepRetryBackoff, "shell-step-retry-backoff", opts.ShellStepRetryBackoff, "wait time before the first retry of a shell step, doubled for every further retry")
+	cmd.Flags().StringToStringVar(&opts.ShellStepTimeoutOverrides, "shell-step-timeout-overrides", opts.ShellStepTimeoutOverrides, "per step shell timeouts, e.g. deploy=30m")
+	cmd.Flags().StringToIntVar(&opts.ShellStepRetriesOverrides, "shell-step-retries-overrides", opts.ShellStepRetriesOverrides, "per step shell retries, e.g. deploy=3")
+	cmd.Flags().StringSliceVar(&opts.SensitiveVariables, "sensitive-variable", opts.SensitiveVariables, "name of a step variable whose value is masked in the shell output")
+	cmd.Flags().StringVar(&opts.ClusterAccessRole, "cluster-access-role", opts.ClusterAccessRole, fmt.Sprintf("AKS RBAC role assigned to access AKS clusters, one of %s", strings.Join(slices.Sorted(maps.Keys(aks.Roles)), ", ")))
+	cmd.Flags().StringSliceVar(&opts.ClusterAccessNamespaces, "cluster-access-namespace", opts.ClusterAccessNamespaces, "namespace to scope the AKS cluster role assignment to, the whole cluster is used if not set")
+	cmd.Flags().DurationVar(&opts.ClusterAccessTTL, "cluster-access-ttl", opts.ClusterAccessTTL, "expiry of the lease on AKS cluster role assignments, role assignments left behind by interrupted runs are removed by later runs once expired")
+	cmd.Flags().BoolVar(&opts.KeepClusterAccess, "keep-cluster-access", opts.KeepClusterAccess, "keep the AKS cluster role assignments created for the run instead of revoking them afterwards")
+	cmd.Flags().StringSliceVar(&opts.AzureCredentialSources, "azure-credential", opts.AzureCredentialSources, fmt.Sprintf("Azure credential sources tried in order, any of %v, defaults to %v", azauth.Sources, azauth.DefaultSources))
+	cmd.Flags().StringVar(&opts.AzureClientID, "azure-client-id", opts.AzureClientID, "client ID of the app registration or managed identity to authenticate as, defaults to $"+azauth.AZURE_CLIENT_ID)
+	cmd.Flags().StringVar(&opts.AzureTenantID, "azure-tenant-id", opts.AzureTenantID, "tenant ID of the app registration to authenticate as, defaults to $"+azauth.AZURE_TENANT_ID)
+	cmd.Flags().StringVar(&opts.AzureFederatedTokenFile, "azure-federated-token-file", opts.AzureFederatedTokenFile, "file holding the workload identity token, defaults to $"+azauth.AZURE_FEDERATED_TOKEN_FILE)
+	cmd.Flags().StringVar(&opts.AzureServiceConnectionID, "azure-devops-service-connection-id", opts.AzureServiceConnectionID, "ID of the Azure DevOps service connection to authenticate with, defaults to $"+azauth.AZURESUBSCRIPTION_SERVICE_CONNECTION_ID)
+	cmd.Flags().StringVar(&opts.AzureCLILogin, "azure-cli-login", opts.AzureCLILogin, "credential source to log in the Azure CLI used by shell steps with, one of workload-identity, managed-identity, azure-devops or github")
+
+	if err := cmd.MarkFlagFilename("azure-federated-token-file"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as a file: %w", "azure-federated-token-file", err)
+	}
+	if err := cmd.MarkFlagFilename("change-policy-file"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as a file: %w", "change-policy-file", err)
+	}
+	if err := cmd.MarkFlagDirname("what-if-report-dir"); err != nil {
+		return fmt.Errorf("failed to mark flag %q as a directory: %w", "what-if-report-dir", err)
+	}
 	return nil
 }
 
 type RawRunOptions struct {
-	PipelineOptions          *options.RawPipelineOptions
-	DryRun                   bool
-	NoPersist                bool
-	DeploymentTimeoutSeconds int
+	PipelineOptions           *options.RawPipelineOptions
+	DryRun                    bool
+	NoPersist                 bool
+	DeploymentTimeoutSeconds  int
+	DeploymentRetries         int
+	DeploymentRetryBackoff    time.Duration
+	WhatIfReportDir           string
+	ChangePolicyFile          string
+	ShellStepTimeout          time.Duration
+	ShellStepRetries          int
+	ShellStepRetryBackoff     time.Duration
+	ShellStepTimeoutOverrides map[string]string
+	ShellStepRetriesOverrides map[string]int
+	SensitiveVariables        []string
+	ClusterAccessRole         string
+	ClusterAccessNamespaces   []string
+	ClusterAccessTTL          time.Duration
+	KeepClusterAccess         bool
+	AzureCredentialSources    []string
+	AzureClientID             string
+	AzureTenantID             string
+	AzureFederatedTokenFile   string
+	AzureServiceConnectionID  string
+	AzureCLILogin             string
 }
 
 // validatedRunOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
 type validatedRunOptions struct {
 	*RawRunOptions
 	*options.ValidatedPipelineOptions
+	AzureAuth *azauth.Options
 }
 
 type ValidatedRunOptions struct {
@@ -67,6 +128,16 @@ type completedRunOptions struct {
 	DryRun                   bool
 	NoPersist                bool
 	DeploymentTimeoutSeconds int
+	DeploymentRetries        int
+	DeploymentRetryBackoff   time.Duration
+	WhatIfReportDir          string
+	ChangePolicy             *pipeline.ChangePolicy
+	ShellStepDefaults        pipeline.ShellStepOptions
+	ShellStepOverrides       map[string]pipeline.ShellStepOptions
+	SensitiveVariables       []string
+	ClusterAccess            *aks.ClusterAdminAssignmentOptions
+	KeepClusterAccess        bool
+	AzureAuth                *azauth.Options
 }
 
 type RunOptions struct {
@@ -80,26 +151,134 @@ func (o *RawRunOptions) Validate() (*ValidatedRunOptions, error) {
 		return nil, err
 	}
 
+	if o.DeploymentRetries < 0 {
+		return nil, fmt.Errorf("deployment retries must not be negative")
+	}
+	if o.ShellStepRetries < 0 {
+		return nil, fmt.Errorf("shell step retries must not be negative")
+	}
+	for step, retries := range o.ShellStepRetriesOverrides {
+		if retries < 0 {
+			return nil, fmt.Errorf("shell step retries for step %s must not be negative", step)
+		}
+	}
+	if _, ok := aks.Roles[o.ClusterAccessRole]; !ok {
+		return nil, fmt.Errorf("invalid cluster access role %q, must be one of %s", o.ClusterAccessRole, strings.Join(slices.Sorted(maps.Keys(aks.Roles)), ", "))
+	}
+	if o.ClusterAccessTTL < 0 {
+		return nil, fmt.Errorf("cluster access TTL must not be negative")
+	}
+	if o.ClusterAccessTTL == 0 && !o.KeepClusterAccess {
+		// role assignments without expiry can't be shared with other runs
+		return nil, fmt.Errorf("cluster access TTL must be set unless the cluster access is kept")
+	}
+
+	azureAuth := &azauth.Options{
+		ClientID:            o.AzureClientID,
+		TenantID:            o.AzureTenantID,
+		FederatedTokenFile:  o.AzureFederatedTokenFile,
+		ServiceConnectionID: o.AzureServiceConnectionID,
+		CLILogin:            azauth.Source(o.AzureCLILogin),
+	}
+	for _, source := range o.AzureCredentialSources {
+		azureAuth.Sources = append(azureAuth.Sources, azauth.Source(source))
+	}
+	if err := azureAuth.Validate(); err != nil {
+		return nil, err
+	}
+
 	return &ValidatedRunOptions{
 		validatedRunOptions: &validatedRunOptions{
 			RawRunOptions:            o,
 			ValidatedPipelineOptions: validatedPipelineOptions,
+			AzureAuth:                azureAuth,
 		},
 	}, nil
 }
 
+// WithTarget returns a copy of the options that targets the given region and stamp instead of the ones set by flags
+func (o *ValidatedRunOptions) WithTarget(region, regionShort, stamp string) *ValidatedRunOptions {
+	return &ValidatedRunOptions{
+		validatedRunOptions: &validatedRunOptions{
+			RawRunOptions:            o.RawRunOptions,
+			ValidatedPipelineOptions: o.ValidatedPipelineOptions.WithTarget(region, regionShort, stamp),
+			AzureAuth:                o.AzureAuth,
+		},
+	}
+}
+
 func (o *ValidatedRunOptions) Complete() (*RunOptions, error) {
 	completed, err := o.ValidatedPipelineOptions.Complete()
 	if err != nil {
 		return nil, err
 	}
 
+	// the pipeline runs in the directory of the pipeline file, so relative paths need to be resolved upfront
+	var whatIfReportDir string
+	if o.WhatIfReportDir != "" {
+		whatIfReportDir, err = filepath.Abs(o.WhatIfReportDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve what-if report directory %s: %w", o.WhatIfReportDir, err)
+		}
+	}
+
+	var changePolicy *pipeline.ChangePolicy
+	if o.ChangePolicyFile != "" {
+		changePolicy, err = pipeline.NewChangePolicyFromFile(o.ChangePolicyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	shellStepDefaults := pipeline.ShellStepOptions{
+		Timeout:      o.ShellStepTimeout,
+		Retries:      o.ShellStepRetries,
+		RetryBackoff: o.ShellStepRetryBackoff,
+	}
+	shellStepOverrides := make(map[string]pipeline.ShellStepOptions)
+	for step, rawTimeout := range o.ShellStepTimeoutOverrides {
+		timeout, err := time.ParseDuration(rawTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shell step timeout for step %s: %w", step, err)
+		}
+		override := shellStepDefaults
+		override.Timeout = timeout
+		shellStepOverrides[step] = override
+	}
+	for step, retries := range o.ShellStepRetriesOverrides {
+		override, ok := shellStepOverrides[step]
+		if !ok {
+			override = shellStepDefaults
+		}
+		override.Retries = retries
+		shellStepOverrides[step] = override
+	}
+
+	clusterAccess := aks.DefaultClusterAdminAssignmentOptions()
+	clusterAccess.Role = o.ClusterAccessRole
+	clusterAccess.Namespaces = o.ClusterAccessNamespaces
+	clusterAccess.TTL = o.ClusterAccessTTL
+	if o.KeepClusterAccess {
+		// kept role assignments must not be removed as expired leftovers by later runs
+		clusterAccess.TTL = 0
+	}
+
 	return &RunOptions{
 		completedRunOptions: &completedRunOptions{
 			PipelineOptions:          completed,
 			DryRun:                   o.DryRun,
 			NoPersist:                o.NoPersist,
 			DeploymentTimeoutSeconds: o.DeploymentTimeoutSeconds,
+			DeploymentRetries:        o.DeploymentRetries,
+			DeploymentRetryBackoff:   o.DeploymentRetryBackoff,
+			WhatIfReportDir:          whatIfReportDir,
+			ChangePolicy:             changePolicy,
+			ShellStepDefaults:        shellStepDefaults,
+			ShellStepOverrides:       shellStepOverrides,
+			SensitiveVariables:       o.SensitiveVariables,
+			ClusterAccess:            clusterAccess,
+			KeepClusterAccess:        o.KeepClusterAccess,
+			AzureAuth:                o.AzureAuth,
 		},
 	}, nil
 }
@@ -129,7 +308,16 @@ func (o *RunOptions) RunPipeline(ctx context.Context) error {
 		SubsciptionLookupFunc:    pipeline.LookupSubscriptionID,
 		NoPersist:                o.NoPersist,
 		DeploymentTimeoutSeconds: o.DeploymentTimeoutSeconds,
+		DeploymentRetries:        o.DeploymentRetries,
+		DeploymentRetryBackoff:   o.DeploymentRetryBackoff,
 		PipelineFilePath:         o.PipelineOptions.PipelineFilePath,
+		WhatIfReportDir:          o.WhatIfReportDir,
+		ChangePolicy:             o.ChangePolicy,
+		ShellStepDefaults:        o.ShellStepDefaults,
+		ShellStepOverrides:       o.ShellStepOverrides,
+		SensitiveVariables:       o.SensitiveVariables,
+		ClusterAccess:            o.ClusterAccess,
+		KeepClusterAccess:        o.KeepClusterAccess,
 	})
 	return err
 }
@@ -0,0 +1,195 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ARO-Tools/pkg/config"
+	"github.com/Azure/ARO-Tools/pkg/types"
+
+	options "github.com/Azure/ARO-HCP/tooling/templatize/cmd"
+	"github.com/Azure/ARO-HCP/tooling/templatize/pkg/pipeline"
+)
+
+func DefaultOptions() *RawValidateOptions {
+	return &RawValidateOptions{
+		BaseOptions: options.DefaultOptions(),
+		Stamps:      []string{"1"},
+	}
+}
+
+func BindOptions(opts *RawValidateOptions, cmd *cobra.Command) error {
+	err := options.BindOptions(opts.BaseOptions, cmd)
+	if err != nil {
+		return fmt.Errorf("failed to bind options: %w", err)
+	}
+	cmd.Flags().StringVar(&opts.PipelineFile, "pipeline-file", opts.PipelineFile, "pipeline file path")
+	cmd.Flags().StringSliceVar(&opts.Regions, "regions", opts.Regions, "regions to validate the pipeline for, defaults to all regions of the deploy environment")
+	cmd.Flags().StringSliceVar(&opts.Stamps, "stamps", opts.Stamps, "stamps to validate the pipeline for")
+	cmd.Flags().StringToStringVar(&opts.RegionShorts, "region-short", opts.RegionShorts, "short region name of every validated region, e.g. westus3=usw3")
+
+	for _, flag := range []string{"pipeline-file"} {
+		if err := cmd.MarkFlagFilename(flag); err != nil {
+			return fmt.Errorf("failed to mark flag %q as a file: %w", flag, err)
+		}
+		if err := cmd.MarkFlagRequired(flag); err != nil {
+			return fmt.Errorf("failed to mark flag %q as required: %w", flag, err)
+		}
+	}
+	return nil
+}
+
+// RawValidateOptions holds input values.
+type RawValidateOptions struct {
+	BaseOptions  *options.RawOptions
+	PipelineFile string
+	Regions      []string
+	Stamps       []string
+	// RegionShorts maps the validated regions to their short names
+	RegionShorts map[string]string
+}
+
+// validatedValidateOptions is a private wrapper that enforces a call of Validate() before Complete() can be invoked.
+type validatedValidateOptions struct {
+	*RawValidateOptions
+	*options.ValidatedOptions
+}
+
+type ValidatedValidateOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*validatedValidateOptions
+}
+
+// completedValidateOptions is a private wrapper that enforces a call of Complete() before validation can be invoked.
+type completedValidateOptions struct {
+	Options      *options.Options
+	Cloud        string
+	DeployEnv    string
+	PipelineFile string
+	Regions      []string
+	Stamps       []string
+	RegionShorts map[string]string
+}
+
+type ValidateOptions struct {
+	// Embed a private pointer that cannot be instantiated outside of this package.
+	*completedValidateOptions
+}
+
+func (o *RawValidateOptions) Validate() (*ValidatedValidateOptions, error) {
+	validatedBaseOptions, err := o.BaseOptions.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(o.PipelineFile); os.IsNotExist(err) {
+		return nil, fmt.Errorf("pipeline file %s does not exist", o.PipelineFile)
+	}
+	if len(o.Stamps) == 0 {
+		return nil, fmt.Errorf("at least one stamp is required")
+	}
+
+	return &ValidatedValidateOptions{
+		validatedValidateOptions: &validatedValidateOptions{
+			RawValidateOptions: o,
+			ValidatedOptions:   validatedBaseOptions,
+		},
+	}, nil
+}
+
+func (o *ValidatedValidateOptions) Complete() (*ValidateOptions, error) {
+	completed, err := o.ValidatedOptions.Complete()
+	if err != nil {
+		return nil, err
+	}
+
+	regions := o.Regions
+	if len(regions) == 0 {
+		regions, err = completed.ConfigProvider.GetRegions(o.Cloud, o.DeployEnv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get regions: %w", err)
+		}
+	}
+	var missing []string
+	for _, region := range regions {
+		if o.RegionShorts[region] == "" {
+			missing = append(missing, region)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("short region names are required for regions %s, set them with --region-short", strings.Join(missing, ", "))
+	}
+
+	return &ValidateOptions{
+		completedValidateOptions: &completedValidateOptions{
+			Options:      completed,
+			Cloud:        o.Cloud,
+			DeployEnv:    o.DeployEnv,
+			PipelineFile: o.PipelineFile,
+			Regions:      regions,
+			Stamps:       o.Stamps,
+			RegionShorts: o.RegionShorts,
+		},
+	}, nil
+}
+
+func (o *ValidateOptions) ValidatePipeline(ctx context.Context) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	var issues []string
+	for _, region := range o.Regions {
+		for _, stamp := range o.Stamps {
+			logger.V(1).Info("validating pipeline", "region", region, "stamp", stamp)
+			for _, err := range o.validateFor(region, stamp) {
+				issues = append(issues, fmt.Sprintf("region %s, stamp %s: %v", region, stamp, err))
+			}
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("pipeline %s has %d issues:\n%s", o.PipelineFile, len(issues), strings.Join(issues, "\n"))
+	}
+	logger.Info("pipeline is valid", "pipeline", o.PipelineFile, "regions", o.Regions, "stamps", o.Stamps)
+	return nil
+}
+
+func (o *ValidateOptions) validateFor(region, stamp string) []error {
+	variables, err := o.Options.ConfigProvider.GetDeployEnvRegionConfiguration(
+		o.Cloud, o.DeployEnv, region,
+		&config.ConfigReplacements{
+			RegionReplacement:      region,
+			RegionShortReplacement: o.RegionShorts[region],
+			StampReplacement:       stamp,
+			CloudReplacement:       o.Cloud,
+			EnvironmentReplacement: o.DeployEnv,
+		},
+	)
+	if err != nil {
+		return []error{fmt.Errorf("failed to get variables: %w", err)}
+	}
+	variables["extraVars"] = map[string]interface{}{}
+
+	p, err := types.NewPipelineFromFile(o.PipelineFile, variables)
+	if err != nil {
+		return []error{fmt.Errorf("failed to load pipeline: %w", err)}
+	}
+	return pipeline.ValidatePipeline(p, variables, o.PipelineFile)
+}
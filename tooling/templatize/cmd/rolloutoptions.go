@@ -101,6 +101,20 @@ func (o *RawRolloutOptions) Validate() (*ValidatedRolloutOptions, error) {
 	}, nil
 }
 
+// WithTarget returns a copy of the options that targets the given region and stamp instead of the ones set by flags
+func (o *ValidatedRolloutOptions) WithTarget(region, regionShort, stamp string) *ValidatedRolloutOptions {
+	raw := *o.RawRolloutOptions
+	raw.Region = region
+	raw.RegionShort = regionShort
+	raw.Stamp = stamp
+	return &ValidatedRolloutOptions{
+		validatedRolloutOptions: &validatedRolloutOptions{
+			RawRolloutOptions: &raw,
+			ValidatedOptions:  o.ValidatedOptions,
+		},
+	}
+}
+
 func (o *ValidatedRolloutOptions) Complete() (*RolloutOptions, error) {
 	completed, err := o.ValidatedOptions.Complete()
 	if err != nil {
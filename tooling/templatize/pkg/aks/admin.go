@@ -18,7 +18,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"net/http"
 	"os"
+	"path"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
@@ -36,37 +41,244 @@ import (
 
 const (
 	clusterAdminRoleID = "b1ff04bb-8a4e-4dc4-8eb5-8693973ce19b" // Azure Kubernetes Service RBAC Cluster Admin
+	adminRoleID        = "3498e952-d568-435e-9b2c-8d77e338d7f7" // Azure Kubernetes Service RBAC Admin
+	writerRoleID       = "a7ffa36f-339b-4b5c-8bdf-e2c188b2c0eb" // Azure Kubernetes Service RBAC Writer
+	readerRoleID       = "7f6c6a51-bcf8-42ba-9220-52d62157d7db" // Azure Kubernetes Service RBAC Reader
+
+	// assignmentDescriptionPrefix marks role assignments created by templatize, it is followed by the comma
+	// separated expiry times of the runs using them
+	assignmentDescriptionPrefix = "Created by templatize, expires "
+)
+
+const (
+	// DefaultRole is the role assigned when no role is configured
+	DefaultRole = "cluster-admin"
+	// DefaultAssignmentTTL is the time after which role assignments that were not revoked are considered leftovers
+	DefaultAssignmentTTL = 2 * time.Hour
 )
 
+// Roles maps the names of the assignable AKS RBAC roles to their role definition IDs
+var Roles = map[string]string{
+	"cluster-admin": clusterAdminRoleID,
+	"admin":         adminRoleID,
+	"writer":        writerRoleID,
+	"reader":        readerRoleID,
+}
+
+// roleRanks orders the roles by the permissions they grant, a role covers all roles with a lower rank
+var roleRanks = map[string]int{
+	readerRoleID:       1,
+	writerRoleID:       2,
+	adminRoleID:        3,
+	clusterAdminRoleID: 4,
+}
+
 type ClusterAdminAssignmentOptions struct {
 	Timeout        time.Duration
 	CheckFrequency time.Duration
+	// Role is the name of the AKS RBAC role to assign, one of the keys of Roles. Defaults to DefaultRole.
+	Role string
+	// Namespaces scope the role assignment down to these namespaces instead of the whole cluster
+	Namespaces []string
+	// TTL is recorded as expiry on the role assignments created or reused. Expired role assignments that
+	// were not revoked, e.g. because a run was killed, are removed the next time access is ensured.
+	// Zero creates role assignments without expiry, which can't be shared with other runs.
+	TTL time.Duration
 }
 
-func EnsureClusterAdmin(ctx context.Context, kubeconfigPath, subscriptionID, resourceGroupName, aksClusterName string, options *ClusterAdminAssignmentOptions) error {
-	if options == nil {
-		options = &ClusterAdminAssignmentOptions{
-			Timeout:        time.Duration(2 * time.Minute),
-			CheckFrequency: time.Duration(5 * time.Second),
+func DefaultClusterAdminAssignmentOptions() *ClusterAdminAssignmentOptions {
+	return &ClusterAdminAssignmentOptions{
+		Timeout:        time.Duration(2 * time.Minute),
+		CheckFrequency: time.Duration(5 * time.Second),
+		Role:           DefaultRole,
+		TTL:            DefaultAssignmentTTL,
+	}
+}
+
+// ClusterAdminAssignment tracks the role assignments used by EnsureClusterAdmin, so they can be revoked
+// once access to the cluster is no longer needed. Equivalent role assignments that were not created by
+// templatize are not tracked and are never revoked.
+type ClusterAdminAssignment struct {
+	client *armauthorization.RoleAssignmentsClient
+	// Created holds the IDs of the role assignments created
+	Created []string
+	// Reused holds the IDs of the role assignments created by other runs that this run holds a lease on
+	Reused []string
+	// expiry is the lease of this run recorded on the role assignments, zero for none
+	expiry time.Time
+}
+
+// Revoke releases the lease of this run on its role assignments. A role assignment is deleted once no
+// other run holds an unexpired lease on it.
+func (a *ClusterAdminAssignment) Revoke(ctx context.Context) error {
+	if a == nil {
+		return nil
+	}
+	var errs []error
+	a.Created = a.release(ctx, a.Created, &errs)
+	a.Reused = a.release(ctx, a.Reused, &errs)
+	return errors.Join(errs...)
+}
+
+// release releases the lease on each of the role assignments and returns those that failed
+func (a *ClusterAdminAssignment) release(ctx context.Context, ids []string, errs *[]error) []string {
+	var remaining []string
+	for _, id := range ids {
+		if err := a.releaseOne(ctx, id); err != nil {
+			*errs = append(*errs, err)
+			remaining = append(remaining, id)
+		}
+	}
+	return remaining
+}
+
+func (a *ClusterAdminAssignment) releaseOne(ctx context.Context, id string) error {
+	if !a.expiry.IsZero() {
+		resp, err := a.client.GetByID(ctx, id, nil)
+		if isNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get role assignment %s: %w", id, err)
 		}
+		leases, ok := assignmentLeases(&resp.RoleAssignment)
+		if !ok {
+			fmt.Printf("Role assignment %s kept, a run keeping its cluster access took it over\n", id)
+			return nil
+		}
+		if leases = releaseLease(leases, a.expiry, time.Now()); len(leases) > 0 {
+			if err := updateAssignmentDescription(ctx, a.client, &resp.RoleAssignment, leaseDescription(leases)); err != nil {
+				return fmt.Errorf("failed to release lease on role assignment %s: %w", id, err)
+			}
+			fmt.Printf("Role assignment %s kept, other runs still use it\n", id)
+			return nil
+		}
+	}
+	if _, err := a.client.DeleteByID(ctx, id, nil); err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete role assignment %s: %w", id, err)
+	}
+	fmt.Printf("Role assignment %s revoked\n", id)
+	return nil
+}
+
+// reuse takes a lease on an equivalent role assignment. The expiry of this run is added to role assignments
+// created by other runs of templatize, so that they are not deleted while this run still needs them. Runs
+// without expiry take the role assignment over for good.
+func (a *ClusterAdminAssignment) reuse(ctx context.Context, ra *armauthorization.RoleAssignment, now time.Time) error {
+	id := stringValue(ra.ID)
+	leases, ok := assignmentLeases(ra)
+	if !ok || slices.Contains(a.Reused, id) {
+		fmt.Printf("Reusing existing role assignment %s\n", id)
+		return nil
+	}
+	description := ""
+	if !a.expiry.IsZero() {
+		description = leaseDescription(addLease(leases, a.expiry, now))
+	}
+	if err := updateAssignmentDescription(ctx, a.client, ra, description); err != nil {
+		return fmt.Errorf("failed to take lease on role assignment %s: %w", id, err)
+	}
+	fmt.Printf("Reusing role assignment %s of another run\n", id)
+	if !a.expiry.IsZero() {
+		a.Reused = append(a.Reused, id)
+	}
+	return nil
+}
+
+// EnsureClusterAdmin makes sure the current user holds the configured AKS RBAC role on the cluster, or on each
+// of the configured namespaces, and waits until the role is effective in all of them. Existing equivalent role
+// assignments are reused, those created by other runs of templatize are shared with them. The returned
+// assignment holds the role assignments used and is returned even on error, so that callers can revoke what
+// was used before the failure.
+func EnsureClusterAdmin(ctx context.Context, kubeconfigPath, subscriptionID, resourceGroupName, aksClusterName string, options *ClusterAdminAssignmentOptions) (*ClusterAdminAssignment, error) {
+	if options == nil {
+		options = DefaultClusterAdminAssignmentOptions()
+	}
+	role := options.Role
+	if role == "" {
+		role = DefaultRole
+	}
+	roleID, ok := Roles[role]
+	if !ok {
+		return nil, fmt.Errorf("unknown role %q, must be one of %s", role, strings.Join(slices.Sorted(maps.Keys(Roles)), ", "))
 	}
 
 	// Get the current user's object ID
 	userObjectID, err := getCurrentUserObjectID(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get current user object ID: %w", err)
+		return nil, fmt.Errorf("failed to get current user object ID: %w", err)
 	}
 
-	// Assign the Azure Kubernetes Service RBAC Cluster Admin role to the current user
-	err = assignClusterAdminRBACRole(ctx, subscriptionID, resourceGroupName, aksClusterName, userObjectID, clusterAdminRoleID)
+	cred, err := azauth.GetAzureTokenCredentials()
 	if err != nil {
-		return fmt.Errorf("failed to assign cluster admin role: %w", err)
+		return nil, fmt.Errorf("failed to obtain a credential: %w", err)
+	}
+	client, err := armauthorization.NewRoleAssignmentsClient(subscriptionID, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create role assignments client: %w", err)
+	}
+	now := time.Now()
+	assignment := &ClusterAdminAssignment{client: client}
+	if options.TTL > 0 {
+		// expiry times are recorded with second precision
+		assignment.expiry = now.Add(options.TTL).UTC().Truncate(time.Second)
+	}
+
+	aksID := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s", subscriptionID, resourceGroupName, aksClusterName)
+	roleDefinitionID := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", subscriptionID, roleID)
+
+	existing, err := listRoleAssignments(ctx, client, aksID, userObjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role assignments: %w", err)
+	}
+
+	// Remove leftovers of earlier runs that were not revoked
+	existing = slices.DeleteFunc(existing, func(ra *armauthorization.RoleAssignment) bool {
+		if !assignmentExpired(ra, now) || ra.ID == nil {
+			return false
+		}
+		if _, err := client.DeleteByID(ctx, *ra.ID, nil); err != nil {
+			fmt.Printf("Failed to delete expired role assignment %s: %v\n", *ra.ID, err)
+			return false
+		}
+		fmt.Printf("Expired role assignment %s deleted\n", *ra.ID)
+		return true
+	})
+
+	for _, scope := range assignmentScopes(aksID, options.Namespaces) {
+		equivalent := findEquivalentAssignment(existing, scope, roleID)
+		if equivalent == nil {
+			id, err := assignRBACRole(ctx, client, scope, userObjectID, roleDefinitionID, assignment.expiry)
+			if err != nil {
+				return assignment, fmt.Errorf("failed to assign %s role: %w", role, err)
+			}
+			if id != "" {
+				assignment.Created = append(assignment.Created, id)
+				continue
+			}
+			// the role assignment exists but was not listed before, it may belong to another run
+			existing, err = listRoleAssignments(ctx, client, aksID, userObjectID)
+			if err != nil {
+				return assignment, fmt.Errorf("failed to list role assignments: %w", err)
+			}
+			if equivalent = findEquivalentAssignment(existing, scope, roleID); equivalent == nil {
+				fmt.Printf("Role assignment for %s exists but is not listed yet, it is not tracked\n", scope)
+				continue
+			}
+		}
+		if err := assignment.reuse(ctx, equivalent, now); err != nil {
+			return assignment, err
+		}
 	}
 
 	// Validate assignment
-	err = CheckClusterAdminPermissions(ctx, kubeconfigPath)
+	namespaces := options.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{"default"}
+	}
+	err = checkPermissions(ctx, kubeconfigPath, namespaces)
 	if err == nil {
-		return nil
+		return assignment, nil
 	}
 
 	// Wait for role assignment to be effective
@@ -78,14 +290,14 @@ func EnsureClusterAdmin(ctx context.Context, kubeconfigPath, subscriptionID, res
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return assignment, ctx.Err()
 		case <-timeout:
-			return fmt.Errorf("timed out waiting for role assignment to be effective")
+			return assignment, fmt.Errorf("timed out waiting for role assignment to be effective")
 		case <-ticker.C:
-			err = CheckClusterAdminPermissions(ctx, kubeconfigPath)
+			err = checkPermissions(ctx, kubeconfigPath, namespaces)
 			if err == nil {
-				fmt.Println("Cluster admin permissions are now effective")
-				return nil
+				fmt.Println("Cluster permissions are now effective")
+				return assignment, nil
 			}
 			fmt.Println("Waiting for role assignment to be effective...")
 		}
@@ -93,16 +305,29 @@ func EnsureClusterAdmin(ctx context.Context, kubeconfigPath, subscriptionID, res
 }
 
 func CheckClusterAdminPermissions(ctx context.Context, kubeconfigPath string) error {
+	return CheckNamespacePermissions(ctx, kubeconfigPath, "default")
+}
+
+// CheckNamespacePermissions verifies access to the cluster by listing the pods in the given namespace
+func CheckNamespacePermissions(ctx context.Context, kubeconfigPath, namespace string) error {
 	clientset, err := createKubeClient(kubeconfigPath)
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
 
-	// Implement the logic to test cluster admin permissions
-	// by checking if the user can list pods in the default namespace
-	_, err = clientset.CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+	_, err = clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to list pods in the default namespace: %w", err)
+		return fmt.Errorf("failed to list pods in the %s namespace: %w", namespace, err)
+	}
+	return nil
+}
+
+// checkPermissions verifies access to each of the namespaces
+func checkPermissions(ctx context.Context, kubeconfigPath string, namespaces []string) error {
+	for _, namespace := range namespaces {
+		if err := CheckNamespacePermissions(ctx, kubeconfigPath, namespace); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -143,22 +368,154 @@ func getCurrentUserObjectID(ctx context.Context) (string, error) {
 	return *userID, nil
 }
 
-func assignClusterAdminRBACRole(ctx context.Context, subscriptionID, resourceGroupName, aksClusterName, userObjectID, roleID string) error {
-	// Create a new Azure identity client
-	cred, err := azauth.GetAzureTokenCredentials()
-	if err != nil {
-		return fmt.Errorf("failed to obtain a credential: %w", err)
+// listRoleAssignments returns the role assignments of the principal that apply to the cluster, including
+// those inherited from parent scopes and those on namespaces of the cluster
+func listRoleAssignments(ctx context.Context, client *armauthorization.RoleAssignmentsClient, aksID, principalID string) ([]*armauthorization.RoleAssignment, error) {
+	var assignments []*armauthorization.RoleAssignment
+	pager := client.NewListForScopePager(aksID, &armauthorization.RoleAssignmentsClientListForScopeOptions{
+		Filter: to.Ptr(fmt.Sprintf("assignedTo('%s')", principalID)),
+	})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, page.Value...)
 	}
+	return assignments, nil
+}
 
-	// Create a new role assignments client
-	client, err := armauthorization.NewRoleAssignmentsClient(subscriptionID, cred, nil)
+// assignmentScopes returns the scopes to assign the role on, the namespaces of the cluster if given or the cluster itself
+func assignmentScopes(aksID string, namespaces []string) []string {
+	if len(namespaces) == 0 {
+		return []string{aksID}
+	}
+	scopes := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		scopes = append(scopes, fmt.Sprintf("%s/namespaces/%s", aksID, namespace))
+	}
+	return scopes
+}
+
+// findEquivalentAssignment returns an unconditional role assignment that grants at least the permissions of
+// the given role on the scope, either directly or inherited from a parent scope. Role assignments not created
+// by templatize are preferred, as they don't need to be shared with other runs.
+func findEquivalentAssignment(assignments []*armauthorization.RoleAssignment, scope, roleID string) *armauthorization.RoleAssignment {
+	var shared *armauthorization.RoleAssignment
+	for _, ra := range assignments {
+		if ra == nil || ra.Properties == nil || ra.Properties.Condition != nil {
+			continue
+		}
+		if !scopeCovers(stringValue(ra.Properties.Scope), scope) {
+			continue
+		}
+		existingRoleID := strings.ToLower(path.Base(stringValue(ra.Properties.RoleDefinitionID)))
+		if roleRanks[existingRoleID] < roleRanks[roleID] {
+			continue
+		}
+		if _, ok := assignmentLeases(ra); !ok {
+			return ra
+		}
+		if shared == nil {
+			shared = ra
+		}
+	}
+	return shared
+}
+
+// scopeCovers reports whether a role assigned on the parent scope applies to the given scope
+func scopeCovers(parent, scope string) bool {
+	switch parent {
+	case "":
+		return false
+	case "/":
+		// the root scope covers everything
+		return true
+	}
+	parent = strings.TrimSuffix(strings.ToLower(parent), "/")
+	scope = strings.ToLower(scope)
+	return parent == scope || strings.HasPrefix(scope, parent+"/")
+}
+
+// assignmentLeases returns the expiry times of the runs using a role assignment created by templatize. It
+// returns false for role assignments that were not created by templatize or carry no valid expiry.
+func assignmentLeases(ra *armauthorization.RoleAssignment) ([]time.Time, bool) {
+	if ra == nil || ra.Properties == nil {
+		return nil, false
+	}
+	expiries, ok := strings.CutPrefix(stringValue(ra.Properties.Description), assignmentDescriptionPrefix)
+	if !ok {
+		return nil, false
+	}
+	var leases []time.Time
+	for _, expiry := range strings.Split(expiries, ",") {
+		expiresAt, err := time.Parse(time.RFC3339, strings.TrimSpace(expiry))
+		if err != nil {
+			return nil, false
+		}
+		leases = append(leases, expiresAt)
+	}
+	return leases, true
+}
+
+// leaseDescription records the expiry times of the runs using a role assignment
+func leaseDescription(leases []time.Time) string {
+	expiries := make([]string, 0, len(leases))
+	for _, lease := range leases {
+		expiries = append(expiries, lease.UTC().Format(time.RFC3339))
+	}
+	return assignmentDescriptionPrefix + strings.Join(expiries, ", ")
+}
+
+// addLease adds the expiry of a run to the leases, dropping those that expired
+func addLease(leases []time.Time, expiry, now time.Time) []time.Time {
+	leases = slices.DeleteFunc(slices.Clone(leases), now.After)
+	return append(leases, expiry)
+}
+
+// releaseLease removes the expiry of a run from the leases, dropping those that expired
+func releaseLease(leases []time.Time, expiry, now time.Time) []time.Time {
+	leases = slices.Clone(leases)
+	if i := slices.IndexFunc(leases, expiry.Equal); i >= 0 {
+		leases = slices.Delete(leases, i, i+1)
+	}
+	return slices.DeleteFunc(leases, now.After)
+}
+
+// assignmentExpired reports whether the role assignment was created by templatize and all of its leases expired
+func assignmentExpired(ra *armauthorization.RoleAssignment, now time.Time) bool {
+	leases, ok := assignmentLeases(ra)
+	return ok && !slices.ContainsFunc(leases, func(lease time.Time) bool {
+		return !now.After(lease)
+	})
+}
+
+// updateAssignmentDescription replaces the description of an existing role assignment
+func updateAssignmentDescription(ctx context.Context, client *armauthorization.RoleAssignmentsClient, ra *armauthorization.RoleAssignment, description string) error {
+	_, err := client.CreateByID(ctx, stringValue(ra.ID), armauthorization.RoleAssignmentCreateParameters{
+		Properties: &armauthorization.RoleAssignmentProperties{
+			RoleDefinitionID: ra.Properties.RoleDefinitionID,
+			PrincipalID:      ra.Properties.PrincipalID,
+			PrincipalType:    ra.Properties.PrincipalType,
+			Description:      to.Ptr(description),
+		},
+	}, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create role assignments client: %w", err)
+		return err
 	}
+	ra.Properties.Description = to.Ptr(description)
+	return nil
+}
 
-	aksID := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s", subscriptionID, resourceGroupName, aksClusterName)
-	roleDefinitionID := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", subscriptionID, roleID)
+func isNotFound(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
 
+// assignRBACRole assigns the role on the scope and returns the ID of the created role assignment. The expiry
+// is recorded as lease of this run unless it is zero. An empty ID is returned if an identical role assignment
+// exists already.
+func assignRBACRole(ctx context.Context, client *armauthorization.RoleAssignmentsClient, scope, userObjectID, roleDefinitionID string, expiry time.Time) (string, error) {
 	// Define the role assignment parameters
 	parameters := armauthorization.RoleAssignmentCreateParameters{
 		Properties: &armauthorization.RoleAssignmentProperties{
@@ -166,23 +523,24 @@ func assignClusterAdminRBACRole(ctx context.Context, subscriptionID, resourceGro
 			PrincipalID:      to.Ptr(userObjectID),
 		},
 	}
+	if !expiry.IsZero() {
+		parameters.Properties.Description = to.Ptr(leaseDescription([]time.Time{expiry}))
+	}
 
 	// Create the role assignment
-	_, err = client.Create(ctx, aksID, uuid.New().String(), parameters, nil)
+	resp, err := client.Create(ctx, scope, uuid.New().String(), parameters, nil)
 	if err != nil {
 		var respErr *azcore.ResponseError
 		if errors.As(err, &respErr) && respErr.ErrorCode == "RoleAssignmentExists" {
-			// we could check if the roleassignment exists upfront but even when
-			// the role exists, checking for it is not always reliably detect it
-			// so there is no point why we should check. all our users have
-			// permissions to create such role assignments anyways
-			return nil
+			// listing role assignments does not always reliably detect an existing
+			// assignment, so it may only show up here. the caller looks it up to reuse it
+			return "", nil
 		}
-		return fmt.Errorf("failed to create role assignment: %w", err)
+		return "", fmt.Errorf("failed to create role assignment: %w", err)
 	}
 
-	fmt.Println("Azure Kubernetes Service RBAC Cluster Admin role assignment created successfully")
-	return nil
+	fmt.Printf("Role assignment %s created on %s\n", stringValue(resp.ID), scope)
+	return stringValue(resp.ID), nil
 }
 
 func createKubeClient(kubeconfigPath string) (*kubernetes.Clientset, error) {
@@ -200,3 +558,10 @@ func createKubeClient(kubeconfigPath string) (*kubernetes.Clientset, error) {
 
 	return clientset, nil
 }
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,227 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	armauthorization "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAKSID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks"
+
+func roleAssignment(id, scope, roleID, description string) *armauthorization.RoleAssignment {
+	ra := &armauthorization.RoleAssignment{
+		ID: to.Ptr(id),
+		Properties: &armauthorization.RoleAssignmentProperties{
+			Scope:            to.Ptr(scope),
+			RoleDefinitionID: to.Ptr("/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/" + roleID),
+		},
+	}
+	if description != "" {
+		ra.Properties.Description = to.Ptr(description)
+	}
+	return ra
+}
+
+func TestAssignmentScopes(t *testing.T) {
+	assert.Equal(t, []string{testAKSID}, assignmentScopes(testAKSID, nil))
+	assert.Equal(t, []string{
+		testAKSID + "/namespaces/a",
+		testAKSID + "/namespaces/b",
+	}, assignmentScopes(testAKSID, []string{"a", "b"}))
+}
+
+func TestScopeCovers(t *testing.T) {
+	for _, tc := range []struct {
+		parent   string
+		scope    string
+		expected bool
+	}{
+		{parent: testAKSID, scope: testAKSID, expected: true},
+		{parent: "/subscriptions/sub", scope: testAKSID, expected: true},
+		{parent: "/SUBSCRIPTIONS/SUB/resourcegroups/RG", scope: testAKSID, expected: true},
+		{parent: "/", scope: testAKSID, expected: true},
+		{parent: testAKSID, scope: testAKSID + "/namespaces/a", expected: true},
+		{parent: testAKSID + "/namespaces/a", scope: testAKSID, expected: false},
+		{parent: testAKSID + "/namespaces/a", scope: testAKSID + "/namespaces/ab", expected: false},
+		{parent: "/subscriptions/sub/resourceGroups/r", scope: testAKSID, expected: false},
+		{parent: "", scope: testAKSID, expected: false},
+	} {
+		assert.Equal(t, tc.expected, scopeCovers(tc.parent, tc.scope), "%s covers %s", tc.parent, tc.scope)
+	}
+}
+
+func TestAssignmentExpired(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name        string
+		description string
+		expected    bool
+	}{
+		{name: "no description"},
+		{name: "foreign description", description: "granted by the platform team"},
+		{name: "not yet expired", description: assignmentDescriptionPrefix + "2025-05-01T13:00:00Z"},
+		{name: "expired", description: assignmentDescriptionPrefix + "2025-05-01T11:00:00Z", expected: true},
+		{name: "unparsable expiry", description: assignmentDescriptionPrefix + "tomorrow"},
+		{name: "one lease not yet expired", description: assignmentDescriptionPrefix + "2025-05-01T11:00:00Z, 2025-05-01T13:00:00Z"},
+		{name: "all leases expired", description: assignmentDescriptionPrefix + "2025-05-01T10:00:00Z, 2025-05-01T11:00:00Z", expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ra := roleAssignment("id", testAKSID, clusterAdminRoleID, tc.description)
+			assert.Equal(t, tc.expected, assignmentExpired(ra, now))
+		})
+	}
+}
+
+func TestFindEquivalentAssignment(t *testing.T) {
+	namespaceScope := testAKSID + "/namespaces/a"
+
+	conditional := roleAssignment("conditional", testAKSID, clusterAdminRoleID, "")
+	conditional.Properties.Condition = to.Ptr("@Resource[...] StringEquals 'x'")
+
+	for _, tc := range []struct {
+		name        string
+		assignments []*armauthorization.RoleAssignment
+		scope       string
+		roleID      string
+		expected    string
+	}{
+		{
+			name:   "none",
+			scope:  testAKSID,
+			roleID: clusterAdminRoleID,
+		},
+		{
+			name:        "same role on cluster",
+			assignments: []*armauthorization.RoleAssignment{roleAssignment("direct", testAKSID, clusterAdminRoleID, "")},
+			scope:       testAKSID,
+			roleID:      clusterAdminRoleID,
+			expected:    "direct",
+		},
+		{
+			name:        "inherited from subscription",
+			assignments: []*armauthorization.RoleAssignment{roleAssignment("inherited", "/subscriptions/sub", clusterAdminRoleID, "")},
+			scope:       testAKSID,
+			roleID:      clusterAdminRoleID,
+			expected:    "inherited",
+		},
+		{
+			name:        "cluster admin covers namespace writer",
+			assignments: []*armauthorization.RoleAssignment{roleAssignment("admin", testAKSID, clusterAdminRoleID, "")},
+			scope:       namespaceScope,
+			roleID:      writerRoleID,
+			expected:    "admin",
+		},
+		{
+			name:        "reader does not cover writer",
+			assignments: []*armauthorization.RoleAssignment{roleAssignment("reader", namespaceScope, readerRoleID, "")},
+			scope:       namespaceScope,
+			roleID:      writerRoleID,
+		},
+		{
+			name:        "namespace does not cover cluster",
+			assignments: []*armauthorization.RoleAssignment{roleAssignment("namespaced", namespaceScope, clusterAdminRoleID, "")},
+			scope:       testAKSID,
+			roleID:      clusterAdminRoleID,
+		},
+		{
+			name:        "unrelated role",
+			assignments: []*armauthorization.RoleAssignment{roleAssignment("contributor", testAKSID, "b24988ac-6180-42a0-ab88-20f7382dd24c", "")},
+			scope:       testAKSID,
+			roleID:      readerRoleID,
+		},
+		{
+			name:        "conditional",
+			assignments: []*armauthorization.RoleAssignment{conditional},
+			scope:       testAKSID,
+			roleID:      clusterAdminRoleID,
+		},
+		{
+			name: "created by templatize",
+			assignments: []*armauthorization.RoleAssignment{
+				roleAssignment("reader", testAKSID, readerRoleID, assignmentDescriptionPrefix+"2025-05-01T13:00:00Z"),
+				roleAssignment("templatize", testAKSID, clusterAdminRoleID, assignmentDescriptionPrefix+"2025-05-01T13:00:00Z"),
+			},
+			scope:    testAKSID,
+			roleID:   clusterAdminRoleID,
+			expected: "templatize",
+		},
+		{
+			name: "not created by templatize preferred",
+			assignments: []*armauthorization.RoleAssignment{
+				roleAssignment("templatize", testAKSID, clusterAdminRoleID, assignmentDescriptionPrefix+"2025-05-01T13:00:00Z"),
+				roleAssignment("inherited", "/subscriptions/sub", clusterAdminRoleID, ""),
+			},
+			scope:    testAKSID,
+			roleID:   clusterAdminRoleID,
+			expected: "inherited",
+		},
+		{
+			name: "foreign description",
+			assignments: []*armauthorization.RoleAssignment{
+				roleAssignment("templatize", testAKSID, clusterAdminRoleID, assignmentDescriptionPrefix+"2025-05-01T13:00:00Z"),
+				roleAssignment("platform", testAKSID, clusterAdminRoleID, "granted by the platform team"),
+			},
+			scope:    testAKSID,
+			roleID:   clusterAdminRoleID,
+			expected: "platform",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			found := findEquivalentAssignment(tc.assignments, tc.scope, tc.roleID)
+			if tc.expected == "" {
+				assert.Nil(t, found)
+				return
+			}
+			if assert.NotNil(t, found) {
+				assert.Equal(t, tc.expected, *found.ID)
+			}
+		})
+	}
+}
+
+func TestAssignmentLeases(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+	first := now.Add(time.Hour)
+	second := now.Add(2 * time.Hour)
+
+	// a second run takes a lease on the role assignment of the first one, expired leases are dropped
+	leases := addLease([]time.Time{expired, first}, second, now)
+	assert.Equal(t, []time.Time{first, second}, leases)
+
+	description := leaseDescription(leases)
+	assert.Equal(t, assignmentDescriptionPrefix+"2025-05-01T13:00:00Z, 2025-05-01T14:00:00Z", description)
+	parsed, ok := assignmentLeases(roleAssignment("id", testAKSID, clusterAdminRoleID, description))
+	assert.True(t, ok)
+	assert.Equal(t, leases, parsed)
+
+	// the first run finishing keeps the role assignment for the second one
+	leases = releaseLease(leases, first, now)
+	assert.Equal(t, []time.Time{second}, leases)
+	// which deletes it once it finished
+	assert.Empty(t, releaseLease(leases, second, now))
+	// a lease that is not held is not released
+	assert.Equal(t, []time.Time{second}, releaseLease(leases, first, now))
+
+	_, ok = assignmentLeases(roleAssignment("id", testAKSID, clusterAdminRoleID, ""))
+	assert.False(t, ok)
+	_, ok = assignmentLeases(roleAssignment("id", testAKSID, clusterAdminRoleID, assignmentDescriptionPrefix+"tomorrow"))
+	assert.False(t, ok)
+}
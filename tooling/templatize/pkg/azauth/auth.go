@@ -17,22 +17,64 @@ package azauth
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/go-logr/logr"
 )
 
-func SetupAzureAuth(ctx context.Context) error {
-	if githubAuthSupported() {
-		err := setupGithubAzureFederationAuthRefresher(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to setup GitHub Azure Federation Auth Refresher: %w", err)
+var (
+	configuredMu         sync.Mutex
+	configuredCredential azcore.TokenCredential
+)
+
+// SetupAzureAuth builds the configured credential chain, returned by GetAzureTokenCredentials from now on, logs
+// the Azure CLI in if configured and logs the identity the chain resolves to.
+func SetupAzureAuth(ctx context.Context, opts *Options) error {
+	logger := logr.FromContextOrDiscard(ctx)
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	opts = opts.withDefaults()
+
+	// the Azure CLI is logged in first, as it may be part of the credential chain
+	if opts.CLILogin != "" {
+		if err := setupCLILogin(ctx, opts); err != nil {
+			return fmt.Errorf("failed to log in the Azure CLI with %s: %w", opts.CLILogin, err)
 		}
 	}
+
+	cred, err := NewCredential(ctx, opts)
+	if err != nil {
+		return err
+	}
+	configuredMu.Lock()
+	configuredCredential = cred
+	configuredMu.Unlock()
+
+	identity, err := ResolveIdentity(ctx, cred)
+	if err != nil {
+		// the pipeline reports a meaningful error once it needs the credential
+		logger.Error(err, "failed to resolve Azure identity", "sources", opts.Sources)
+		return nil
+	}
+	logger.Info("Resolved Azure identity", identity.logValues()...)
 	return nil
 }
 
+// GetAzureTokenCredentials returns the credential chain configured with SetupAzureAuth, or the default chain of
+// Azure CLI and azidentity default credentials if none was configured.
 func GetAzureTokenCredentials() (azcore.TokenCredential, error) {
+	configuredMu.Lock()
+	defer configuredMu.Unlock()
+	if configuredCredential != nil {
+		return configuredCredential, nil
+	}
+
 	azCLI, err := azidentity.NewAzureCLICredential(nil)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,129 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azauth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// federatedTokenFunc returns a federated ID token that can be exchanged for an Azure session
+type federatedTokenFunc func(ctx context.Context) (string, error)
+
+// setupCLILogin logs the Azure CLI in with the configured login source, so shell steps run as the same identity
+func setupCLILogin(ctx context.Context, opts *Options) error {
+	switch opts.CLILogin {
+	case SourceManagedIdentity:
+		// managed identity sessions of the Azure CLI do not need to be refreshed
+		args := []string{"login", "--identity"}
+		if opts.ClientID != "" {
+			args = append(args, "--username", opts.ClientID)
+		}
+		return runAzLogin(ctx, args...)
+	case SourceWorkloadIdentity:
+		if opts.FederatedTokenFile == "" {
+			return fmt.Errorf("no federated token file configured, set %s", AZURE_FEDERATED_TOKEN_FILE)
+		}
+		return setupFederatedAuthRefresher(ctx, "workload identity token", opts.ClientID, opts.TenantID, func(_ context.Context) (string, error) {
+			// the token file is rotated by kubelet, so it is read on every refresh
+			token, err := os.ReadFile(opts.FederatedTokenFile)
+			if err != nil {
+				return "", fmt.Errorf("failed to read federated token file: %w", err)
+			}
+			return strings.TrimSpace(string(token)), nil
+		})
+	case SourceAzureDevOps:
+		requestURL := os.Getenv(SYSTEM_OIDCREQUESTURI)
+		accessToken := os.Getenv(SYSTEM_ACCESSTOKEN)
+		if requestURL == "" || accessToken == "" {
+			return fmt.Errorf("environment variables %s and %s of Azure DevOps OIDC are not set", SYSTEM_OIDCREQUESTURI, SYSTEM_ACCESSTOKEN)
+		}
+		if opts.ServiceConnectionID == "" {
+			return fmt.Errorf("no Azure DevOps service connection ID configured, set %s", AZURESUBSCRIPTION_SERVICE_CONNECTION_ID)
+		}
+		return setupFederatedAuthRefresher(ctx, "Azure DevOps ID token", opts.ClientID, opts.TenantID, func(ctx context.Context) (string, error) {
+			return getAzureDevOpsIDToken(ctx, requestURL, accessToken, opts.ServiceConnectionID)
+		})
+	case SourceGitHub:
+		if !githubAuthSupported() {
+			return fmt.Errorf("environment variables %s and %s of GitHub Actions OIDC are not set", ACTIONS_ID_TOKEN_REQUEST_URL, ACTIONS_ID_TOKEN_REQUEST_TOKEN)
+		}
+		requestURL := os.Getenv(ACTIONS_ID_TOKEN_REQUEST_URL)
+		requestToken := os.Getenv(ACTIONS_ID_TOKEN_REQUEST_TOKEN)
+		return setupFederatedAuthRefresher(ctx, "GitHub ID token", opts.ClientID, opts.TenantID, func(ctx context.Context) (string, error) {
+			return getGithubIDToken(ctx, requestURL, requestToken)
+		})
+	default:
+		return fmt.Errorf("unsupported Azure CLI login source %q", opts.CLILogin)
+	}
+}
+
+// setupFederatedAuthRefresher logs the Azure CLI in with a federated token and refreshes the session periodically,
+// as federated tokens are short-lived
+func setupFederatedAuthRefresher(ctx context.Context, tokenName, clientId, tenantId string, getToken federatedTokenFunc) error {
+	logger := logr.FromContextOrDiscard(ctx)
+	if clientId == "" || tenantId == "" {
+		return fmt.Errorf("client ID and tenant ID are required to log in with a %s", tokenName)
+	}
+	err := refreshAzureFederatedSession(ctx, tokenName, clientId, tenantId, getToken)
+	if err != nil {
+		return fmt.Errorf("failed to refresh Azure session with federated %s: %w", tokenName, err)
+	}
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				err := refreshAzureFederatedSession(ctx, tokenName, clientId, tenantId, getToken)
+				if err != nil {
+					logger.Error(err, "failed to refresh Azure session with federated "+tokenName)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func refreshAzureFederatedSession(ctx context.Context, tokenName, clientId, tenantId string, getToken federatedTokenFunc) error {
+	logger := logr.FromContextOrDiscard(ctx)
+	logger.V(7).Info("Refreshing Azure session with federated " + tokenName)
+	token, err := getToken(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", tokenName, err)
+	}
+	if err := runAzLogin(ctx, "login", "--service-principal", "--username", clientId, "--tenant", tenantId, "--federated-token", token); err != nil {
+		return err
+	}
+	logger.V(7).Info("Azure session refreshed with federated " + tokenName)
+	return nil
+}
+
+func runAzLogin(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "az", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to run az login: %s %v", string(output), err)
+	}
+	return nil
+}
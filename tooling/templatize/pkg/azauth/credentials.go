@@ -0,0 +1,221 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/go-logr/logr"
+)
+
+// Source is a source of Azure credentials
+type Source string
+
+const (
+	// SourceAzureCLI uses the session of the Azure CLI
+	SourceAzureCLI Source = "azure-cli"
+	// SourceDefault uses the azidentity default credential chain
+	SourceDefault Source = "default"
+	// SourceWorkloadIdentity uses a Kubernetes workload identity federated token
+	SourceWorkloadIdentity Source = "workload-identity"
+	// SourceManagedIdentity uses a managed identity, the one with the configured client ID if set
+	SourceManagedIdentity Source = "managed-identity"
+	// SourceAzureDevOps uses an Azure DevOps service connection with workload identity federation
+	SourceAzureDevOps Source = "azure-devops"
+	// SourceGitHub uses a GitHub Actions ID token federated with an app registration
+	SourceGitHub Source = "github"
+)
+
+const (
+	AZURESUBSCRIPTION_SERVICE_CONNECTION_ID = "AZURESUBSCRIPTION_SERVICE_CONNECTION_ID"
+	SYSTEM_ACCESSTOKEN                      = "SYSTEM_ACCESSTOKEN"
+	SYSTEM_OIDCREQUESTURI                   = "SYSTEM_OIDCREQUESTURI"
+)
+
+// Sources are all supported credential sources
+var Sources = []Source{SourceAzureCLI, SourceDefault, SourceWorkloadIdentity, SourceManagedIdentity, SourceAzureDevOps, SourceGitHub}
+
+// DefaultSources is the credential chain used when no sources are configured
+var DefaultSources = []Source{SourceAzureCLI, SourceDefault}
+
+// cliLoginSources are the sources that can log the Azure CLI in
+var cliLoginSources = []Source{SourceWorkloadIdentity, SourceManagedIdentity, SourceAzureDevOps, SourceGitHub}
+
+// Options configure the credential chain and the Azure CLI session used by shell steps.
+type Options struct {
+	// Sources are tried in order until one provides a token, DefaultSources are used if empty
+	Sources []Source
+	// ClientID of the app registration or managed identity, defaults to $AZURE_CLIENT_ID
+	ClientID string
+	// TenantID of the app registration, defaults to $AZURE_TENANT_ID
+	TenantID string
+	// FederatedTokenFile holds the workload identity token, defaults to $AZURE_FEDERATED_TOKEN_FILE
+	FederatedTokenFile string
+	// ServiceConnectionID of the Azure DevOps service connection, defaults to $AZURESUBSCRIPTION_SERVICE_CONNECTION_ID
+	ServiceConnectionID string
+	// CLILogin is the source the Azure CLI is logged in with, so that shell steps run as the same identity.
+	// If empty, the Azure CLI is logged in with GitHub when running in GitHub Actions with OIDC.
+	CLILogin Source
+}
+
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+func (o *Options) Validate() error {
+	for i, source := range o.Sources {
+		if !slices.Contains(Sources, source) {
+			return fmt.Errorf("unknown credential source %q, must be one of %v", source, Sources)
+		}
+		if slices.Contains(o.Sources[:i], source) {
+			return fmt.Errorf("credential source %q is configured more than once", source)
+		}
+	}
+	if o.CLILogin != "" && !slices.Contains(cliLoginSources, o.CLILogin) {
+		return fmt.Errorf("unsupported Azure CLI login source %q, must be one of %v", o.CLILogin, cliLoginSources)
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the options with unset values taken from the environment
+func (o *Options) withDefaults() *Options {
+	completed := *o
+	if len(completed.Sources) == 0 {
+		completed.Sources = DefaultSources
+	}
+	for value, env := range map[*string]string{
+		&completed.ClientID:            AZURE_CLIENT_ID,
+		&completed.TenantID:            AZURE_TENANT_ID,
+		&completed.FederatedTokenFile:  AZURE_FEDERATED_TOKEN_FILE,
+		&completed.ServiceConnectionID: AZURESUBSCRIPTION_SERVICE_CONNECTION_ID,
+	} {
+		if *value == "" {
+			*value = os.Getenv(env)
+		}
+	}
+	if completed.CLILogin == "" && len(o.Sources) == 0 && githubAuthSupported() {
+		completed.CLILogin = SourceGitHub
+	}
+	return &completed
+}
+
+// NewCredential builds the credential chain configured by the options. Sources that cannot be built in this
+// environment, e.g. workload identity without a token file, are logged and left out of the chain.
+func NewCredential(ctx context.Context, opts *Options) (azcore.TokenCredential, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+	opts = opts.withDefaults()
+	tracker := &sourceTracker{}
+	var credentials []azcore.TokenCredential
+	var errs []error
+	for _, source := range opts.Sources {
+		cred, err := newSourceCredential(source, opts)
+		if err != nil {
+			err = fmt.Errorf("failed to create %s credential: %w", source, err)
+			logger.Error(err, "skipping Azure credential source", "source", source)
+			errs = append(errs, err)
+			continue
+		}
+		credentials = append(credentials, &trackedCredential{source: source, cred: cred, tracker: tracker})
+	}
+	if len(credentials) == 0 {
+		return nil, fmt.Errorf("none of the Azure credential sources %v could be created: %w", opts.Sources, errors.Join(errs...))
+	}
+	chain, err := azidentity.NewChainedTokenCredential(credentials, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &chainedCredential{ChainedTokenCredential: chain, tracker: tracker}, nil
+}
+
+func newSourceCredential(source Source, opts *Options) (azcore.TokenCredential, error) {
+	switch source {
+	case SourceAzureCLI:
+		return azidentity.NewAzureCLICredential(nil)
+	case SourceDefault:
+		return azidentity.NewDefaultAzureCredential(nil)
+	case SourceWorkloadIdentity:
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientID:      opts.ClientID,
+			TenantID:      opts.TenantID,
+			TokenFilePath: opts.FederatedTokenFile,
+		})
+	case SourceManagedIdentity:
+		miOpts := &azidentity.ManagedIdentityCredentialOptions{}
+		if opts.ClientID != "" {
+			miOpts.ID = azidentity.ClientID(opts.ClientID)
+		}
+		return azidentity.NewManagedIdentityCredential(miOpts)
+	case SourceAzureDevOps:
+		return azidentity.NewAzurePipelinesCredential(opts.TenantID, opts.ClientID, opts.ServiceConnectionID, os.Getenv(SYSTEM_ACCESSTOKEN), nil)
+	case SourceGitHub:
+		if !githubAuthSupported() {
+			return nil, fmt.Errorf("environment variables %s and %s of GitHub Actions OIDC are not set", ACTIONS_ID_TOKEN_REQUEST_URL, ACTIONS_ID_TOKEN_REQUEST_TOKEN)
+		}
+		requestURL := os.Getenv(ACTIONS_ID_TOKEN_REQUEST_URL)
+		requestToken := os.Getenv(ACTIONS_ID_TOKEN_REQUEST_TOKEN)
+		return azidentity.NewClientAssertionCredential(opts.TenantID, opts.ClientID, func(ctx context.Context) (string, error) {
+			return getGithubIDToken(ctx, requestURL, requestToken)
+		}, nil)
+	default:
+		return nil, fmt.Errorf("unknown credential source %q", source)
+	}
+}
+
+// sourceTracker records the first source of a chain that provided a token
+type sourceTracker struct {
+	mu     sync.Mutex
+	source Source
+}
+
+func (t *sourceTracker) record(source Source) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.source == "" {
+		t.source = source
+	}
+}
+
+func (t *sourceTracker) get() Source {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.source
+}
+
+type trackedCredential struct {
+	source  Source
+	cred    azcore.TokenCredential
+	tracker *sourceTracker
+}
+
+func (c *trackedCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	token, err := c.cred.GetToken(ctx, opts)
+	if err == nil {
+		c.tracker.record(c.source)
+	}
+	return token, err
+}
+
+type chainedCredential struct {
+	*azidentity.ChainedTokenCredential
+	tracker *sourceTracker
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azauth
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts Options
+		err  string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "chain",
+			opts: Options{Sources: []Source{SourceWorkloadIdentity, SourceManagedIdentity, SourceAzureCLI}, CLILogin: SourceWorkloadIdentity},
+		},
+		{
+			name: "unknown source",
+			opts: Options{Sources: []Source{"password"}},
+			err:  `unknown credential source "password", must be one of [azure-cli default workload-identity managed-identity azure-devops github]`,
+		},
+		{
+			name: "duplicate source",
+			opts: Options{Sources: []Source{SourceAzureCLI, SourceDefault, SourceAzureCLI}},
+			err:  `credential source "azure-cli" is configured more than once`,
+		},
+		{
+			name: "unsupported CLI login",
+			opts: Options{CLILogin: SourceAzureCLI},
+			err:  `unsupported Azure CLI login source "azure-cli", must be one of [workload-identity managed-identity azure-devops github]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.Validate()
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestOptionsWithDefaults(t *testing.T) {
+	t.Setenv(AZURE_CLIENT_ID, "env-client")
+	t.Setenv(AZURE_TENANT_ID, "env-tenant")
+	t.Setenv(AZURE_FEDERATED_TOKEN_FILE, "/var/run/secrets/token")
+	t.Setenv(AZURESUBSCRIPTION_SERVICE_CONNECTION_ID, "env-connection")
+	t.Setenv(ACTIONS_ID_TOKEN_REQUEST_URL, "https://token.actions.githubusercontent.com")
+	t.Setenv(ACTIONS_ID_TOKEN_REQUEST_TOKEN, "request-token")
+
+	opts := (&Options{ClientID: "flag-client"}).withDefaults()
+	assert.Equal(t, &Options{
+		Sources:             DefaultSources,
+		ClientID:            "flag-client",
+		TenantID:            "env-tenant",
+		FederatedTokenFile:  "/var/run/secrets/token",
+		ServiceConnectionID: "env-connection",
+		// running in GitHub Actions with OIDC
+		CLILogin: SourceGitHub,
+	}, opts)
+
+	// the GitHub login is only implied for the default chain
+	opts = (&Options{Sources: []Source{SourceManagedIdentity}}).withDefaults()
+	assert.Equal(t, Source(""), opts.CLILogin)
+}
+
+func TestNewCredential(t *testing.T) {
+	// GitHub cannot be built outside of GitHub Actions
+	t.Setenv(ACTIONS_ID_TOKEN_REQUEST_URL, "")
+	require.NoError(t, os.Unsetenv(ACTIONS_ID_TOKEN_REQUEST_URL))
+
+	for _, tc := range []struct {
+		name    string
+		sources []Source
+		skipped []Source
+		err     string
+	}{
+		{
+			name:    "all sources built",
+			sources: []Source{SourceAzureCLI},
+		},
+		{
+			name:    "unavailable source skipped",
+			sources: []Source{SourceGitHub, SourceAzureCLI},
+			skipped: []Source{SourceGitHub},
+		},
+		{
+			name:    "no source built",
+			sources: []Source{SourceGitHub},
+			skipped: []Source{SourceGitHub},
+			err:     "none of the Azure credential sources [github] could be created: failed to create github credential",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var skipped []Source
+			logger := funcr.New(func(_, args string) {
+				for _, source := range tc.sources {
+					if strings.Contains(args, fmt.Sprintf(`"source"=%q`, source)) {
+						skipped = append(skipped, source)
+					}
+				}
+			}, funcr.Options{})
+
+			cred, err := NewCredential(logr.NewContext(context.Background(), logger), &Options{Sources: tc.sources})
+			assert.Equal(t, tc.skipped, skipped)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, cred)
+		})
+	}
+}
+
+type fakeCredential struct {
+	token string
+	err   error
+}
+
+func (c *fakeCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: c.token}, c.err
+}
+
+func testToken(claims string) string {
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".signature"
+}
+
+func TestResolveIdentity(t *testing.T) {
+	tracker := &sourceTracker{}
+	managedIdentity := &trackedCredential{
+		source:  SourceManagedIdentity,
+		cred:    &fakeCredential{token: testToken(`{"oid":"object","tid":"tenant","appid":"client","idtyp":"app"}`)},
+		tracker: tracker,
+	}
+	azureCLI := &trackedCredential{
+		source:  SourceAzureCLI,
+		cred:    &fakeCredential{token: testToken(`{"oid":"user","tid":"tenant","upn":"jane@example.com"}`)},
+		tracker: tracker,
+	}
+	chain, err := azidentity.NewChainedTokenCredential([]azcore.TokenCredential{managedIdentity, azureCLI}, nil)
+	require.NoError(t, err)
+
+	identity, err := ResolveIdentity(context.Background(), &chainedCredential{ChainedTokenCredential: chain, tracker: tracker})
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{
+		Source:   SourceManagedIdentity,
+		ObjectID: "object",
+		ClientID: "client",
+		TenantID: "tenant",
+	}, identity)
+}
+
+func TestParseIdentity(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		token    string
+		expected *Identity
+		err      string
+	}{
+		{
+			name:     "user",
+			token:    testToken(`{"oid":"object","tid":"tenant","appid":"04b07795-8ddb-461a-bbee-02f9e1bf7b46","upn":"jane@example.com"}`),
+			expected: &Identity{ObjectID: "object", TenantID: "tenant", Name: "jane@example.com"},
+		},
+		{
+			name:     "service principal without idtyp",
+			token:    testToken(`{"oid":"object","tid":"tenant","azp":"client"}`),
+			expected: &Identity{ObjectID: "object", TenantID: "tenant", ClientID: "client"},
+		},
+		{
+			name:  "not a JWT",
+			token: "opaque",
+			err:   "token is not a JWT",
+		},
+		{
+			name:  "invalid claims",
+			token: "header.e30K!.signature",
+			err:   "failed to decode token claims: illegal base64 data at input byte 4",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			identity, err := parseIdentity(tc.token)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, identity)
+		})
+	}
+}
+
+func TestGetAzureDevOpsIDToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"oidcToken":"id-token-for-%s-%s"}`, r.URL.Query().Get("serviceConnectionId"), r.URL.Query().Get("api-version"))
+	}))
+	defer server.Close()
+
+	token, err := getAzureDevOpsIDToken(context.Background(), server.URL, "access", "connection")
+	require.NoError(t, err)
+	assert.Equal(t, "id-token-for-connection-7.1", token)
+
+	_, err = getAzureDevOpsIDToken(context.Background(), server.URL, "wrong", "connection")
+	assert.EqualError(t, err, "failed to get ID token: status code 401")
+}
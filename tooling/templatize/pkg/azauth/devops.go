@@ -0,0 +1,71 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const azureDevOpsOIDCAPIVersion = "7.1"
+
+// getAzureDevOpsIDToken requests an ID token for the service connection from the OIDC endpoint of the running pipeline
+func getAzureDevOpsIDToken(ctx context.Context, requestURL, accessToken, serviceConnectionID string) (string, error) {
+	timeoutContext, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// build request, add auth and service connection
+	req, err := http.NewRequestWithContext(timeoutContext, http.MethodPost, requestURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	// return 401 instead of redirecting to a login page if the access token is invalid
+	req.Header.Set("X-TFS-FedAuthRedirect", "Suppress")
+	q := req.URL.Query()
+	q.Add("api-version", azureDevOpsOIDCAPIVersion)
+	q.Add("serviceConnectionId", serviceConnectionID)
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get ID token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// process response
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get ID token: status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	var tokenResponse struct {
+		OIDCToken string `json:"oidcToken"`
+	}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	if tokenResponse.OIDCToken == "" {
+		return "", fmt.Errorf("response did not contain an ID token")
+	}
+
+	return tokenResponse.OIDCToken, nil
+}
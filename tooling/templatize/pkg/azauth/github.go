@@ -21,10 +21,7 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"time"
-
-	"github.com/go-logr/logr"
 )
 
 const (
@@ -51,50 +48,6 @@ func githubAuthSupported() bool {
 	return true
 }
 
-func setupGithubAzureFederationAuthRefresher(ctx context.Context) error {
-	logger := logr.FromContextOrDiscard(ctx)
-	clientId := os.Getenv(AZURE_CLIENT_ID)
-	tenantId := os.Getenv(AZURE_TENANT_ID)
-	requestToken := os.Getenv(ACTIONS_ID_TOKEN_REQUEST_TOKEN)
-	requestURL := os.Getenv(ACTIONS_ID_TOKEN_REQUEST_URL)
-	err := refreshGithubAzureFederatedSession(ctx, clientId, tenantId, requestURL, requestToken)
-	if err != nil {
-		return fmt.Errorf("failed to refresh Azure session with federated GitHub ID token: %w", err)
-	}
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				err := refreshGithubAzureFederatedSession(ctx, clientId, tenantId, requestURL, requestToken)
-				if err != nil {
-					logger.Error(err, "failed to refresh Azure session with federated GitHub ID token")
-				}
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
-	return nil
-}
-
-func refreshGithubAzureFederatedSession(ctx context.Context, clientId, tenantId, requestUrl, requestToken string) error {
-	logger := logr.FromContextOrDiscard(ctx)
-	logger.V(7).Info("Refreshing Azure session with federated GitHub ID token")
-	token, err := getGithubIDToken(ctx, requestUrl, requestToken)
-	if err != nil {
-		return fmt.Errorf("failed to get GitHub ID token: %w", err)
-	}
-	cmd := exec.CommandContext(ctx, "az", "login", "--service-principal", "--username", clientId, "--tenant", tenantId, "--federated-token", token)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to run az login: %s %v", string(output), err)
-	}
-	logger.V(7).Info("Azure session refreshed with federated GitHub ID token", "az cli output", output)
-	return nil
-}
-
 func getGithubIDToken(ctx context.Context, requestURL, requestToken string) (string, error) {
 	timeoutContext, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -0,0 +1,114 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+const armScope = "https://management.azure.com/.default"
+
+// Identity describes the principal a credential authenticates as
+type Identity struct {
+	// Source is the source of the credential chain that provided the token, empty for other credentials
+	Source Source
+	// ObjectID of the principal
+	ObjectID string
+	// ClientID of the app registration or managed identity, empty for users
+	ClientID string
+	TenantID string
+	// Name is the user principal name of users, empty for service principals
+	Name string
+}
+
+func (i *Identity) logValues() []any {
+	values := []any{"objectId", i.ObjectID, "tenantId", i.TenantID}
+	if i.Source != "" {
+		values = append(values, "source", i.Source)
+	}
+	if i.ClientID != "" {
+		values = append(values, "clientId", i.ClientID)
+	}
+	if i.Name != "" {
+		values = append(values, "name", i.Name)
+	}
+	return values
+}
+
+// ResolveIdentity requests an ARM token with the credential and returns the identity it was issued to
+func ResolveIdentity(ctx context.Context, cred azcore.TokenCredential) (*Identity, error) {
+	token, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{armScope}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	identity, err := parseIdentity(token.Token)
+	if err != nil {
+		return nil, err
+	}
+	if chain, ok := cred.(*chainedCredential); ok {
+		identity.Source = chain.tracker.get()
+	}
+	return identity, nil
+}
+
+// parseIdentity reads the identity from the claims of an access token, the signature is not verified as the
+// token was just issued to us and the identity is only informational
+func parseIdentity(token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token claims: %w", err)
+	}
+	var claims struct {
+		ObjectID   string `json:"oid"`
+		TenantID   string `json:"tid"`
+		AppID      string `json:"appid"`
+		AZP        string `json:"azp"`
+		IDType     string `json:"idtyp"`
+		UPN        string `json:"upn"`
+		UniqueName string `json:"unique_name"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token claims: %w", err)
+	}
+
+	identity := &Identity{
+		ObjectID: claims.ObjectID,
+		TenantID: claims.TenantID,
+	}
+	// idtyp is not always issued, tokens of users carry a user principal name though
+	if claims.IDType == "app" || (claims.IDType == "" && claims.UPN == "") {
+		identity.ClientID = claims.AppID
+		if identity.ClientID == "" {
+			identity.ClientID = claims.AZP
+		}
+		return identity, nil
+	}
+	identity.Name = claims.UPN
+	if identity.Name == "" {
+		identity.Name = claims.UniqueName
+	}
+	return identity, nil
+}
@@ -16,6 +16,7 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/Azure/ARO-HCP/tooling/templatize/pkg/azauth"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
@@ -38,8 +40,13 @@ type armClient struct {
 
 	Region        string
 	GetDeployment func(ctx context.Context, rgName, deploymentName string) (armresources.DeploymentsClientGetResponse, error)
+	// ListDeploymentOperations lists the operations of a deployment, an empty rgName lists the operations of a subscription deployment
+	ListDeploymentOperations func(ctx context.Context, rgName, deploymentName string) ([]*armresources.DeploymentOperation, error)
 }
 
+// maxNestedDeploymentDepth limits how deep failed nested deployments are followed to find the root cause of a failure
+const maxNestedDeploymentDepth = 5
+
 func newArmClient(subscriptionID, region string) *armClient {
 	cred, err := azauth.GetAzureTokenCredentials()
 	if err != nil {
@@ -53,6 +60,10 @@ func newArmClient(subscriptionID, region string) *armClient {
 	if err != nil {
 		return nil
 	}
+	deploymentOperationsClient, err := armresources.NewDeploymentOperationsClient(subscriptionID, cred, nil)
+	if err != nil {
+		return nil
+	}
 	return &armClient{
 		deploymentClient:        deploymentClient,
 		deploymentRetryWaitTime: 15,
@@ -61,6 +72,29 @@ func newArmClient(subscriptionID, region string) *armClient {
 		GetDeployment: func(ctx context.Context, rgName, deploymentName string) (armresources.DeploymentsClientGetResponse, error) {
 			return deploymentClient.Get(ctx, rgName, deploymentName, nil)
 		},
+		ListDeploymentOperations: func(ctx context.Context, rgName, deploymentName string) ([]*armresources.DeploymentOperation, error) {
+			var operations []*armresources.DeploymentOperation
+			if rgName == "" {
+				pager := deploymentOperationsClient.NewListAtSubscriptionScopePager(deploymentName, nil)
+				for pager.More() {
+					page, err := pager.NextPage(ctx)
+					if err != nil {
+						return nil, err
+					}
+					operations = append(operations, page.Value...)
+				}
+				return operations, nil
+			}
+			pager := deploymentOperationsClient.NewListPager(rgName, deploymentName, nil)
+			for pager.More() {
+				page, err := pager.NextPage(ctx)
+				if err != nil {
+					return nil, err
+				}
+				operations = append(operations, page.Value...)
+			}
+			return operations, nil
+		},
 	}
 }
 
@@ -98,16 +132,81 @@ func (a *armClient) runArmStep(ctx context.Context, options *PipelineRunOptions,
 	}
 
 	// Run deployment
+	logger := logr.FromContextOrDiscard(ctx)
+	for attempt := 0; ; attempt++ {
+		if err := a.waitForExistingDeployment(ctx, options.DeploymentTimeoutSeconds, rgName, step.Name); err != nil {
+			return nil, fmt.Errorf("error waiting for deploymenty %w", err)
+		}
+
+		var output Output
+		if !options.DryRun || (options.DryRun && step.OutputOnly) {
+			output, err = doWaitForDeployment(ctx, a.deploymentClient, rgName, step, options.Configuration, input)
+			err = a.describeDeploymentError(ctx, rgName, step, err)
+		} else {
+			output, err = doDryRun(ctx, a.deploymentClient, rgName, step, options, input)
+		}
+		if err == nil {
+			return output, nil
+		}
+		if attempt >= options.DeploymentRetries || !isRetryableARMError(err) {
+			return nil, err
+		}
 
-	if err := a.waitForExistingDeployment(ctx, options.DeploymentTimeoutSeconds, rgName, step.Name); err != nil {
-		return nil, fmt.Errorf("error waiting for deploymenty %w", err)
+		backoff := options.DeploymentRetryBackoff * time.Duration(1<<attempt)
+		logger.Info("Deployment failed with a transient error, retrying", "deployment", step.Name, "attempt", attempt+1, "retries", options.DeploymentRetries, "backoff", backoff.String(), "error", err.Error())
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to retry deployment %s: %w", step.Name, ctx.Err())
+		case <-time.After(backoff):
+		}
 	}
+}
 
-	if !options.DryRun || (options.DryRun && step.OutputOnly) {
-		return doWaitForDeployment(ctx, a.deploymentClient, rgName, step, options.Configuration, input)
+// describeDeploymentError adds the failed operations of a deployment to a deployment error
+func (a *armClient) describeDeploymentError(ctx context.Context, rgName string, step *types.ARMStep, err error) error {
+	var dErr *deploymentError
+	if !errors.As(err, &dErr) || a.ListDeploymentOperations == nil {
+		return err
 	}
+	if step.DeploymentLevel == "Subscription" {
+		rgName = ""
+	}
+	operations, listErr := a.failedDeploymentOperations(ctx, rgName, step.Name, 0)
+	if listErr != nil {
+		logr.FromContextOrDiscard(ctx).Error(listErr, "failed to list deployment operations", "deployment", step.Name)
+	}
+	dErr.failedOperations = operations
+	return err
+}
 
-	return doDryRun(ctx, a.deploymentClient, rgName, step, options.Configuration, input)
+// failedDeploymentOperations returns the failed operations of a deployment, following failed nested deployments
+func (a *armClient) failedDeploymentOperations(ctx context.Context, rgName, deploymentName string, depth int) ([]*armresources.DeploymentOperation, error) {
+	operations, err := a.ListDeploymentOperations(ctx, rgName, deploymentName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list operations of deployment %s: %w", deploymentName, err)
+	}
+	var failed []*armresources.DeploymentOperation
+	for _, op := range operations {
+		if !isFailedOperation(op) {
+			continue
+		}
+		failed = append(failed, op)
+
+		target := op.Properties.TargetResource
+		if depth >= maxNestedDeploymentDepth || target == nil || target.ID == nil || !strings.EqualFold(stringOrEmpty(target.ResourceType), "Microsoft.Resources/deployments") {
+			continue
+		}
+		nestedID, err := arm.ParseResourceID(*target.ID)
+		if err != nil {
+			return failed, fmt.Errorf("failed to parse nested deployment ID %s: %w", *target.ID, err)
+		}
+		nested, err := a.failedDeploymentOperations(ctx, nestedID.ResourceGroupName, nestedID.Name, depth+1)
+		failed = append(failed, nested...)
+		if err != nil {
+			return failed, err
+		}
+	}
+	return failed, nil
 }
 
 func recursivePrint(level int, change *armresources.WhatIfPropertyChange) {
@@ -156,45 +255,69 @@ func printChangeReport(changes []*armresources.WhatIfChange) {
 	printChanges(armresources.ChangeTypeUnsupported, changes)
 }
 
-func createError(errors armresources.ErrorResponse) error {
-	errB, err := errors.MarshalJSON()
-	if err != nil {
-		return err
-	}
-	return fmt.Errorf("%s", string(errB))
-}
-
-func pollAndPrint[T any](ctx context.Context, p *runtime.Poller[T]) error {
+func pollAndPrint[T any](ctx context.Context, p *runtime.Poller[T]) ([]*armresources.WhatIfChange, error) {
 	resp, err := p.PollUntilDone(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to wait for deployment completion: %w", err)
+		return nil, fmt.Errorf("failed to wait for deployment completion: %w", err)
 	}
+	var changes []*armresources.WhatIfChange
 	switch m := any(resp).(type) {
 	case armresources.DeploymentsClientWhatIfResponse:
 		if *m.Status == "Failed" {
-			return createError(*m.Error)
+			return nil, createError(*m.Error)
 		}
-		printChangeReport(m.Properties.Changes)
+		changes = m.Properties.Changes
 	case armresources.DeploymentsClientWhatIfAtSubscriptionScopeResponse:
 		if *m.Status == "Failed" {
-			return createError(*m.Error)
+			return nil, createError(*m.Error)
 		}
-		printChangeReport(m.Properties.Changes)
+		changes = m.Properties.Changes
 	default:
-		return fmt.Errorf("unknown type %T", m)
+		return nil, fmt.Errorf("unknown type %T", m)
+	}
+	printChangeReport(changes)
+	return changes, nil
+}
+
+// checkWhatIfChanges persists the WhatIf changes of a step if a report directory is configured
+// and fails if any of the changes violate the configured change policy
+func checkWhatIfChanges(ctx context.Context, options *PipelineRunOptions, rgName string, step *types.ARMStep, changes []*armresources.WhatIfChange) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	report := &WhatIfReport{
+		Step:       step.Name,
+		Region:     options.Region,
+		Changes:    changes,
+		Violations: options.ChangePolicy.Evaluate(changes),
+	}
+	if step.DeploymentLevel != "Subscription" {
+		report.ResourceGroup = rgName
+	}
+	if options.WhatIfReportDir != "" {
+		if err := writeWhatIfReport(options.WhatIfReportDir, report); err != nil {
+			return err
+		}
+		logger.V(1).Info("WhatIf report written", "deployment", step.Name, "dir", options.WhatIfReportDir)
+	}
+	if len(report.Violations) > 0 {
+		violations := make([]string, 0, len(report.Violations))
+		for _, v := range report.Violations {
+			violations = append(violations, v.String())
+		}
+		return fmt.Errorf("WhatIf deployment %s violates change policy:\n%s", step.Name, strings.Join(violations, "\n"))
 	}
 	return nil
 }
 
-func doDryRun(ctx context.Context, client *armresources.DeploymentsClient, rgName string, step *types.ARMStep, cfg config.Configuration, input map[string]Output) (Output, error) {
+func doDryRun(ctx context.Context, client *armresources.DeploymentsClient, rgName string, step *types.ARMStep, options *PipelineRunOptions, input map[string]Output) (Output, error) {
 	logger := logr.FromContextOrDiscard(ctx)
 
-	inputValues, err := getInputValues(step.Variables, cfg, input)
+	inputValues, err := getInputValues(step.Variables, options.Configuration, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get input values: %w", err)
 	}
 	// Transform Bicep to ARM
-	deploymentProperties, err := transformBicepToARMWhatIfDeployment(ctx, step.Parameters, cfg, inputValues)
+	deploymentProperties, err := transformBicepToARMWhatIfDeployment(ctx, step.Parameters, options.Configuration, inputValues)
 	if err != nil {
 		return nil, fmt.Errorf("failed to transform Bicep to ARM: %w", err)
 	}
@@ -204,6 +327,7 @@ func doDryRun(ctx context.Context, client *armresources.DeploymentsClient, rgNam
 		Properties: deploymentProperties,
 	}
 
+	var changes []*armresources.WhatIfChange
 	if step.DeploymentLevel == "Subscription" {
 		// Hardcode until schema is adapted
 		deployment.Location = to.Ptr("eastus2")
@@ -212,7 +336,7 @@ func doDryRun(ctx context.Context, client *armresources.DeploymentsClient, rgNam
 			return nil, fmt.Errorf("failed to create WhatIf Deployment: %w", err)
 		}
 		logger.Info("WhatIf Deployment started", "deployment", step.Name)
-		err = pollAndPrint(ctx, poller)
+		changes, err = pollAndPrint(ctx, poller)
 		if err != nil {
 			return nil, fmt.Errorf("failed to poll and print: %w", err)
 		}
@@ -222,13 +346,13 @@ func doDryRun(ctx context.Context, client *armresources.DeploymentsClient, rgNam
 			return nil, fmt.Errorf("failed to create WhatIf Deployment: %w", err)
 		}
 		logger.Info("WhatIf Deployment started", "deployment", step.Name)
-		err = pollAndPrint(ctx, poller)
+		changes, err = pollAndPrint(ctx, poller)
 		if err != nil {
 			return nil, fmt.Errorf("failed to poll and print: %w", err)
 		}
 	}
 
-	return nil, nil
+	return nil, checkWhatIfChanges(ctx, options, rgName, step, changes)
 }
 
 func pollAndGetOutput[T any](ctx context.Context, p *runtime.Poller[T]) (ArmOutput, error) {
@@ -291,7 +415,11 @@ func doWaitForDeployment(ctx context.Context, client *armresources.DeploymentsCl
 		}
 		logger.V(1).Info("Deployment started", "deployment", step.Name)
 
-		return pollAndGetOutput(ctx, poller)
+		output, err := pollAndGetOutput(ctx, poller)
+		if err != nil {
+			return nil, &deploymentError{deployment: step.Name, err: err}
+		}
+		return output, nil
 	} else {
 		poller, err := client.BeginCreateOrUpdate(ctx, rgName, step.Name, deployment, nil)
 		if err != nil {
@@ -299,7 +427,11 @@ func doWaitForDeployment(ctx context.Context, client *armresources.DeploymentsCl
 		}
 		logger.V(1).Info("Deployment started", "deployment", step.Name)
 
-		return pollAndGetOutput(ctx, poller)
+		output, err := pollAndGetOutput(ctx, poller)
+		if err != nil {
+			return nil, &deploymentError{deployment: step.Name, err: err}
+		}
+		return output, nil
 	}
 }
 
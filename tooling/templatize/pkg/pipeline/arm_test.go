@@ -17,11 +17,15 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ARO-Tools/pkg/types"
 )
 
 func TestWaitForExistingDeployment(t *testing.T) {
@@ -102,3 +106,157 @@ func TestWaitForExistingDeployment(t *testing.T) {
 		})
 	}
 }
+
+func failedOperation(resourceType, id, name, code, message string, details ...*armresources.ErrorResponse) *armresources.DeploymentOperation {
+	return &armresources.DeploymentOperation{
+		Properties: &armresources.DeploymentOperationProperties{
+			ProvisioningOperation: to.Ptr(armresources.ProvisioningOperationCreate),
+			ProvisioningState:     to.Ptr("Failed"),
+			StatusCode:            to.Ptr("Conflict"),
+			StatusMessage: &armresources.StatusMessage{
+				Error: &armresources.ErrorResponse{
+					Code:    to.Ptr(code),
+					Message: to.Ptr(message),
+					Details: details,
+				},
+			},
+			TargetResource: &armresources.TargetResource{
+				ID:           to.Ptr(id),
+				ResourceName: to.Ptr(name),
+				ResourceType: to.Ptr(resourceType),
+			},
+		},
+	}
+}
+
+func TestFailedDeploymentOperations(t *testing.T) {
+	nestedDeployment := failedOperation("Microsoft.Resources/deployments", "/subscriptions/sub/resourceGroups/other-rg/providers/Microsoft.Resources/deployments/nested", "nested", "DeploymentFailed", "nested deployment failed")
+	kvOperation := failedOperation("Microsoft.KeyVault/vaults", "/subscriptions/sub/resourceGroups/other-rg/providers/Microsoft.KeyVault/vaults/kv", "kv", "Conflict", "outer", &armresources.ErrorResponse{
+		Code:    to.Ptr("AnotherOperationInProgress"),
+		Message: to.Ptr("another operation is in progress"),
+	})
+
+	a := armClient{
+		ListDeploymentOperations: func(_ context.Context, rgName, deploymentName string) ([]*armresources.DeploymentOperation, error) {
+			switch {
+			case rgName == "rg" && deploymentName == "test":
+				return []*armresources.DeploymentOperation{
+					{Properties: &armresources.DeploymentOperationProperties{ProvisioningState: to.Ptr("Succeeded")}},
+					nestedDeployment,
+				}, nil
+			case rgName == "other-rg" && deploymentName == "nested":
+				return []*armresources.DeploymentOperation{kvOperation}, nil
+			default:
+				return nil, fmt.Errorf("unexpected deployment %s/%s", rgName, deploymentName)
+			}
+		},
+	}
+
+	err := a.describeDeploymentError(context.Background(), "rg", types.NewARMStep("test", "test.bicep", "test.bicepparam", "ResourceGroup"), &deploymentError{deployment: "test", err: fmt.Errorf("failed to wait for deployment completion")})
+	assert.Equal(t, `deployment test failed: failed to wait for deployment completion
+failed operations:
+- Create Microsoft.Resources/deployments nested (status code Conflict):
+  DeploymentFailed: nested deployment failed
+- Create Microsoft.KeyVault/vaults kv (status code Conflict):
+  Conflict: outer
+    AnotherOperationInProgress: another operation is in progress`, err.Error())
+	assert.True(t, isRetryableARMError(err))
+}
+
+func TestIsRetryableARMError(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{
+			name:      "throttled",
+			err:       fmt.Errorf("wrapped: %w", &azcore.ResponseError{StatusCode: http.StatusTooManyRequests}),
+			retryable: true,
+		},
+		{
+			name:      "server error",
+			err:       &azcore.ResponseError{StatusCode: http.StatusBadGateway},
+			retryable: true,
+		},
+		{
+			name:      "in-flight deployment",
+			err:       &azcore.ResponseError{StatusCode: http.StatusConflict, ErrorCode: "DeploymentActive"},
+			retryable: true,
+		},
+		{
+			name:      "invalid template",
+			err:       &azcore.ResponseError{StatusCode: http.StatusBadRequest, ErrorCode: "InvalidTemplate"},
+			retryable: false,
+		},
+		{
+			name: "nested retryable code",
+			err: createError(armresources.ErrorResponse{
+				Code: to.Ptr("DeploymentFailed"),
+				Details: []*armresources.ErrorResponse{
+					{Code: to.Ptr("AnotherOperationInProgress")},
+				},
+			}),
+			retryable: true,
+		},
+		{
+			name: "permanent code wrapped in conflict",
+			err: createError(armresources.ErrorResponse{
+				Code: to.Ptr("Conflict"),
+				Details: []*armresources.ErrorResponse{
+					{Code: to.Ptr("PropertyChangeNotAllowed")},
+				},
+			}),
+			retryable: false,
+		},
+		{
+			name: "permanent operation code wrapped in conflict",
+			err: &deploymentError{
+				deployment: "test",
+				err:        fmt.Errorf("failed"),
+				failedOperations: []*armresources.DeploymentOperation{
+					failedOperation("Microsoft.Network/virtualNetworks", "id", "vnet", "Conflict", "outer", &armresources.ErrorResponse{
+						Code: to.Ptr("PropertyChangeNotAllowed"),
+					}),
+				},
+			},
+			retryable: false,
+		},
+		{
+			name: "deployment without retryable operations",
+			err: &deploymentError{
+				deployment: "test",
+				err:        fmt.Errorf("failed"),
+				failedOperations: []*armresources.DeploymentOperation{
+					failedOperation("Microsoft.KeyVault/vaults", "id", "kv", "BadRequest", "invalid"),
+				},
+			},
+			retryable: false,
+		},
+		{
+			name:      "plain error",
+			err:       fmt.Errorf("failed to transform Bicep to ARM"),
+			retryable: false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.retryable, isRetryableARMError(c.err))
+		})
+	}
+}
+
+func TestCreateError(t *testing.T) {
+	err := createError(armresources.ErrorResponse{
+		Code:    to.Ptr("InvalidTemplateDeployment"),
+		Message: to.Ptr("The template deployment is not valid"),
+		Details: []*armresources.ErrorResponse{
+			{
+				Code:    to.Ptr("PreflightValidationCheckFailed"),
+				Message: to.Ptr("Preflight validation failed"),
+				Target:  to.Ptr("kv"),
+			},
+		},
+	})
+	assert.Equal(t, "InvalidTemplateDeployment: The template deployment is not valid\n  PreflightValidationCheckFailed: Preflight validation failed (target: kv)", err.Error())
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+// retryableARMErrorCodes are error codes of transient failures, a deployment failing with
+// one of these codes is likely to succeed when it is submitted again. Generic codes like
+// Conflict are left out, they wrap permanent failures like PropertyChangeNotAllowed as well.
+var retryableARMErrorCodes = map[string]bool{
+	"AnotherOperationInProgress":          true,
+	"DeploymentActive":                    true,
+	"GatewayTimeout":                      true,
+	"InternalServerError":                 true,
+	"OperationPreempted":                  true,
+	"ResourceCollectionRequestsThrottled": true,
+	"RetryableError":                      true,
+	"ServiceUnavailable":                  true,
+	"SubscriptionRequestsThrottled":       true,
+	"TooManyRequests":                     true,
+}
+
+// armError is an error reported by ARM in the body of a response
+type armError struct {
+	response armresources.ErrorResponse
+}
+
+func createError(errorResponse armresources.ErrorResponse) error {
+	return &armError{response: errorResponse}
+}
+
+func (e *armError) Error() string {
+	var b strings.Builder
+	writeErrorResponse(&b, &e.response, 0)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func writeErrorResponse(b *strings.Builder, resp *armresources.ErrorResponse, level int) {
+	if resp == nil {
+		return
+	}
+	fmt.Fprintf(b, "%s%s: %s", strings.Repeat("  ", level), stringOrEmpty(resp.Code), stringOrEmpty(resp.Message))
+	if resp.Target != nil && *resp.Target != "" {
+		fmt.Fprintf(b, " (target: %s)", *resp.Target)
+	}
+	b.WriteString("\n")
+	for _, detail := range resp.Details {
+		writeErrorResponse(b, detail, level+1)
+	}
+}
+
+// leafErrorCodes returns the codes of the innermost errors of the response, which carry the
+// actual causes while the outer errors only summarize them
+func leafErrorCodes(resp *armresources.ErrorResponse) []string {
+	if resp == nil {
+		return nil
+	}
+	var codes []string
+	for _, detail := range resp.Details {
+		codes = append(codes, leafErrorCodes(detail)...)
+	}
+	if len(codes) == 0 && resp.Code != nil {
+		codes = append(codes, *resp.Code)
+	}
+	return codes
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// deploymentError is returned when an ARM deployment finished unsuccessfully. It carries the
+// failed operations of the deployment, which contain the actual root causes of the failure.
+type deploymentError struct {
+	deployment       string
+	err              error
+	failedOperations []*armresources.DeploymentOperation
+}
+
+func (e *deploymentError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "deployment %s failed: %v", e.deployment, e.err)
+	if len(e.failedOperations) > 0 {
+		b.WriteString("\nfailed operations:\n")
+		for _, op := range e.failedOperations {
+			props := op.Properties
+			target := "unknown resource"
+			if props.TargetResource != nil {
+				target = fmt.Sprintf("%s %s", stringOrEmpty(props.TargetResource.ResourceType), stringOrEmpty(props.TargetResource.ResourceName))
+			}
+			operation := ""
+			if props.ProvisioningOperation != nil {
+				operation = string(*props.ProvisioningOperation)
+			}
+			fmt.Fprintf(&b, "- %s %s (status code %s):\n", operation, target, stringOrEmpty(props.StatusCode))
+			writeErrorResponse(&b, props.StatusMessage.Error, 1)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (e *deploymentError) Unwrap() error {
+	return e.err
+}
+
+// armErrorCodes collects the innermost ARM error codes contained in an error. The code of a
+// response error is only used if the error carries no error details.
+func armErrorCodes(err error) []string {
+	var codes []string
+	var aErr *armError
+	if errors.As(err, &aErr) {
+		codes = append(codes, leafErrorCodes(&aErr.response)...)
+	}
+	var dErr *deploymentError
+	if errors.As(err, &dErr) {
+		for _, op := range dErr.failedOperations {
+			codes = append(codes, leafErrorCodes(op.Properties.StatusMessage.Error)...)
+		}
+	}
+	var respErr *azcore.ResponseError
+	if len(codes) == 0 && errors.As(err, &respErr) && respErr.ErrorCode != "" {
+		codes = append(codes, respErr.ErrorCode)
+	}
+	return codes
+}
+
+// isRetryableARMError reports whether an error is caused by a transient ARM failure, judged
+// by its innermost error codes
+func isRetryableARMError(err error) bool {
+	for _, code := range armErrorCodes(err) {
+		if retryableARMErrorCodes[code] {
+			return true
+		}
+	}
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode == http.StatusTooManyRequests || respErr.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// isFailedOperation reports whether a deployment operation failed with an error
+func isFailedOperation(op *armresources.DeploymentOperation) bool {
+	return op != nil && op.Properties != nil &&
+		op.Properties.ProvisioningState != nil && *op.Properties.ProvisioningState == string(armresources.ProvisioningStateFailed) &&
+		op.Properties.StatusMessage != nil && op.Properties.StatusMessage.Error != nil
+}
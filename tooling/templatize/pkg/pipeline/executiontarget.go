@@ -56,6 +56,8 @@ func LookupSubscriptionID(ctx context.Context, subscriptionName string) (string,
 
 type ExecutionTarget interface {
 	KubeConfig(ctx context.Context) (string, error)
+	// ReleaseClusterAccess revokes the cluster access granted while preparing the kubeconfig
+	ReleaseClusterAccess(ctx context.Context) error
 	GetSubscriptionID() string
 	GetAkSClusterName() string
 	GetResourceGroup() string
@@ -68,6 +70,10 @@ type executionTargetImpl struct {
 	resourceGroup    string
 	region           string
 	aksClusterName   string
+
+	clusterAccess     *aks.ClusterAdminAssignmentOptions
+	keepClusterAccess bool
+	assignment        *aks.ClusterAdminAssignment
 }
 
 func (target *executionTargetImpl) KubeConfig(ctx context.Context) (string, error) {
@@ -82,13 +88,27 @@ func (target *executionTargetImpl) KubeConfig(ctx context.Context) (string, erro
 	}
 
 	// Make sure we have cluster admin
-	err = aks.EnsureClusterAdmin(ctx, kubeconfigPath, target.GetSubscriptionID(), target.GetResourceGroup(), target.GetAkSClusterName(), nil)
+	assignment, err := aks.EnsureClusterAdmin(ctx, kubeconfigPath, target.GetSubscriptionID(), target.GetResourceGroup(), target.GetAkSClusterName(), target.clusterAccess)
+	if !target.keepClusterAccess {
+		target.assignment = assignment
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to ensure cluster admin role: %w", err)
 	}
 	return kubeconfigPath, nil
 }
 
+func (target *executionTargetImpl) ReleaseClusterAccess(ctx context.Context) error {
+	if target.assignment == nil {
+		return nil
+	}
+	if err := target.assignment.Revoke(ctx); err != nil {
+		return fmt.Errorf("failed to revoke cluster access: %w", err)
+	}
+	target.assignment = nil
+	return nil
+}
+
 func (target *executionTargetImpl) GetSubscriptionID() string {
 	return target.subscriptionID
 }
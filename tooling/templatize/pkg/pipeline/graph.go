@@ -0,0 +1,171 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Azure/ARO-Tools/pkg/types"
+)
+
+// stepVariables returns the variables configured on a step, if the action type supports them
+func stepVariables(s types.Step) []types.Variable {
+	switch step := s.(type) {
+	case *types.ShellStep:
+		return step.Variables
+	case *types.ARMStep:
+		return step.Variables
+	default:
+		return nil
+	}
+}
+
+// outputChainingDependencies returns the sorted, unique list of steps referenced as inputs by the given variables
+func outputChainingDependencies(vars []types.Variable) []string {
+	dependencies := make(map[string]bool)
+	for _, v := range vars {
+		if v.Input != nil && v.Input.Step != "" {
+			dependencies[v.Input.Step] = true
+		}
+	}
+	dependencyList := make([]string, 0, len(dependencies))
+	for step := range dependencies {
+		dependencyList = append(dependencyList, step)
+	}
+	slices.Sort(dependencyList)
+	return dependencyList
+}
+
+// stepDependencies returns the direct upstream steps of a step, both explicit
+// dependsOn declarations and implicit output chaining inputs
+func stepDependencies(s types.Step) []string {
+	dependencies := slices.Clone(s.Dependencies())
+	dependencies = append(dependencies, outputChainingDependencies(stepVariables(s))...)
+	slices.Sort(dependencies)
+	return slices.Compact(dependencies)
+}
+
+// upstreamSteps returns the transitive upstream dependencies of a step, ordered so that
+// every step is listed after all of its own dependencies
+func upstreamSteps(p *types.Pipeline, stepName string) ([]string, error) {
+	steps := make(map[string]types.Step)
+	for _, rg := range p.ResourceGroups {
+		for _, step := range rg.Steps {
+			steps[step.StepName()] = step
+		}
+	}
+
+	var ordered []string
+	visited := make(map[string]bool)
+	inProgress := make(map[string]bool)
+	var visit func(name string) error
+	visit = func(name string) error {
+		if visited[name] {
+			return nil
+		}
+		if inProgress[name] {
+			return fmt.Errorf("dependency cycle detected at step %q", name)
+		}
+		step, ok := steps[name]
+		if !ok {
+			return fmt.Errorf("step %q not found", name)
+		}
+		inProgress[name] = true
+		for _, dep := range stepDependencies(step) {
+			if _, ok := steps[dep]; !ok {
+				return fmt.Errorf("step %q depends on unknown step %q", name, dep)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		inProgress[name] = false
+		visited[name] = true
+		ordered = append(ordered, name)
+		return nil
+	}
+	if err := visit(stepName); err != nil {
+		return nil, err
+	}
+	// the last entry is the step itself
+	return ordered[:len(ordered)-1], nil
+}
+
+// writeTextGraph lists the steps of every resource group along with the steps they depend on
+func writeTextGraph(p *types.Pipeline, writer io.Writer) {
+	for _, rg := range p.ResourceGroups {
+		fmt.Fprintf(writer, "%s:\n", rg.Name)
+		for _, step := range rg.Steps {
+			fmt.Fprintf(writer, "  %s (%s)", step.StepName(), step.ActionType())
+			if dependencies := stepDependencies(step); len(dependencies) > 0 {
+				fmt.Fprintf(writer, " <- %s", strings.Join(dependencies, ", "))
+			}
+			fmt.Fprintln(writer)
+		}
+	}
+}
+
+func writeDOTGraph(p *types.Pipeline, writer io.Writer) {
+	fmt.Fprintln(writer, "digraph pipeline {")
+	fmt.Fprintln(writer, "\trankdir=LR;")
+	for i, rg := range p.ResourceGroups {
+		fmt.Fprintf(writer, "\tsubgraph cluster_%d {\n", i)
+		fmt.Fprintf(writer, "\t\tlabel=%q;\n", rg.Name)
+		for _, step := range rg.Steps {
+			fmt.Fprintf(writer, "\t\t%q [label=%q];\n", step.StepName(), fmt.Sprintf("%s (%s)", step.StepName(), step.ActionType()))
+		}
+		fmt.Fprintln(writer, "\t}")
+	}
+	for _, rg := range p.ResourceGroups {
+		for _, step := range rg.Steps {
+			for _, dep := range stepDependencies(step) {
+				fmt.Fprintf(writer, "\t%q -> %q;\n", dep, step.StepName())
+			}
+		}
+	}
+	fmt.Fprintln(writer, "}")
+}
+
+var mermaidIDReplacer = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func mermaidID(name string) string {
+	return "step_" + mermaidIDReplacer.ReplaceAllString(name, "_")
+}
+
+func mermaidLabel(label string) string {
+	return strings.ReplaceAll(label, `"`, "#quot;")
+}
+
+func writeMermaidGraph(p *types.Pipeline, writer io.Writer) {
+	fmt.Fprintln(writer, "flowchart LR")
+	for i, rg := range p.ResourceGroups {
+		fmt.Fprintf(writer, "\tsubgraph rg_%d [\"%s\"]\n", i, mermaidLabel(rg.Name))
+		for _, step := range rg.Steps {
+			fmt.Fprintf(writer, "\t\t%s[\"%s (%s)\"]\n", mermaidID(step.StepName()), mermaidLabel(step.StepName()), step.ActionType())
+		}
+		fmt.Fprintln(writer, "\tend")
+	}
+	for _, rg := range p.ResourceGroups {
+		for _, step := range rg.Steps {
+			for _, dep := range stepDependencies(step) {
+				fmt.Fprintf(writer, "\t%s --> %s\n", mermaidID(dep), mermaidID(step.StepName()))
+			}
+		}
+	}
+}
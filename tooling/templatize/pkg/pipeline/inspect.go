@@ -15,80 +15,156 @@
 package pipeline
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
 
 	"github.com/Azure/ARO-Tools/pkg/config"
 	"github.com/Azure/ARO-Tools/pkg/types"
+	"github.com/go-logr/logr"
 )
 
+// errScopeNotImplemented is returned by step scopes that don't support the action type of the inspected step
+var errScopeNotImplemented = errors.New("not implemented")
+
 type StepInspectScope func(context.Context, *types.Pipeline, types.Step, *InspectOptions) error
 
+// PipelineInspectScope inspects the pipeline as a whole instead of a single step
+type PipelineInspectScope func(context.Context, *types.Pipeline, *InspectOptions) error
+
 func NewStepInspectScopes() map[string]StepInspectScope {
 	return map[string]StepInspectScope{
-		"vars": inspectVars,
+		"vars":         inspectVars,
+		"resolved":     inspectResolved,
+		"dependencies": inspectDependencies,
+		"bicepparam":   inspectBicepParam,
+	}
+}
+
+func NewPipelineInspectScopes() map[string]PipelineInspectScope {
+	return map[string]PipelineInspectScope{
+		"graph": inspectGraph,
 	}
 }
 
 // InspectOptions contains the options for the Inspect method
 type InspectOptions struct {
-	Scope          string
-	Format         string
-	Step           string
-	Region         string
-	Configuration  config.Configuration
-	ScopeFunctions map[string]StepInspectScope
-	OutputFile     io.Writer
+	Scope                  string
+	Format                 string
+	Step                   string
+	Region                 string
+	PipelineFilePath       string
+	Configuration          config.Configuration
+	ScopeFunctions         map[string]StepInspectScope
+	PipelineScopeFunctions map[string]PipelineInspectScope
+	OutputFile             io.Writer
 }
 
 // NewInspectOptions creates a new PipelineInspectOptions struct
-func NewInspectOptions(cfg config.Configuration, region, step, scope, format string, outputFile io.Writer) *InspectOptions {
+func NewInspectOptions(cfg config.Configuration, region, pipelineFilePath, step, scope, format string, outputFile io.Writer) *InspectOptions {
 	return &InspectOptions{
-		Scope:          scope,
-		Format:         format,
-		Step:           step,
-		Region:         region,
-		Configuration:  cfg,
-		ScopeFunctions: NewStepInspectScopes(),
-		OutputFile:     outputFile,
+		Scope:                  scope,
+		Format:                 format,
+		Step:                   step,
+		Region:                 region,
+		PipelineFilePath:       pipelineFilePath,
+		Configuration:          cfg,
+		ScopeFunctions:         NewStepInspectScopes(),
+		PipelineScopeFunctions: NewPipelineInspectScopes(),
+		OutputFile:             outputFile,
 	}
 }
 
+// Inspect runs the configured scope. Pipeline scopes are run once, step scopes are run
+// for the selected step or, if no step is selected, for every step of the pipeline.
 func Inspect(p *types.Pipeline, ctx context.Context, options *InspectOptions) error {
+	if pipelineInspectFunc, ok := options.PipelineScopeFunctions[options.Scope]; ok {
+		return pipelineInspectFunc(ctx, p, options)
+	}
+	inspectFunc, ok := options.ScopeFunctions[options.Scope]
+	if !ok {
+		return fmt.Errorf("unknown inspect scope %q", options.Scope)
+	}
+
+	if options.Step == "" {
+		return inspectAllSteps(ctx, p, inspectFunc, options)
+	}
+
 	for _, rg := range p.ResourceGroups {
 		for _, step := range rg.Steps {
 			if step.StepName() == options.Step {
-				if inspectFunc, ok := options.ScopeFunctions[options.Scope]; ok {
-					err := inspectFunc(ctx, p, step, options)
-					if err != nil {
-						return err
-					}
-				} else {
-					return fmt.Errorf("unknown inspect scope %q", options.Scope)
-				}
-				return nil
+				return inspectFunc(ctx, p, step, options)
 			}
 		}
 	}
 	return fmt.Errorf("step %q not found", options.Step)
 }
 
+// inspectAllSteps runs the step scope for every step of the pipeline. If the outputs of all steps are JSON,
+// they are combined into a single JSON object keyed by step name, otherwise they are concatenated. Inspected
+// and skipped steps are logged instead of written to the output, so that it stays machine readable.
+func inspectAllSteps(ctx context.Context, p *types.Pipeline, inspectFunc StepInspectScope, options *InspectOptions) error {
+	logger := logr.FromContextOrDiscard(ctx)
+	var inspected []string
+	outputs := make(map[string][]byte)
+	for _, rg := range p.ResourceGroups {
+		for _, step := range rg.Steps {
+			output := new(bytes.Buffer)
+			stepOptions := *options
+			stepOptions.OutputFile = output
+			err := inspectFunc(ctx, p, step, &stepOptions)
+			if errors.Is(err, errScopeNotImplemented) {
+				logger.Info("Skipping step", "step", step.StepName(), "reason", err.Error())
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to inspect step %q: %w", step.StepName(), err)
+			}
+			logger.Info("Inspected step", "step", step.StepName())
+			inspected = append(inspected, step.StepName())
+			outputs[step.StepName()] = output.Bytes()
+		}
+	}
+
+	if allJSON(outputs) {
+		combined := make(map[string]json.RawMessage, len(outputs))
+		for step, output := range outputs {
+			combined[step] = output
+		}
+		combinedJSON, err := json.MarshalIndent(combined, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal step outputs: %w", err)
+		}
+		_, err = fmt.Fprintln(options.OutputFile, string(combinedJSON))
+		return err
+	}
+	for _, step := range inspected {
+		if _, err := options.OutputFile.Write(outputs[step]); err != nil {
+			return fmt.Errorf("failed to write output of step %q: %w", step, err)
+		}
+	}
+	return nil
+}
+
+// allJSON reports whether there are outputs and all of them are JSON documents
+func allJSON(outputs map[string][]byte) bool {
+	for _, output := range outputs {
+		if len(bytes.TrimSpace(output)) == 0 || !json.Valid(output) {
+			return false
+		}
+	}
+	return len(outputs) > 0
+}
+
 func inspectVars(ctx context.Context, pipeline *types.Pipeline, s types.Step, options *InspectOptions) error {
 	var envVars map[string]string
 	switch step := s.(type) {
 	case *types.ShellStep:
-		outputChainingDependencies := make(map[string]bool)
-		for _, stepVar := range step.Variables {
-			if stepVar.Input != nil && stepVar.Input.Step != "" {
-				outputChainingDependencies[stepVar.Input.Step] = true
-			}
-		}
-		outputChainingDependenciesList := make([]string, 0, len(outputChainingDependencies))
-		for depStep := range outputChainingDependencies {
-			outputChainingDependenciesList = append(outputChainingDependenciesList, depStep)
-		}
-		inputs, err := aquireOutputChainingInputs(ctx, outputChainingDependenciesList, pipeline, options)
+		inputs, err := aquireOutputChainingInputs(ctx, outputChainingDependencies(step.Variables), pipeline, options)
 		if err != nil {
 			return err
 		}
@@ -97,7 +173,7 @@ func inspectVars(ctx context.Context, pipeline *types.Pipeline, s types.Step, op
 			return err
 		}
 	default:
-		return fmt.Errorf("inspecting step variables not implemented for action type %s", s.ActionType())
+		return fmt.Errorf("inspecting step variables %w for action type %s", errScopeNotImplemented, s.ActionType())
 	}
 
 	switch options.Format {
@@ -111,6 +187,96 @@ func inspectVars(ctx context.Context, pipeline *types.Pipeline, s types.Step, op
 	return nil
 }
 
+// inspectResolved prints the shell script a shell step would execute, including its
+// exported variables, or the parameters an ARM step would be deployed with.
+func inspectResolved(ctx context.Context, pipeline *types.Pipeline, s types.Step, options *InspectOptions) error {
+	switch step := s.(type) {
+	case *types.ShellStep:
+		inputs, err := aquireOutputChainingInputs(ctx, outputChainingDependencies(step.Variables), pipeline, options)
+		if err != nil {
+			return err
+		}
+		envVars, err := mapStepVariables(step.Variables, options.Configuration, inputs)
+		if err != nil {
+			return err
+		}
+		printShellVars(envVars, options.OutputFile)
+		fmt.Fprintln(options.OutputFile, buildBashScript(step.Command))
+	case *types.ARMStep:
+		inputs, err := aquireOutputChainingInputs(ctx, outputChainingDependencies(step.Variables), pipeline, options)
+		if err != nil {
+			return err
+		}
+		inputValues, err := getInputValues(step.Variables, options.Configuration, inputs)
+		if err != nil {
+			return fmt.Errorf("failed to get input values: %w", err)
+		}
+		_, params, err := transformParameters(ctx, options.Configuration, inputValues, options.pipelineRelativePath(step.Parameters))
+		if err != nil {
+			return fmt.Errorf("failed to transform parameters: %w", err)
+		}
+		paramsJSON, err := json.MarshalIndent(params, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal parameters: %w", err)
+		}
+		fmt.Fprintln(options.OutputFile, string(paramsJSON))
+	default:
+		return fmt.Errorf("inspecting resolved step %w for action type %s", errScopeNotImplemented, s.ActionType())
+	}
+	return nil
+}
+
+// inspectDependencies prints the transitive upstream steps of a step, one per line,
+// in the order they need to run.
+func inspectDependencies(_ context.Context, pipeline *types.Pipeline, s types.Step, options *InspectOptions) error {
+	upstream, err := upstreamSteps(pipeline, s.StepName())
+	if err != nil {
+		return err
+	}
+	for _, step := range upstream {
+		fmt.Fprintln(options.OutputFile, step)
+	}
+	return nil
+}
+
+// inspectBicepParam prints the bicep parameter file of an ARM step after config templating.
+func inspectBicepParam(_ context.Context, _ *types.Pipeline, s types.Step, options *InspectOptions) error {
+	step, ok := s.(*types.ARMStep)
+	if !ok {
+		return fmt.Errorf("inspecting bicep parameters %w for action type %s", errScopeNotImplemented, s.ActionType())
+	}
+	bicepParamContent, err := config.PreprocessFile(options.pipelineRelativePath(step.Parameters), options.Configuration)
+	if err != nil {
+		return fmt.Errorf("failed to preprocess file: %w", err)
+	}
+	fmt.Fprint(options.OutputFile, string(bicepParamContent))
+	return nil
+}
+
+// inspectGraph prints the step dependency graph of the pipeline, as plain text unless
+// dot or mermaid output is requested.
+func inspectGraph(_ context.Context, pipeline *types.Pipeline, options *InspectOptions) error {
+	switch options.Format {
+	case "", "text":
+		writeTextGraph(pipeline, options.OutputFile)
+	case "dot":
+		writeDOTGraph(pipeline, options.OutputFile)
+	case "mermaid":
+		writeMermaidGraph(pipeline, options.OutputFile)
+	default:
+		return fmt.Errorf("unknown output format %q", options.Format)
+	}
+	return nil
+}
+
+// pipelineRelativePath resolves file references within the pipeline relative to the pipeline file
+func (o *InspectOptions) pipelineRelativePath(path string) string {
+	if filepath.IsAbs(path) || o.PipelineFilePath == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(o.PipelineFilePath), path)
+}
+
 func aquireOutputChainingInputs(ctx context.Context, steps []string, pipeline *types.Pipeline, options *InspectOptions) (map[string]Output, error) {
 	inputs := make(map[string]Output)
 	for _, depStep := range steps {
@@ -122,6 +288,7 @@ func aquireOutputChainingInputs(ctx context.Context, steps []string, pipeline *t
 			SubsciptionLookupFunc:    LookupSubscriptionID,
 			NoPersist:                true,
 			DeploymentTimeoutSeconds: 60,
+			PipelineFilePath:         options.PipelineFilePath,
 		}
 		outputs, err := RunPipeline(pipeline, ctx, runOptions)
 		if err != nil {
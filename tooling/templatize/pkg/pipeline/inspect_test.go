@@ -17,6 +17,9 @@ package pipeline
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -99,7 +102,7 @@ func TestInspect(t *testing.T) {
 		},
 		},
 	}
-	opts := NewInspectOptions(config.Configuration{}, "", "step1", "scope", "format", new(bytes.Buffer))
+	opts := NewInspectOptions(config.Configuration{}, "", "", "step1", "scope", "format", new(bytes.Buffer))
 
 	opts.ScopeFunctions = map[string]StepInspectScope{
 		"scope": func(ctx context.Context, p *types.Pipeline, s types.Step, o *InspectOptions) error {
@@ -121,8 +124,221 @@ func TestInspectWrongScope(t *testing.T) {
 		},
 		},
 	}
-	opts := NewInspectOptions(config.Configuration{}, "", "step1", "foo", "format", new(bytes.Buffer))
+	opts := NewInspectOptions(config.Configuration{}, "", "", "step1", "foo", "format", new(bytes.Buffer))
 
 	err := Inspect(&p, context.Background(), opts)
 	assert.Error(t, err, "unknown inspect scope \"foo\"")
 }
+
+func chainedTestPipeline() *types.Pipeline {
+	return &types.Pipeline{
+		ResourceGroups: []*types.ResourceGroup{
+			{
+				Name: "rg1",
+				Steps: []types.Step{
+					types.NewShellStep("step1", "echo hello"),
+					types.NewShellStep("step2", "echo $FOO").WithVariables(types.Variable{
+						Name:  "FOO",
+						Input: &types.Input{Name: "output", Step: "step1"},
+					}),
+				},
+			},
+			{
+				Name: "rg2",
+				Steps: []types.Step{
+					types.NewARMStep("step-3", "test.bicep", "test.bicepparam", "ResourceGroup").WithVariables(types.Variable{
+						Name:  "bar",
+						Input: &types.Input{Name: "output", Step: "step2"},
+					}),
+				},
+			},
+		},
+	}
+}
+
+func TestInspectAllSteps(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		outputs  map[string]string
+		expected string
+	}{
+		{
+			name:     "no output",
+			expected: "",
+		},
+		{
+			name: "text is concatenated",
+			outputs: map[string]string{
+				"step1": "export FOO=\"bar\"\n",
+				"step2": "echo $FOO\n",
+			},
+			expected: "export FOO=\"bar\"\necho $FOO\n",
+		},
+		{
+			name: "JSON is combined by step",
+			outputs: map[string]string{
+				"step1": "{\"a\": 1}\n",
+				"step2": "[\"b\"]\n",
+			},
+			expected: "{\n  \"step1\": {\n    \"a\": 1\n  },\n  \"step2\": [\n    \"b\"\n  ]\n}\n",
+		},
+		{
+			name: "JSON and text are concatenated",
+			outputs: map[string]string{
+				"step1": "{\"a\": 1}\n",
+				"step2": "echo $FOO\n",
+			},
+			expected: "{\"a\": 1}\necho $FOO\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			opts := NewInspectOptions(config.Configuration{}, "", "", "", "scope", "format", buf)
+			var inspected []string
+			opts.ScopeFunctions = map[string]StepInspectScope{
+				"scope": func(ctx context.Context, p *types.Pipeline, s types.Step, o *InspectOptions) error {
+					inspected = append(inspected, s.StepName())
+					if s.ActionType() == "ARM" {
+						return fmt.Errorf("test %w", errScopeNotImplemented)
+					}
+					_, err := fmt.Fprint(o.OutputFile, tc.outputs[s.StepName()])
+					return err
+				},
+			}
+
+			err := Inspect(chainedTestPipeline(), context.Background(), opts)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"step1", "step2", "step-3"}, inspected)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestInspectDependencies(t *testing.T) {
+	p := chainedTestPipeline()
+	buf := new(bytes.Buffer)
+	err := inspectDependencies(context.Background(), p, p.ResourceGroups[1].Steps[0], &InspectOptions{OutputFile: buf})
+	assert.NoError(t, err)
+	assert.Equal(t, "step1\nstep2\n", buf.String())
+
+	buf.Reset()
+	err = inspectDependencies(context.Background(), p, p.ResourceGroups[0].Steps[0], &InspectOptions{OutputFile: buf})
+	assert.NoError(t, err)
+	assert.Equal(t, "", buf.String())
+}
+
+func TestInspectDependenciesUnknownStep(t *testing.T) {
+	p := &types.Pipeline{
+		ResourceGroups: []*types.ResourceGroup{{
+			Steps: []types.Step{
+				types.NewShellStep("step1", "echo $FOO").WithVariables(types.Variable{
+					Name:  "FOO",
+					Input: &types.Input{Name: "output", Step: "missing"},
+				}),
+			},
+		}},
+	}
+	err := inspectDependencies(context.Background(), p, p.ResourceGroups[0].Steps[0], &InspectOptions{OutputFile: new(bytes.Buffer)})
+	assert.ErrorContains(t, err, "step \"step1\" depends on unknown step \"missing\"")
+}
+
+func TestInspectGraph(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   string
+		expected string
+		err      string
+	}{
+		{
+			name: "default text",
+			expected: `rg1:
+  step1 (Shell)
+  step2 (Shell) <- step1
+rg2:
+  step-3 (ARM) <- step2
+`,
+		},
+		{
+			name:   "text",
+			format: "text",
+			expected: `rg1:
+  step1 (Shell)
+  step2 (Shell) <- step1
+rg2:
+  step-3 (ARM) <- step2
+`,
+		},
+		{
+			name:   "dot",
+			format: "dot",
+			expected: `digraph pipeline {
+	rankdir=LR;
+	subgraph cluster_0 {
+		label="rg1";
+		"step1" [label="step1 (Shell)"];
+		"step2" [label="step2 (Shell)"];
+	}
+	subgraph cluster_1 {
+		label="rg2";
+		"step-3" [label="step-3 (ARM)"];
+	}
+	"step1" -> "step2";
+	"step2" -> "step-3";
+}
+`,
+		},
+		{
+			name:   "mermaid",
+			format: "mermaid",
+			expected: `flowchart LR
+	subgraph rg_0 ["rg1"]
+		step_step1["step1 (Shell)"]
+		step_step2["step2 (Shell)"]
+	end
+	subgraph rg_1 ["rg2"]
+		step_step_3["step-3 (ARM)"]
+	end
+	step_step1 --> step_step2
+	step_step2 --> step_step_3
+`,
+		},
+		{
+			name:   "unknown format",
+			format: "svg",
+			err:    "unknown output format \"svg\"",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			opts := NewInspectOptions(config.Configuration{}, "", "", "", "graph", tc.format, buf)
+			err := Inspect(chainedTestPipeline(), context.Background(), opts)
+			if tc.err == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, buf.String())
+			} else {
+				assert.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestInspectBicepParam(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(tmpDir, "test.bicepparam"), []byte("using 'test.bicep'\nparam foo = '{{ .foo }}'\n"), 0644)
+	assert.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	opts := &InspectOptions{
+		Configuration:    config.Configuration{"foo": "bar"},
+		PipelineFilePath: filepath.Join(tmpDir, "pipeline.yaml"),
+		OutputFile:       buf,
+	}
+	step := types.NewARMStep("step", "test.bicep", "test.bicepparam", "ResourceGroup")
+	err = inspectBicepParam(context.Background(), &types.Pipeline{}, step, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "using 'test.bicep'\nparam foo = 'bar'\n", buf.String())
+
+	err = inspectBicepParam(context.Background(), &types.Pipeline{}, types.NewShellStep("step", "echo hello"), opts)
+	assert.ErrorIs(t, err, errScopeNotImplemented)
+}
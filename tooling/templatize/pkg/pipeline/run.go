@@ -20,15 +20,24 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/go-logr/logr"
 
 	"github.com/Azure/ARO-Tools/pkg/config"
 	"github.com/Azure/ARO-Tools/pkg/types"
+
+	"github.com/Azure/ARO-HCP/tooling/templatize/pkg/aks"
 )
 
 var DefaultDeploymentTimeoutSeconds = 30 * 60
 
+var DefaultDeploymentRetries = 3
+
+var DefaultDeploymentRetryBackoff = 30 * time.Second
+
+const releaseClusterAccessTimeout = time.Minute
+
 type subsciptionLookup func(context.Context, string) (string, error)
 
 type PipelineRunOptions struct {
@@ -40,6 +49,23 @@ type PipelineRunOptions struct {
 	NoPersist                bool
 	DeploymentTimeoutSeconds int
 	PipelineFilePath         string
+	// DeploymentRetries is the number of times an ARM deployment failing with a transient error is retried
+	DeploymentRetries int
+	// DeploymentRetryBackoff is the wait time before the first deployment retry, it doubles with every further retry
+	DeploymentRetryBackoff time.Duration
+	// WhatIfReportDir is the directory WhatIf changes are written to as JSON during dry-runs, one file per step
+	WhatIfReportDir string
+	// ChangePolicy fails dry-runs of ARM steps that would make forbidden changes
+	ChangePolicy *ChangePolicy
+	// ShellStepDefaults apply to all shell steps without an entry in ShellStepOverrides
+	ShellStepDefaults  ShellStepOptions
+	ShellStepOverrides map[string]ShellStepOptions
+	// SensitiveVariables are the names of step variables whose values are masked in logs
+	SensitiveVariables []string
+	// ClusterAccess configures the role assigned to access AKS clusters, nil assigns cluster admin
+	ClusterAccess *aks.ClusterAdminAssignmentOptions
+	// KeepClusterAccess retains the role assignments created to access AKS clusters after a resource group ran
+	KeepClusterAccess bool
 }
 
 type Output interface {
@@ -113,6 +139,9 @@ func RunPipeline(pipeline *types.Pipeline, ctx context.Context, options *Pipelin
 			region:           options.Region,
 			resourceGroup:    rg.Name,
 			aksClusterName:   rg.AKSCluster,
+
+			clusterAccess:     options.ClusterAccess,
+			keepClusterAccess: options.KeepClusterAccess,
 		}
 		err = RunResourceGroup(rg, ctx, options, &executionTarget, outPuts)
 		if err != nil {
@@ -126,6 +155,14 @@ func RunResourceGroup(rg *types.ResourceGroup, ctx context.Context, options *Pip
 	logger := logr.FromContextOrDiscard(ctx)
 
 	kubeconfigFile, err := executionTarget.KubeConfig(ctx)
+	defer func() {
+		// revoke access even when the run was interrupted, role assignments are not meant to outlive it
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseClusterAccessTimeout)
+		defer cancel()
+		if err := executionTarget.ReleaseClusterAccess(releaseCtx); err != nil {
+			logger.Error(err, "failed to release cluster access", "aksCluster", executionTarget.GetAkSClusterName())
+		}
+	}()
 	if kubeconfigFile != "" {
 		defer func() {
 			if err := os.Remove(kubeconfigFile); err != nil {
@@ -183,9 +220,8 @@ func RunStep(s types.Step, ctx context.Context, kubeconfigFile string, execution
 		if err != nil {
 			return nil, fmt.Errorf("error running Shell Step, %v", err)
 		}
-		output := buf.String()
-		fmt.Println(output)
-		return ShellOutput(output), nil
+		// the output has already been streamed to the logger while the command was running
+		return ShellOutput(buf.String()), nil
 	case *types.ARMStep:
 		a := newArmClient(executionTarget.GetSubscriptionID(), executionTarget.GetRegion())
 		if a == nil {
@@ -70,6 +70,9 @@ type testExecutionTarget struct{}
 func (t *testExecutionTarget) KubeConfig(_ context.Context) (string, error) {
 	return "", nil
 }
+func (t *testExecutionTarget) ReleaseClusterAccess(_ context.Context) error {
+	return nil
+}
 func (t *testExecutionTarget) GetSubscriptionID() string { return "test" }
 func (t *testExecutionTarget) GetAkSClusterName() string { return "test" }
 func (t *testExecutionTarget) GetResourceGroup() string  { return "test" }
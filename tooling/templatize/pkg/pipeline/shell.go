@@ -15,11 +15,18 @@
 package pipeline
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"maps"
 	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/go-logr/logr"
 
@@ -47,6 +54,24 @@ func buildBashScript(command string) string {
 	return fmt.Sprintf("set -o errexit -o nounset  -o pipefail\n%s", command)
 }
 
+// ShellStepOptions control how a shell step is executed
+type ShellStepOptions struct {
+	// Timeout is the maximum duration of a single attempt, zero means no timeout
+	Timeout time.Duration
+	// Retries is the number of times a failed attempt is retried
+	Retries int
+	// RetryBackoff is the wait time before the first retry, it doubles with every further retry
+	RetryBackoff time.Duration
+}
+
+// shellStepOptions returns the options for the given step, overrides take precedence over the defaults
+func (o *PipelineRunOptions) shellStepOptions(stepName string) ShellStepOptions {
+	if override, ok := o.ShellStepOverrides[stepName]; ok {
+		return override
+	}
+	return o.ShellStepDefaults
+}
+
 func runShellStep(s *types.ShellStep, ctx context.Context, kubeconfigFile string, options *PipelineRunOptions, inputs map[string]Output, outputWriter io.Writer) error {
 	logger := logr.FromContextOrDiscard(ctx)
 
@@ -68,29 +93,166 @@ func runShellStep(s *types.ShellStep, ctx context.Context, kubeconfigFile string
 		return fmt.Errorf("failed to build env vars: %w", err)
 	}
 
+	masker := newSecretMasker(options.SensitiveVariables, stepVars, dryRunVars)
+	logger.V(7).Info("Step variables", "variables", maskSensitiveVariables(stepVars, options.SensitiveVariables), "dryRunVariables", maskSensitiveVariables(dryRunVars, options.SensitiveVariables))
+
 	envVars := utils.GetOsVariable()
 
 	maps.Copy(envVars, stepVars)
 	maps.Copy(envVars, dryRunVars)
 
+	stepOptions := options.shellStepOptions(s.Name)
+	for attempt := 0; ; attempt++ {
+		output, skipCommand, err := runShellCommand(ctx, s, dryRun, envVars, kubeconfigFile, stepOptions.Timeout, masker)
+		if skipCommand {
+			logger.V(5).Info(fmt.Sprintf("Skipping step '%s' due to missing dry-run configuration", s.Name))
+			return nil
+		}
+		if err == nil {
+			fmt.Fprint(outputWriter, output)
+			return nil
+		}
+		if attempt >= stepOptions.Retries {
+			return fmt.Errorf("failed to execute shell command: %s %w", masker.Replace(output), err)
+		}
+		backoff := stepOptions.RetryBackoff * time.Duration(1<<attempt)
+		logger.Info("Shell command failed, retrying", "attempt", attempt+1, "retries", stepOptions.Retries, "backoff", backoff.String(), "error", masker.Replace(err.Error()))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to execute shell command: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// runShellCommand runs a single attempt of a shell step. Output is streamed line by line to the
+// logger while it is produced and is returned combined once the command finished.
+func runShellCommand(ctx context.Context, s *types.ShellStep, dryRun *types.DryRun, envVars map[string]string, kubeconfigFile string, timeout time.Duration, masker *strings.Replacer) (string, bool, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	cmd, skipCommand := createCommand(ctx, s.Command, dryRun, envVars)
 	if skipCommand {
-		logger.V(5).Info(fmt.Sprintf("Skipping step '%s' due to missing dry-run configuration", s.Name))
-		return nil
+		return "", true, nil
 	}
 
 	if kubeconfigFile != "" {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("KUBECONFIG=%s", kubeconfigFile))
 	}
 
+	var output syncBuffer
+	stdout := newLineLogWriter(logger.WithValues("stream", "stdout"), masker)
+	stderr := newLineLogWriter(logger.WithValues("stream", "stderr"), masker)
+	cmd.Stdout = io.MultiWriter(&output, stdout)
+	cmd.Stderr = io.MultiWriter(&output, stderr)
+	// run the command in its own process group so that a timeout kills all processes started by the script
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// don't wait forever for processes that escaped the process group and keep the output pipes open
+	cmd.WaitDelay = 10 * time.Second
+
 	logger.V(5).Info(fmt.Sprintf("Executing shell command: %s\n", s.Command), "command", s.Command)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to execute shell command: %s %w", string(output), err)
+	err := cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s: %w", timeout, err)
 	}
+	return output.String(), false, err
+}
 
-	fmt.Fprint(outputWriter, string(output))
-	return nil
+// syncBuffer is a bytes.Buffer that can be written to from stdout and stderr concurrently
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// lineLogWriter logs every complete line written to it
+type lineLogWriter struct {
+	logger  logr.Logger
+	masker  *strings.Replacer
+	partial []byte
+}
+
+func newLineLogWriter(logger logr.Logger, masker *strings.Replacer) *lineLogWriter {
+	return &lineLogWriter{logger: logger, masker: masker}
+}
+
+func (w *lineLogWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.logger.Info(w.masker.Replace(string(w.partial[:i])))
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush logs a trailing line that was not terminated by a newline
+func (w *lineLogWriter) Flush() {
+	if len(w.partial) > 0 {
+		w.logger.Info(w.masker.Replace(string(w.partial)))
+		w.partial = nil
+	}
+}
+
+// newSecretMasker returns a replacer that masks the values of all sensitive variables
+func newSecretMasker(sensitiveVariables []string, vars ...map[string]string) *strings.Replacer {
+	var secrets []string
+	for _, name := range sensitiveVariables {
+		for _, v := range vars {
+			if value := v[name]; value != "" {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+	// replace longer secrets first so that a secret containing another one is masked entirely
+	slices.SortFunc(secrets, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a, b)
+	})
+	oldnew := make([]string, 0, len(secrets)*2)
+	for _, secret := range slices.Compact(secrets) {
+		oldnew = append(oldnew, secret, maskedValue)
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+const maskedValue = "***"
+
+// maskSensitiveVariables returns a copy of the variables with the values of sensitive variables masked
+func maskSensitiveVariables(vars map[string]string, sensitiveVariables []string) map[string]string {
+	masked := maps.Clone(vars)
+	for _, name := range sensitiveVariables {
+		if _, ok := masked[name]; ok {
+			masked[name] = maskedValue
+		}
+	}
+	return masked
 }
 
 func mapStepVariables(vars []types.Variable, cfg config.Configuration, inputs map[string]Output) (map[string]string, error) {
@@ -19,9 +19,12 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr/funcr"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Azure/ARO-Tools/pkg/config"
@@ -340,3 +343,80 @@ func TestRunShellStepCaptureOutput(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, buf.String(), "hallo\n")
 }
+
+func TestRunShellStepTimeout(t *testing.T) {
+	step := types.NewShellStep("step", "sleep 10")
+	var buf bytes.Buffer
+
+	start := time.Now()
+	err := runShellStep(step, context.Background(), "", &PipelineRunOptions{
+		ShellStepDefaults: ShellStepOptions{Timeout: 100 * time.Millisecond},
+	}, map[string]Output{}, &buf)
+	assert.ErrorContains(t, err, "timed out after 100ms")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRunShellStepRetries(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options ShellStepOptions
+		output  string
+		err     string
+	}{
+		{
+			name:    "retries exhausted",
+			options: ShellStepOptions{Retries: 1, RetryBackoff: time.Millisecond},
+			err:     "failed to execute shell command: 2\n exit status 1",
+		},
+		{
+			name:    "succeeds on retry",
+			options: ShellStepOptions{Retries: 2, RetryBackoff: time.Millisecond},
+			output:  "3\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// the command succeeds on its third attempt, counted in a file of the subtest
+			counter := filepath.Join(t.TempDir(), "counter")
+			step := types.NewShellStep("step", fmt.Sprintf("echo attempt >> %[1]s; echo $(wc -l < %[1]s); [ $(wc -l < %[1]s) -ge 3 ]", counter))
+
+			var buf bytes.Buffer
+			err := runShellStep(step, context.Background(), "", &PipelineRunOptions{
+				ShellStepOverrides: map[string]ShellStepOptions{"step": tc.options},
+			}, map[string]Output{}, &buf)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.output, buf.String())
+			}
+		})
+	}
+}
+
+func TestLineLogWriterMasksSecrets(t *testing.T) {
+	var lines []string
+	logger := funcr.New(func(_, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{})
+	masker := newSecretMasker([]string{"PASSWORD", "MISSING"}, map[string]string{"PASSWORD": "hunter2", "USER": "admin"})
+
+	w := newLineLogWriter(logger, masker)
+	_, err := w.Write([]byte("user admin\npassword hun"))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("ter2\ntrailing hunter2"))
+	assert.NoError(t, err)
+	w.Flush()
+
+	assert.Equal(t, []string{
+		`"level"=0 "msg"="user admin"`,
+		`"level"=0 "msg"="password ***"`,
+		`"level"=0 "msg"="trailing ***"`,
+	}, lines)
+}
+
+func TestMaskSensitiveVariables(t *testing.T) {
+	vars := map[string]string{"PASSWORD": "hunter2", "USER": "admin"}
+	assert.Equal(t, map[string]string{"PASSWORD": "***", "USER": "admin"}, maskSensitiveVariables(vars, []string{"PASSWORD", "MISSING"}))
+	assert.Equal(t, "hunter2", vars["PASSWORD"])
+}
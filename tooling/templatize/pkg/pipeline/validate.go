@@ -0,0 +1,175 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/ARO-Tools/pkg/config"
+	"github.com/Azure/ARO-Tools/pkg/types"
+)
+
+var bicepOutputRegexp = regexp.MustCompile(`(?m)^\s*output\s+([A-Za-z_][A-Za-z0-9_]*)\s`)
+
+// ValidatePipeline checks a pipeline against a configuration without touching Azure. It reports
+// config references that don't resolve, output chaining inputs that don't refer to an output of
+// an earlier step, missing bicep and script files and duplicate step names.
+func ValidatePipeline(p *types.Pipeline, cfg config.Configuration, pipelineFilePath string) []error {
+	v := &pipelineValidator{
+		cfg:          cfg,
+		baseDir:      filepath.Dir(pipelineFilePath),
+		earlierSteps: make(map[string]types.Step),
+		bicepOutputs: make(map[string]map[string]bool),
+	}
+
+	allSteps := make(map[string]bool)
+	for _, rg := range p.ResourceGroups {
+		for _, step := range rg.Steps {
+			allSteps[step.StepName()] = true
+		}
+	}
+
+	for _, rg := range p.ResourceGroups {
+		for _, step := range rg.Steps {
+			if _, duplicate := v.earlierSteps[step.StepName()]; duplicate {
+				v.addError(step, "duplicate step name")
+			}
+			for _, dep := range step.Dependencies() {
+				if !allSteps[dep] {
+					v.addError(step, "depends on unknown step %q", dep)
+				}
+			}
+			v.validateStep(step)
+			v.earlierSteps[step.StepName()] = step
+		}
+	}
+	return v.errs
+}
+
+type pipelineValidator struct {
+	cfg          config.Configuration
+	baseDir      string
+	earlierSteps map[string]types.Step
+	// bicepOutputs caches the outputs declared in a bicep template
+	bicepOutputs map[string]map[string]bool
+	errs         []error
+}
+
+func (v *pipelineValidator) addError(step types.Step, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("step %q: %s", step.StepName(), fmt.Sprintf(format, args...)))
+}
+
+func (v *pipelineValidator) validateStep(s types.Step) {
+	switch step := s.(type) {
+	case *types.ShellStep:
+		v.validateVariables(step, step.Variables)
+		v.validateVariables(step, step.DryRun.Variables)
+		if script := shellScriptPath(step.Command); script != "" {
+			v.validateFile(step, "script", script)
+		}
+		if step.DryRun.Command != "" {
+			if script := shellScriptPath(step.DryRun.Command); script != "" {
+				v.validateFile(step, "dry-run script", script)
+			}
+		}
+	case *types.ARMStep:
+		v.validateVariables(step, step.Variables)
+		v.validateFile(step, "bicep template", step.Template)
+		v.validateFile(step, "bicep parameter file", step.Parameters)
+	}
+}
+
+func (v *pipelineValidator) validateVariables(step types.Step, vars []types.Variable) {
+	for _, variable := range vars {
+		if variable.Input != nil {
+			v.validateInput(step, variable)
+		} else if variable.ConfigRef != "" {
+			if _, found := v.cfg.GetByPath(variable.ConfigRef); !found {
+				v.addError(step, "variable %s references unknown config %q", variable.Name, variable.ConfigRef)
+			}
+		}
+	}
+}
+
+func (v *pipelineValidator) validateInput(step types.Step, variable types.Variable) {
+	upstream, ok := v.earlierSteps[variable.Input.Step]
+	if !ok {
+		v.addError(step, "variable %s uses output of step %q, which is not an earlier step", variable.Name, variable.Input.Step)
+		return
+	}
+	armStep, ok := upstream.(*types.ARMStep)
+	if !ok {
+		// shell steps provide their whole output regardless of the name
+		return
+	}
+	outputs, err := v.templateOutputs(armStep.Template)
+	if err != nil {
+		// a missing template is reported for the upstream step already
+		return
+	}
+	if !outputs[variable.Input.Name] {
+		v.addError(step, "variable %s uses output %q of step %q, which is not declared in %s", variable.Name, variable.Input.Name, variable.Input.Step, armStep.Template)
+	}
+}
+
+func (v *pipelineValidator) templateOutputs(template string) (map[string]bool, error) {
+	if outputs, ok := v.bicepOutputs[template]; ok {
+		return outputs, nil
+	}
+	content, err := os.ReadFile(v.resolve(template))
+	if err != nil {
+		return nil, err
+	}
+	outputs := make(map[string]bool)
+	for _, match := range bicepOutputRegexp.FindAllStringSubmatch(string(content), -1) {
+		outputs[match[1]] = true
+	}
+	v.bicepOutputs[template] = outputs
+	return outputs, nil
+}
+
+func (v *pipelineValidator) validateFile(step types.Step, kind, path string) {
+	if path == "" {
+		v.addError(step, "no %s configured", kind)
+		return
+	}
+	if _, err := os.Stat(v.resolve(path)); err != nil {
+		v.addError(step, "%s %s not found", kind, path)
+	}
+}
+
+func (v *pipelineValidator) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(v.baseDir, path)
+}
+
+// shellScriptPath returns the script a shell command executes, if the command starts with a
+// reference to a script file instead of a regular command like make
+func shellScriptPath(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	if strings.Contains(fields[0], "/") || strings.HasSuffix(fields[0], ".sh") {
+		return fields[0]
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ARO-Tools/pkg/config"
+	"github.com/Azure/ARO-Tools/pkg/types"
+)
+
+func TestValidatePipeline(t *testing.T) {
+	tmpDir := t.TempDir()
+	for name, content := range map[string]string{
+		"test.bicep":      "param foo string\noutput bar string = foo\n",
+		"test.bicepparam": "using 'test.bicep'\nparam foo = 'foo'\n",
+		"deploy.sh":       "#!/bin/bash\necho $BAR\n",
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644))
+	}
+	pipelineFile := filepath.Join(tmpDir, "pipeline.yaml")
+	cfg := config.Configuration{
+		"foo": "baz",
+	}
+
+	testCases := []struct {
+		name     string
+		steps    []types.Step
+		expected []string
+	}{
+		{
+			name: "valid",
+			steps: []types.Step{
+				types.NewARMStep("arm", "test.bicep", "test.bicepparam", "ResourceGroup").WithVariables(types.Variable{
+					Name:      "foo",
+					ConfigRef: "foo",
+				}),
+				types.NewShellStep("shell", "./deploy.sh").WithVariables(types.Variable{
+					Name:  "BAR",
+					Input: &types.Input{Name: "bar", Step: "arm"},
+				}),
+				types.NewShellStep("make", "make deploy").WithVariables(types.Variable{
+					Name:  "OUTPUT",
+					Input: &types.Input{Name: "anything", Step: "shell"},
+				}),
+			},
+		},
+		{
+			name: "unresolved config ref",
+			steps: []types.Step{
+				types.NewShellStep("shell", "echo $FOO").WithVariables(types.Variable{
+					Name:      "FOO",
+					ConfigRef: "fooo",
+				}),
+			},
+			expected: []string{`step "shell": variable FOO references unknown config "fooo"`},
+		},
+		{
+			name: "input from later step",
+			steps: []types.Step{
+				types.NewShellStep("shell", "echo $BAR").WithVariables(types.Variable{
+					Name:  "BAR",
+					Input: &types.Input{Name: "bar", Step: "arm"},
+				}),
+				types.NewARMStep("arm", "test.bicep", "test.bicepparam", "ResourceGroup"),
+			},
+			expected: []string{`step "shell": variable BAR uses output of step "arm", which is not an earlier step`},
+		},
+		{
+			name: "undeclared output",
+			steps: []types.Step{
+				types.NewARMStep("arm", "test.bicep", "test.bicepparam", "ResourceGroup"),
+				types.NewShellStep("shell", "echo $BAR").WithVariables(types.Variable{
+					Name:  "BAR",
+					Input: &types.Input{Name: "baz", Step: "arm"},
+				}),
+			},
+			expected: []string{`step "shell": variable BAR uses output "baz" of step "arm", which is not declared in test.bicep`},
+		},
+		{
+			name: "missing files",
+			steps: []types.Step{
+				types.NewARMStep("arm", "missing.bicep", "missing.bicepparam", "ResourceGroup"),
+				types.NewShellStep("shell", "./missing.sh --flag"),
+			},
+			expected: []string{
+				`step "arm": bicep template missing.bicep not found`,
+				`step "arm": bicep parameter file missing.bicepparam not found`,
+				`step "shell": script ./missing.sh not found`,
+			},
+		},
+		{
+			name: "duplicate step",
+			steps: []types.Step{
+				types.NewShellStep("shell", "echo hello"),
+				types.NewShellStep("shell", "echo world"),
+			},
+			expected: []string{`step "shell": duplicate step name`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &types.Pipeline{
+				ResourceGroups: []*types.ResourceGroup{{Steps: tc.steps}},
+			}
+			var actual []string
+			for _, err := range ValidatePipeline(p, cfg, pipelineFile) {
+				actual = append(actual, err.Error())
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
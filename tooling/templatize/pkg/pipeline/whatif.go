@@ -0,0 +1,217 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"sigs.k8s.io/yaml"
+)
+
+// WhatIfReport is the structured result of a WhatIf deployment of a single ARM step
+type WhatIfReport struct {
+	Step          string                       `json:"step"`
+	Region        string                       `json:"region,omitempty"`
+	ResourceGroup string                       `json:"resourceGroup,omitempty"`
+	Changes       []*armresources.WhatIfChange `json:"changes"`
+	Violations    []ChangePolicyViolation      `json:"violations,omitempty"`
+}
+
+// ChangePolicy declares which WhatIf changes are not allowed to happen during a rollout
+type ChangePolicy struct {
+	// ForbiddenChanges fail the dry-run if a resource matching the pattern would be changed with the given change type
+	ForbiddenChanges []ForbiddenChange `json:"forbiddenChanges,omitempty"`
+	// ProtectedProperties fail the dry-run if a property of a resource matching the pattern would be modified
+	ProtectedProperties []ProtectedProperty `json:"protectedProperties,omitempty"`
+}
+
+type ForbiddenChange struct {
+	ChangeType armresources.ChangeType `json:"changeType"`
+	// ResourceIDPattern is a regular expression matched against the resource ID, empty matches all resources
+	ResourceIDPattern string `json:"resourceIDPattern,omitempty"`
+
+	resourceIDRegexp *regexp.Regexp
+}
+
+type ProtectedProperty struct {
+	// ResourceIDPattern is a regular expression matched against the resource ID, empty matches all resources
+	ResourceIDPattern string `json:"resourceIDPattern,omitempty"`
+	// Path is the dot separated property path, changes to nested properties are covered as well
+	Path string `json:"path"`
+
+	resourceIDRegexp *regexp.Regexp
+}
+
+type ChangePolicyViolation struct {
+	ResourceID string                  `json:"resourceID"`
+	ChangeType armresources.ChangeType `json:"changeType"`
+	Path       string                  `json:"path,omitempty"`
+}
+
+func (v ChangePolicyViolation) String() string {
+	if v.Path != "" {
+		return fmt.Sprintf("%s of protected property %s on %s", v.ChangeType, v.Path, v.ResourceID)
+	}
+	return fmt.Sprintf("forbidden %s of %s", v.ChangeType, v.ResourceID)
+}
+
+// NewChangePolicyFromFile loads a change policy from a YAML or JSON file
+func NewChangePolicyFromFile(path string) (*ChangePolicy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change policy %s: %w", path, err)
+	}
+	policy := &ChangePolicy{}
+	if err := yaml.Unmarshal(raw, policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal change policy %s: %w", path, err)
+	}
+	if err := policy.compile(); err != nil {
+		return nil, fmt.Errorf("invalid change policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+func compileResourceIDPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile resource ID pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+func (p *ChangePolicy) compile() error {
+	for i := range p.ForbiddenChanges {
+		changeType := p.ForbiddenChanges[i].ChangeType
+		if changeType == "" {
+			return fmt.Errorf("forbidden change %d has no change type", i)
+		}
+		if !slices.Contains(armresources.PossibleChangeTypeValues(), changeType) {
+			return fmt.Errorf("forbidden change %d has unknown change type %q, must be one of %v", i, changeType, armresources.PossibleChangeTypeValues())
+		}
+		re, err := compileResourceIDPattern(p.ForbiddenChanges[i].ResourceIDPattern)
+		if err != nil {
+			return err
+		}
+		p.ForbiddenChanges[i].resourceIDRegexp = re
+	}
+	for i := range p.ProtectedProperties {
+		if p.ProtectedProperties[i].Path == "" {
+			return fmt.Errorf("protected property %d has no path", i)
+		}
+		re, err := compileResourceIDPattern(p.ProtectedProperties[i].ResourceIDPattern)
+		if err != nil {
+			return err
+		}
+		p.ProtectedProperties[i].resourceIDRegexp = re
+	}
+	return nil
+}
+
+func matchesResourceID(re *regexp.Regexp, resourceID string) bool {
+	return re == nil || re.MatchString(resourceID)
+}
+
+// Evaluate returns all changes that violate the policy
+func (p *ChangePolicy) Evaluate(changes []*armresources.WhatIfChange) []ChangePolicyViolation {
+	if p == nil {
+		return nil
+	}
+	var violations []ChangePolicyViolation
+	for _, change := range changes {
+		if change.ChangeType == nil || change.ResourceID == nil {
+			continue
+		}
+		for _, forbidden := range p.ForbiddenChanges {
+			if *change.ChangeType == forbidden.ChangeType && matchesResourceID(forbidden.resourceIDRegexp, *change.ResourceID) {
+				violations = append(violations, ChangePolicyViolation{
+					ResourceID: *change.ResourceID,
+					ChangeType: *change.ChangeType,
+				})
+			}
+		}
+		if *change.ChangeType != armresources.ChangeTypeModify {
+			continue
+		}
+		for _, protected := range p.ProtectedProperties {
+			if !matchesResourceID(protected.resourceIDRegexp, *change.ResourceID) {
+				continue
+			}
+			for _, path := range changedPropertyPaths("", change.Delta) {
+				if path == protected.Path || strings.HasPrefix(path, protected.Path+".") {
+					violations = append(violations, ChangePolicyViolation{
+						ResourceID: *change.ResourceID,
+						ChangeType: *change.ChangeType,
+						Path:       path,
+					})
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// changedPropertyPaths flattens the property changes into dot separated paths of the
+// leaves that are actually changed
+func changedPropertyPaths(prefix string, changes []*armresources.WhatIfPropertyChange) []string {
+	var paths []string
+	for _, change := range changes {
+		if change.Path == nil {
+			continue
+		}
+		path := *change.Path
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if len(change.Children) > 0 {
+			paths = append(paths, changedPropertyPaths(path, change.Children)...)
+			continue
+		}
+		if change.PropertyChangeType != nil && *change.PropertyChangeType == armresources.PropertyChangeTypeNoEffect {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// whatIfReportPath nests the report of a step by region and resource group, so that
+// the same step deployed to several resource groups or regions keeps one report each
+func whatIfReportPath(dir string, report *WhatIfReport) string {
+	return filepath.Join(dir, report.Region, report.ResourceGroup, report.Step+".json")
+}
+
+// writeWhatIfReport stores the report as <region>/<resource group>/<step>.json in the given
+// directory, subscription level deployments are stored directly in the region directory
+func writeWhatIfReport(dir string, report *WhatIfReport) error {
+	path := whatIfReportPath(dir, report)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create what-if report directory: %w", err)
+	}
+	raw, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal what-if report: %w", err)
+	}
+	return os.WriteFile(path, raw, 0644)
+}
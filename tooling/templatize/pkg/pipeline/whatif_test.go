@@ -0,0 +1,169 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPolicy = `
+forbiddenChanges:
+- changeType: Delete
+  resourceIDPattern: "/Microsoft.KeyVault/vaults/"
+protectedProperties:
+- resourceIDPattern: "/Microsoft.ContainerService/managedClusters/"
+  path: properties.networkProfile
+`
+
+func loadTestPolicy(t *testing.T, content string) (*ChangePolicy, error) {
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	assert.NoError(t, os.WriteFile(policyFile, []byte(content), 0644))
+	return NewChangePolicyFromFile(policyFile)
+}
+
+func TestChangePolicyEvaluate(t *testing.T) {
+	policy, err := loadTestPolicy(t, testPolicy)
+	assert.NoError(t, err)
+
+	kvID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv"
+	aksID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks"
+	storageID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa"
+
+	changes := []*armresources.WhatIfChange{
+		{
+			ChangeType: to.Ptr(armresources.ChangeTypeDelete),
+			ResourceID: to.Ptr(kvID),
+		},
+		{
+			ChangeType: to.Ptr(armresources.ChangeTypeDelete),
+			ResourceID: to.Ptr(storageID),
+		},
+		{
+			ChangeType: to.Ptr(armresources.ChangeTypeModify),
+			ResourceID: to.Ptr(aksID),
+			Delta: []*armresources.WhatIfPropertyChange{
+				{
+					Path:               to.Ptr("properties.networkProfile"),
+					PropertyChangeType: to.Ptr(armresources.PropertyChangeTypeModify),
+					Children: []*armresources.WhatIfPropertyChange{
+						{
+							Path:               to.Ptr("podCidr"),
+							PropertyChangeType: to.Ptr(armresources.PropertyChangeTypeModify),
+						},
+						{
+							Path:               to.Ptr("outboundType"),
+							PropertyChangeType: to.Ptr(armresources.PropertyChangeTypeNoEffect),
+						},
+					},
+				},
+				{
+					Path:               to.Ptr("properties.kubernetesVersion"),
+					PropertyChangeType: to.Ptr(armresources.PropertyChangeTypeModify),
+				},
+			},
+		},
+	}
+
+	violations := policy.Evaluate(changes)
+	assert.Equal(t, []ChangePolicyViolation{
+		{
+			ResourceID: kvID,
+			ChangeType: armresources.ChangeTypeDelete,
+		},
+		{
+			ResourceID: aksID,
+			ChangeType: armresources.ChangeTypeModify,
+			Path:       "properties.networkProfile.podCidr",
+		},
+	}, violations)
+}
+
+func TestChangePolicyEvaluateNil(t *testing.T) {
+	var policy *ChangePolicy
+	assert.Empty(t, policy.Evaluate([]*armresources.WhatIfChange{
+		{
+			ChangeType: to.Ptr(armresources.ChangeTypeDelete),
+			ResourceID: to.Ptr("/subscriptions/sub"),
+		},
+	}))
+}
+
+func TestChangePolicyInvalid(t *testing.T) {
+	_, err := loadTestPolicy(t, "forbiddenChanges:\n- resourceIDPattern: foo\n")
+	assert.ErrorContains(t, err, "forbidden change 0 has no change type")
+
+	_, err = loadTestPolicy(t, "forbiddenChanges:\n- changeType: Delete\n- changeType: delete\n")
+	assert.ErrorContains(t, err, `forbidden change 1 has unknown change type "delete", must be one of [Create Delete Deploy Ignore Modify NoChange Unsupported]`)
+
+	_, err = loadTestPolicy(t, "protectedProperties:\n- path: foo\n  resourceIDPattern: \"[\"\n")
+	assert.ErrorContains(t, err, "failed to compile resource ID pattern")
+}
+
+func TestWriteWhatIfReport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	report := &WhatIfReport{
+		Step:          "deploy",
+		Region:        "westus3",
+		ResourceGroup: "rg",
+		Changes: []*armresources.WhatIfChange{
+			{
+				ChangeType: to.Ptr(armresources.ChangeTypeCreate),
+				ResourceID: to.Ptr("/subscriptions/sub/resourceGroups/rg"),
+			},
+		},
+	}
+	assert.NoError(t, writeWhatIfReport(dir, report))
+
+	raw, err := os.ReadFile(filepath.Join(dir, "westus3", "rg", "deploy.json"))
+	assert.NoError(t, err)
+	var written WhatIfReport
+	assert.NoError(t, json.Unmarshal(raw, &written))
+	assert.Equal(t, report, &written)
+}
+
+func TestWhatIfReportPath(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		report   *WhatIfReport
+		expected string
+	}{
+		{
+			name:     "resource group",
+			report:   &WhatIfReport{Step: "deploy", Region: "westus3", ResourceGroup: "rg"},
+			expected: filepath.Join("reports", "westus3", "rg", "deploy.json"),
+		},
+		{
+			name:     "other resource group",
+			report:   &WhatIfReport{Step: "deploy", Region: "westus3", ResourceGroup: "other"},
+			expected: filepath.Join("reports", "westus3", "other", "deploy.json"),
+		},
+		{
+			name:     "subscription",
+			report:   &WhatIfReport{Step: "deploy", Region: "eastus"},
+			expected: filepath.Join("reports", "eastus", "deploy.json"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, whatIfReportPath("reports", tc.report))
+		})
+	}
+}
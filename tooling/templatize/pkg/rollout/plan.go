@@ -0,0 +1,157 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollout
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Plan declares the waves of a multi-region rollout. Waves are rolled out one after another, the targets of a
+// wave one after another in the given order.
+type Plan struct {
+	Waves []Wave `json:"waves"`
+}
+
+// Wave is a group of targets that is rolled out together, e.g. a canary region
+type Wave struct {
+	Name    string   `json:"name"`
+	Targets []Target `json:"targets"`
+	// HealthGate runs once all targets of the wave were rolled out, the next wave only starts if it passes
+	HealthGate *HealthGate `json:"healthGate,omitempty"`
+	// Pause stops the rollout once the wave and its health gate passed, until the rollout is resumed
+	Pause bool `json:"pause,omitempty"`
+}
+
+// Target is a region and stamp the pipeline is run for
+type Target struct {
+	Region string `json:"region"`
+	// RegionShort is the short name of the region used in resource names, it is required as it cannot be derived
+	RegionShort string `json:"regionShort"`
+	Stamp       string `json:"stamp,omitempty"`
+}
+
+func (t Target) String() string {
+	if t.Stamp == "" {
+		return t.Region
+	}
+	return fmt.Sprintf("%s/%s", t.Region, t.Stamp)
+}
+
+// HealthGate is a shell command that verifies the health of a wave
+type HealthGate struct {
+	Command string `json:"command"`
+	// Timeout is the maximum duration of a single attempt, e.g. 10m, no timeout if empty
+	Timeout string `json:"timeout,omitempty"`
+	// Retries is the number of times a failed attempt is retried
+	Retries int `json:"retries,omitempty"`
+	// RetryInterval is the wait time between attempts, e.g. 1m
+	RetryInterval string `json:"retryInterval,omitempty"`
+
+	timeout       time.Duration
+	retryInterval time.Duration
+}
+
+// NewPlanFromFile loads a rollout plan from a YAML or JSON file
+func NewPlanFromFile(path string) (*Plan, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rollout plan %s: %w", path, err)
+	}
+	plan := &Plan{}
+	if err := yaml.UnmarshalStrict(raw, plan); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rollout plan %s: %w", path, err)
+	}
+	if err := plan.compile(); err != nil {
+		return nil, fmt.Errorf("invalid rollout plan %s: %w", path, err)
+	}
+	return plan, nil
+}
+
+// Pauses reports whether any wave but the last pauses the rollout
+func (p *Plan) Pauses() bool {
+	for _, wave := range p.Waves[:len(p.Waves)-1] {
+		if wave.Pause {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plan) compile() error {
+	if len(p.Waves) == 0 {
+		return fmt.Errorf("no waves defined")
+	}
+	waves := make(map[string]bool)
+	targets := make(map[string]string)
+	for i := range p.Waves {
+		wave := &p.Waves[i]
+		if wave.Name == "" {
+			return fmt.Errorf("wave %d has no name", i)
+		}
+		if waves[wave.Name] {
+			return fmt.Errorf("wave %s is defined more than once", wave.Name)
+		}
+		waves[wave.Name] = true
+
+		if len(wave.Targets) == 0 {
+			return fmt.Errorf("wave %s has no targets", wave.Name)
+		}
+		for j, target := range wave.Targets {
+			if target.Region == "" {
+				return fmt.Errorf("target %d of wave %s has no region", j, wave.Name)
+			}
+			if target.RegionShort == "" {
+				return fmt.Errorf("target %s of wave %s has no regionShort", target, wave.Name)
+			}
+			if other, ok := targets[target.String()]; ok {
+				return fmt.Errorf("target %s of wave %s is already part of wave %s", target, wave.Name, other)
+			}
+			targets[target.String()] = wave.Name
+		}
+
+		if wave.HealthGate != nil {
+			if err := wave.HealthGate.compile(); err != nil {
+				return fmt.Errorf("invalid health gate of wave %s: %w", wave.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *HealthGate) compile() error {
+	if strings.TrimSpace(g.Command) == "" {
+		return fmt.Errorf("no command defined")
+	}
+	if g.Retries < 0 {
+		return fmt.Errorf("retries must not be negative")
+	}
+	var err error
+	if g.Timeout != "" {
+		if g.timeout, err = time.ParseDuration(g.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	if g.RetryInterval != "" {
+		if g.retryInterval, err = time.ParseDuration(g.RetryInterval); err != nil {
+			return fmt.Errorf("invalid retry interval: %w", err)
+		}
+	}
+	return nil
+}
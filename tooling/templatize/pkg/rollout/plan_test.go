@@ -0,0 +1,129 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollout
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPlan = `
+waves:
+- name: canary
+  targets:
+  - region: uksouth
+    regionShort: uks
+    stamp: "1"
+  healthGate:
+    command: ./check-health.sh
+    timeout: 10m
+    retries: 3
+    retryInterval: 1m
+  pause: true
+- name: wave1
+  targets:
+  - region: westus3
+    regionShort: usw3
+    stamp: "1"
+  - region: westus3
+    regionShort: usw3
+    stamp: "2"
+`
+
+func loadTestPlan(t *testing.T, content string) (*Plan, error) {
+	planFile := filepath.Join(t.TempDir(), "plan.yaml")
+	require.NoError(t, os.WriteFile(planFile, []byte(content), 0644))
+	return NewPlanFromFile(planFile)
+}
+
+func TestNewPlanFromFile(t *testing.T) {
+	plan, err := loadTestPlan(t, testPlan)
+	require.NoError(t, err)
+	require.Len(t, plan.Waves, 2)
+	assert.Equal(t, []Target{{Region: "uksouth", RegionShort: "uks", Stamp: "1"}}, plan.Waves[0].Targets)
+	assert.Equal(t, 10*time.Minute, plan.Waves[0].HealthGate.timeout)
+	assert.Equal(t, time.Minute, plan.Waves[0].HealthGate.retryInterval)
+	assert.Equal(t, "westus3/2", plan.Waves[1].Targets[1].String())
+	assert.True(t, plan.Pauses())
+}
+
+func TestNewPlanFromFileInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		plan string
+		err  string
+	}{
+		{
+			name: "no waves",
+			plan: "waves: []",
+			err:  "no waves defined",
+		},
+		{
+			name: "unknown field",
+			plan: "waves:\n- name: canary\n  regions: [uksouth]",
+			err:  `unknown field "regions"`,
+		},
+		{
+			name: "unnamed wave",
+			plan: "waves:\n- targets:\n  - region: uksouth",
+			err:  "wave 0 has no name",
+		},
+		{
+			name: "duplicate wave",
+			plan: "waves:\n- name: a\n  targets:\n  - region: uksouth\n    regionShort: uks\n- name: a\n  targets:\n  - region: westus3\n    regionShort: usw3",
+			err:  "wave a is defined more than once",
+		},
+		{
+			name: "no targets",
+			plan: "waves:\n- name: a",
+			err:  "wave a has no targets",
+		},
+		{
+			name: "no region",
+			plan: "waves:\n- name: a\n  targets:\n  - stamp: \"1\"",
+			err:  "target 0 of wave a has no region",
+		},
+		{
+			name: "no region short name",
+			plan: "waves:\n- name: a\n  targets:\n  - region: uksouth\n    stamp: \"1\"",
+			err:  "target uksouth/1 of wave a has no regionShort",
+		},
+		{
+			name: "duplicate target",
+			plan: "waves:\n- name: a\n  targets:\n  - region: uksouth\n    regionShort: uks\n- name: b\n  targets:\n  - region: uksouth\n    regionShort: uks",
+			err:  "target uksouth of wave b is already part of wave a",
+		},
+		{
+			name: "gate without command",
+			plan: "waves:\n- name: a\n  targets:\n  - region: uksouth\n    regionShort: uks\n  healthGate:\n    retries: 1",
+			err:  "invalid health gate of wave a: no command defined",
+		},
+		{
+			name: "gate with invalid timeout",
+			plan: "waves:\n- name: a\n  targets:\n  - region: uksouth\n    regionShort: uks\n  healthGate:\n    command: \"true\"\n    timeout: soon",
+			err:  `invalid health gate of wave a: invalid timeout: time: invalid duration "soon"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := loadTestPlan(t, tc.plan)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
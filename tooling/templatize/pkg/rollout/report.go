@@ -0,0 +1,166 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// Status is the state of a rollout, wave, target or health gate
+type Status string
+
+const (
+	StatusPending   Status = "Pending"
+	StatusRunning   Status = "Running"
+	StatusSucceeded Status = "Succeeded"
+	StatusFailed    Status = "Failed"
+	StatusPaused    Status = "Paused"
+)
+
+// Report is the combined result of a rollout. It is persisted as state while rolling out, so that paused,
+// failed or interrupted rollouts can be resumed.
+type Report struct {
+	Status Status `json:"status"`
+	// PausedAfter is the wave the rollout was paused after
+	PausedAfter string       `json:"pausedAfter,omitempty"`
+	Started     *time.Time   `json:"started,omitempty"`
+	Finished    *time.Time   `json:"finished,omitempty"`
+	Waves       []WaveReport `json:"waves"`
+}
+
+type WaveReport struct {
+	Name       string         `json:"name"`
+	Status     Status         `json:"status"`
+	Targets    []TargetReport `json:"targets"`
+	HealthGate *GateReport    `json:"healthGate,omitempty"`
+}
+
+type TargetReport struct {
+	Target
+	Status   Status     `json:"status"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+type GateReport struct {
+	Status   Status `json:"status"`
+	Attempts int    `json:"attempts,omitempty"`
+	Output   string `json:"output,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NewReport returns a report with all waves and targets of the plan pending
+func NewReport(plan *Plan) *Report {
+	report := &Report{Status: StatusPending}
+	for _, wave := range plan.Waves {
+		waveReport := WaveReport{Name: wave.Name, Status: StatusPending}
+		for _, target := range wave.Targets {
+			waveReport.Targets = append(waveReport.Targets, TargetReport{Target: target, Status: StatusPending})
+		}
+		if wave.HealthGate != nil {
+			waveReport.HealthGate = &GateReport{Status: StatusPending}
+		}
+		report.Waves = append(report.Waves, waveReport)
+	}
+	return report
+}
+
+// LoadReport reads a report persisted by an earlier rollout
+func LoadReport(path string) (*Report, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rollout state %s: %w", path, err)
+	}
+	report := &Report{}
+	if err := json.Unmarshal(raw, report); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rollout state %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// Write persists the report as JSON
+func (r *Report) Write(path string) error {
+	raw, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal rollout state: %w", err)
+	}
+	// write to a temporary file first, so an interrupted write does not lose the state
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write rollout state %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write rollout state %s: %w", path, err)
+	}
+	return nil
+}
+
+// Matches verifies that the report was created for the plan, so it can be used to resume a rollout of the plan
+func (r *Report) Matches(plan *Plan) error {
+	expected := NewReport(plan)
+	if len(r.Waves) != len(expected.Waves) {
+		return fmt.Errorf("rollout state has %d waves, the plan has %d", len(r.Waves), len(expected.Waves))
+	}
+	for i, wave := range expected.Waves {
+		actual := r.Waves[i]
+		if actual.Name != wave.Name {
+			return fmt.Errorf("wave %d of the rollout state is %s, the plan has %s", i, actual.Name, wave.Name)
+		}
+		if !slices.EqualFunc(actual.Targets, wave.Targets, func(a, b TargetReport) bool { return a.Target == b.Target }) {
+			return fmt.Errorf("targets of wave %s differ between rollout state and plan", wave.Name)
+		}
+		if (actual.HealthGate == nil) != (wave.HealthGate == nil) {
+			return fmt.Errorf("health gate of wave %s differs between rollout state and plan", wave.Name)
+		}
+	}
+	return nil
+}
+
+// WriteSummary writes a table with the status of all targets and health gates
+func (r *Report) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "WAVE\tTARGET\tSTATUS\tDURATION\tERROR")
+	for _, wave := range r.Waves {
+		for _, target := range wave.Targets {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", wave.Name, target.Target, target.Status, duration(target.Started, target.Finished), target.Error)
+		}
+		if wave.HealthGate != nil {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t\t%s\n", wave.Name, "health gate", wave.HealthGate.Status, wave.HealthGate.Error)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	status := string(r.Status)
+	if r.Status == StatusPaused {
+		status = fmt.Sprintf("%s after wave %s", status, r.PausedAfter)
+	}
+	_, err := fmt.Fprintf(w, "\nRollout %s\n", status)
+	return err
+}
+
+func duration(started, finished *time.Time) string {
+	if started == nil || finished == nil {
+		return ""
+	}
+	return finished.Sub(*started).Round(time.Second).String()
+}
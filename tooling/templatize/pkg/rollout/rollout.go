@@ -0,0 +1,216 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// TargetRunner runs the pipeline for a single target
+type TargetRunner func(ctx context.Context, target Target) error
+
+// Rollout runs a pipeline for all targets of a plan, wave by wave. It halts on the first failing target or
+// health gate and pauses after waves that ask for it.
+type Rollout struct {
+	Plan      *Plan
+	RunTarget TargetRunner
+	// StateFile persists the report after every change, if set
+	StateFile string
+
+	now func() time.Time
+}
+
+func NewRollout(plan *Plan, runTarget TargetRunner, stateFile string) *Rollout {
+	return &Rollout{
+		Plan:      plan,
+		RunTarget: runTarget,
+		StateFile: stateFile,
+		now:       time.Now,
+	}
+}
+
+// Run rolls out all targets of the report that did not succeed yet, a report of an earlier rollout resumes it.
+// The report is updated in place and also returned on error.
+func (r *Rollout) Run(ctx context.Context, report *Report) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	if err := report.Matches(r.Plan); err != nil {
+		return err
+	}
+	if report.Status == StatusSucceeded {
+		logger.Info("Rollout already succeeded, nothing to do")
+		return nil
+	}
+
+	report.Status = StatusRunning
+	report.PausedAfter = ""
+	report.Finished = nil
+	if report.Started == nil {
+		report.Started = r.timestamp()
+	}
+	if err := r.save(report); err != nil {
+		return err
+	}
+
+	for i, wave := range r.Plan.Waves {
+		waveReport := &report.Waves[i]
+		if waveReport.Status == StatusSucceeded {
+			logger.V(5).Info("Skipping wave that already succeeded", "wave", wave.Name)
+			continue
+		}
+		if err := r.runWave(ctx, wave, report, waveReport); err != nil {
+			waveReport.Status = StatusFailed
+			report.Status = StatusFailed
+			report.Finished = r.timestamp()
+			if saveErr := r.save(report); saveErr != nil {
+				logger.Error(saveErr, "failed to persist rollout state")
+			}
+			return fmt.Errorf("rollout halted in wave %s: %w", wave.Name, err)
+		}
+		waveReport.Status = StatusSucceeded
+
+		if wave.Pause && i < len(r.Plan.Waves)-1 {
+			logger.Info("Pausing rollout", "wave", wave.Name, "next", r.Plan.Waves[i+1].Name)
+			report.Status = StatusPaused
+			report.PausedAfter = wave.Name
+			return r.save(report)
+		}
+		if err := r.save(report); err != nil {
+			return err
+		}
+	}
+
+	report.Status = StatusSucceeded
+	report.Finished = r.timestamp()
+	return r.save(report)
+}
+
+func (r *Rollout) runWave(ctx context.Context, wave Wave, report *Report, waveReport *WaveReport) error {
+	logger := logr.FromContextOrDiscard(ctx).WithValues("wave", wave.Name)
+	logger.Info("Rolling out wave", "targets", len(wave.Targets))
+	waveReport.Status = StatusRunning
+
+	for j, target := range wave.Targets {
+		targetReport := &waveReport.Targets[j]
+		if targetReport.Status == StatusSucceeded {
+			logger.V(5).Info("Skipping target that already succeeded", "target", target.String())
+			continue
+		}
+
+		targetReport.Status = StatusRunning
+		targetReport.Started = r.timestamp()
+		targetReport.Finished = nil
+		targetReport.Error = ""
+		if err := r.save(report); err != nil {
+			return err
+		}
+
+		targetLogger := logger.WithValues("region", target.Region, "stamp", target.Stamp)
+		targetLogger.Info("Rolling out target")
+		err := r.RunTarget(logr.NewContext(ctx, targetLogger), target)
+		targetReport.Finished = r.timestamp()
+		if err != nil {
+			targetReport.Status = StatusFailed
+			targetReport.Error = err.Error()
+			return fmt.Errorf("target %s failed: %w", target, err)
+		}
+		targetReport.Status = StatusSucceeded
+		targetLogger.Info("Target rolled out")
+		if err := r.save(report); err != nil {
+			return err
+		}
+	}
+
+	if wave.HealthGate != nil && waveReport.HealthGate.Status != StatusSucceeded {
+		waveReport.HealthGate.Status = StatusRunning
+		if err := r.save(report); err != nil {
+			return err
+		}
+		if err := runHealthGate(logr.NewContext(ctx, logger), wave, waveReport.HealthGate); err != nil {
+			waveReport.HealthGate.Status = StatusFailed
+			waveReport.HealthGate.Error = err.Error()
+			return fmt.Errorf("health gate failed: %w", err)
+		}
+		waveReport.HealthGate.Status = StatusSucceeded
+		waveReport.HealthGate.Error = ""
+	}
+	return nil
+}
+
+// runHealthGate runs the health gate command until it succeeds or all retries failed. The regions of the wave
+// are passed to the command as $ROLLOUT_REGIONS, its name as $ROLLOUT_WAVE.
+func runHealthGate(ctx context.Context, wave Wave, gateReport *GateReport) error {
+	logger := logr.FromContextOrDiscard(ctx)
+	gate := wave.HealthGate
+
+	regions := make([]string, 0, len(wave.Targets))
+	for _, target := range wave.Targets {
+		regions = append(regions, target.Region)
+	}
+	env := append(os.Environ(), "ROLLOUT_WAVE="+wave.Name, "ROLLOUT_REGIONS="+strings.Join(regions, ","))
+
+	for attempt := 0; ; attempt++ {
+		gateReport.Attempts++
+		output, err := runGateCommand(ctx, gate.Command, env, gate.timeout)
+		gateReport.Output = output
+		if err == nil {
+			logger.Info("Health gate passed", "attempts", attempt+1)
+			return nil
+		}
+		if attempt >= gate.Retries {
+			return err
+		}
+		logger.Info("Health gate failed, retrying", "attempt", attempt+1, "retries", gate.Retries, "interval", gate.retryInterval.String(), "error", err.Error())
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(gate.retryInterval):
+		}
+	}
+}
+
+func runGateCommand(ctx context.Context, command string, env []string, timeout time.Duration) (string, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", fmt.Sprintf("set -o errexit -o nounset -o pipefail\n%s", command))
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("health gate command failed: %w", err)
+	}
+	return string(output), nil
+}
+
+func (r *Rollout) save(report *Report) error {
+	if r.StateFile == "" {
+		return nil
+	}
+	return report.Write(r.StateFile)
+}
+
+func (r *Rollout) timestamp() *time.Time {
+	now := r.now().UTC()
+	return &now
+}
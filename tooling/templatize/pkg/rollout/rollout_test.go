@@ -0,0 +1,201 @@
+// Copyright 2025 Microsoft Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRolloutPlan(gate *HealthGate, pause bool) *Plan {
+	return &Plan{
+		Waves: []Wave{
+			{
+				Name:       "canary",
+				Targets:    []Target{{Region: "uksouth", RegionShort: "uks", Stamp: "1"}},
+				HealthGate: gate,
+				Pause:      pause,
+			},
+			{
+				Name:    "wave1",
+				Targets: []Target{{Region: "westus3", RegionShort: "usw3", Stamp: "1"}, {Region: "westus3", RegionShort: "usw3", Stamp: "2"}},
+			},
+		},
+	}
+}
+
+// recordingRunner records the targets it ran and fails the ones in failing
+type recordingRunner struct {
+	ran     []string
+	failing map[string]bool
+}
+
+func (r *recordingRunner) run(_ context.Context, target Target) error {
+	r.ran = append(r.ran, target.String())
+	if r.failing[target.String()] {
+		return fmt.Errorf("deployment failed")
+	}
+	return nil
+}
+
+func newTestRollout(t *testing.T, plan *Plan, runner *recordingRunner) *Rollout {
+	rollout := NewRollout(plan, runner.run, filepath.Join(t.TempDir(), "state.json"))
+	rollout.now = func() time.Time { return time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC) }
+	return rollout
+}
+
+func targetStatuses(report *Report) map[string]Status {
+	statuses := make(map[string]Status)
+	for _, wave := range report.Waves {
+		for _, target := range wave.Targets {
+			statuses[target.Target.String()] = target.Status
+		}
+	}
+	return statuses
+}
+
+func TestRolloutSucceeds(t *testing.T) {
+	plan := testRolloutPlan(&HealthGate{Command: `test "$ROLLOUT_WAVE/$ROLLOUT_REGIONS" = canary/uksouth && echo healthy`}, false)
+	runner := &recordingRunner{}
+	rollout := newTestRollout(t, plan, runner)
+
+	report := NewReport(plan)
+	require.NoError(t, rollout.Run(context.Background(), report))
+	assert.Equal(t, []string{"uksouth/1", "westus3/1", "westus3/2"}, runner.ran)
+	assert.Equal(t, StatusSucceeded, report.Status)
+	assert.Equal(t, &GateReport{Status: StatusSucceeded, Attempts: 1, Output: "healthy\n"}, report.Waves[0].HealthGate)
+
+	// the persisted state equals the report
+	persisted, err := LoadReport(rollout.StateFile)
+	require.NoError(t, err)
+	assert.Equal(t, report, persisted)
+
+	// running a finished rollout again does nothing
+	require.NoError(t, rollout.Run(context.Background(), persisted))
+	assert.Len(t, runner.ran, 3)
+}
+
+func TestRolloutHaltsOnFailureAndResumes(t *testing.T) {
+	plan := testRolloutPlan(nil, false)
+	plan.Waves = append(plan.Waves, Wave{Name: "wave2", Targets: []Target{{Region: "eastus", RegionShort: "use"}}})
+	runner := &recordingRunner{failing: map[string]bool{"westus3/1": true}}
+	rollout := newTestRollout(t, plan, runner)
+
+	report := NewReport(plan)
+	err := rollout.Run(context.Background(), report)
+	assert.EqualError(t, err, "rollout halted in wave wave1: target westus3/1 failed: deployment failed")
+	assert.Equal(t, []string{"uksouth/1", "westus3/1"}, runner.ran)
+	assert.Equal(t, StatusFailed, report.Status)
+	assert.Equal(t, map[string]Status{
+		"uksouth/1": StatusSucceeded,
+		"westus3/1": StatusFailed,
+		"westus3/2": StatusPending,
+		"eastus":    StatusPending,
+	}, targetStatuses(report))
+	assert.Equal(t, "deployment failed", report.Waves[1].Targets[0].Error)
+
+	// resuming from the persisted state skips what already succeeded
+	persisted, err := LoadReport(rollout.StateFile)
+	require.NoError(t, err)
+	runner.ran = nil
+	runner.failing = nil
+	require.NoError(t, rollout.Run(context.Background(), persisted))
+	assert.Equal(t, []string{"westus3/1", "westus3/2", "eastus"}, runner.ran)
+	assert.Equal(t, StatusSucceeded, persisted.Status)
+	assert.Empty(t, persisted.Waves[1].Targets[0].Error)
+}
+
+func TestRolloutPausesAndResumes(t *testing.T) {
+	plan := testRolloutPlan(nil, true)
+	runner := &recordingRunner{}
+	rollout := newTestRollout(t, plan, runner)
+
+	report := NewReport(plan)
+	require.NoError(t, rollout.Run(context.Background(), report))
+	assert.Equal(t, []string{"uksouth/1"}, runner.ran)
+	assert.Equal(t, StatusPaused, report.Status)
+	assert.Equal(t, "canary", report.PausedAfter)
+
+	persisted, err := LoadReport(rollout.StateFile)
+	require.NoError(t, err)
+	runner.ran = nil
+	require.NoError(t, rollout.Run(context.Background(), persisted))
+	assert.Equal(t, []string{"westus3/1", "westus3/2"}, runner.ran)
+	assert.Equal(t, StatusSucceeded, persisted.Status)
+	assert.Empty(t, persisted.PausedAfter)
+}
+
+func TestRolloutHealthGateFailure(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "healthy")
+	gate := &HealthGate{Command: fmt.Sprintf("echo checking; test -f %s", marker), Retries: 1}
+	plan := testRolloutPlan(gate, false)
+	runner := &recordingRunner{}
+	rollout := newTestRollout(t, plan, runner)
+
+	report := NewReport(plan)
+	err := rollout.Run(context.Background(), report)
+	assert.EqualError(t, err, "rollout halted in wave canary: health gate failed: health gate command failed: exit status 1")
+	assert.Equal(t, []string{"uksouth/1"}, runner.ran)
+	assert.Equal(t, &GateReport{Status: StatusFailed, Attempts: 2, Output: "checking\n", Error: "health gate command failed: exit status 1"}, report.Waves[0].HealthGate)
+
+	// once healthy, resuming only reruns the health gate
+	require.NoError(t, os.WriteFile(marker, nil, 0644))
+	runner.ran = nil
+	require.NoError(t, rollout.Run(context.Background(), report))
+	assert.Equal(t, []string{"westus3/1", "westus3/2"}, runner.ran)
+	assert.Equal(t, StatusSucceeded, report.Waves[0].HealthGate.Status)
+	assert.Equal(t, 3, report.Waves[0].HealthGate.Attempts)
+}
+
+func TestRolloutRejectsForeignState(t *testing.T) {
+	plan := testRolloutPlan(nil, false)
+	other := testRolloutPlan(nil, false)
+	other.Waves[1].Targets = other.Waves[1].Targets[:1]
+
+	rollout := newTestRollout(t, plan, &recordingRunner{})
+	assert.EqualError(t, rollout.Run(context.Background(), NewReport(other)), "targets of wave wave1 differ between rollout state and plan")
+}
+
+func TestReportWriteSummary(t *testing.T) {
+	started := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	finished := started.Add(90 * time.Second)
+	report := NewReport(testRolloutPlan(&HealthGate{Command: "true"}, true))
+	report.Status = StatusPaused
+	report.PausedAfter = "canary"
+	report.Waves[0].Status = StatusSucceeded
+	report.Waves[0].Targets[0].Status = StatusSucceeded
+	report.Waves[0].Targets[0].Started = &started
+	report.Waves[0].Targets[0].Finished = &finished
+	report.Waves[0].HealthGate.Status = StatusSucceeded
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteSummary(&out))
+	assert.Equal(t, ""+
+		"WAVE     TARGET        STATUS      DURATION   ERROR\n"+
+		"canary   uksouth/1     Succeeded   1m30s      \n"+
+		"canary   health gate   Succeeded              \n"+
+		"wave1    westus3/1     Pending                \n"+
+		"wave1    westus3/2     Pending                \n"+
+		"\n"+
+		"Rollout Paused after wave canary\n", out.String())
+}